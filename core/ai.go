@@ -0,0 +1,333 @@
+package core
+
+import (
+	"sort"
+)
+
+// Chaser is a Component providing hostile AI. On each Act, the Chaser looks
+// for the nearest hostile Entity within its field of view, and moves toward
+// it, attacking by bumping into it once adjacent. If sight of the target is
+// lost, the Chaser heads to where the target was last seen for up to Memory
+// turns before giving up. If no path to the target exists, the Chaser simply
+// steps in its general direction if possible, and otherwise idles. Chaser has
+// a priority of 1, and consumes the Act whenever it has a target, so that
+// idle behaviors such as Wanderer only act when there is nothing to chase.
+//
+// If the Entity has a Memory, the Chaser also investigates the last position at
+// which the Memory saw any Entity once it has lost track of its own target.
+//
+// An Alerted is treated like a sighting of a target at the source of the Noise,
+// so that the Chaser investigates the noise for up to Memory turns unless it
+// spots a target first.
+//
+// If Range is positive, the Chaser is capable of ranged attacks, and sends its
+// Entity a RangedAttack against a visible target which is within Range but not
+// adjacent, only moving if the RangedAttack fails.
+type Chaser struct {
+	Self   Entity
+	Pos    *Tile
+	Radius int
+	Memory int
+	Range  int
+
+	// Hostile decides whether an Entity is a target. If nil, every other
+	// occupant is a target unless RelationBetween gives Friendly or Neutral.
+	Hostile func(Entity) bool
+
+	// Path computes a path between two Tiles. If nil, AStarPath is used.
+	Path func(origin, goal *Tile) []*Tile
+
+	last   *Tile // where a target was last seen
+	forget int   // turns until the last position is forgotten
+}
+
+// Process implements Component for Chaser.
+func (c *Chaser) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *Alerted:
+		if v.Source != c.Pos {
+			c.last, c.forget = v.Source, c.Memory
+		}
+	case *Act:
+		if c.Pos == nil {
+			return
+		}
+		if target := c.nearest(); target != nil {
+			c.last, c.forget = target, c.Memory
+			if c.shoot(target) {
+				v.Consume()
+				return
+			}
+		} else if c.forget > 0 && c.last != c.Pos {
+			c.forget--
+		} else if seen := c.recall(); seen != nil {
+			c.last, c.forget = seen, c.Memory
+		} else {
+			c.last, c.forget = nil, 0
+			return
+		}
+
+		v.Consume()
+		if delta, ok := c.step(c.last); ok {
+			if cost := Move(c.Pos, delta); cost > 0 {
+				v.Cost = cost
+			}
+		}
+	}
+}
+
+// Priority implements Prioritized for Chaser.
+func (c *Chaser) Priority() int {
+	return 1
+}
+
+// HasTarget returns true if the Chaser is currently chasing a target, either
+// in sight or remembered.
+func (c *Chaser) HasTarget() bool {
+	return c.last != nil
+}
+
+// Wanderer is a Component providing idle AI. On each Act, the Wanderer moves
+// with probability Chance to a random adjacent Tile which is passable,
+// unoccupied, and within Radius of Home, and otherwise stands still. If Home is
+// nil, the first known position is used.
+type Wanderer struct {
+	Pos    *Tile
+	Home   *Tile
+	Radius int
+	Chance float64
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// Process implements Component for Wanderer.
+func (c *Wanderer) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+		if c.Home == nil {
+			c.Home = v.Pos
+		}
+	case *Act:
+		if c.Pos == nil {
+			return
+		}
+		if c.Home == nil {
+			c.Home = c.Pos
+		}
+		v.Consume()
+
+		dice := c.Dice
+		if dice.Rand == nil {
+			dice = globalDice
+		}
+		if !dice.Chance(c.Chance) {
+			return
+		}
+
+		for _, i := range dice.Perm(len(wanderDeltas)) {
+			delta := wanderDeltas[i]
+			adj, ok := c.Pos.Adjacent[delta]
+			if !ok || !adj.Pass || adj.Occupant != nil {
+				continue
+			}
+			if adj.Offset.Sub(c.Home.Offset).Chebyshev() > c.Radius {
+				continue
+			}
+
+			// the move can still fail, for example if a Component blocks it
+			if cost := Move(c.Pos, delta); cost > 0 {
+				v.Cost = cost
+				return
+			}
+		}
+	}
+}
+
+// wanderDeltas are the directions a Wanderer may move, in a fixed order so
+// that random choices are reproducible.
+var wanderDeltas = []Offset{
+	{-1, -1}, {0, -1}, {1, -1},
+	{-1, 0}, {1, 0},
+	{-1, 1}, {0, 1}, {1, 1},
+}
+
+// nearest finds the Tile of the nearest visible hostile Entity, or nil if
+// there is none. Ties are broken by Offset so that the choice is
+// deterministic.
+func (c *Chaser) nearest() *Tile {
+	var candidates []Offset
+	fov := FoV(c.Pos, c.Radius)
+	for off, tile := range fov {
+		if o := tile.Occupant; o != nil && !sameEntity(o, c.Self) && c.hostile(o) {
+			candidates = append(candidates, off)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if da, db := a.Euclidean(), b.Euclidean(); da != db {
+			return da < db
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Y < b.Y
+	})
+	return fov[candidates[0]]
+}
+
+// recall asks the Entity for the last position at which it saw anything,
+// returning nil if there is nowhere new to investigate.
+func (c *Chaser) recall() *Tile {
+	if c.Self == nil {
+		return nil
+	}
+	query := LastSeen{}
+	c.Self.Handle(&query)
+	if !query.Found || query.Pos == c.Pos {
+		return nil
+	}
+	return query.Pos
+}
+
+// shoot attempts a RangedAttack on a target which is not adjacent, returning
+// true if the attack was made.
+func (c *Chaser) shoot(target *Tile) bool {
+	if c.Range <= 0 || target.Offset.Sub(c.Pos.Offset).Chebyshev() <= 1 {
+		return false
+	}
+	attack := RangedAttack{Attacker: c.Self, From: c.Pos, Target: target, Range: c.Range}
+	c.Self.Handle(&attack)
+	return attack.Done
+}
+
+// hostile determines whether an Entity is a target.
+func (c *Chaser) hostile(e Entity) bool {
+	return hostileTo(c.Self, c.Hostile, e)
+}
+
+// hostileTo determines whether an Entity is hostile to self, using the given
+// predicate if any, and otherwise RelationBetween. If self is nil, it has no
+// faction, so every Entity is hostile.
+func hostileTo(self Entity, hostile func(Entity) bool, e Entity) bool {
+	if hostile != nil {
+		return hostile(e)
+	}
+	rel := RelationBetween(self, e)
+	return rel != Friendly && rel != Neutral
+}
+
+// step determines the direction in which to move toward the goal.
+func (c *Chaser) step(goal *Tile) (Offset, bool) {
+	delta := goal.Offset.Sub(c.Pos.Offset)
+	if delta.Chebyshev() == 1 {
+		return delta, true
+	}
+
+	path := c.Path
+	if path == nil {
+		path = AStarPath
+	}
+	if steps := path(c.Pos, goal); len(steps) > 0 {
+		return steps[0].Offset.Sub(c.Pos.Offset), true
+	}
+	return stepToward(c.Pos, goal)
+}
+
+// stepToward finds a passable, unoccupied adjacent Tile which is closer to the
+// goal, preferring the diagonal.
+func stepToward(from, goal *Tile) (Offset, bool) {
+	delta := goal.Offset.Sub(from.Offset)
+	dx, dy := Offset{Signum(delta.X), 0}, Offset{0, Signum(delta.Y)}
+	for _, step := range []Offset{dx.Add(dy), dx, dy} {
+		if step == (Offset{}) {
+			continue
+		}
+		if adj, ok := from.Adjacent[step]; ok && adj.Pass && adj.Occupant == nil {
+			return step, true
+		}
+	}
+	return Offset{}, false
+}
+
+// Fleer is a Component providing fleeing AI. While the hit points of Health
+// are below Threshold times the maximum, on each Act the Fleer looks for
+// hostile Entity within its field of view, and steps down a FleeMap away from
+// all of them. Fleer has a priority of 2 and consumes the Act whenever it
+// flees, so that an Entity with a Chaser or Wanderer switches to fleeing once
+// badly hurt. If the Fleer is cornered with nowhere better to go, the Act is
+// left to the other Components, so that a Chaser fights back.
+//
+// Hostile is as for Chaser, and Coefficient is passed to FleeMap, with zero
+// meaning -1.2.
+type Fleer struct {
+	Self        Entity
+	Pos         *Tile
+	Radius      int
+	Health      *Health
+	Threshold   float64
+	Coefficient float64
+	Hostile     func(Entity) bool
+}
+
+// Process implements Component for Fleer.
+func (c *Fleer) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *Act:
+		if c.Pos == nil || !c.Fleeing() {
+			return
+		}
+
+		var threats []*Tile
+		for _, tile := range FoV(c.Pos, c.Radius) {
+			if o := tile.Occupant; o != nil && !sameEntity(o, c.Self) && hostileTo(c.Self, c.Hostile, o) {
+				threats = append(threats, tile)
+			}
+		}
+		if len(threats) == 0 {
+			return
+		}
+
+		coefficient := c.Coefficient
+		if coefficient == 0 {
+			coefficient = -1.2
+		}
+		dm := FleeMap(threats, nil, coefficient)
+		var next *Tile
+		best := dm[c.Pos]
+		for _, delta := range descentOrder {
+			if adj, ok := c.Pos.Adjacent[delta]; ok && adj.Occupant == nil {
+				if d, ok := dm[adj]; ok && d < best {
+					next, best = adj, d
+				}
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		v.Consume()
+		if cost := Move(c.Pos, next.Offset.Sub(c.Pos.Offset)); cost > 0 {
+			v.Cost = cost
+		}
+	}
+}
+
+// Priority implements Prioritized for Fleer.
+func (c *Fleer) Priority() int {
+	return 2
+}
+
+// Fleeing returns true if the hit points of Health are below the Threshold.
+func (c *Fleer) Fleeing() bool {
+	return c.Health != nil && float64(c.Health.HP) < c.Threshold*float64(c.Health.Max)
+}
@@ -1,5 +1,10 @@
 package core
 
+import (
+	"reflect"
+	"testing"
+)
+
 type StrGrid []string
 
 func (g StrGrid) Convert(callback func(*Tile, byte)) [][]Tile {
@@ -41,3 +46,233 @@ func (g StrGrid) Convert(callback func(*Tile, byte)) [][]Tile {
 
 	return tiles
 }
+
+// AICase converts a StrGrid into lit Tiles, placing a new Chaser on the 'c',
+// a testally on the 't', with '#' for walls and '~' for impassable but
+// transparent Tiles.
+func AICase(g StrGrid) (chaser *Chaser, target *testally) {
+	target = &testally{}
+	g.Convert(func(t *Tile, ch byte) {
+		t.Lite = true
+		switch ch {
+		case '#':
+			t.Pass, t.Lite = false, false
+		case '~':
+			t.Pass = false
+		case 'c':
+			chaser = &Chaser{Pos: t, Radius: 10}
+			chaser.Self = &ComponentSlice{chaser}
+			t.Occupant = chaser.Self
+		case 't':
+			target.Pos = t
+			t.Occupant = target
+		}
+	})
+	return chaser, target
+}
+
+func TestChaser(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"########",
+		"#c.~...#",
+		"#..~~..#",
+		"#.....t#",
+		"########",
+	})
+	var bumped Entity
+	chaser.Self = &ComponentSlice{chaser, testfunc(func(v Event) {
+		if v, ok := v.(*Bump); ok {
+			bumped = v.Bumped
+		}
+	})}
+	chaser.Pos.Occupant = chaser.Self
+
+	for i := 0; i < 10 && bumped == nil; i++ {
+		chaser.Self.Handle(&Act{})
+	}
+	if bumped != target {
+		t.Errorf("Chaser did not attack target, stopped at %v", chaser.Pos.Offset)
+	}
+	if chaser.Pos.Offset.Sub(target.Pos.Offset).Chebyshev() != 1 {
+		t.Errorf("Chaser attacked from %v", chaser.Pos.Offset)
+	}
+}
+
+func TestChaser_Memory(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"########",
+		"#c....t#",
+		"########",
+	})
+	chaser.Memory = 2
+
+	// the target vanishes, so the chaser heads to the last known position
+	chaser.Self.Handle(&Act{})
+	target.Pos.Occupant = nil
+	for i := 0; i < 2; i++ {
+		chaser.Self.Handle(&Act{})
+	}
+	if !chaser.HasTarget() || chaser.Pos.Offset.X != 4 {
+		t.Errorf("Chaser did not pursue remembered target, at %v", chaser.Pos.Offset)
+	}
+	chaser.Self.Handle(&Act{})
+	if chaser.HasTarget() || chaser.Pos.Offset.X != 4 {
+		t.Errorf("Chaser did not forget target, at %v", chaser.Pos.Offset)
+	}
+}
+
+func TestChaser_NoPath(t *testing.T) {
+	chaser, _ := AICase(StrGrid{
+		"#######",
+		"#c.~.t#",
+		"#######",
+	})
+
+	for i := 0; i < 3; i++ {
+		chaser.Self.Handle(&Act{})
+	}
+	if chaser.Pos.Offset.X != 2 || !chaser.HasTarget() {
+		t.Errorf("Chaser without path at %v", chaser.Pos.Offset)
+	}
+}
+
+func TestChaser_Range(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"########",
+		"#c....t#",
+		"########",
+	})
+	chaser.Range = 3
+	shots := 0
+	chaser.Self = &ComponentSlice{chaser, On(func(v *RangedAttack) {
+		if v.Err = v.Check(); v.Err == nil {
+			v.Done = true
+			shots++
+		}
+	})}
+	chaser.Pos.Occupant = chaser.Self
+
+	// approach until in range, then shoot instead of moving
+	for i := 0; i < 4; i++ {
+		chaser.Self.Handle(&Act{})
+	}
+	if chaser.Pos.Offset.X != 3 || shots != 2 {
+		t.Errorf("Chaser with Range at %v after %d shots", chaser.Pos.Offset, shots)
+	}
+	if target.Pos.Offset.X != 6 {
+		t.Errorf("target moved to %v", target.Pos.Offset)
+	}
+
+	// an unanswered RangedAttack is not a shot, so the Chaser keeps moving
+	chaser.Self = &ComponentSlice{chaser}
+	chaser.Pos.Occupant = chaser.Self
+	chaser.Self.Handle(&Act{})
+	if chaser.Pos.Offset.X != 4 {
+		t.Errorf("Chaser with unanswered RangedAttack at %v", chaser.Pos.Offset)
+	}
+}
+
+// wanderCase places a Wanderer with the given seed in an open room with a
+// single obstacle and a bystander, and returns its positions over many turns.
+func wanderCase(seed int64) (tiles [][]Tile, visited []Offset) {
+	tiles = StrGrid{
+		"#########",
+		"#.......#",
+		"#..#....#",
+		"#...w.t.#",
+		"#.......#",
+		"#########",
+	}.Convert(func(t *Tile, ch byte) {
+		switch ch {
+		case '#':
+			t.Pass = false
+		case 't':
+			t.Occupant = &testally{Pos: t}
+		}
+	})
+	home := &tiles[4][3]
+	wanderer := &Wanderer{Radius: 1, Chance: .75, Dice: NewDice(newXorshift(seed))}
+	entity := NewEntity(wanderer)
+	home.Occupant = entity
+	entity.Handle(&UpdatePos{home})
+
+	for i := 0; i < 100; i++ {
+		entity.Handle(&Act{})
+		visited = append(visited, wanderer.Pos.Offset)
+	}
+	return tiles, visited
+}
+
+func TestWanderer(t *testing.T) {
+	_, visited := wanderCase(1)
+	moved := false
+	for i, pos := range visited {
+		if pos.Sub(Offset{4, 3}).Chebyshev() > 1 {
+			t.Errorf("Wanderer left home radius to %v", pos)
+		}
+		if pos == (Offset{3, 2}) {
+			t.Errorf("Wanderer walked into wall")
+		}
+		if i > 0 && pos != visited[i-1] {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Errorf("Wanderer never moved")
+	}
+
+	if _, replay := wanderCase(1); !reflect.DeepEqual(visited, replay) {
+		t.Errorf("Wanderer with same seed gave different moves")
+	}
+}
+
+func TestWanderer_YieldsToChaser(t *testing.T) {
+	chaser, _ := AICase(StrGrid{
+		"#######",
+		"#c...t#",
+		"#######",
+	})
+	wanderer := &Wanderer{Radius: 5, Chance: 1}
+	self := NewEntity(wanderer, chaser)
+	chaser.Self = self
+	chaser.Pos.Occupant = self
+	self.Handle(&UpdatePos{chaser.Pos})
+
+	for i := 0; i < 3; i++ {
+		self.Handle(&Act{})
+	}
+	if chaser.Pos.Offset.X != 4 || wanderer.Pos != chaser.Pos {
+		t.Errorf("Wanderer interfered with Chaser, at %v", chaser.Pos.Offset)
+	}
+}
+
+func TestFleer(t *testing.T) {
+	grid := StrGrid{
+		"########################################",
+		"#.....c.t..............................#",
+		"#......................................#",
+		"########################################",
+	}
+	chaser, target := AICase(grid)
+	health := &Health{HP: 2, Max: 10}
+	fleer := &Fleer{Radius: 10, Health: health, Threshold: .5}
+	self := NewEntity(fleer, chaser)
+	chaser.Self, fleer.Self = self, self
+	chaser.Pos.Occupant = self
+	self.Handle(&UpdatePos{chaser.Pos})
+
+	for i := 0; i < 4; i++ {
+		self.Handle(&Act{})
+	}
+	if x := fleer.Pos.Offset.X; x <= target.Pos.Offset.X {
+		t.Errorf("Fleer cowered at %v", fleer.Pos.Offset)
+	}
+
+	health.HP = 10
+	for i := 0; i < 10; i++ {
+		self.Handle(&Act{})
+	}
+	if fleer.Pos.Offset.Sub(target.Pos.Offset).Chebyshev() != 1 {
+		t.Errorf("Chaser did not take over from Fleer, at %v", fleer.Pos.Offset)
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"sort"
+)
+
+// MapStats summarizes the layout of a generated Grid, for checking that a
+// generator stays within its intended bounds across seeds, or for tuning its
+// parameters.
+type MapStats struct {
+	// Open is the fraction of the Grid which is passable, and Regions the
+	// number of separate passable regions.
+	Open    float64
+	Regions int
+
+	// Rooms is the number of rooms, being groups of passable Tiles which are
+	// each part of an open 2x2 square, and RoomSizes gives the number of
+	// Tiles in each room, in ascending order.
+	Rooms     int
+	RoomSizes []int
+
+	// Corridors is the number of corridors, being groups of the remaining
+	// passable Tiles, and CorridorLength the average number of Tiles in each.
+	Corridors      int
+	CorridorLength float64
+
+	// ChokePoints is the number of passable Tiles whose removal would
+	// disconnect the region containing them.
+	ChokePoints int
+}
+
+// Analyze computes MapStats for a Grid, with Tiles connected as for Regions.
+func Analyze(g *Grid) MapStats {
+	var stats MapStats
+	if total := g.Width() * g.Height(); total > 0 {
+		stats.Open = float64(g.Count(IsPassable)) / float64(total)
+	}
+	stats.Regions = len(Regions(g, IsPassable))
+
+	room := func(t *Tile) bool { return t.Pass && inOpenSquare(t) }
+	for _, region := range Regions(g, room) {
+		stats.RoomSizes = append(stats.RoomSizes, len(region))
+	}
+	sort.Ints(stats.RoomSizes)
+	stats.Rooms = len(stats.RoomSizes)
+
+	corridors := Regions(g, func(t *Tile) bool { return t.Pass && !room(t) })
+	if stats.Corridors = len(corridors); stats.Corridors > 0 {
+		length := 0
+		for _, corridor := range corridors {
+			length += len(corridor)
+		}
+		stats.CorridorLength = float64(length) / float64(stats.Corridors)
+	}
+
+	stats.ChokePoints = len(articulationPoints(g, IsPassable))
+	return stats
+}
+
+// inOpenSquare returns true if any 2x2 square containing the Tile is entirely
+// passable.
+func inOpenSquare(t *Tile) bool {
+	for _, dx := range []int{-1, 1} {
+		for _, dy := range []int{-1, 1} {
+			a, aok := t.Adjacent[Offset{dx, 0}]
+			b, bok := t.Adjacent[Offset{0, dy}]
+			c, cok := t.Adjacent[Offset{dx, dy}]
+			if aok && bok && cok && a.Pass && b.Pass && c.Pass {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// articulationPoints returns the Tiles for which the predicate is true whose
+// removal would split their region in two, using the depth first search of
+// Hopcroft and Tarjan. The search uses an explicit stack, since corridors on
+// a large Grid can make it very deep.
+func articulationPoints(g *Grid, pred func(*Tile) bool) []*Tile {
+	type frame struct {
+		tile, parent *Tile
+		next         int
+		children     int
+	}
+
+	disc := make(map[*Tile]int)
+	low := make(map[*Tile]int)
+	cut := make(map[*Tile]bool)
+	var points []*Tile
+	g.Each(func(_ Offset, root *Tile) {
+		if _, seen := disc[root]; seen || !pred(root) {
+			return
+		}
+
+		disc[root], low[root] = len(disc), len(disc)
+		stack := []frame{{tile: root}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.next < len(descentOrder) {
+				adj, ok := top.tile.Adjacent[descentOrder[top.next]]
+				top.next++
+				if !ok || !pred(adj) {
+					continue
+				}
+				if _, seen := disc[adj]; !seen {
+					top.children++
+					disc[adj], low[adj] = len(disc), len(disc)
+					stack = append(stack, frame{tile: adj, parent: top.tile})
+				} else if adj != top.parent {
+					low[top.tile] = Min(low[top.tile], disc[adj])
+				}
+				continue
+			}
+
+			done := *top
+			stack = stack[:len(stack)-1]
+			if done.parent == nil {
+				if done.children > 1 {
+					cut[done.tile] = true
+				}
+				continue
+			}
+			low[done.parent] = Min(low[done.parent], low[done.tile])
+			if done.parent != root && low[done.tile] >= disc[done.parent] {
+				cut[done.parent] = true
+			}
+		}
+	})
+	g.Each(func(_ Offset, t *Tile) {
+		if cut[t] {
+			points = append(points, t)
+		}
+	})
+	return points
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	g := gridCase(
+		"#############",
+		"#...#####...#",
+		"#...........#",
+		"#...#####...#",
+		"#############",
+	)
+	expected := MapStats{
+		Open:           23.0 / 65,
+		Regions:        1,
+		Rooms:          2,
+		RoomSizes:      []int{9, 9},
+		Corridors:      1,
+		CorridorLength: 5,
+		ChokePoints:    5,
+	}
+	if actual := Analyze(g); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Analyze() = %+v != %+v", actual, expected)
+	}
+}
+
+func TestAnalyze_Loop(t *testing.T) {
+	g := gridCase(
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.....#",
+		"###.###",
+		"###.###",
+		"#######",
+		"#.#####",
+		"#######",
+	)
+	stats := Analyze(g)
+	if stats.Regions != 2 || stats.Rooms != 0 || stats.Corridors != 2 {
+		t.Errorf("Analyze() = %+v", stats)
+	}
+	// only the Tile joining the spur to the loop is a choke point
+	if stats.ChokePoints != 1 {
+		t.Errorf("Analyze() found %d choke points", stats.ChokePoints)
+	}
+	if stats.CorridorLength != 7.5 {
+		t.Errorf("Analyze() gave corridor length %v", stats.CorridorLength)
+	}
+}
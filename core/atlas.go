@@ -14,19 +14,32 @@ func (f MapGenFloat) Overworld(h *Heightmap) []*Tile {
 }
 
 // Biome stores data for generating Tile in a single region of an overworld.
+// If PassTerrain or ImpassTerrain is set, the generated Tile uses that terrain,
+// with the Face chosen from PassTiles or ImpassTiles if there are any.
+// Otherwise, impassable Tiles are lit according to ImpassLite.
 type Biome struct {
-	Boundary    float64
-	PassTiles   []Glyph
-	ImpassTiles []Glyph
-	PassChance  float64
-	ImpassLite  bool
+	Boundary      float64
+	PassTiles     []Glyph
+	ImpassTiles   []Glyph
+	PassChance    float64
+	ImpassLite    bool
+	PassTerrain   TerrainID
+	ImpassTerrain TerrainID
 }
 
 // Generate creates a new tile chosen according to the Biome parameters.
 func (b Biome) Generate(o Offset) *Tile {
 	t := NewTile(o)
 	if RandChance(b.PassChance) {
-		t.Face = b.PassTiles[RandIntn(len(b.PassTiles))]
+		t.SetTerrain(b.PassTerrain)
+		if len(b.PassTiles) > 0 {
+			t.Face = b.PassTiles[RandIntn(len(b.PassTiles))]
+		}
+	} else if b.ImpassTerrain != "" {
+		t.SetTerrain(b.ImpassTerrain)
+		if len(b.ImpassTiles) > 0 {
+			t.Face = b.ImpassTiles[RandIntn(len(b.ImpassTiles))]
+		}
 	} else {
 		t.Face = b.ImpassTiles[RandIntn(len(b.ImpassTiles))]
 		t.Pass = false
@@ -78,6 +91,10 @@ type Heightmap struct {
 	RadiusX, RadiusY int
 	NumEllipses      int
 	WrapX            bool
+
+	// Dice places the ellipses, so that a seeded Dice gives the same
+	// Heightmap each time. If unset, the global Dice is used.
+	Dice Dice
 }
 
 // NewHeightmap creates a new Heightmap with the given dimensions, and default
@@ -87,7 +104,7 @@ func NewHeightmap(cols, rows int) *Heightmap {
 	for x := 0; x < cols; x++ {
 		buf[x] = make([]float64, rows)
 	}
-	return &Heightmap{cols, rows, buf, cols / 8, rows / 8, cols + rows, true}
+	return &Heightmap{cols, rows, buf, cols / 8, rows / 8, cols + rows, true, Dice{}}
 }
 
 // Generate performs the full heightmap generation process.
@@ -141,8 +158,12 @@ func (h *Heightmap) RaiseEllipse(center Offset) {
 // RadiusY. The ellipses will wrap around the x-axis if WrapX is true.
 func (h *Heightmap) RaiseEllipses() {
 	// Raise NumEllipses randomly placed ellipses.
+	dice := h.Dice
+	if dice.Rand == nil {
+		dice = globalDice
+	}
 	for i := 0; i < h.NumEllipses; i++ {
-		h.RaiseEllipse(RandOffset(h.cols, h.rows))
+		h.RaiseEllipse(dice.Offset(h.cols, h.rows))
 	}
 }
 
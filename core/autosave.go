@@ -0,0 +1,145 @@
+package core
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Autosaver saves the game automatically in response to Events published on
+// an EventBus: every Every turns, as given by Tick, and on every Transition,
+// such as taking the Stairs of a World whose bus was set with SetBus. Saves
+// made less than Interval after the previous one are skipped, as are saves
+// while Busy returns true, such as while an interactive widget holds the
+// screen. Busy has no default, so the caller must set it to skip such saves,
+// usually to the Busy method of the SceneStack running the game.
+//
+// Saves alternate between two files, Path with ".0" and ".1" appended, so
+// that a crash while writing one leaves the other intact, and LoadAutosave
+// loads whichever is newest and valid. Save writes the actual save, usually by
+// calling SaveGame with the current World, Registry and extra data. Since the
+// Autosaver only runs while an Event is being published, a failure is
+// reported by publishing a Message on the EventBus rather than returned.
+type Autosaver struct {
+	Path     string
+	Every    int
+	Interval time.Duration
+	Save     func(w io.Writer) error
+	Busy     func() bool
+
+	bus    *EventBus
+	cancel []func()
+	slot   int
+	last   time.Time
+}
+
+// NewAutosaver creates an Autosaver subscribed to the EventBus, saving every
+// given number of turns and on every Transition, with no Interval. The first
+// save replaces the older of the two files.
+func NewAutosaver(bus *EventBus, path string, every int, save func(w io.Writer) error) *Autosaver {
+	a := &Autosaver{Path: path, Every: every, Save: save, bus: bus}
+	if newer, ok := newestAutosave(path); ok && newer == 0 {
+		a.slot = 1
+	}
+	a.cancel = []func(){
+		Subscribe(bus, func(v *Tick) {
+			if a.Every > 0 && v.Turn%a.Every == 0 {
+				a.SaveNow()
+			}
+		}),
+		Subscribe(bus, func(*Transition) { a.SaveNow() }),
+	}
+	return a
+}
+
+// Stop cancels the subscriptions of the Autosaver.
+func (a *Autosaver) Stop() {
+	for _, cancel := range a.cancel {
+		cancel()
+	}
+	a.cancel = nil
+}
+
+// SaveNow saves the game unless Busy or within Interval of the last save,
+// returning true if the save was written.
+func (a *Autosaver) SaveNow() bool {
+	if a.Busy != nil && a.Busy() || !a.last.IsZero() && time.Since(a.last) < a.Interval {
+		return false
+	}
+	if err := a.write(autosavePath(a.Path, a.slot), autosavePath(a.Path, 1-a.slot)); err != nil {
+		a.bus.Publish(&Message{Text: "Autosave failed: " + err.Error(), Color: ColorRed})
+		return false
+	}
+	a.slot, a.last = 1-a.slot, time.Now()
+	return true
+}
+
+// write saves the game to the file at path, making sure it is newer than the
+// other file even when the filesystem's clock is too coarse to tell them apart.
+func (a *Autosaver) write(path, other string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := a.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	saved, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if prev, err := os.Stat(other); err == nil && !saved.ModTime().After(prev.ModTime()) {
+		mtime := prev.ModTime().Add(time.Millisecond)
+		return os.Chtimes(path, mtime, mtime)
+	}
+	return nil
+}
+
+// autosavePath gives the path of one of the two autosave files.
+func autosavePath(path string, slot int) string {
+	return path + []string{".0", ".1"}[slot]
+}
+
+// newestAutosave gives the slot of the most recently written autosave file,
+// or false if neither exists.
+func newestAutosave(path string) (slot int, ok bool) {
+	var newest time.Time
+	for i := 0; i < 2; i++ {
+		if info, err := os.Stat(autosavePath(path, i)); err == nil && (!ok || info.ModTime().After(newest)) {
+			slot, ok, newest = i, true, info.ModTime()
+		}
+	}
+	return slot, ok
+}
+
+// LoadAutosave loads the newest of the two files written by an Autosaver with
+// the given path, as with LoadGame, falling back to the other if the newest
+// cannot be loaded, such as after a crash while it was being written. If
+// neither can be loaded, the error from the newest is returned.
+func LoadAutosave(path string, gen LevelGen, extra any) (*World, *Registry, error) {
+	newest, ok := newestAutosave(path)
+	if !ok {
+		return nil, nil, os.ErrNotExist
+	}
+	var first error
+	for _, slot := range []int{newest, 1 - newest} {
+		f, err := os.Open(autosavePath(path, slot))
+		if err == nil {
+			var world *World
+			var reg *Registry
+			world, reg, err = LoadGame(f, gen, extra)
+			f.Close()
+			if err == nil {
+				return world, reg, nil
+			}
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return nil, nil, first
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAutosaver(t *testing.T) {
+	bus := NewEventBus()
+	reg := NewRegistry()
+	generated := 0
+	world := NewWorld(7, worldCase(reg, &generated))
+	world.Level(0)
+	world.SetBus(bus)
+	clock := NewClock(bus)
+
+	path := filepath.Join(t.TempDir(), "save")
+	saves := 0
+	var failure error
+	a := NewAutosaver(bus, path, 5, func(w io.Writer) error {
+		saves++
+		if failure != nil {
+			return failure
+		}
+		return SaveGame(w, world, reg, clock)
+	})
+	var messages []string
+	Subscribe(bus, func(m *Message) { messages = append(messages, m.Text) })
+
+	clock.Advance(4 * TicksPerTurn)
+	if saves != 0 {
+		t.Errorf("Autosaver saved before turn 5")
+	}
+	clock.Advance(TicksPerTurn)
+	if _, err := os.Stat(path + ".0"); saves != 1 || err != nil {
+		t.Errorf("Autosaver at turn 5 saved %d times, %v", saves, err)
+	}
+
+	// Take the down stairs, which publish their Transition.
+	_, down := world.Stairs(0)
+	next := down.Adjacent[Offset{-1, 0}]
+	if next.Occupant == nil {
+		next.Occupant = &testsaved{Pos: next}
+		reg.Register(next.Occupant)
+	}
+	next.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if _, err := os.Stat(path + ".1"); saves != 2 || err != nil {
+		t.Errorf("Autosaver on Transition saved %d times, %v", saves, err)
+	}
+
+	a.Busy = func() bool { return true }
+	clock.Advance(5 * TicksPerTurn)
+	a.Busy, a.Interval = nil, time.Hour
+	clock.Advance(5 * TicksPerTurn)
+	if saves != 2 {
+		t.Errorf("Autosaver saved while busy or throttled")
+	}
+
+	a.Interval, failure = 0, ErrNoFit
+	clock.Advance(5 * TicksPerTurn)
+	if saves != 3 || len(messages) != 1 {
+		t.Errorf("failed autosave gave messages %v", messages)
+	}
+
+	a.Stop()
+	clock.Advance(5 * TicksPerTurn)
+	if saves != 3 {
+		t.Errorf("Autosaver saved after Stop")
+	}
+}
+
+func TestLoadAutosave(t *testing.T) {
+	reg := NewRegistry()
+	generated := 0
+	world := NewWorld(7, worldCase(reg, &generated))
+	world.Level(0)
+	path := filepath.Join(t.TempDir(), "save")
+	if _, _, err := LoadAutosave(path, world.Gen, nil); !os.IsNotExist(err) {
+		t.Errorf("LoadAutosave() with no saves = %v", err)
+	}
+
+	bus := NewEventBus()
+	a := NewAutosaver(bus, path, 1, func(w io.Writer) error { return SaveGame(w, world, reg, nil) })
+	a.SaveNow()
+	world.Level(1)
+	a.SaveNow()
+
+	loaded, _, err := LoadAutosave(path, world.Gen, nil)
+	if err != nil || !loaded.Generated(1) {
+		t.Errorf("LoadAutosave() did not load the newest save, %v", err)
+	}
+
+	// A crash while writing the newest save leaves it truncated.
+	os.Truncate(path+".1", 20)
+	loaded, _, err = LoadAutosave(path, world.Gen, nil)
+	if err != nil || loaded.Generated(1) {
+		t.Errorf("LoadAutosave() did not fall back to the older save, %v", err)
+	}
+
+	// A new Autosaver replaces the older file first.
+	b := NewAutosaver(bus, path, 1, func(w io.Writer) error { return SaveGame(w, world, reg, nil) })
+	if b.SaveNow(); b.slot != 1 {
+		t.Errorf("new Autosaver wrote slot %d first", 1-b.slot)
+	}
+}
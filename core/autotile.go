@@ -0,0 +1,98 @@
+package core
+
+// Bits of the neighbor mask used by Autotile, set for each orthogonal
+// neighbor which is also a wall.
+const (
+	WallNorth = 1 << iota
+	WallEast
+	WallSouth
+	WallWest
+)
+
+// UnicodeWalls is a glyph set for Autotile using box drawing characters.
+var UnicodeWalls = [16]Glyph{
+	{'■', ColorWhite}, // isolated
+	{'│', ColorWhite}, // N
+	{'─', ColorWhite}, // E
+	{'└', ColorWhite}, // N E
+	{'│', ColorWhite}, // S
+	{'│', ColorWhite}, // N S
+	{'┌', ColorWhite}, // E S
+	{'├', ColorWhite}, // N E S
+	{'─', ColorWhite}, // W
+	{'┘', ColorWhite}, // N W
+	{'─', ColorWhite}, // E W
+	{'┴', ColorWhite}, // N E W
+	{'┐', ColorWhite}, // S W
+	{'┤', ColorWhite}, // N S W
+	{'┬', ColorWhite}, // E S W
+	{'┼', ColorWhite}, // N E S W
+}
+
+// ASCIIWalls is a glyph set for Autotile for terminals without box drawing
+// characters, using '|' and '-' for straight walls and '+' for the rest.
+var ASCIIWalls = [16]Glyph{
+	{'#', ColorWhite},
+	{'|', ColorWhite},
+	{'-', ColorWhite},
+	{'+', ColorWhite},
+	{'|', ColorWhite},
+	{'|', ColorWhite},
+	{'+', ColorWhite},
+	{'+', ColorWhite},
+	{'-', ColorWhite},
+	{'+', ColorWhite},
+	{'-', ColorWhite},
+	{'+', ColorWhite},
+	{'+', ColorWhite},
+	{'+', ColorWhite},
+	{'+', ColorWhite},
+	{'+', ColorWhite},
+}
+
+// Autotile sets the Face of every wall Tile in the Grid to the glyph indexed
+// by its neighbor mask, built from WallNorth, WallEast, WallSouth and WallWest
+// for each orthogonal neighbor which is also a wall. Neighbors off the edge of
+// the Grid do not count as walls. Only wall Tiles are changed, so Autotile may
+// be run again after the map changes, such as when a wall is dug out.
+func Autotile(g *Grid, isWall func(*Tile) bool, tiles [16]Glyph) {
+	g.Each(func(_ Offset, t *Tile) {
+		autotile(t, isWall, tiles)
+	})
+}
+
+// AutotileOnChange keeps a Grid autotiled as its terrain changes, by
+// subscribing to TerrainChanged on the EventBus of the Grid and autotiling
+// each changed Tile and its orthogonal neighbors. The returned function
+// cancels the subscription.
+func AutotileOnChange(g *Grid, isWall func(*Tile) bool, tiles [16]Glyph) (cancel func()) {
+	if g.bus == nil {
+		g.bus = NewEventBus()
+	}
+	return Subscribe(g.bus, func(v *TerrainChanged) {
+		autotile(v.Tile, isWall, tiles)
+		for _, step := range autotileSteps {
+			if adj, ok := v.Tile.Adjacent[step]; ok {
+				autotile(adj, isWall, tiles)
+			}
+		}
+	})
+}
+
+// autotileSteps are the orthogonal directions, in the order of their bits in
+// the neighbor mask.
+var autotileSteps = []Offset{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// autotile sets the Face of a single wall Tile from its neighbor mask.
+func autotile(t *Tile, isWall func(*Tile) bool, tiles [16]Glyph) {
+	if !isWall(t) {
+		return
+	}
+	mask := 0
+	for bit, step := range autotileSteps {
+		if adj, ok := t.Adjacent[step]; ok && isWall(adj) {
+			mask |= 1 << uint(bit)
+		}
+	}
+	t.Face = tiles[mask]
+}
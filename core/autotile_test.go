@@ -0,0 +1,61 @@
+package core
+
+import (
+	"testing"
+)
+
+// faces renders the Face of every Tile in a Grid row by row.
+func faces(g *Grid) []string {
+	rows := make([][]rune, g.Height())
+	for y := range rows {
+		rows[y] = make([]rune, g.Width())
+	}
+	g.Each(func(o Offset, t *Tile) {
+		rows[o.Y][o.X] = t.Face.Ch
+	})
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, string(row))
+	}
+	return lines
+}
+
+func TestAutotile(t *testing.T) {
+	g := gridCase(
+		"#####.#",
+		"#...#..",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	)
+	isWall := func(t *Tile) bool { return t.Terrain == TerrainWall }
+	Autotile(g, isWall, UnicodeWalls)
+	expected := []string{
+		"┌───┐.■",
+		"│...│..",
+		"│.──┘.│",
+		"│.....│",
+		"└─────┘",
+	}
+	for y, line := range faces(g) {
+		if line != expected[y] {
+			t.Errorf("Autotile() row %d = %q != %q", y, line, expected[y])
+		}
+	}
+
+	// digging out a wall and rerunning only changes the walls
+	g.At(4, 2).SetTerrain(TerrainFloor)
+	Autotile(g, isWall, ASCIIWalls)
+	expected = []string{
+		"+---+.#",
+		"|...|..",
+		"|.--..|",
+		"|.....|",
+		"+-----+",
+	}
+	for y, line := range faces(g) {
+		if line != expected[y] {
+			t.Errorf("Autotile() after dig row %d = %q != %q", y, line, expected[y])
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions for the eight directions of movement. Keys bound to these actions
+// make up KeyMap once the Bindings are given to SetBindings.
+const (
+	ActionNorth     = "north"
+	ActionSouth     = "south"
+	ActionEast      = "east"
+	ActionWest      = "west"
+	ActionNorthEast = "northeast"
+	ActionNorthWest = "northwest"
+	ActionSouthEast = "southeast"
+	ActionSouthWest = "southwest"
+)
+
+// actionOffsets gives the Offset for each direction action.
+var actionOffsets = map[string]Offset{
+	ActionNorth:     {0, -1},
+	ActionSouth:     {0, 1},
+	ActionEast:      {1, 0},
+	ActionWest:      {-1, 0},
+	ActionNorthEast: {1, -1},
+	ActionNorthWest: {-1, -1},
+	ActionSouthEast: {1, 1},
+	ActionSouthWest: {-1, 1},
+}
+
+// Bindings maps each bound Key to the name of its action. Besides the
+// direction actions, such as ActionNorth, an action may be any name the game
+// chooses, such as "pickup" or "quit".
+type Bindings map[Key]string
+
+// bindingsOf builds Bindings from the keys of each direction, given in the
+// order west, east, north, south, northwest, northeast, southwest, southeast.
+func bindingsOf(keys ...Key) Bindings {
+	actions := []string{
+		ActionWest, ActionEast, ActionNorth, ActionSouth,
+		ActionNorthWest, ActionNorthEast, ActionSouthWest, ActionSouthEast,
+	}
+	b := make(Bindings, len(keys))
+	for i, key := range keys {
+		b[key] = actions[i]
+	}
+	return b
+}
+
+// BindingsVi returns movement on the vi keys, hjkl with yubn for diagonals.
+func BindingsVi() Bindings {
+	return bindingsOf(Keys("hlkjyubn")...)
+}
+
+// BindingsNumpad returns movement on the number keys, as laid out on a numpad
+// with num lock on.
+func BindingsNumpad() Bindings {
+	return bindingsOf(Keys("46827913")...)
+}
+
+// BindingsArrows returns movement on the arrow keys, with home, page up, end
+// and page down for diagonals, as laid out on a numpad with num lock off.
+func BindingsArrows() Bindings {
+	return bindingsOf(KeyArrowLeft, KeyArrowRight, KeyArrowUp, KeyArrowDown, KeyHome, KeyPgup, KeyEnd, KeyPgdn)
+}
+
+// BindingsWASD returns movement on wasd, with qezc for diagonals.
+func BindingsWASD() Bindings {
+	return bindingsOf(Keys("adwsqezc")...)
+}
+
+// DefaultBindings returns the Bindings which KeyMap starts from, the vi keys
+// merged with the numpad.
+func DefaultBindings() Bindings {
+	return BindingsVi().Merge(BindingsNumpad())
+}
+
+// Merge returns new Bindings holding every Key of b and of each of the
+// overrides, with later overrides replacing the action of any Key already
+// bound, so that users can rebind individual keys.
+func (b Bindings) Merge(overrides ...Bindings) Bindings {
+	merged := make(Bindings, len(b))
+	for _, src := range append([]Bindings{b}, overrides...) {
+		for key, action := range src {
+			merged[key] = action
+		}
+	}
+	return merged
+}
+
+// Keys returns every Key bound to the given action, in ascending order.
+func (b Bindings) Keys(action string) []Key {
+	var keys []Key
+	for key, bound := range b {
+		if bound == action {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// Offsets returns the Offset of each Key bound to a direction action, in the
+// form of KeyMap.
+func (b Bindings) Offsets() map[Key]Offset {
+	offsets := make(map[Key]Offset)
+	for key, action := range b {
+		if delta, ok := actionOffsets[action]; ok {
+			offsets[key] = delta
+		}
+	}
+	return offsets
+}
+
+// SetBindings makes b the active Bindings by regenerating KeyMap from its
+// direction actions, so that every core function which reads directional
+// keys, such as a Targeter or TextDump scrolling, honors the new keys.
+func SetBindings(b Bindings) {
+	KeyMap = b.Offsets()
+}
+
+// keyNames gives the Key for each name a bindings file may use in place of a
+// single character. Names are matched without regard to case.
+var keyNames = map[string]Key{
+	"esc":       KeyEsc,
+	"enter":     KeyEnter,
+	"tab":       KeyTab,
+	"space":     ' ',
+	"backspace": KeyBackspace,
+	"pgup":      KeyPgup,
+	"pgdn":      KeyPgdn,
+	"home":      KeyHome,
+	"end":       KeyEnd,
+	"up":        KeyArrowUp,
+	"down":      KeyArrowDown,
+	"left":      KeyArrowLeft,
+	"right":     KeyArrowRight,
+}
+
+// parseKey gives the Key for a single character or one of keyNames.
+func parseKey(s string) (Key, bool) {
+	if ch, size := utf8.DecodeRuneInString(s); size == len(s) && ch != utf8.RuneError {
+		return Key(ch), true
+	}
+	key, ok := keyNames[strings.ToLower(s)]
+	return key, ok
+}
+
+// BindingError reports a problem with a line of a bindings file. Err is one of
+// ErrBindingSyntax, ErrUnknownKey or ErrBindingConflict. For a conflict,
+// Previous is the line which first bound the Key.
+type BindingError struct {
+	Line, Previous int
+	Err            error
+}
+
+// Error describes the problem along with its line number.
+func (e *BindingError) Error() string {
+	if e.Previous != 0 {
+		return fmt.Sprintf("line %d: %v on line %d", e.Line, e.Err, e.Previous)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns Err, so that errors.Is can check the kind of problem.
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// LoadBindings reads Bindings from text with one "action = key" per line,
+// where the key is a single character or a name such as "enter", "space" or
+// "up". Blank lines and lines starting with '#' are ignored. The same action
+// may be given several keys, but a key given to two different actions is a
+// conflict. Problems are reported as a *BindingError. The result is usually
+// merged over DefaultBindings or one of the presets.
+func LoadBindings(r io.Reader) (Bindings, error) {
+	b := make(Bindings)
+	lines := make(map[Key]int)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		action, name, found := strings.Cut(line, "=")
+		action, name = strings.TrimSpace(action), strings.TrimSpace(name)
+		if !found || action == "" || name == "" {
+			return nil, &BindingError{Line: n, Err: ErrBindingSyntax}
+		}
+		key, ok := parseKey(name)
+		if !ok {
+			return nil, &BindingError{Line: n, Err: ErrUnknownKey}
+		}
+		if bound, ok := b[key]; ok && bound != action {
+			return nil, &BindingError{Line: n, Previous: lines[key], Err: ErrBindingConflict}
+		}
+		b[key], lines[key] = action, n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// LoadBindingsFile reads Bindings from the file at path, as with LoadBindings.
+func LoadBindingsFile(path string) (Bindings, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadBindings(f)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindings_presets(t *testing.T) {
+	if !reflect.DeepEqual(KeyMap, DefaultBindings().Offsets()) {
+		t.Errorf("KeyMap does not match DefaultBindings")
+	}
+	if KeyMap['h'] != (Offset{-1, 0}) || KeyMap['3'] != (Offset{1, 1}) {
+		t.Errorf("DefaultBindings lost the vi or numpad keys")
+	}
+	for _, b := range []Bindings{BindingsVi(), BindingsNumpad(), BindingsArrows(), BindingsWASD()} {
+		if len(b.Offsets()) != 8 {
+			t.Errorf("preset %v does not cover the eight directions", b)
+		}
+	}
+}
+
+func TestBindings_Merge(t *testing.T) {
+	base := BindingsVi()
+	merged := base.Merge(Bindings{'h': "help"}, BindingsArrows())
+	if merged['h'] != "help" || merged[KeyArrowLeft] != ActionWest || merged['l'] != ActionEast {
+		t.Errorf("Merge() = %v", merged)
+	}
+	if base['h'] != ActionWest {
+		t.Errorf("Merge() altered its receiver")
+	}
+	if keys := merged.Keys(ActionWest); !reflect.DeepEqual(keys, []Key{KeyArrowLeft}) {
+		t.Errorf("Keys(west) = %v", keys)
+	}
+}
+
+func TestSetBindings(t *testing.T) {
+	defer SetBindings(DefaultBindings())
+	SetBindings(BindingsWASD().Merge(Bindings{'x': "examine"}))
+	if _, ok := KeyMap['h']; ok {
+		t.Errorf("SetBindings() kept h")
+	}
+	if KeyMap['w'] != (Offset{0, -1}) || len(KeyMap) != 8 {
+		t.Errorf("SetBindings() gave KeyMap %v", KeyMap)
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	b, err := LoadBindings(strings.NewReader(`
+# movement on the arrows
+west = left
+west = Left
+east = RIGHT
+
+quit = Q
+pickup = ,
+cast = =
+`))
+	if err != nil {
+		t.Fatalf("LoadBindings() = %v", err)
+	}
+	expected := Bindings{KeyArrowLeft: "west", KeyArrowRight: "east", 'Q': "quit", ',': "pickup", '=': "cast"}
+	if !reflect.DeepEqual(b, expected) {
+		t.Errorf("LoadBindings() = %v", b)
+	}
+
+	cases := []struct {
+		text           string
+		line, previous int
+		err            error
+	}{
+		{"quit = q\nwest = h\n# comment\nhelp = h", 4, 2, ErrBindingConflict},
+		{"quit q", 1, 0, ErrBindingSyntax},
+		{"\n = q", 2, 0, ErrBindingSyntax},
+		{"quit = ", 1, 0, ErrBindingSyntax},
+		{"quit = q\nhelp = F1", 2, 0, ErrUnknownKey},
+	}
+	for _, c := range cases {
+		_, err := LoadBindings(strings.NewReader(c.text))
+		var berr *BindingError
+		if !errors.As(err, &berr) || berr.Line != c.line || berr.Previous != c.previous || !errors.Is(err, c.err) {
+			t.Errorf("LoadBindings(%q) = %v", c.text, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package core
+
+// BSPOptions configures BSPDungeon.
+type BSPOptions struct {
+	// MinLeaf is the smallest width or height of a leaf. Leaves are only
+	// split if both halves would be at least this large. Values below 4 are
+	// treated as 4, and zero gives a default of 8.
+	MinLeaf int
+
+	// Jitter moves each split away from the middle of its leaf by up to this
+	// fraction of the leaf size, so 0 always splits evenly and .5 allows
+	// splits anywhere which respects MinLeaf.
+	Jitter float64
+
+	// Fill makes each room fill its leaf, apart from a one Tile wall. If
+	// false, rooms are given a random size and position within their leaf.
+	Fill bool
+
+	// Paint sets up a Tile as one of TileTypeRoom, TileTypeCorridor or
+	// TileTypeWall. If nil, rooms and corridors are set to TerrainFloor and
+	// walls to TerrainWall.
+	Paint func(t *Tile, tiletype int)
+
+	// Prefabs, if any, are placed into the solid rock left between rooms
+	// once the rooms are carved, up to PrefabCount of them, with
+	// PlacePrefabs. They are then joined to the rooms like any other floor.
+	Prefabs     []WeightedPrefab
+	PrefabCount int
+
+	// Prune is the number of iterations of PruneDeadEnds applied to the
+	// corridors once everything is connected, painting the pruned Tiles as
+	// walls, with -1 pruning every dead end. Rooms and Prefabs are never
+	// pruned.
+	Prune int
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// bspNode is a leaf, or a split leaf, of a binary space partition.
+type bspNode struct {
+	Leaf        Rect
+	Room        Rect
+	Left, Right *bspNode
+	Vertical    bool // split by a vertical line, so Left is left of Right
+	Split       int  // x or y coordinate where Right begins
+}
+
+// BSPDungeon carves a dungeon into a Grid by binary space partition. The Grid
+// is recursively split into leaves, a room is carved in each leaf, and the
+// rooms on either side of each split are joined with a corridor crossing the
+// split line, so that every room is connected. The rooms are returned in
+// depth first order of their leaves. The edge of the Grid is always left as
+// wall, so a Grid with fewer than three rows or columns gets no rooms. Any
+// error from placing the Prefabs is returned along with the rooms.
+func BSPDungeon(g *Grid, opts BSPOptions) ([]Rect, error) {
+	if opts.MinLeaf == 0 {
+		opts.MinLeaf = 8
+	}
+	opts.MinLeaf = Max(opts.MinLeaf, 4)
+	if opts.Paint == nil {
+		opts.Paint = paintTerrain
+	}
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+
+	g.Each(func(_ Offset, t *Tile) {
+		opts.Paint(t, TileTypeWall)
+	})
+	if g.Width() < 3 || g.Height() < 3 {
+		return nil, nil
+	}
+
+	floor, room := make(map[*Tile]bool), make(map[*Tile]bool)
+	paint := opts.Paint
+	opts.Paint = func(t *Tile, tiletype int) {
+		floor[t], room[t] = tiletype != TileTypeWall, tiletype == TileTypeRoom
+		paint(t, tiletype)
+	}
+
+	var rooms []Rect
+	root := &bspNode{Leaf: Rect{0, 0, g.Width(), g.Height()}}
+	root.split(&opts)
+	root.carve(g, &opts, &rooms)
+	if _, _, err := PlacePrefabs(g, opts.Prefabs, opts.PrefabCount, opts.Dice); err != nil {
+		return rooms, err
+	}
+
+	// the corridors should already join every room, but ConnectRegions
+	// guarantees it regardless of how the corridors happened to fall, and
+	// joins any Prefabs as well
+	regions := Regions(g, func(t *Tile) bool { return floor[t] || t.Pass })
+	ConnectRegions(g, regions, func(t *Tile) { opts.Paint(t, TileTypeCorridor) })
+
+	if opts.Prune != 0 {
+		fill := func(t *Tile) { opts.Paint(t, TileTypeWall) }
+		protected := func(t *Tile) bool { return room[t] || !floor[t] }
+		pruneDeadEnds(g, Max(opts.Prune, 0), fill, []func(*Tile) bool{protected})
+	}
+	return rooms, nil
+}
+
+// Generate carves a dungeon with BSPDungeon, adding the rooms to the Rooms of
+// the GenResult. The Dice, if set, is used in place of opts.Dice.
+func (opts BSPOptions) Generate(g *Grid, dice Dice, res *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	rooms, err := BSPDungeon(g, opts)
+	if res != nil {
+		res.Rooms = append(res.Rooms, rooms...)
+	}
+	return err
+}
+
+// paintTerrain is the default Paint for BSPOptions.
+func paintTerrain(t *Tile, tiletype int) {
+	if tiletype == TileTypeWall {
+		t.SetTerrain(TerrainWall)
+	} else {
+		t.SetTerrain(TerrainFloor)
+	}
+}
+
+// split recursively divides the node, splitting across its longer side when
+// both sides are large enough.
+func (n *bspNode) split(opts *BSPOptions) {
+	canX, canY := n.Leaf.W >= 2*opts.MinLeaf, n.Leaf.H >= 2*opts.MinLeaf
+	switch {
+	case canX && canY:
+		n.Vertical = n.Leaf.W > n.Leaf.H || n.Leaf.W == n.Leaf.H && opts.Dice.Bool()
+	case canX || canY:
+		n.Vertical = canX
+	default:
+		return
+	}
+
+	size, start := n.Leaf.H, n.Leaf.Y
+	if n.Vertical {
+		size, start = n.Leaf.W, n.Leaf.X
+	}
+	ratio := .5 + opts.Jitter*(2*opts.Dice.Float64()-1)
+	at := Clamp(opts.MinLeaf, int(float64(size)*ratio), size-opts.MinLeaf)
+	n.Split = start + at
+
+	left, right := n.Leaf, n.Leaf
+	if n.Vertical {
+		left.W, right.X, right.W = at, n.Split, size-at
+	} else {
+		left.H, right.Y, right.H = at, n.Split, size-at
+	}
+	n.Left, n.Right = &bspNode{Leaf: left}, &bspNode{Leaf: right}
+	n.Left.split(opts)
+	n.Right.split(opts)
+}
+
+// carve paints the rooms of every leaf below the node, followed by the
+// corridors joining them, collecting the rooms in order.
+func (n *bspNode) carve(g *Grid, opts *BSPOptions, rooms *[]Rect) {
+	if n.Left == nil {
+		n.Room = n.Leaf.inset(opts)
+		*rooms = append(*rooms, n.Room)
+		paintRect(g, n.Room, TileTypeRoom, opts)
+		return
+	}
+
+	n.Left.carve(g, opts, rooms)
+	n.Right.carve(g, opts, rooms)
+	n.corridor(g, opts)
+}
+
+// inset chooses a room within a leaf, keeping a wall of at least one Tile
+// around it.
+func (r Rect) inset(opts *BSPOptions) Rect {
+	room := Rect{r.X + 1, r.Y + 1, r.W - 2, r.H - 2}
+	if opts.Fill {
+		return room
+	}
+	w := opts.Dice.Range(Min(3, room.W), room.W)
+	h := opts.Dice.Range(Min(3, room.H), room.H)
+	room.X += opts.Dice.Range(0, room.W-w)
+	room.Y += opts.Dice.Range(0, room.H-h)
+	room.W, room.H = w, h
+	return room
+}
+
+// nearest finds the room below the node which is closest to the split line of
+// an ancestor, on the given side of it.
+func (n *bspNode) nearest(vertical bool, split int, before bool) Rect {
+	if n.Left == nil {
+		return n.Room
+	}
+	a := n.Left.nearest(vertical, split, before)
+	b := n.Right.nearest(vertical, split, before)
+	if bspGap(a, vertical, split, before) <= bspGap(b, vertical, split, before) {
+		return a
+	}
+	return b
+}
+
+// bspGap gives the distance between a room and a split line on its side.
+func bspGap(r Rect, vertical bool, split int, before bool) int {
+	lo, hi := r.Y, r.Y+r.H
+	if vertical {
+		lo, hi = r.X, r.X+r.W
+	}
+	if before {
+		return split - hi
+	}
+	return lo - split
+}
+
+// corridor joins the room closest to the split on each side of the node with
+// a corridor which runs straight from the first room to the split line, along
+// the split line, and then straight into the second room.
+func (n *bspNode) corridor(g *Grid, opts *BSPOptions) {
+	a := n.Left.nearest(n.Vertical, n.Split, true).Center()
+	b := n.Right.nearest(n.Vertical, n.Split, false).Center()
+
+	var path []Offset
+	if n.Vertical {
+		path = append(path, bspLine(a, Offset{n.Split, a.Y})...)
+		path = append(path, bspLine(Offset{n.Split, a.Y}, Offset{n.Split, b.Y})...)
+		path = append(path, bspLine(Offset{n.Split, b.Y}, b)...)
+	} else {
+		path = append(path, bspLine(a, Offset{a.X, n.Split})...)
+		path = append(path, bspLine(Offset{a.X, n.Split}, Offset{b.X, n.Split})...)
+		path = append(path, bspLine(Offset{b.X, n.Split}, b)...)
+	}
+
+	for _, o := range path {
+		if t := g.At(o.X, o.Y); t != nil && !bspInRoom(n, o) {
+			opts.Paint(t, TileTypeCorridor)
+		}
+	}
+}
+
+// bspLine gives the Offsets on a horizontal or vertical line, inclusive.
+func bspLine(from, to Offset) []Offset {
+	step := Offset{Signum(to.X - from.X), Signum(to.Y - from.Y)}
+	line := []Offset{from}
+	for from != to {
+		from = from.Add(step)
+		line = append(line, from)
+	}
+	return line
+}
+
+// bspInRoom returns true if the Offset lies in any room below the node, so
+// that corridors do not repaint rooms.
+func bspInRoom(n *bspNode, o Offset) bool {
+	if n.Left == nil {
+		return n.Room.Contains(o)
+	}
+	return bspInRoom(n.Left, o) || bspInRoom(n.Right, o)
+}
+
+// paintRect paints every Tile of the Grid within the Rect.
+func paintRect(g *Grid, r Rect, tiletype int, opts *BSPOptions) {
+	for x := r.X; x < r.X+r.W; x++ {
+		for y := r.Y; y < r.Y+r.H; y++ {
+			if t := g.At(x, y); t != nil {
+				opts.Paint(t, tiletype)
+			}
+		}
+	}
+}
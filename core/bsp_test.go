@@ -0,0 +1,108 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bspCase carves a BSPDungeon into a new Grid using a Dice with the seed.
+func bspCase(w, h int, seed int64, opts BSPOptions) (*Grid, []Rect) {
+	g, _ := NewGrid(w, h)
+	opts.Dice = NewDice(rand.NewSource(seed))
+	rooms, err := BSPDungeon(g, opts)
+	if err != nil {
+		panic(err)
+	}
+	return g, rooms
+}
+
+func TestBSPDungeon_Connected(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		g, rooms := bspCase(60, 40, seed, BSPOptions{MinLeaf: 6, Jitter: .3})
+		if len(rooms) < 4 {
+			t.Errorf("seed %d gave only %d rooms", seed, len(rooms))
+			continue
+		}
+
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+		g.Each(func(o Offset, tile *Tile) {
+			if edge := o.X == 0 || o.Y == 0 || o.X == g.Width()-1 || o.Y == g.Height()-1; edge && tile.Pass {
+				t.Errorf("seed %d carved edge %v", seed, o)
+			}
+		})
+		for _, room := range rooms {
+			if room.W < 1 || room.H < 1 {
+				t.Errorf("seed %d gave empty room %v", seed, room)
+			}
+		}
+	}
+}
+
+func TestBSPDungeon_Deterministic(t *testing.T) {
+	layout := func(g *Grid) []bool {
+		var pass []bool
+		g.Each(func(_ Offset, tile *Tile) { pass = append(pass, tile.Pass) })
+		return pass
+	}
+
+	opts := BSPOptions{Jitter: .4}
+	g1, rooms1 := bspCase(50, 30, 7, opts)
+	g2, rooms2 := bspCase(50, 30, 7, opts)
+	if !reflect.DeepEqual(rooms1, rooms2) || !reflect.DeepEqual(layout(g1), layout(g2)) {
+		t.Errorf("BSPDungeon() differs for the same seed")
+	}
+}
+
+func TestBSPDungeon_Fill(t *testing.T) {
+	_, rooms := bspCase(32, 16, 1, BSPOptions{MinLeaf: 8, Fill: true})
+	expected := []Rect{{1, 1, 6, 6}, {1, 9, 6, 6}, {9, 1, 6, 6}, {9, 9, 6, 6}}
+	if !reflect.DeepEqual(rooms[:4], expected) {
+		t.Errorf("BSPDungeon(Fill) gave rooms %v", rooms)
+	}
+	if len(rooms) != 8 {
+		t.Errorf("BSPDungeon(Fill) gave %d != 8 rooms", len(rooms))
+	}
+}
+
+func TestBSPDungeon_Small(t *testing.T) {
+	g, rooms := bspCase(2, 5, 1, BSPOptions{})
+	if rooms != nil || g.At(1, 1).Pass {
+		t.Errorf("BSPDungeon() carved a Grid too small for rooms")
+	}
+}
+
+func TestBSPDungeon_Prefabs(t *testing.T) {
+	vault, _ := NewPrefab("#####\n#.~.#\n#####", nil)
+	vault.Rotate = true
+	opts := BSPOptions{MinLeaf: 10, Prefabs: []WeightedPrefab{{vault, 1}}, PrefabCount: 2}
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := bspCase(60, 40, seed, opts)
+		water := g.Count(func(tile *Tile) bool { return tile.Terrain == TerrainWater })
+		if water == 0 {
+			t.Errorf("seed %d placed no Prefabs", seed)
+		}
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+		countPass(t, g)
+	}
+}
+
+func TestBSPDungeon_Stats(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		g, rooms := bspCase(60, 40, seed, BSPOptions{MinLeaf: 6, Jitter: .3})
+		stats := Analyze(g)
+		if stats.Open < .3 || stats.Open > .5 {
+			t.Errorf("seed %d is %.2f open", seed, stats.Open)
+		}
+		if stats.Regions != 1 || stats.Rooms < len(rooms)*3/4 || stats.Rooms > len(rooms) {
+			t.Errorf("seed %d gave %+v for %d rooms", seed, stats, len(rooms))
+		}
+		if stats.CorridorLength < 2 || stats.CorridorLength > 6 || stats.ChokePoints == 0 {
+			t.Errorf("seed %d gave %+v", seed, stats)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+// subscription is a single subscriber to an EventBus.
+type subscription struct {
+	handler   func(Event)
+	cancelled bool
+}
+
+// EventBus broadcasts Events to any subscriber interested in them, rather
+// than addressing a single Entity. Subscribers receive Events in the order in
+// which they subscribed.
+type EventBus struct {
+	subs []*subscription
+}
+
+// NewEventBus creates an EventBus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a function to receive every published Event which has
+// type T. The returned function cancels the subscription, and may be safely
+// called at any time, including during a Publish.
+func Subscribe[T Event](bus *EventBus, handler func(T)) (cancel func()) {
+	return bus.SubscribeAll(func(v Event) {
+		if v, ok := v.(T); ok {
+			handler(v)
+		}
+	})
+}
+
+// SubscribeAll registers a function to receive every published Event. The
+// returned function cancels the subscription, and may be safely called at any
+// time, including during a Publish.
+func (b *EventBus) SubscribeAll(handler func(Event)) (cancel func()) {
+	sub := &subscription{handler, false}
+	b.subs = append(b.subs, sub)
+	return func() { b.cancel(sub) }
+}
+
+// cancel removes a subscription from the bus. The subscription slice is
+// copied, so any in progress Publish can continue iterating over the old one.
+func (b *EventBus) cancel(sub *subscription) {
+	if sub.cancelled {
+		return
+	}
+	sub.cancelled = true
+
+	subs := make([]*subscription, 0, len(b.subs)-1)
+	for _, s := range b.subs {
+		if s != sub {
+			subs = append(subs, s)
+		}
+	}
+	b.subs = subs
+}
+
+// Publish sends an Event to each interested subscriber. Subscribers added
+// during the Publish will not receive the Event, and subscribers cancelled
+// during the Publish will not receive it if they have not already. If the
+// Event is Consumable, publication stops once it is consumed.
+func (b *EventBus) Publish(v Event) {
+	if eventTracer != nil {
+		traceEnter(nil, v)
+		defer traceExit()
+	}
+	for _, sub := range b.subs {
+		if isConsumed(v) {
+			return
+		}
+		if !sub.cancelled {
+			sub.handler(v)
+		}
+	}
+}
+
+// Len returns the number of active subscriptions on the EventBus.
+func (b *EventBus) Len() int {
+	return len(b.subs)
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventBus_Publish(t *testing.T) {
+	bus := NewEventBus()
+	var seen []string
+
+	Subscribe(bus, func(v *Bump) { seen = append(seen, "bump") })
+	var cancelCollide func()
+	cancelCollide = Subscribe(bus, func(v *Collide) {
+		seen = append(seen, "collide")
+		cancelCollide()
+	})
+	bus.SubscribeAll(func(v Event) { seen = append(seen, "all") })
+
+	bus.Publish(&Bump{})
+	bus.Publish(&Collide{})
+	bus.Publish(&Collide{})
+	if expected := []string{"bump", "all", "collide", "all", "all"}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("Publish() delivered %v != %v", seen, expected)
+	}
+	if bus.Len() != 2 {
+		t.Errorf("Len() = %d != 2", bus.Len())
+	}
+	cancelCollide()
+	if bus.Len() != 2 {
+		t.Errorf("Len() after double cancel = %d != 2", bus.Len())
+	}
+}
+
+func TestEventBus_cancelDuringPublish(t *testing.T) {
+	bus := NewEventBus()
+	var seen []string
+
+	var cancelB func()
+	Subscribe(bus, func(v *Bump) {
+		seen = append(seen, "a")
+		cancelB()
+		Subscribe(bus, func(v *Bump) { seen = append(seen, "new") })
+	})
+	cancelB = Subscribe(bus, func(v *Bump) { seen = append(seen, "b") })
+	Subscribe(bus, func(v *Bump) { seen = append(seen, "c") })
+
+	bus.Publish(&Bump{})
+	if expected := []string{"a", "c"}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("Publish() delivered %v != %v", seen, expected)
+	}
+}
+
+func TestEventBus_consume(t *testing.T) {
+	bus := NewEventBus()
+	var seen []string
+	Subscribe(bus, func(v *testevent) { seen = append(seen, "a"); v.Consume() })
+	Subscribe(bus, func(v *testevent) { seen = append(seen, "b") })
+
+	bus.Publish(&testevent{})
+	if expected := []string{"a"}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("Publish() delivered %v != %v", seen, expected)
+	}
+}
@@ -12,6 +12,7 @@ type deltanode struct {
 }
 
 // DeltaClock implements a data structure which allows for fast scheduling.
+// It only orders events, so game time is kept separately by a Clock.
 //
 // The DeltaClock is essentially a linked list in which each node stores a
 // collection of Entity events, and a delta (in time) until the next node.
@@ -107,4 +108,61 @@ func (c *DeltaClock) Advance() map[Entity]struct{} {
 	return events
 }
 
+// TicksPerTurn is the number of Clock ticks in a standard turn. This is the
+// same as the default Threshold of a Scheduler, so the Cost of an action can
+// be given directly in ticks. For example, an action with a Cost of 50 takes
+// half a turn.
+const TicksPerTurn = 100
+
+// Tick is an Event published by a Clock at the end of each turn, for things
+// which happen over time regardless of who is acting, such as hunger or the
+// turn counter on a HUD.
+type Tick struct {
+	Turn int
+}
+
+// Clock tracks game time in ticks. Each time the Clock passes the end of a
+// turn, it publishes a Tick on its EventBus, if any. A Scheduler with a Clock
+// advances it by one turn on each of its Ticks.
+//
+// Whereas a DeltaClock only decides which Entity acts next from their relative
+// delays, and keeps no record of the time which has passed, a Clock only keeps
+// that record. A game driven by a DeltaClock rather than a Scheduler should
+// Advance its Clock by the delay of each action itself.
+//
+// Only Ticks is exported, so a Clock can be saved with gob to keep durations
+// across a reload, but the EventBus must be set again with SetBus afterward.
+type Clock struct {
+	Ticks int
+
+	bus *EventBus
+}
+
+// NewClock creates a Clock at turn zero which publishes Tick on the EventBus.
+func NewClock(bus *EventBus) *Clock {
+	return &Clock{0, bus}
+}
+
+// SetBus changes the EventBus on which the Clock publishes each Tick.
+func (c *Clock) SetBus(bus *EventBus) {
+	c.bus = bus
+}
+
+// Now returns the current turn.
+func (c *Clock) Now() int {
+	return c.Ticks / TicksPerTurn
+}
+
+// Advance moves the Clock forward by the given number of ticks, publishing a
+// Tick for each turn which ends along the way.
+func (c *Clock) Advance(ticks int) {
+	start := c.Now()
+	c.Ticks += ticks
+	for turn := start + 1; turn <= c.Now(); turn++ {
+		if c.bus != nil {
+			c.bus.Publish(&Tick{turn})
+		}
+	}
+}
+
 // TODO Add distance based delay calculator
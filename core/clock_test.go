@@ -1,6 +1,9 @@
 package core
 
 import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
 	"testing"
 )
 
@@ -60,3 +63,50 @@ func TestDeltaClock_Unschedule(t *testing.T) {
 	schedule := [][]Entity{{e1}, {}, {e1}}
 	checkSchedule(t, c, schedule, speeds)
 }
+
+func TestClock_Advance(t *testing.T) {
+	bus := NewEventBus()
+	var turns []int
+	Subscribe(bus, func(v *Tick) { turns = append(turns, v.Turn) })
+	clock := NewClock(bus)
+
+	clock.Advance(50)
+	if clock.Now() != 0 || len(turns) != 0 {
+		t.Errorf("Advance(50) gave turn %d, ticks %v", clock.Now(), turns)
+	}
+	clock.Advance(260)
+	if clock.Now() != 3 || !reflect.DeepEqual(turns, []int{1, 2, 3}) {
+		t.Errorf("Advance(260) gave turn %d, ticks %v", clock.Now(), turns)
+	}
+}
+
+func TestClock_Scheduler(t *testing.T) {
+	bus := NewEventBus()
+	var turns []int
+	Subscribe(bus, func(v *Tick) { turns = append(turns, v.Turn) })
+	s := NewScheduler()
+	s.Clock = NewClock(bus)
+	for i := 0; i < 3; i++ {
+		s.Tick()
+	}
+	if s.Clock.Now() != 3 || !reflect.DeepEqual(turns, []int{1, 2, 3}) {
+		t.Errorf("Scheduler advanced Clock to turn %d, ticks %v", s.Clock.Now(), turns)
+	}
+}
+
+func TestClock_Save(t *testing.T) {
+	clock := NewClock(nil)
+	clock.Advance(1234)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(clock); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	var loaded Clock
+	if err := gob.NewDecoder(&buf).Decode(&loaded); err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if loaded.Now() != 12 || loaded.Ticks != 1234 {
+		t.Errorf("loaded Clock at %d ticks", loaded.Ticks)
+	}
+}
@@ -0,0 +1,247 @@
+package core
+
+// Attack is an Event sent to the Target when the Attacker attacks it. Amount
+// and Type describe the Damage the attack will deal if it lands. Consuming the
+// Attack, for example by dodging, prevents any Damage. Before it is sent, the
+// Attack is handled by the Attacker with Outgoing set, so that Components such
+// as Equipment may modify it.
+type Attack struct {
+	Consumption
+	Attacker Entity
+	Target   Entity
+	Amount   int
+	Type     string
+	Outgoing bool
+}
+
+// Damage is an Event dealing damage to an Entity. Components such as Defense
+// may reduce the Amount, or consume the Damage entirely, before it reaches
+// the Health of the Entity.
+type Damage struct {
+	Consumption
+	Amount int
+	Type   string
+	Source Entity
+}
+
+// RangedAttack is an Event sent to an Entity to attack the occupant of the
+// Target Tile from the From Tile, such as with a bow or a spell. The Combat of
+// the Entity checks the attack with Check, and if it is valid, resolves it
+// with the same Attack as a melee attack and sets Done. Otherwise, Err is set
+// to ErrOutOfRange, ErrNoLineOfSight or ErrNoTarget, so that the UI can
+// explain the failure. If nothing handles the RangedAttack, Done stays false.
+//
+// Range is measured using Chebyshev distance, or Euclidean distance if
+// Euclidean is true. If Launch is non-nil, the Attack is passed to Launch
+// instead of being sent to the target, such as to make it the Payload of a
+// Projectile.
+type RangedAttack struct {
+	Attacker  Entity
+	From      *Tile
+	Target    *Tile
+	Range     int
+	Euclidean bool
+	Launch    func(*Attack)
+	Err       error
+	Done      bool
+}
+
+// Check determines whether the RangedAttack can be made, returning nil if the
+// Target is within Range, is in line of sight of From, and has an occupant. If
+// From or Target is nil, ErrNoTarget is returned.
+func (v *RangedAttack) Check() error {
+	if v.From == nil || v.Target == nil {
+		return ErrNoTarget
+	}
+	delta := v.Target.Offset.Sub(v.From.Offset)
+	if v.Euclidean && delta.Euclidean() > float64(v.Range) || !v.Euclidean && delta.Chebyshev() > v.Range {
+		return ErrOutOfRange
+	}
+	if !LoS(v.From, v.Target) {
+		return ErrNoLineOfSight
+	}
+	if v.Target.Occupant == nil {
+		return ErrNoTarget
+	}
+	return nil
+}
+
+// Combat is a Component providing standard melee and ranged attacks. When its
+// Entity bumps another, Combat sends an Attack to the bumped Entity, and when
+// its Entity is sent a RangedAttack, Combat sends an Attack to the occupant of
+// the Target. When its Entity is attacked, Combat turns the Attack into
+// Damage. Before an Attack is sent, it is handled by the attacking Entity
+// itself as an Outgoing Attack, which Combat ignores.
+type Combat struct {
+	Self  Entity
+	Power int
+	Type  string
+
+	// Hostile decides whether to attack a bumped Entity. If nil, every bumped
+	// Entity is attacked.
+	Hostile func(Entity) bool
+
+	// Formula computes the Amount of an outgoing Attack. If nil, the Amount
+	// is simply the Power.
+	Formula func(attacker, target Entity) int
+}
+
+// Process implements Component for Combat.
+func (c *Combat) Process(v Event) {
+	switch v := v.(type) {
+	case *Bump:
+		if c.Hostile == nil || c.Hostile(v.Bumped) {
+			send(v.Bumped, c.attack(v.Bumped))
+		}
+	case *RangedAttack:
+		if v.Err = v.Check(); v.Err != nil {
+			return
+		}
+		attack := c.attack(v.Target.Occupant)
+		if v.Launch != nil {
+			v.Launch(attack)
+		} else {
+			send(v.Target.Occupant, attack)
+		}
+		v.Done = true
+	case *Attack:
+		if !v.Outgoing {
+			send(c.Self, &Damage{Amount: v.Amount, Type: v.Type, Source: v.Attacker})
+		}
+	}
+}
+
+// attack creates an Attack against the target, letting the Entity modify it.
+func (c *Combat) attack(target Entity) *Attack {
+	attack := &Attack{Attacker: c.Self, Target: target, Type: c.Type, Outgoing: true}
+	if c.Formula != nil {
+		attack.Amount = c.Formula(c.Self, target)
+	} else {
+		attack.Amount = c.Power
+	}
+	c.Self.Handle(attack)
+	attack.Outgoing = false
+	return attack
+}
+
+// Defense is a Component which reduces incoming Damage before it reaches the
+// Health of its Entity. Defense has a priority of 1, so in a SortedEntity it
+// processes Damage before Component with the default priority. Damage which is
+// reduced to nothing is consumed.
+type Defense struct {
+	Armor int
+
+	// Reduce modifies incoming Damage. If nil, the Amount is reduced by the
+	// Armor.
+	Reduce func(*Damage)
+}
+
+// Priority implements Prioritized for Defense.
+func (c *Defense) Priority() int {
+	return 1
+}
+
+// Process implements Component for Defense.
+func (c *Defense) Process(v Event) {
+	if v, ok := v.(*Damage); ok {
+		if c.Reduce != nil {
+			c.Reduce(v)
+		} else {
+			v.Amount -= c.Armor
+		}
+		if v.Amount <= 0 {
+			v.Amount = 0
+			v.Consume()
+		}
+	}
+}
+
+// Health is a Component tracking the hit points of its Entity.
+type Health struct {
+	HP  int
+	Max int
+}
+
+// NewHealth creates a Health with full hit points.
+func NewHealth(max int) *Health {
+	return &Health{max, max}
+}
+
+// Process implements Component for Health.
+func (c *Health) Process(v Event) {
+	if v, ok := v.(*Damage); ok {
+		c.HP -= v.Amount
+	}
+}
+
+// Dead returns true if the hit points have reached zero.
+func (c *Health) Dead() bool {
+	return c.HP <= 0
+}
+
+// Death is an Event informing an Entity that the Victim has died. Killer is
+// the Source of the fatal Damage, if known.
+type Death struct {
+	Victim Entity
+	Killer Entity
+}
+
+// Mortal is a Component which sends a Death to its Entity once the tracked
+// Health reaches zero. Mortal has a priority of -1, so in a SortedEntity it
+// checks the Health only after Damage has been applied. Death is sent at most
+// once.
+type Mortal struct {
+	Self   Entity
+	Health *Health
+	dead   bool
+}
+
+// Priority implements Prioritized for Mortal.
+func (c *Mortal) Priority() int {
+	return -1
+}
+
+// Process implements Component for Mortal.
+func (c *Mortal) Process(v Event) {
+	if v, ok := v.(*Damage); ok && !c.dead && c.Health.Dead() {
+		c.dead = true
+		send(c.Self, &Death{c.Self, v.Source})
+	}
+}
+
+// Remains is a Component which cleans up after the death of its Entity. On
+// Death, the Entity is removed as the Occupant of its Tile, the Corpse (if
+// any) is placed on the Tile, the Entity is removed from the Clock and the
+// Scheduler (if any), and the Death is published on the Bus (if any). The
+// position of the Entity is tracked through UpdatePos.
+type Remains struct {
+	Pos       *Tile
+	Corpse    Entity
+	Clock     *DeltaClock
+	Scheduler *Scheduler
+	Bus       *EventBus
+}
+
+// Process implements Component for Remains.
+func (c *Remains) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *Death:
+		if c.Pos != nil {
+			c.Pos.Vacate(v.Victim)
+			if c.Corpse != nil {
+				c.Pos.Handle(&PlaceItem{c.Corpse})
+			}
+		}
+		if c.Clock != nil {
+			c.Clock.Unschedule(v.Victim)
+		}
+		if c.Scheduler != nil {
+			c.Scheduler.Remove(v.Victim)
+		}
+		if c.Bus != nil {
+			c.Bus.Publish(v)
+		}
+	}
+}
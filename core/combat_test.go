@@ -0,0 +1,231 @@
+package core
+
+import (
+	"testing"
+)
+
+// testfighter is a simple combatant made from a ComponentSlice.
+type testfighter struct {
+	ComponentSlice
+	combat  *Combat
+	defense *Defense
+	health  *Health
+}
+
+func newtestfighter(power, armor, hp int) *testfighter {
+	f := &testfighter{}
+	f.combat = &Combat{Self: f, Power: power, Type: "blunt"}
+	f.defense = &Defense{Armor: armor}
+	f.health = NewHealth(hp)
+	f.ComponentSlice = ComponentSlice{f.combat, f.defense, f.health}
+	return f
+}
+
+func TestCombat(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	hero := newtestfighter(3, 0, 10)
+	orc := newtestfighter(2, 1, 5)
+	tiles[0].Occupant, tiles[1].Occupant = hero, orc
+
+	cases := []struct {
+		attacker *Tile
+		delta    Offset
+		heroHP   int
+		orcHP    int
+	}{
+		{tiles[0], Offset{1, 0}, 10, 3},
+		{tiles[1], Offset{-1, 0}, 8, 3},
+		{tiles[0], Offset{1, 0}, 8, 1},
+		{tiles[0], Offset{1, 0}, 8, -1},
+	}
+	for i, c := range cases {
+		c.attacker.Handle(&MoveEntity{Delta: c.delta})
+		if hero.health.HP != c.heroHP || orc.health.HP != c.orcHP {
+			t.Errorf("case %d: hp = %d, %d != %d, %d", i, hero.health.HP, orc.health.HP, c.heroHP, c.orcHP)
+		}
+	}
+	if !orc.health.Dead() || hero.health.Dead() {
+		t.Errorf("Dead() = %v, %v", hero.health.Dead(), orc.health.Dead())
+	}
+}
+
+// newtestslice creates a combatant which is a plain ComponentSlice, with no
+// wrapper to compare by pointer.
+func newtestslice(power, hp int, pos *Tile, bus *EventBus) (ComponentSlice, *Health) {
+	combat, health := &Combat{Power: power}, NewHealth(hp)
+	mortal, remains := &Mortal{Health: health}, &Remains{Pos: pos, Bus: bus}
+	e := ComponentSlice{combat, &Defense{}, health, mortal, remains}
+	combat.Self, mortal.Self = e, e
+	pos.Occupant = e
+	return e, health
+}
+
+func TestCombat_ComponentSlice(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	bus := NewEventBus()
+	hero, heroHP := newtestslice(3, 10, tiles[0], bus)
+	orc, orcHP := newtestslice(2, 5, tiles[1], bus)
+	var deaths []Death
+	Subscribe(bus, func(v *Death) { deaths = append(deaths, *v) })
+
+	// bump, attack, damage and death all reach plain ComponentSlice entities
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	tiles[1].Handle(&MoveEntity{Delta: Offset{-1, 0}})
+	if heroHP.HP != 8 || orcHP.HP != 2 {
+		t.Errorf("hp = %d, %d != 8, 2", heroHP.HP, orcHP.HP)
+	}
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if len(deaths) != 1 || !sameEntity(deaths[0].Victim, orc) || !sameEntity(deaths[0].Killer, hero) {
+		t.Errorf("Death = %v", deaths)
+	}
+	if tiles[1].Occupant != nil || !sameEntity(tiles[0].Occupant, hero) {
+		t.Errorf("Death left occupants %v, %v", tiles[0].Occupant, tiles[1].Occupant)
+	}
+}
+
+func TestDefense(t *testing.T) {
+	cases := []struct {
+		armor    int
+		amount   int
+		expected int
+		consumed bool
+	}{
+		{0, 5, 5, false},
+		{2, 5, 3, false},
+		{5, 5, 0, true},
+		{7, 5, 0, true},
+	}
+	for _, c := range cases {
+		damage := Damage{Amount: c.amount}
+		(&Defense{Armor: c.armor}).Process(&damage)
+		if damage.Amount != c.expected || damage.Consumed() != c.consumed {
+			t.Errorf("Defense{%d} on %d = %d, %v", c.armor, c.amount, damage.Amount, damage.Consumed())
+		}
+	}
+}
+
+func TestCombat_Hostile(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	hero := newtestfighter(3, 0, 10)
+	dog := newtestfighter(2, 0, 5)
+	hero.combat.Hostile = func(e Entity) bool { return e != dog }
+	tiles[0].Occupant, tiles[1].Occupant = hero, dog
+
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if dog.health.HP != 5 {
+		t.Errorf("Combat attacked non-hostile Entity")
+	}
+}
+
+func TestCombat_RangedAttack(t *testing.T) {
+	hero := newtestfighter(3, 0, 10)
+	orc := newtestfighter(2, 0, 5)
+	tiles := map[byte]*Tile{}
+	StrGrid{
+		"#######",
+		"#@.#g.#",
+		"#.....#",
+		"#..o.x#",
+		"#######",
+	}.Convert(func(t *Tile, ch byte) {
+		t.Lite = ch != '#'
+		t.Pass = ch != '#'
+		tiles[ch] = t
+		switch ch {
+		case '@':
+			t.Occupant = hero
+		case 'o':
+			t.Occupant = orc
+		case 'g':
+			t.Occupant = newtestfighter(2, 0, 5)
+		}
+	})
+
+	cases := []struct {
+		target    byte
+		rng       int
+		euclidean bool
+		err       error
+	}{
+		{'o', 1, false, ErrOutOfRange},
+		{'x', 4, false, ErrNoTarget},
+		{'n', 4, false, ErrNoTarget}, // nil Target
+		{'g', 4, false, ErrNoLineOfSight},
+		{'o', 2, true, ErrOutOfRange},
+		{'o', 2, false, nil},
+	}
+	for _, c := range cases {
+		attack := RangedAttack{Attacker: hero, From: tiles['@'], Target: tiles[c.target], Range: c.rng, Euclidean: c.euclidean}
+		hero.Handle(&attack)
+		if attack.Err != c.err || attack.Done != (c.err == nil) {
+			t.Errorf("RangedAttack at %c with range %d gave %v, %v != %v", c.target, c.rng, attack.Err, attack.Done, c.err)
+		}
+	}
+	if orc.health.HP != 2 {
+		t.Errorf("RangedAttack left orc with %d hp", orc.health.HP)
+	}
+
+	var launched *Attack
+	attack := RangedAttack{Attacker: hero, From: tiles['@'], Target: tiles['o'], Range: 2, Launch: func(a *Attack) { launched = a }}
+	hero.Handle(&attack)
+	if launched == nil || launched.Target != orc || launched.Amount != 3 || orc.health.HP != 2 {
+		t.Errorf("RangedAttack with Launch gave %v", launched)
+	}
+}
+
+func TestDeath(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	hero := newtestfighter(3, 0, 10)
+	tiles[0].Occupant = hero
+
+	orc := NewEntityMut()
+	health := NewHealth(4)
+	combat := &Combat{Self: orc, Power: 1}
+	corpse := &testitem{Glyph{'%', ColorRed}, false}
+	clock := NewDeltaClock()
+	bus := NewEventBus()
+	orc.Attach(combat)
+	orc.Attach(&Defense{})
+	orc.Attach(health)
+	orc.Attach(&Mortal{Self: orc, Health: health})
+	orc.Attach(&Remains{Corpse: corpse, Clock: clock, Bus: bus})
+	// detaching during Death must not disturb the remaining Components
+	orc.Attach(testfunc(func(v Event) {
+		if _, ok := v.(*Death); ok {
+			orc.Detach(combat)
+		}
+	}))
+	tiles[1].Occupant = orc
+	orc.Handle(&UpdatePos{tiles[1]})
+	clock.Schedule(orc, 1)
+
+	deaths := 0
+	Subscribe(bus, func(v *Death) { deaths++ })
+
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != orc || deaths != 0 {
+		t.Errorf("orc died early")
+	}
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != nil {
+		t.Errorf("Death did not clear Occupant")
+	}
+	if len(tiles[1].Items) != 1 || tiles[1].Items[0] != corpse {
+		t.Errorf("Death did not leave corpse: %v", tiles[1].Items)
+	}
+	if len(clock.Advance()) != 0 {
+		t.Errorf("Death did not unschedule victim")
+	}
+	if deaths != 1 {
+		t.Errorf("Death published %d times", deaths)
+	}
+	if len(orc.Components()) != 5 {
+		t.Errorf("Death did not detach combat")
+	}
+
+	// further damage does not cause another Death
+	orc.Handle(&Damage{Amount: 1})
+	if deaths != 1 {
+		t.Errorf("Death published %d times", deaths)
+	}
+}
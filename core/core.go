@@ -44,11 +44,37 @@ type Key rune
 const (
 	KeyEsc   Key = Key(termbox.KeyEsc)
 	KeyEnter Key = Key(termbox.KeyEnter)
+	KeyTab   Key = Key(termbox.KeyTab)
 	KeyCtrlC Key = Key(termbox.KeyCtrlC)
 	KeyPgup  Key = Key(termbox.KeyPgup)
 	KeyPgdn  Key = Key(termbox.KeyPgdn)
+	KeyHome  Key = Key(termbox.KeyHome)
+	KeyEnd   Key = Key(termbox.KeyEnd)
+
+	KeyArrowUp    Key = Key(termbox.KeyArrowUp)
+	KeyArrowDown  Key = Key(termbox.KeyArrowDown)
+	KeyArrowLeft  Key = Key(termbox.KeyArrowLeft)
+	KeyArrowRight Key = Key(termbox.KeyArrowRight)
+
+	KeyBackspace Key = Key(termbox.KeyBackspace2)
+	KeyCtrlH     Key = Key(termbox.KeyCtrlH)
+
+	KeyMouseLeft    Key = Key(termbox.MouseLeft)
+	KeyMouseMiddle  Key = Key(termbox.MouseMiddle)
+	KeyMouseRight   Key = Key(termbox.MouseRight)
+	KeyMouseRelease Key = Key(termbox.MouseRelease)
 )
 
+// Keys converts a string to the Key for each of its runes, such as for the
+// Accept keys of a Targeter.
+func Keys(s string) []Key {
+	var keys []Key
+	for _, ch := range s {
+		keys = append(keys, Key(ch))
+	}
+	return keys
+}
+
 // Offset stores a 2-dimensional int vector.
 type Offset struct {
 	X, Y int
@@ -89,19 +115,27 @@ func (o Offset) Chebyshev() int {
 	return Max(Abs(o.X), Abs(o.Y))
 }
 
-// KeyMap stores default directional Key values. This dictionary can be edited
-// to affect any core functions which require knowledge of directional keys.
-var KeyMap = map[Key]Offset{
-	'h': {-1, 0}, '4': {-1, 0},
-	'l': {1, 0}, '6': {1, 0},
-	'k': {0, -1}, '8': {0, -1},
-	'j': {0, 1}, '2': {0, 1},
-	'u': {1, -1}, '9': {1, -1},
-	'y': {-1, -1}, '7': {-1, -1},
-	'n': {1, 1}, '3': {1, 1},
-	'b': {-1, 1}, '1': {-1, 1},
+// Rect stores an axis aligned rectangle of W columns and H rows, with its top
+// left corner at X, Y.
+type Rect struct {
+	X, Y, W, H int
 }
 
+// Contains returns true if the Offset lies within the Rect.
+func (r Rect) Contains(o Offset) bool {
+	return InRange(o.X, r.X, r.X+r.W) && InRange(o.Y, r.Y, r.Y+r.H)
+}
+
+// Center returns the Offset at the middle of the Rect, rounding up and left.
+func (r Rect) Center() Offset {
+	return Offset{r.X + (r.W-1)/2, r.Y + (r.H-1)/2}
+}
+
+// KeyMap stores default directional Key values. This dictionary can be edited
+// to affect any core functions which require knowledge of directional keys, and
+// is regenerated by SetBindings.
+var KeyMap = DefaultBindings().Offsets()
+
 // Max returns the maximum of x and y.
 func Max(x, y int) int {
 	if y > x {
@@ -180,5 +214,42 @@ func (e Error) Error() string {
 
 // Custom stones errors to explicitly check against.
 var (
-	ErrInvalidDimensions = Error("grid: invalid dimensions")
+	ErrInvalidDimensions  = Error("grid: invalid dimensions")
+	ErrNoTile             = Error("grid: no tile satisfies constraints")
+	ErrUnknownRune        = Error("grid: rune missing from legend")
+	ErrCorruptGrid        = Error("grid: corrupt encoding")
+	ErrGridVersion        = Error("grid: unsupported encoding version")
+	ErrOccupied           = Error("grid: tile is occupied")
+	ErrNoFit              = Error("grid: prefab does not fit")
+	ErrDuplicateID        = Error("registry: duplicate entity id")
+	ErrInvalidID          = Error("registry: invalid entity id")
+	ErrEventOverflow      = Error("queue: event limit exceeded")
+	ErrUnregistered       = Error("save: unregistered entity")
+	ErrInvalidTile        = Error("save: invalid tile reference")
+	ErrUnregisteredType   = Error("save: entity type not registered")
+	ErrSaveCycle          = Error("save: entity contains a reference cycle")
+	ErrNotSave            = Error("save: not a saved game")
+	ErrSaveVersion        = Error("save: unsupported format version")
+	ErrTruncatedSave      = Error("save: file is truncated")
+	ErrSaveChecksum       = Error("save: checksum mismatch")
+	ErrCorruptSave        = Error("save: corrupt data")
+	ErrSaveSchema         = Error("save: from a newer version of the game")
+	ErrNoMigration        = Error("save: no migration from an older version")
+	ErrUnknownPrototype   = Error("proto: unknown prototype")
+	ErrPrototypeCycle     = Error("proto: prototype extends itself")
+	ErrUnknownConstructor = Error("proto: unknown component constructor")
+	ErrUnknownColor       = Error("proto: unknown color")
+	ErrOutOfRange         = Error("ranged: target out of range")
+	ErrNoLineOfSight      = Error("ranged: target not in line of sight")
+	ErrNoTarget           = Error("ranged: no target")
+	ErrUnknownEvent       = Error("journal: unknown event type")
+	ErrBindingSyntax      = Error("bindings: expected action = key")
+	ErrUnknownKey         = Error("bindings: unknown key name")
+	ErrBindingConflict    = Error("bindings: key bound to two actions")
+	ErrSettingSyntax      = Error("settings: expected name = value")
+	ErrUnknownSetting     = Error("settings: unknown setting")
+	ErrInvalidSetting     = Error("settings: invalid value")
+	ErrScoresLocked       = Error("scores: file is locked by another game")
+	ErrDiceState          = Error("dice: source state cannot be captured")
+	ErrCorruptDice        = Error("dice: corrupt state")
 )
@@ -2,6 +2,7 @@ package core
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -273,3 +274,12 @@ func TestOffset_Chebyshev(t *testing.T) {
 		}
 	}
 }
+
+func TestKeys(t *testing.T) {
+	if actual, expected := Keys("tä"), []Key{'t', 'ä'}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Keys() = %v != %v", actual, expected)
+	}
+	if actual := Keys(""); len(actual) != 0 {
+		t.Errorf("Keys(\"\") = %v", actual)
+	}
+}
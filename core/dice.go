@@ -1,18 +1,59 @@
 package core
 
 import (
+	"encoding"
+	"encoding/binary"
+	"hash/fnv"
 	"math/rand"
 	"time"
 )
 
 // Dice extends rand.Rand to include functionality useful for roguelikes.
+//
+// Options such as BSPOptions take a Dice for all of their random choices,
+// falling back to the global Dice if it is unset, so that a seeded Dice gives
+// the same results each time, as is needed for replays.
 type Dice struct {
 	*rand.Rand
+	src rand.Source
 }
 
 // NewDice creates a new Dice with the given random source.
 func NewDice(src rand.Source) Dice {
-	return Dice{rand.New(src)}
+	return Dice{rand.New(src), src}
+}
+
+// NewSeededDice creates a new Dice with the same xorshift source as the global
+// Dice, seeded with the given value. Unlike most sources, its exact state can
+// be captured with MarshalBinary.
+func NewSeededDice(seed int64) Dice {
+	return NewDice(newXorshift(seed))
+}
+
+// MarshalBinary captures the exact state of the Dice, so that UnmarshalBinary
+// can later restore it to produce the same values from that point on, as is
+// needed for saved games and replays once an unknown number of values have
+// been used. Only a source which implements encoding.BinaryMarshaler, such as
+// that of NewSeededDice, can be captured, and any other gives ErrDiceState.
+// Since copies of a Dice share their source, restoring any copy restores all
+// of them.
+func (d Dice) MarshalBinary() ([]byte, error) {
+	if m, ok := d.src.(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	return nil, ErrDiceState
+}
+
+// UnmarshalBinary restores the state captured by MarshalBinary. A zero Dice
+// is first given the source of NewSeededDice.
+func (d *Dice) UnmarshalBinary(data []byte) error {
+	if d.src == nil {
+		*d = NewSeededDice(0)
+	}
+	if u, ok := d.src.(encoding.BinaryUnmarshaler); ok {
+		return u.UnmarshalBinary(data)
+	}
+	return ErrDiceState
 }
 
 // Bool returns true with probability .5 and false otherwise.
@@ -88,7 +129,7 @@ func (d Dice) PassTile(tiles []*Tile) *Tile {
 
 // Similar to the math/rand package, we use a global instance Dice. However,
 // ours uses a superior xorshift source and is seeded using the current time.
-var globalDice = NewDice(newXorshift(time.Now().UnixNano()))
+var globalDice = NewSeededDice(time.Now().UnixNano())
 
 // RandBool returns true with probability .5 and false otherwise.
 func RandBool() bool {
@@ -255,6 +296,31 @@ func (x *xorshift) Int63() int64 {
 	return int64(c >> 1)
 }
 
+// MarshalBinary encodes the state of the xorshift as a version byte, the 16
+// words of state and the index.
+func (x *xorshift) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 1+16*8+1)
+	data[0] = 1
+	for i, s := range x.state {
+		binary.BigEndian.PutUint64(data[1+i*8:], s)
+	}
+	data[len(data)-1] = byte(x.index)
+	return data, nil
+}
+
+// UnmarshalBinary decodes the state written by MarshalBinary, giving
+// ErrCorruptDice if it is malformed.
+func (x *xorshift) UnmarshalBinary(data []byte) error {
+	if len(data) != 1+16*8+1 || data[0] != 1 || data[len(data)-1] >= 16 {
+		return ErrCorruptDice
+	}
+	for i := range x.state {
+		x.state[i] = binary.BigEndian.Uint64(data[1+i*8:])
+	}
+	x.index = int(data[len(data)-1])
+	return nil
+}
+
 // newXorshift returns a rand.Source which implements the xorshift1024*
 // algorithm. While not cryptographically secure, this source should be
 // superior in both speed and randomness to the default GFSR source found in
@@ -264,3 +330,23 @@ func newXorshift(seed int64) rand.Source {
 	x.Seed(seed)
 	return &x
 }
+
+// DiceStreams holds independent named Dice, such as one for map generation and
+// one for combat, so that gameplay only draws from its own stream, and the
+// interface can use any other Dice for cosmetic randomness without changing
+// the results of a replay. DiceStreams can be saved with gob, such as in the
+// extra data of SaveGame, capturing the exact state of each Dice.
+type DiceStreams map[string]Dice
+
+// NewDiceStreams creates DiceStreams with a Dice for each name, as with
+// NewSeededDice, each seeded from both the seed and its name so that the
+// streams differ from each other.
+func NewDiceStreams(seed int64, names ...string) DiceStreams {
+	streams := make(DiceStreams, len(names))
+	for _, name := range names {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		streams[name] = NewSeededDice(seed ^ int64(h.Sum64()))
+	}
+	return streams
+}
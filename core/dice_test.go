@@ -1,7 +1,10 @@
 package core
 
 import (
+	"bytes"
+	"encoding/gob"
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -131,6 +134,57 @@ func TestRandSeed(t *testing.T) {
 	}
 }
 
+func TestDice_MarshalBinary(t *testing.T) {
+	dice := NewSeededDice(7)
+	for i := 0; i < 37; i++ {
+		dice.Int63()
+	}
+	state, err := dice.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() = %v", err)
+	}
+	var expected []int64
+	for i := 0; i < 20; i++ {
+		expected = append(expected, dice.Int63())
+	}
+
+	var restored Dice
+	if err := restored.UnmarshalBinary(state); err != nil {
+		t.Fatalf("UnmarshalBinary() = %v", err)
+	}
+	for i, e := range expected {
+		if actual := restored.Int63(); actual != e {
+			t.Fatalf("restored Dice gave %d != %d at %d", actual, e, i)
+		}
+	}
+
+	if err := restored.UnmarshalBinary(state[1:]); err != ErrCorruptDice {
+		t.Errorf("UnmarshalBinary() of short state = %v", err)
+	}
+	if _, err := NewDice(rand.NewSource(1)).MarshalBinary(); err != ErrDiceState {
+		t.Errorf("MarshalBinary() of rand.Source = %v", err)
+	}
+}
+
+func TestDiceStreams(t *testing.T) {
+	streams := NewDiceStreams(1, "map", "combat")
+	if streams["map"].Int63() == streams["combat"].Int63() {
+		t.Errorf("NewDiceStreams() gave identical streams")
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(streams); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	expected := streams["combat"].Int63()
+	var loaded DiceStreams
+	if err := gob.NewDecoder(&buf).Decode(&loaded); err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if actual := loaded["combat"].Int63(); actual != expected {
+		t.Errorf("decoded stream gave %d != %d", actual, expected)
+	}
+}
+
 func TestRandBool(t *testing.T) {
 	for _, seed := range seeds {
 		RandSeed(seed)
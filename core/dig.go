@@ -0,0 +1,52 @@
+package core
+
+// TerrainChanged is an Event published on the EventBus of a Grid whenever
+// Grid.SetTerrain changes a Tile, so that anything depending on the terrain,
+// such as autotiled walls or a minimap, can update. Old describes the Tile as
+// it was before the change.
+type TerrainChanged struct {
+	Grid *Grid
+	Tile *Tile
+	Old  TileSpec
+}
+
+// SetBus changes the EventBus on which the Grid publishes TerrainChanged.
+func (g *Grid) SetBus(bus *EventBus) {
+	g.bus = bus
+}
+
+// SetTerrain changes a Tile of the Grid according to the TileSpec, as with
+// ParseGrid, and publishes a TerrainChanged. A Tile with an Occupant cannot be
+// made impassable, so ErrOccupied is returned and the Tile is unchanged until
+// the Occupant has been dealt with.
+func (g *Grid) SetTerrain(t *Tile, spec TileSpec) error {
+	old := TileSpec{Terrain: t.Terrain, Face: t.Face, Pass: t.Pass, Lite: t.Lite}
+
+	next, setup := *t, spec.Setup
+	spec.Setup = nil
+	spec.apply(&next)
+	if t.Occupant != nil && !next.Pass {
+		return ErrOccupied
+	}
+
+	t.Terrain, t.Face, t.Pass, t.Lite, t.Cost = next.Terrain, next.Face, next.Pass, next.Lite, next.Cost
+	t.updateOpaque()
+	if setup != nil {
+		setup(t)
+	}
+	if g.bus != nil {
+		g.bus.Publish(&TerrainChanged{g, t, old})
+	}
+	return nil
+}
+
+// Dig turns a Tile of the Grid into floor with SetTerrain.
+func (g *Grid) Dig(t *Tile) error {
+	return g.SetTerrain(t, TileSpec{Terrain: TerrainFloor})
+}
+
+// Fill turns a Tile of the Grid into wall with SetTerrain, failing with
+// ErrOccupied if the Tile has an Occupant.
+func (g *Grid) Fill(t *Tile) error {
+	return g.SetTerrain(t, TileSpec{Terrain: TerrainWall})
+}
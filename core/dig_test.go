@@ -0,0 +1,70 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestGrid_SetTerrain(t *testing.T) {
+	g := gridCase(
+		"#####",
+		"#.#.#",
+		"#####",
+	)
+	bus := NewEventBus()
+	g.SetBus(bus)
+	var changes []*TerrainChanged
+	Subscribe(bus, func(v *TerrainChanged) { changes = append(changes, v) })
+
+	wall := g.At(2, 1)
+	if err := g.Dig(wall); err != nil {
+		t.Fatalf("Dig() = %v", err)
+	}
+	if !wall.Pass || !wall.Lite || wall.Terrain != TerrainFloor || wall.Face != Terrains[TerrainFloor].Face {
+		t.Errorf("Dig() left %+v", wall)
+	}
+	if len(changes) != 1 || changes[0].Tile != wall || changes[0].Old.Terrain != TerrainWall || changes[0].Old.Pass {
+		t.Errorf("Dig() published %v", changes)
+	}
+
+	// an occupied Tile cannot be filled
+	floor := g.At(1, 1)
+	floor.Occupant = &testentity{"hero"}
+	if err := g.Fill(floor); err != ErrOccupied || !floor.Pass || len(changes) != 1 {
+		t.Errorf("Fill() occupied = %v", err)
+	}
+	if err := g.SetTerrain(floor, TileSpec{Terrain: TerrainWater}); err != nil || floor.Cost != 2 {
+		t.Errorf("SetTerrain(water) occupied = %v", err)
+	}
+	floor.Occupant = nil
+	if err := g.Fill(floor); err != nil || floor.Pass || len(changes) != 3 {
+		t.Errorf("Fill() = %v", err)
+	}
+}
+
+func TestAutotileOnChange(t *testing.T) {
+	g := gridCase(
+		"#####",
+		"#...#",
+		"#####",
+	)
+	isWall := func(t *Tile) bool { return t.Terrain == TerrainWall }
+	Autotile(g, isWall, ASCIIWalls)
+	AutotileOnChange(g, isWall, ASCIIWalls)
+
+	g.Fill(g.At(2, 1))
+	expected := []string{
+		"+-+-+",
+		"|.|.|",
+		"+-+-+",
+	}
+	for y, line := range faces(g) {
+		if line != expected[y] {
+			t.Errorf("AutotileOnChange() row %d = %q != %q", y, line, expected[y])
+		}
+	}
+
+	g.Dig(g.At(2, 1))
+	if line := faces(g)[0]; line != "+---+" {
+		t.Errorf("AutotileOnChange() after Dig() = %q", line)
+	}
+}
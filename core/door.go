@@ -0,0 +1,229 @@
+package core
+
+// Door is an Entity which blocks a Tile while closed. A closed Door is the
+// Tile Occupant, and the Tile is neither passable nor lit. Bumping into a
+// closed Door opens it, unless it is Locked, in which case the bumper is sent
+// a Message instead. An open Door lies on its Tile as an item, so that other
+// Entity may pass through, until it receives a CloseDoor Event.
+//
+// A Secret Door looks and acts like a wall, drawn as Hidden, until it is
+// discovered by a Search.
+type Door struct {
+	Pos       *Tile
+	Open      bool
+	Locked    bool
+	Key       string
+	Closed    Glyph
+	Opened    Glyph
+	LockedMsg string
+	Secret    bool
+	Hidden    Glyph
+}
+
+// NewDoor creates a closed Door and places it on the given Tile.
+func NewDoor(pos *Tile) *Door {
+	d := &Door{
+		Pos:       pos,
+		Closed:    Glyph{'+', ColorYellow},
+		Opened:    Glyph{'\'', ColorYellow},
+		LockedMsg: "The door is locked.",
+	}
+	d.close()
+	return d
+}
+
+// Handle implements Entity for Door.
+func (d *Door) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		if d.Secret {
+			v.Render = d.Hidden
+		} else if d.Open {
+			v.Render = d.Opened
+		} else {
+			v.Render = d.Closed
+		}
+	case *OpaqueRequest:
+		v.Opaque = !d.Open
+	case *ItemRequest:
+		v.Name, v.Fixed = "door", true
+		if d.Secret {
+			v.Name = "wall"
+		}
+	case *UpdatePos:
+		d.Pos = v.Pos
+	case *Search:
+		if d.Secret {
+			d.Secret = false
+			v.Found = true
+		}
+	case *BumpQuery:
+		if d.Open || d.Secret {
+			return
+		}
+		if d.Locked {
+			send(v.Bumper, &Message{Text: d.LockedMsg, Pos: d.Pos})
+		} else {
+			d.open()
+		}
+	case *OpenDoor:
+		if !d.Open && !d.Locked && !d.Secret {
+			d.open()
+			v.Done = true
+		}
+	case *CloseDoor:
+		if d.Open && d.Pos.Occupant == nil {
+			d.close()
+			v.Done = true
+		}
+	case *UnlockDoor:
+		if d.Locked && v.Key == d.Key {
+			d.Locked = false
+			v.Done = true
+		}
+	}
+}
+
+// open moves the Door from the Tile Occupant to the Tile items.
+func (d *Door) open() {
+	d.Open = true
+	d.Pos.Pass, d.Pos.Lite = true, true
+	if sameEntity(d.Pos.Occupant, d) {
+		d.Pos.Occupant = nil
+	}
+	d.Pos.Handle(&PlaceItem{d})
+}
+
+// close moves the Door from the Tile items to the Tile Occupant.
+func (d *Door) close() {
+	d.Open = false
+	d.Pos.Handle(&RemoveItem{Item: d})
+	d.Pos.Pass, d.Pos.Lite = false, false
+	d.Pos.Occupant = d
+}
+
+// OpenDoor is an Event requesting that a Door open. Done is set to true if the
+// Door was opened.
+type OpenDoor struct {
+	Done bool
+}
+
+// CloseDoor is an Event requesting that a Door close. A Door can only close if
+// its Tile is unoccupied. Done is set to true if the Door was closed.
+type CloseDoor struct {
+	Done bool
+}
+
+// UnlockDoor is an Event attempting to unlock a Door with a key. Done is set
+// to true if the Door was unlocked.
+type UnlockDoor struct {
+	Key  string
+	Done bool
+}
+
+// Search is an Event asking an Entity to reveal anything hidden about it, such
+// as a Secret Door. Found is set to true if something was revealed.
+type Search struct {
+	Found bool
+}
+
+// SearchAround sends a Search to the Occupant of each Tile adjacent to the
+// given Tile, such as when the player spends a turn searching, and returns
+// the number of Entity which revealed something.
+func SearchAround(pos *Tile) int {
+	found := 0
+	for _, delta := range descentOrder {
+		if adj, ok := pos.Adjacent[delta]; ok && adj.Occupant != nil {
+			search := Search{}
+			adj.Occupant.Handle(&search)
+			if search.Found {
+				found++
+			}
+		}
+	}
+	return found
+}
+
+// DoorOptions configures PlaceDoors.
+type DoorOptions struct {
+	// Secret is the fraction of Doors which are made Secret, drawn as the
+	// Face of TerrainWall until found.
+	Secret float64
+
+	// Registry, if set, registers each Door.
+	Registry *Registry
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// PlaceDoors places a closed Door at each entrance to a room in a rooms and
+// corridors map, and returns them. An entrance is a passable, unoccupied Tile
+// between exactly two opposite impassable Tiles, which opens out on at least
+// one side, meaning the Tile beyond on that side has passable Tiles to either
+// side of it. Tiles in open areas or in entrances more than one Tile wide are
+// never given a Door, and no Door is placed next to another.
+func PlaceDoors(g *Grid, opts DoorOptions) []*Door {
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+
+	var doors []*Door
+	for _, t := range g.FindAll(isEntrance) {
+		crowded := false
+		for _, adj := range t.Adjacent {
+			if _, ok := adj.Occupant.(*Door); ok {
+				crowded = true
+			}
+		}
+		if crowded {
+			continue
+		}
+
+		door := NewDoor(t)
+		if opts.Dice.Chance(opts.Secret) {
+			door.Secret, door.Hidden = true, Terrains[TerrainWall].Face
+		}
+		if opts.Registry != nil {
+			opts.Registry.Register(door)
+		}
+		doors = append(doors, door)
+	}
+	return doors
+}
+
+// Generate places Doors with PlaceDoors, adding them to the Doors of the
+// GenResult. The Dice, if set, is used in place of opts.Dice.
+func (opts DoorOptions) Generate(g *Grid, dice Dice, res *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	doors := PlaceDoors(g, opts)
+	if res != nil {
+		res.Doors = append(res.Doors, doors...)
+	}
+	return nil
+}
+
+// isEntrance determines whether a Tile is a room entrance as described by
+// PlaceDoors.
+func isEntrance(t *Tile) bool {
+	if !t.Pass || t.Occupant != nil {
+		return false
+	}
+	pass := func(o Offset) bool {
+		adj, ok := t.Adjacent[o]
+		return ok && adj.Pass
+	}
+	for _, axis := range []struct{ open, wall Offset }{{Offset{1, 0}, Offset{0, 1}}, {Offset{0, 1}, Offset{1, 0}}} {
+		if pass(axis.wall) || pass(axis.wall.Neg()) || !pass(axis.open) || !pass(axis.open.Neg()) {
+			continue
+		}
+		for _, side := range []Offset{axis.open, axis.open.Neg()} {
+			if pass(side.Add(axis.wall)) && pass(side.Sub(axis.wall)) {
+				return true
+			}
+		}
+	}
+	return false
+}
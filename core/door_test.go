@@ -0,0 +1,163 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testmessenger records any Message it receives.
+type testmessenger struct {
+	testally
+	Messages []string
+}
+
+func (m *testmessenger) Handle(v Event) {
+	if v, ok := v.(*Message); ok {
+		m.Messages = append(m.Messages, v.Text)
+	}
+	m.testally.Handle(v)
+}
+
+func TestDoor(t *testing.T) {
+	// a single corridor of three tiles, surrounded by walls
+	grid := NewTileGrid(5, 3, Offset{}, func(o Offset) *Tile {
+		t := NewTile(o)
+		if o.X == 0 || o.X == 4 || o.Y != 1 {
+			t.Pass, t.Lite = false, false
+		}
+		return t
+	})
+	tiles := []*Tile{grid[4], grid[7], grid[10]}
+	door := NewDoor(tiles[1])
+	hero := &testmessenger{}
+	hero.Pos = tiles[0]
+	tiles[0].Occupant = hero
+
+	if tiles[1].Pass || tiles[1].Transparent() || tiles[1].Occupant != door {
+		t.Errorf("NewDoor() did not block tile")
+	}
+	if _, ok := FoV(tiles[0], 2)[Offset{2, 0}]; ok {
+		t.Errorf("FoV through closed door")
+	}
+
+	// bumping opens the door, without moving onto it
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if !door.Open || !tiles[1].Pass || !tiles[1].Transparent() || tiles[1].Occupant != nil {
+		t.Errorf("Bump did not open door")
+	}
+	if _, ok := FoV(tiles[0], 2)[Offset{2, 0}]; !ok {
+		t.Errorf("FoV blocked by open door")
+	}
+	render := RenderRequest{}
+	if tiles[1].Handle(&render); render.Render != door.Opened {
+		t.Errorf("open door rendered as %v != %v", render.Render, door.Opened)
+	}
+
+	// doors cannot close on an occupant
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	closeReq := CloseDoor{}
+	if door.Handle(&closeReq); closeReq.Done || !door.Open {
+		t.Errorf("CloseDoor closed occupied door")
+	}
+	tiles[1].Handle(&MoveEntity{Delta: Offset{-1, 0}})
+	if door.Handle(&closeReq); !closeReq.Done || door.Open || tiles[1].Occupant != door || tiles[1].Pass {
+		t.Errorf("CloseDoor did not close unoccupied door")
+	}
+
+	// locked doors send a message instead of opening
+	door.Locked, door.Key = true, "bone key"
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if door.Open || len(hero.Messages) != 1 {
+		t.Errorf("Bump on locked door = open %v, messages %v", door.Open, hero.Messages)
+	}
+	unlock := UnlockDoor{Key: "stone key"}
+	if door.Handle(&unlock); unlock.Done || !door.Locked {
+		t.Errorf("UnlockDoor with wrong key unlocked door")
+	}
+	unlock = UnlockDoor{Key: "bone key"}
+	if door.Handle(&unlock); !unlock.Done || door.Locked {
+		t.Errorf("UnlockDoor with correct key did not unlock door")
+	}
+	open := OpenDoor{}
+	if door.Handle(&open); !open.Done || !door.Open {
+		t.Errorf("OpenDoor did not open door")
+	}
+}
+
+func TestPlaceDoors(t *testing.T) {
+	g := gridCase(
+		"###########",
+		"#...#.....#",
+		"#.........#",
+		"#...#.....#",
+		"#######.###",
+		"#######.###",
+		"#####.....#",
+		"#####.....#",
+		"###########",
+	)
+	reg := NewRegistry()
+	doors := PlaceDoors(g, DoorOptions{Registry: reg, Dice: NewDice(rand.NewSource(1))})
+	var placed []Offset
+	for _, d := range doors {
+		placed = append(placed, d.Pos.Offset)
+		if _, ok := reg.ID(d); !ok {
+			t.Errorf("PlaceDoors() did not register Door")
+		}
+	}
+	expected := []Offset{{4, 2}, {7, 4}}
+	if !reflect.DeepEqual(placed, expected) {
+		t.Errorf("PlaceDoors() placed %v != %v", placed, expected)
+	}
+}
+
+func TestPlaceDoors_Wide(t *testing.T) {
+	g := gridCase(
+		"#######",
+		"#.....#",
+		"#.....#",
+		"###..##",
+		"#.....#",
+		"##.####",
+		"#...###",
+		"#######",
+	)
+	if doors := PlaceDoors(g, DoorOptions{}); len(doors) != 1 || doors[0].Pos != g.At(2, 5) {
+		t.Errorf("PlaceDoors() placed %d Doors", len(doors))
+	}
+}
+
+func TestPlaceDoors_Secret(t *testing.T) {
+	g := gridCase(
+		"#######",
+		"#..#..#",
+		"#.....#",
+		"#..#..#",
+		"#######",
+	)
+	doors := PlaceDoors(g, DoorOptions{Secret: 1})
+	if len(doors) != 1 || !doors[0].Secret {
+		t.Fatalf("PlaceDoors() did not place Secret Door")
+	}
+	door := doors[0]
+
+	render := RenderRequest{}
+	if door.Pos.Handle(&render); render.Render != Terrains[TerrainWall].Face {
+		t.Errorf("Secret Door rendered as %v", render.Render)
+	}
+	hero := &testmessenger{}
+	hero.Pos = g.At(2, 2)
+	hero.Pos.Occupant = hero
+	hero.Pos.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if door.Open || len(hero.Messages) != 0 {
+		t.Errorf("Bump opened Secret Door")
+	}
+
+	if found := SearchAround(hero.Pos); found != 1 || door.Secret {
+		t.Errorf("SearchAround() found %d", found)
+	}
+	if hero.Pos.Handle(&MoveEntity{Delta: Offset{1, 0}}); !door.Open {
+		t.Errorf("Bump did not open found Door")
+	}
+}
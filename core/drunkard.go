@@ -0,0 +1,152 @@
+package core
+
+// DrunkardOptions configures GenerateDrunkard and DrunkardWalk.
+type DrunkardOptions struct {
+	// Open is the fraction of the Grid, ignoring its edge, which should be
+	// passable once the walk ends, such as .4 for a fairly open cave.
+	Open float64
+
+	// CenterBias is the chance that each step heads toward the center of
+	// the Grid rather than in a random direction, so the walk does not hug
+	// the edges.
+	CenterBias float64
+
+	// Restart makes the walker continue from a random passable Tile whenever
+	// it would leave the Grid. If false, the walker stays put and tries
+	// another direction.
+	Restart bool
+
+	// Start is where the walk begins. If nil, a random passable Tile is used,
+	// or the center of the Grid if there is none.
+	Start *Tile
+
+	// MaxSteps limits the length of the walk, so that an unreachable Open
+	// fraction cannot walk forever. If zero, 50 steps per Tile are allowed.
+	MaxSteps int
+
+	// Paint sets up a Tile as TileTypeRoom or TileTypeWall, and must leave
+	// Pass set accordingly. If nil, floor is set to TerrainFloor and walls
+	// to TerrainWall.
+	Paint func(t *Tile, tiletype int)
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// GenerateDrunkard fills a Grid with wall and then carves a cave into it with
+// DrunkardWalk, returning the number of Tiles carved. Since the walk only ever
+// steps between adjacent Tiles, or restarts from one it has already carved,
+// the cave is always a single region.
+func GenerateDrunkard(g *Grid, opts DrunkardOptions) int {
+	if opts.Paint == nil {
+		opts.Paint = paintTerrain
+	}
+	g.Each(func(_ Offset, t *Tile) {
+		opts.Paint(t, TileTypeWall)
+	})
+	return DrunkardWalk(g, opts)
+}
+
+// Generate carves a cave with GenerateDrunkard. The Dice, if set, is used in
+// place of opts.Dice.
+func (opts DrunkardOptions) Generate(g *Grid, dice Dice, _ *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	GenerateDrunkard(g, opts)
+	return nil
+}
+
+// DrunkardWalk carves floor into an existing Grid by a random walk, until the
+// Open fraction of the Grid is passable, keeping its edge intact. Since Tiles
+// which are already passable count toward Open, this also works as a pass to
+// roughen the rooms and corridors of other generators. A walk which starts
+// away from the existing passable Tiles may carve a separate cave, so once
+// the walk ends, every region is joined with ConnectRegions. The number of
+// Tiles carved by the walk is returned.
+func DrunkardWalk(g *Grid, opts DrunkardOptions) int {
+	w, h := g.Width(), g.Height()
+	if w < 3 || h < 3 {
+		return 0
+	}
+	if opts.Paint == nil {
+		opts.Paint = paintTerrain
+	}
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+	if opts.MaxSteps == 0 {
+		opts.MaxSteps = 50 * w * h
+	}
+
+	var open []Offset
+	for x := 1; x < w-1; x++ {
+		for y := 1; y < h-1; y++ {
+			if g.At(x, y).Pass {
+				open = append(open, Offset{x, y})
+			}
+		}
+	}
+	target := int(opts.Open * float64((w-2)*(h-2)))
+	center := Offset{w / 2, h / 2}
+
+	var pos Offset
+	switch {
+	case opts.Start != nil:
+		if start, ok := gridOffset(g, opts.Start); ok {
+			pos = start
+		}
+	case len(open) > 0:
+		pos = open[opts.Dice.Intn(len(open))]
+	default:
+		pos = center
+	}
+	if pos.X < 1 || pos.X >= w-1 || pos.Y < 1 || pos.Y >= h-1 {
+		pos = center
+	}
+
+	carved := 0
+	for steps := 0; steps < opts.MaxSteps; steps++ {
+		if t := g.At(pos.X, pos.Y); !t.Pass {
+			opts.Paint(t, TileTypeRoom)
+			open = append(open, pos)
+			carved++
+		}
+		if len(open) >= target {
+			break
+		}
+
+		var step Offset
+		if delta := center.Sub(pos); opts.Dice.Chance(opts.CenterBias) && delta != (Offset{}) {
+			step = Offset{Signum(delta.X), 0}
+			if delta.X == 0 || delta.Y != 0 && opts.Dice.Bool() {
+				step = Offset{0, Signum(delta.Y)}
+			}
+		} else {
+			step = mazeSteps[opts.Dice.Intn(len(mazeSteps))]
+		}
+
+		next := pos.Add(step)
+		if next.X >= 1 && next.X < w-1 && next.Y >= 1 && next.Y < h-1 {
+			pos = next
+		} else if opts.Restart {
+			pos = open[opts.Dice.Intn(len(open))]
+		}
+	}
+
+	regions := Regions(g, IsPassable)
+	ConnectRegions(g, regions, func(t *Tile) { opts.Paint(t, TileTypeRoom) })
+	return carved
+}
+
+// gridOffset finds the position of a Tile within a Grid.
+func gridOffset(g *Grid, t *Tile) (Offset, bool) {
+	var pos Offset
+	found := false
+	g.Each(func(o Offset, tile *Tile) {
+		if tile == t {
+			pos, found = o, true
+		}
+	})
+	return pos, found
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// countPass counts the passable Tiles in a Grid, checking that the edge of the
+// Grid is left impassable.
+func countPass(t *testing.T, g *Grid) int {
+	count := 0
+	g.Each(func(o Offset, tile *Tile) {
+		if !tile.Pass {
+			return
+		}
+		count++
+		if o.X == 0 || o.Y == 0 || o.X == g.Width()-1 || o.Y == g.Height()-1 {
+			t.Errorf("carved edge %v", o)
+		}
+	})
+	return count
+}
+
+func TestGenerateDrunkard(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := NewGrid(40, 20)
+		opts := DrunkardOptions{Open: .4, CenterBias: .2, Restart: true, Dice: NewDice(rand.NewSource(seed))}
+		carved := GenerateDrunkard(g, opts)
+		open := countPass(t, g)
+		if target := 273; open < target || open != carved {
+			t.Errorf("seed %d carved %d and left %d open, target %d", seed, carved, open, target)
+		}
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+	}
+}
+
+func TestDrunkardWalk_Existing(t *testing.T) {
+	g, _ := NewGrid(30, 20)
+	rooms, _ := BSPDungeon(g, BSPOptions{Dice: NewDice(rand.NewSource(1))})
+	before := countPass(t, g)
+
+	carved := DrunkardWalk(g, DrunkardOptions{Open: .6, Dice: NewDice(rand.NewSource(2))})
+	if after := countPass(t, g); carved == 0 || after != before+carved {
+		t.Errorf("DrunkardWalk() carved %d, taking %d to %d open", carved, before, after)
+	}
+	for _, room := range rooms {
+		if c := room.Center(); !g.At(c.X, c.Y).Pass {
+			t.Errorf("DrunkardWalk() filled room %v", room)
+		}
+	}
+}
+
+func TestDrunkardWalk_Impossible(t *testing.T) {
+	g, _ := NewGrid(10, 10)
+	carved := GenerateDrunkard(g, DrunkardOptions{Open: 2, Start: g.At(1, 1), MaxSteps: 1000})
+	if open := countPass(t, g); carved != open || open > 64 {
+		t.Errorf("GenerateDrunkard() carved %d with %d open", carved, open)
+	}
+}
+
+func TestDrunkardWalk_Connects(t *testing.T) {
+	g, _ := NewGrid(15, 7)
+	g.Each(func(o Offset, tile *Tile) {
+		paintTerrain(tile, TileTypeWall)
+		if InRange(o.X, 1, 4) && InRange(o.Y, 1, 4) {
+			paintTerrain(tile, TileTypeRoom)
+		}
+	})
+
+	// the walk starts far from the room, so it carves a separate cave
+	DrunkardWalk(g, DrunkardOptions{Open: .2, Start: g.At(12, 3), Dice: NewDice(rand.NewSource(1))})
+	countPass(t, g)
+	if regions := Regions(g, isPass); len(regions) != 1 {
+		t.Errorf("DrunkardWalk() left %d regions", len(regions))
+	}
+}
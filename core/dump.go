@@ -0,0 +1,204 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dumpWidth is the width to which Dump wraps its text.
+const dumpWidth = 80
+
+// KillsRequest is an Event querying an Entity for how many of each kind of
+// Entity it has killed, keyed by name. Kills is a copy, so it may be freely
+// modified.
+type KillsRequest struct {
+	Kills map[string]int
+}
+
+// KillList is a Component which counts the kills of its Entity, by the name
+// each victim gives with fmt, and answers KillsRequest. Since Death is sent
+// to the victim rather than the killer, kills are recorded by subscribing the
+// KillList to an EventBus on which Remains publishes each Death, using
+// Subscribe(bus, kills.Record).
+type KillList struct {
+	Self  Entity
+	Kills map[string]int
+}
+
+// NewKillList creates an empty KillList.
+func NewKillList(self Entity) *KillList {
+	return &KillList{self, make(map[string]int)}
+}
+
+// Record counts the Death if Self was the Killer.
+func (c *KillList) Record(v *Death) {
+	if !sameEntity(v.Killer, c.Self) {
+		return
+	}
+	if c.Kills == nil {
+		c.Kills = make(map[string]int)
+	}
+	c.Kills[fmt.Sprint(v.Victim)]++
+}
+
+// Process implements Component for KillList.
+func (c *KillList) Process(v Event) {
+	if v, ok := v.(*KillsRequest); ok {
+		v.Kills = make(map[string]int, len(c.Kills))
+		for name, count := range c.Kills {
+			v.Kills[name] = count
+		}
+	}
+}
+
+// Dump writes a character dump, such as a morgue file on death, describing
+// the player at 80 columns. Each section is gathered by querying the player
+// with Events, so it works with any set of Components, and a section is
+// omitted if nothing answers for it: StatsRequest for the stats,
+// InventoryRequest and EquippedRequest for the equipment and inventory, and
+// KillsRequest for the kill list. The depth comes from the World, the recent
+// messages from the LogWidget and the final screen from SnapshotText, each
+// omitted if nil or empty.
+func Dump(w io.Writer, player Entity, log *LogWidget, world *World) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Character dump of %v\n", player)
+
+	if world != nil {
+		dumpSection(&b, "Dungeon", dumpDepth(player, world))
+	}
+	dumpSection(&b, "Stats", dumpStats(player))
+	equipped, carried := dumpItems(player)
+	dumpSection(&b, "Equipment", equipped)
+	dumpSection(&b, "Inventory", carried)
+	dumpSection(&b, "Kills", dumpKills(player))
+	if log != nil {
+		var messages []string
+		for _, msg := range log.cache {
+			text := msg.String()
+			if log.Markup {
+				text = glyphText(textGlyphs(text, 0, true))
+			}
+			messages = append(messages, text)
+		}
+		dumpSection(&b, "Last messages", messages)
+	}
+	if screen := SnapshotText(); screen != "" {
+		fmt.Fprintf(&b, "\nFinal screen\n\n%s\n", screen)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dumpSection writes a titled section of a Dump with each line indented and
+// wrapped, unless there are no lines.
+func dumpSection(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s\n", title)
+	for _, line := range lines {
+		for _, wrapped := range wrapText(line, dumpWidth-2) {
+			fmt.Fprintf(b, "  %s\n", wrapped)
+		}
+	}
+}
+
+// dumpDepth describes the level of the World holding the player, and how many
+// levels have been generated.
+func dumpDepth(player Entity, world *World) []string {
+	depths := world.Depths()
+	if len(depths) == 0 {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("Levels visited: %d", len(depths))}
+	for _, depth := range depths {
+		if world.Level(depth).Find(func(t *Tile) bool { return sameEntity(t.Occupant, player) }) != nil {
+			lines = append([]string{fmt.Sprintf("Depth: %d", depth)}, lines...)
+			break
+		}
+	}
+	return lines
+}
+
+// dumpStats lists the hit points and named stats of the player.
+func dumpStats(player Entity) []string {
+	req := StatsRequest{}
+	player.Handle(&req)
+	if req.MaxHP == 0 && len(req.Values) == 0 {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("HP: %d/%d", req.HP, req.MaxHP)}
+	names := make([]string, 0, len(req.Values))
+	for name := range req.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %d", name, req.Values[name]))
+	}
+	return lines
+}
+
+// dumpItems lists the equipped items of the player by slot, and the rest of
+// the items it carries.
+func dumpItems(player Entity) (equipped, carried []string) {
+	inv := InventoryRequest{}
+	player.Handle(&inv)
+	var seen []Entity
+	for _, item := range inv.Items {
+		if containsEntity(seen, item) {
+			continue
+		}
+		seen = append(seen, item)
+		info := itemInfo(item)
+		name := fmt.Sprint(itemName(item, info))
+		if info.Count > 1 {
+			name = fmt.Sprintf("%s (x%d)", name, info.Count)
+		}
+		req := EquippedRequest{Item: item}
+		player.Handle(&req)
+		if len(req.Slots) > 0 {
+			equipped = append(equipped, fmt.Sprintf("%s: %s", strings.Join(req.Slots, ", "), name))
+		} else {
+			carried = append(carried, name)
+		}
+	}
+	sort.Strings(equipped)
+	return equipped, carried
+}
+
+// dumpKills lists the kills of the player, most common first, with a total.
+func dumpKills(player Entity) []string {
+	req := KillsRequest{}
+	player.Handle(&req)
+	if len(req.Kills) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(req.Kills))
+	total := 0
+	for name, count := range req.Kills {
+		names = append(names, name)
+		total += count
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ci, cj := req.Kills[names[i]], req.Kills[names[j]]
+		return ci > cj || ci == cj && names[i] < names[j]
+	})
+	lines := []string{fmt.Sprintf("Total: %d", total)}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%d %s", req.Kills[name], name))
+	}
+	return lines
+}
+
+// glyphText returns the runes of the Glyphs as a string.
+func glyphText(glyphs []Glyph) string {
+	runes := make([]rune, len(glyphs))
+	for i, g := range glyphs {
+		runes[i] = g.Ch
+	}
+	return string(runes)
+}
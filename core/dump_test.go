@@ -0,0 +1,86 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// testhero is a named player Entity.
+type testhero struct {
+	*SortedEntity
+}
+
+func (testhero) String() string {
+	return "Grok"
+}
+
+func TestDump(t *testing.T) {
+	hero := testhero{NewEntity()}
+	dagger := &testgear{"dagger", EquipRequest{Slots: []string{"weapon"}}}
+	rope := &testgear{"rope", EquipRequest{}}
+	inv := &Inventory{Self: hero, Items: []Entity{dagger, rope}}
+	equip := NewEquipment(hero, inv, "weapon")
+	stats := NewStats(hero, 10)
+	stats.Values["str"] = 12
+	kills := NewKillList(hero)
+	hero.Add(inv, equip, stats, kills)
+	hero.Handle(&EquipItem{Item: dagger})
+	hero.Handle(&Damage{Amount: 7})
+
+	bus := NewEventBus()
+	Subscribe(bus, kills.Record)
+	for _, victim := range []string{"rat", "orc", "rat"} {
+		bus.Publish(&Death{testnamed(victim), hero})
+	}
+	bus.Publish(&Death{testnamed("troll"), nil})
+
+	log := NewLogWidget(0, 0, 80, 5)
+	log.Markup = true
+	log.Log("You hit the {red}orc{/}.")
+	log.Log(strings.Repeat("long ", 20))
+
+	reg := NewRegistry()
+	generated := 0
+	world := NewWorld(1, worldCase(reg, &generated))
+	world.Level(0)
+	world.Level(1).At(3, 1).Occupant = hero
+
+	var out strings.Builder
+	if err := Dump(&out, hero, log, world); err != nil {
+		t.Fatalf("Dump() = %v", err)
+	}
+	expected := `Character dump of Grok
+
+Dungeon
+  Depth: 1
+  Levels visited: 2
+
+Stats
+  HP: 3/10
+  str: 12
+
+Equipment
+  weapon: dagger
+
+Inventory
+  rope
+
+Kills
+  Total: 3
+  2 rat
+  1 orc
+
+Last messages
+  You hit the orc.
+  long long long long long long long long long long long long long long long
+  long long long long long
+`
+	if actual := out.String(); actual != expected {
+		t.Errorf("Dump() = \n%s\n!=\n%s", actual, expected)
+	}
+
+	out.Reset()
+	if err := Dump(&out, testhero{NewEntity()}, nil, nil); err != nil || out.String() != "Character dump of Grok\n" {
+		t.Errorf("Dump() of a bare Entity = %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"sort"
+)
+
+// EquipRequest is an Event querying an item Entity for how it is equipped.
+// Slots lists every slot the item occupies at once, so a two-handed weapon
+// might list both "weapon" and "offhand". An item which occupies a single slot
+// may instead be placed in any slot listed in Fits when an EquipItem gives a
+// Slot, such as a ring worn on either hand. Cursed items cannot be unequipped.
+// While equipped, Power is added to outgoing Attack and Armor is subtracted
+// from incoming Damage.
+type EquipRequest struct {
+	Slots  []string
+	Fits   []string
+	Cursed bool
+	Power  int
+	Armor  int
+}
+
+// UnequipItem is an Event requesting that an Entity remove whatever is
+// equipped in a slot. Done is set to true if the slot was emptied.
+type UnequipItem struct {
+	Slot string
+	Done bool
+}
+
+// EquippedRequest is an Event querying an Entity for the slots in which an
+// item is equipped. Slots is left empty if the item is not equipped.
+type EquippedRequest struct {
+	Item  Entity
+	Slots []string
+}
+
+// Equipment is a Component which manages equipped items in named slots. It
+// handles EquipItem, UnequipItem and EquippedRequest, moving items between
+// the slots and the Inventory. Only items listed by an InventoryRequest to
+// Self, or carried by the Inventory if Self is nil, can be equipped, and only
+// in the slots the item allows. Equipping an item first unequips whatever
+// occupies its slots, failing with a Message to Self if any of those items are
+// cursed. Equipped items are still listed by InventoryRequest.
+//
+// Equipment has a priority of 1, so in a SortedEntity it reduces incoming
+// Damage before it reaches the Health, much like Defense.
+type Equipment struct {
+	Self      Entity
+	Inventory *Inventory
+	Slots     map[string]Entity
+}
+
+// NewEquipment creates an Equipment with the given empty slots.
+func NewEquipment(self Entity, inv *Inventory, slots ...string) *Equipment {
+	e := &Equipment{self, inv, make(map[string]Entity)}
+	for _, slot := range slots {
+		e.Slots[slot] = nil
+	}
+	return e
+}
+
+// Priority implements Prioritized for Equipment.
+func (c *Equipment) Priority() int {
+	return 1
+}
+
+// Process implements Component for Equipment.
+func (c *Equipment) Process(v Event) {
+	switch v := v.(type) {
+	case *EquipItem:
+		v.Done = c.equip(v.Item, v.Slot)
+	case *UnequipItem:
+		if item := c.Slots[v.Slot]; item != nil {
+			v.Done = c.unequip(item)
+		}
+	case *EquippedRequest:
+		v.Slots = append(v.Slots, c.slots(v.Item)...)
+	case *InventoryRequest:
+		v.Items = append(v.Items, c.Equipped()...)
+	case *Attack:
+		if v.Outgoing {
+			for _, item := range c.Equipped() {
+				v.Amount += equipInfo(item).Power
+			}
+		}
+	case *Damage:
+		for _, item := range c.Equipped() {
+			v.Amount -= equipInfo(item).Armor
+		}
+		if v.Amount <= 0 {
+			v.Amount = 0
+			v.Consume()
+		}
+	}
+}
+
+// Equipped returns each equipped item once, in slot name order.
+func (c *Equipment) Equipped() []Entity {
+	var items []Entity
+	for _, slot := range c.slotNames() {
+		if item := c.Slots[slot]; item != nil && !containsEntity(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// equip moves an item into its slots. If slot is given, the item occupies only
+// that slot, which must be one it allows, so items which occupy several slots
+// at once, such as two-handed weapons, cannot be given a slot.
+func (c *Equipment) equip(item Entity, slot string) bool {
+	info := equipInfo(item)
+	slots := info.Slots
+	if slot != "" {
+		if len(info.Slots) > 1 || !containsString(info.Slots, slot) && !containsString(info.Fits, slot) {
+			slots = nil
+		} else {
+			slots = []string{slot}
+		}
+	}
+	if len(slots) == 0 || !c.carried(item) {
+		c.message("%s <can> not equip %o", c.Self, item)
+		return false
+	}
+	for _, slot := range slots {
+		if _, ok := c.Slots[slot]; !ok {
+			c.message("%s <can> not equip %o", c.Self, item)
+			return false
+		}
+	}
+
+	// clear the slots, checking for curses before anything is moved
+	var displaced []Entity
+	for _, slot := range slots {
+		if old := c.Slots[slot]; old != nil && !sameEntity(old, item) {
+			if equipInfo(old).Cursed {
+				c.message("%s <be> cursed", old)
+				return false
+			}
+			displaced = append(displaced, old)
+		}
+	}
+	for _, old := range displaced {
+		c.unequip(old)
+	}
+
+	if c.Inventory != nil {
+		for i, carried := range c.Inventory.Items {
+			if sameEntity(carried, item) {
+				c.Inventory.Items = append(c.Inventory.Items[:i:i], c.Inventory.Items[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, slot := range slots {
+		c.Slots[slot] = item
+	}
+	return true
+}
+
+// carried returns true if the item is carried, as listed by an
+// InventoryRequest to Self, or by the Inventory if Self is nil.
+func (c *Equipment) carried(item Entity) bool {
+	var items []Entity
+	if c.Self != nil {
+		req := InventoryRequest{}
+		c.Self.Handle(&req)
+		items = req.Items
+	} else if c.Inventory != nil {
+		items = c.Inventory.Items
+	}
+	for _, carried := range items {
+		if sameEntity(carried, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns true if s is one of the strings.
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// unequip moves an item from its slots back into the Inventory.
+func (c *Equipment) unequip(item Entity) bool {
+	slots := c.slots(item)
+	if len(slots) == 0 {
+		return false
+	}
+	if equipInfo(item).Cursed {
+		c.message("%s <be> cursed", item)
+		return false
+	}
+	for _, slot := range slots {
+		c.Slots[slot] = nil
+	}
+	if c.Inventory != nil {
+		c.Inventory.Items = append(c.Inventory.Items, item)
+	}
+	return true
+}
+
+// slots returns the names of the slots holding the item, in sorted order.
+func (c *Equipment) slots(item Entity) []string {
+	var slots []string
+	for _, slot := range c.slotNames() {
+		if sameEntity(c.Slots[slot], item) {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// slotNames returns the names of the slots in sorted order.
+func (c *Equipment) slotNames() []string {
+	names := make([]string, 0, len(c.Slots))
+	for slot := range c.Slots {
+		names = append(names, slot)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// message formats a Message with Fmt and sends it to Self, if set.
+func (c *Equipment) message(format string, args ...interface{}) {
+	if c.Self != nil {
+		send(c.Self, Messagef(format, args...))
+	}
+}
+
+// equipInfo queries an item for how it is equipped.
+func equipInfo(item Entity) EquipRequest {
+	info := EquipRequest{}
+	item.Handle(&info)
+	return info
+}
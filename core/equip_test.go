@@ -0,0 +1,104 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testgear is an equippable item.
+type testgear struct {
+	Name string
+	Info EquipRequest
+}
+
+func (g *testgear) Handle(v Event) {
+	if v, ok := v.(*EquipRequest); ok {
+		*v = g.Info
+	}
+}
+
+func (g *testgear) Process(v Event) {
+	g.Handle(v)
+}
+
+func (g *testgear) String() string {
+	return g.Name
+}
+
+func TestEquipment(t *testing.T) {
+	dagger := &testgear{"dagger", EquipRequest{Slots: []string{"weapon"}, Power: 1}}
+	shield := &testgear{"shield", EquipRequest{Slots: []string{"offhand"}, Armor: 2}}
+	axe := &testgear{"axe", EquipRequest{Slots: []string{"weapon", "offhand"}, Power: 5}}
+	ring := &testgear{"ring", EquipRequest{Slots: []string{"ring"}, Fits: []string{"left ring", "right ring"}, Cursed: true}}
+	elsewhere := &testgear{"sword", EquipRequest{Slots: []string{"weapon"}}}
+
+	inv := &Inventory{Items: []Entity{dagger, shield, axe, ring}}
+	equip := NewEquipment(nil, inv, "weapon", "offhand", "left ring", "right ring")
+
+	cases := []struct {
+		event    Event
+		equipped []Entity
+		carried  int
+	}{
+		{&EquipItem{Item: dagger}, []Entity{dagger}, 3},
+		{&EquipItem{Item: shield}, []Entity{shield, dagger}, 2},
+		{&EquipItem{Item: axe}, []Entity{axe}, 3},
+		{&EquipItem{Item: ring}, []Entity{axe}, 3},
+		{&EquipItem{Item: dagger, Slot: "offhand"}, []Entity{axe}, 3},
+		{&EquipItem{Item: ring, Slot: "left ring"}, []Entity{ring, axe}, 2},
+		{&EquipItem{Item: axe, Slot: "weapon"}, []Entity{ring, axe}, 2},
+		{&EquipItem{Item: elsewhere}, []Entity{ring, axe}, 2},
+		{&UnequipItem{Slot: "left ring"}, []Entity{ring, axe}, 2},
+		{&UnequipItem{Slot: "offhand"}, []Entity{ring}, 3},
+		{&EquipItem{Item: dagger}, []Entity{ring, dagger}, 2},
+	}
+	for i, c := range cases {
+		equip.Process(c.event)
+		if actual := equip.Equipped(); !reflect.DeepEqual(actual, c.equipped) || len(inv.Items) != c.carried {
+			t.Errorf("case %d: equipped %v, carrying %v", i, actual, inv.Items)
+		}
+	}
+
+	equipped := EquippedRequest{Item: ring}
+	if equip.Process(&equipped); !reflect.DeepEqual(equipped.Slots, []string{"left ring"}) {
+		t.Errorf("EquippedRequest = %v", equipped.Slots)
+	}
+}
+
+func TestEquipment_ComponentSlice(t *testing.T) {
+	dagger := ComponentSlice{&testgear{"dagger", EquipRequest{Slots: []string{"weapon"}}}}
+	sword := ComponentSlice{&testgear{"sword", EquipRequest{Slots: []string{"weapon"}}}}
+
+	inv := &Inventory{Items: []Entity{dagger, sword}}
+	equip := NewEquipment(nil, inv, "weapon")
+	equip.Process(&EquipItem{Item: dagger})
+	equip.Process(&EquipItem{Item: sword})
+	if !sameEntity(equip.Slots["weapon"], sword) || len(inv.Items) != 1 || !sameEntity(inv.Items[0], dagger) {
+		t.Errorf("swap equipped %v, carrying %v", equip.Slots["weapon"], inv.Items)
+	}
+	equip.Process(&EquipItem{Item: sword})
+	if !sameEntity(equip.Slots["weapon"], sword) || len(inv.Items) != 1 {
+		t.Errorf("equip twice equipped %v, carrying %v", equip.Slots["weapon"], inv.Items)
+	}
+}
+
+func TestEquipment_Modifiers(t *testing.T) {
+	sword := &testgear{"sword", EquipRequest{Slots: []string{"weapon"}, Power: 2}}
+	mail := &testgear{"mail", EquipRequest{Slots: []string{"body"}, Armor: 3}}
+
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	hero := newtestfighter(1, 0, 10)
+	inv := &Inventory{Self: hero, Items: []Entity{sword, mail}}
+	equip := NewEquipment(hero, inv, "weapon", "body")
+	hero.ComponentSlice = ComponentSlice{inv, equip, hero.combat, hero.defense, hero.health}
+	equip.Process(&EquipItem{Item: sword})
+	equip.Process(&EquipItem{Item: mail})
+	orc := newtestfighter(4, 0, 10)
+	tiles[0].Occupant, tiles[1].Occupant = hero, orc
+
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	tiles[1].Handle(&MoveEntity{Delta: Offset{-1, 0}})
+	if orc.health.HP != 7 || hero.health.HP != 9 {
+		t.Errorf("Equipment modifiers gave hp %d, %d", hero.health.HP, orc.health.HP)
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+// FactionID identifies a faction, such as "orcs" or "town guard".
+type FactionID string
+
+// Relation describes how members of one faction regard members of another.
+// NoRelation is used when either Entity has no faction.
+type Relation int
+
+// Relation values, as returned by Relationship and RelationBetween.
+const (
+	NoRelation Relation = iota
+	Hostile
+	Neutral
+	Friendly
+)
+
+// relations is the table of Relation set by SetRelation.
+var relations = make(map[[2]FactionID]Relation)
+
+// SetRelation sets the Relation between two factions, in both directions.
+func SetRelation(a, b FactionID, rel Relation) {
+	relations[[2]FactionID{a, b}] = rel
+	relations[[2]FactionID{b, a}] = rel
+}
+
+// Relationship gets the Relation between two factions. Unless changed with
+// SetRelation, a faction is Friendly with itself, and Neutral to others.
+func Relationship(a, b FactionID) Relation {
+	if rel, ok := relations[[2]FactionID{a, b}]; ok {
+		return rel
+	}
+	if a == b {
+		return Friendly
+	}
+	return Neutral
+}
+
+// FactionQuery is an Event querying an Entity for its current faction. Found
+// is set to true if the Entity has a faction.
+type FactionQuery struct {
+	Consumption
+	Faction FactionID
+	Found   bool
+}
+
+// FactionOf gets the current faction of an Entity. A nil Entity has no
+// faction.
+func FactionOf(e Entity) (id FactionID, ok bool) {
+	if e == nil {
+		return "", false
+	}
+	query := FactionQuery{}
+	e.Handle(&query)
+	return query.Faction, query.Found
+}
+
+// RelationBetween gets the Relation between the current factions of two
+// Entity, or NoRelation if either has no faction.
+func RelationBetween(a, b Entity) Relation {
+	fa, ok := FactionOf(a)
+	if !ok {
+		return NoRelation
+	}
+	fb, ok := FactionOf(b)
+	if !ok {
+		return NoRelation
+	}
+	return Relationship(fa, fb)
+}
+
+// Faction is a Component giving its Entity a faction, by answering a
+// FactionQuery.
+type Faction struct {
+	ID FactionID
+}
+
+// Process implements Component for Faction.
+func (c *Faction) Process(v Event) {
+	if v, ok := v.(*FactionQuery); ok {
+		v.Faction, v.Found = c.ID, true
+	}
+}
+
+// FactionOverride is a Component which temporarily replaces the faction of
+// its Entity for the given number of Turns, such as while charmed. It counts
+// down on each Tick, which the Entity must be sent, such as by subscribing it
+// to the EventBus of a Clock. FactionOverride has a priority of 1, so in a
+// SortedEntity it answers and consumes each FactionQuery before any Faction.
+type FactionOverride struct {
+	ID    FactionID
+	Turns int
+}
+
+// Priority implements Prioritized for FactionOverride.
+func (c *FactionOverride) Priority() int {
+	return 1
+}
+
+// Process implements Component for FactionOverride.
+func (c *FactionOverride) Process(v Event) {
+	switch v := v.(type) {
+	case *FactionQuery:
+		if c.Turns > 0 {
+			v.Faction, v.Found = c.ID, true
+			v.Consume()
+		}
+	case *Tick:
+		if c.Turns > 0 {
+			c.Turns--
+		}
+	}
+}
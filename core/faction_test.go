@@ -0,0 +1,86 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestRelationBetween(t *testing.T) {
+	SetRelation("test-orcs", "test-elves", Hostile)
+	orc := NewEntity(&Faction{"test-orcs"})
+	elf := NewEntity(&Faction{"test-elves"})
+	dwarf := NewEntity(&Faction{"test-dwarves"})
+	loner := NewEntity()
+
+	cases := []struct {
+		a, b     Entity
+		expected Relation
+	}{
+		{orc, elf, Hostile},
+		{elf, orc, Hostile},
+		{orc, orc, Friendly},
+		{orc, dwarf, Neutral},
+		{orc, loner, NoRelation},
+		{loner, loner, NoRelation},
+		{nil, orc, NoRelation},
+		{orc, nil, NoRelation},
+	}
+	for i, c := range cases {
+		if actual := RelationBetween(c.a, c.b); actual != c.expected {
+			t.Errorf("case %d: RelationBetween() = %v != %v", i, actual, c.expected)
+		}
+	}
+}
+
+func TestFactionOverride(t *testing.T) {
+	SetRelation("test-orcs", "test-heroes", Hostile)
+	hero := NewEntity(&Faction{"test-heroes"})
+	charm := &FactionOverride{"test-heroes", 2}
+	orc := NewEntity(&Faction{"test-orcs"}, charm)
+
+	for turn := 0; turn < 3; turn++ {
+		expected := Friendly
+		if turn == 2 {
+			expected = Hostile
+		}
+		if rel := RelationBetween(orc, hero); rel != expected {
+			t.Errorf("turn %d: RelationBetween() = %v != %v", turn, rel, expected)
+		}
+		orc.Handle(&Tick{})
+	}
+}
+
+func TestFaction_Swap(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	hero := &testswimmer{Pos: tiles[0]}
+	heroes := ComponentSlice{&Faction{"test-heroes"}, testfunc(hero.Handle)}
+	ally := &testswimmer{Pos: tiles[1]}
+	allies := ComponentSlice{&Faction{"test-heroes"}, testfunc(ally.Handle)}
+	tiles[0].Occupant, tiles[1].Occupant = &heroes, &allies
+
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if hero.Pos != tiles[1] || ally.Pos != tiles[0] {
+		t.Errorf("MoveEntity into Friendly occupant did not swap")
+	}
+}
+
+func TestChaser_Faction(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"#######",
+		"#c...t#",
+		"#######",
+	})
+	chaser.Self = &ComponentSlice{chaser, &Faction{"test-orcs"}}
+	chaser.Pos.Occupant = chaser.Self
+	friend := &ComponentSlice{&Faction{"test-orcs"}, testfunc(target.Handle)}
+	target.Pos.Occupant = friend
+
+	chaser.Self.Handle(&Act{})
+	if chaser.HasTarget() {
+		t.Errorf("Chaser targeted Friendly occupant")
+	}
+
+	// without a Self, every occupant is hostile
+	if !hostileTo(nil, nil, friend) {
+		t.Errorf("hostileTo() with nil self spared Friendly occupant")
+	}
+}
@@ -1,23 +1,120 @@
 package core
 
 import (
+	"fmt"
+	"strings"
 	"unicode"
 )
 
 // Label is a Visual which displays fixed text on screen.
+//
+// If Width is 0, the Text is drawn as a single run starting at the Label
+// location. Otherwise, the Text is split into lines on '\n' (and soft-wrapped
+// on word boundaries if Wrap is true), with each line aligned within Width.
+// Any cells in the box not covered by text are cleared, so shorter text will
+// not leave stale characters from a previous Update. If Markup is true, the
+// Text may contain color markup as described by ParseMarkup.
 type Label struct {
 	texter
 	Fg Color
+
+	Width  int
+	Align  Align
+	Wrap   bool
+	Markup bool
+
+	drawn int // number of lines drawn by the last Update
 }
 
 // NewLabel creates a new label with the given text.
 func NewLabel(text string, x, y int) *Label {
-	return &Label{texter{text, x, y}, ColorWhite}
+	return &Label{texter{text, x, y}, ColorWhite, 0, AlignLeft, false, false, 0}
 }
 
 // Update draws the Label text at the given location.
 func (l *Label) Update() {
-	l.drawText(l.Fg)
+	if l.Width <= 0 {
+		drawGlyphs(l.X, l.Y, textGlyphs(l.Text, l.Fg, l.Markup))
+		return
+	}
+
+	lines := l.lines()
+	for y, line := range lines {
+		start := l.Align.offset(len(line), l.Width)
+		for x := 0; x < l.Width; x++ {
+			g := Glyph{' ', l.Fg}
+			if i := x - start; 0 <= i && i < len(line) {
+				g = line[i]
+			}
+			TermDraw(l.X+x, l.Y+y, g)
+		}
+	}
+
+	// blank out any lines left over from previous longer text
+	for y := len(lines); y < l.drawn; y++ {
+		for x := 0; x < l.Width; x++ {
+			TermDraw(l.X+x, l.Y+y, Glyph{' ', l.Fg})
+		}
+	}
+	l.drawn = len(lines)
+}
+
+// lines splits the Label Text into the lines which fit within the Width.
+func (l *Label) lines() [][]Glyph {
+	var lines [][]Glyph
+	for _, line := range strings.Split(l.Text, "\n") {
+		glyphs := textGlyphs(line, l.Fg, l.Markup)
+		if l.Wrap {
+			lines = append(lines, wrapGlyphs(glyphs, l.Width)...)
+		} else if len(glyphs) > l.Width {
+			lines = append(lines, append(glyphs[:l.Width-1], Glyph{ellipsis, l.Fg}))
+		} else {
+			lines = append(lines, glyphs)
+		}
+	}
+	return lines
+}
+
+// textGlyphs converts text to Glyph with the given Color, optionally parsing
+// color markup in the text.
+func textGlyphs(s string, fg Color, markup bool) []Glyph {
+	if markup {
+		return ParseMarkup(s, fg)
+	}
+	var glyphs []Glyph
+	for _, ch := range s {
+		glyphs = append(glyphs, Glyph{ch, fg})
+	}
+	return glyphs
+}
+
+// drawGlyphs draws a run of Glyph on screen starting at the given location.
+func drawGlyphs(x, y int, glyphs []Glyph) {
+	for i, g := range glyphs {
+		TermDraw(x+i, y, g)
+	}
+}
+
+// Align specifies the horizontal alignment of text within a Widget.
+type Align int
+
+// Align constants for use with Visual which display aligned text.
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// offset computes the x offset of a run of text with the given length so that
+// it is aligned within the given width.
+func (a Align) offset(length, width int) int {
+	switch a {
+	case AlignCenter:
+		return (width - length) / 2
+	case AlignRight:
+		return width - length
+	}
+	return 0
 }
 
 // Border is a Visual which displays a border
@@ -25,11 +122,19 @@ type Border struct {
 	Widget
 	UpperLeft, UpperRight, LowerLeft, LowerRight Glyph
 	Vertical, Horizontal                         Glyph
+
+	// Title is drawn over the top edge between the TitleLeft and TitleRight
+	// separators. A TitleFg of 0 means the Title uses the Horizontal color.
+	Title                 string
+	TitleAlign            Align
+	TitleFg               Color
+	TitleLeft, TitleRight Glyph
 }
 
 // NewBorder creates a new Border with the given parameters.
 func NewBorder(vert, horiz Glyph, x, y, w, h int) *Border {
-	return &Border{Widget{x, y, w, h}, horiz, horiz, horiz, horiz, vert, horiz}
+	sep := Glyph{' ', horiz.Fg}
+	return &Border{Widget{x, y, w, h}, horiz, horiz, horiz, horiz, vert, horiz, "", AlignLeft, 0, sep, sep}
 }
 
 // Update draws the Border on screen.
@@ -46,6 +151,30 @@ func (w *Border) Update() {
 		w.DrawRel(x, 0, w.Horizontal)
 		w.DrawRel(x, w.h-1, w.Horizontal)
 	}
+	w.drawTitle()
+}
+
+// drawTitle draws the Border Title over the top edge. The Title is truncated
+// if it does not fit between the corners along with its separators.
+func (w *Border) drawTitle() {
+	// room between the corners, less the two separators
+	width := w.w - 4
+	if w.Title == "" || width <= 0 {
+		return
+	}
+
+	title := []rune(truncate(w.Title, width))
+	fg := w.TitleFg
+	if fg == 0 {
+		fg = w.Horizontal.Fg
+	}
+
+	x := 1 + w.TitleAlign.offset(len(title)+2, w.w-2)
+	w.DrawRel(x, 0, w.TitleLeft)
+	for i, ch := range title {
+		w.DrawRel(x+i+1, 0, Glyph{ch, fg})
+	}
+	w.DrawRel(x+len(title)+1, 0, w.TitleRight)
 }
 
 // TextBox is an Element which allows a user to enter custom text.
@@ -66,7 +195,7 @@ func NewTextBox(text string, length, x, y int) *TextBox {
 func (t *TextBox) Update(selected bool) {
 	color := t.getColor(selected)
 	t.drawText(color)
-	for x := len(t.Text); x < t.Len; x++ {
+	for x := len([]rune(t.Text)); x < t.Len; x++ {
 		TermDraw(t.X+x, t.Y, Glyph{t.ExtraCh, color})
 	}
 }
@@ -75,23 +204,79 @@ func (t *TextBox) Update(selected bool) {
 func (t *TextBox) Activate() FormResult {
 	old := t.Text
 	t.Text = ""
+	if !t.edit() {
+		t.Text = old
+	}
+	return nil
+}
+
+// edit lets the user modify the current text of the TextBox until they hit
+// enter or escape. The text is limited to Len runes, and backspace erases the
+// last rune. The result is false if the user hit escape.
+func (t *TextBox) edit() bool {
 	t.Update(true)
 	TermRefresh()
 
 	var key Key
 	for key != KeyEnter && key != KeyEsc {
 		key = GetKey()
-		if unicode.IsPrint(rune(key)) {
+		text := []rune(t.Text)
+		if key == KeyBackspace || key == KeyCtrlH {
+			if len(text) > 0 {
+				t.Text = string(text[:len(text)-1])
+			}
+		} else if unicode.IsPrint(rune(key)) && len(text) < t.Len {
 			t.Text += string(key)
 		}
 		t.Update(true)
 		TermRefresh()
 	}
 
-	if key == KeyEsc {
-		t.Text = old
+	return key != KeyEsc
+}
+
+// Prompter allows for customization of on-screen text prompts.
+type Prompter struct {
+	X, Y int
+	Fg   Color
+}
+
+// Prompt asks the user a question, and lets them type an answer of at most
+// maxLen runes. The result is not ok if the user hit escape. The screen is
+// restored after the user answers.
+func (p Prompter) Prompt(question string, maxLen int) (answer string, ok bool) {
+	return p.PromptDefault(question, "", maxLen)
+}
+
+// PromptDefault is like Prompt, except the answer is pre-filled with a
+// default, which the user can accept by hitting enter.
+func (p Prompter) PromptDefault(question, def string, maxLen int) (answer string, ok bool) {
+	state := TermSave()
+	defer TermRefresh()
+	defer state.Restore()
+
+	label := texter{question, p.X, p.Y}
+	label.drawText(p.Fg)
+
+	box := NewTextBox(truncate(def, maxLen), maxLen, p.X+len([]rune(question))+1, p.Y)
+	box.NormalFg, box.SelectedFg = p.Fg, p.Fg
+	if !box.edit() {
+		return "", false
 	}
-	return nil
+	return box.Text, true
+}
+
+// Prompt asks the user a question on the top line of the screen, and lets them
+// type an answer of at most maxLen runes. The result is not ok if the user hit
+// escape.
+func Prompt(question string, maxLen int) (answer string, ok bool) {
+	return Prompter{0, 0, ColorWhite}.Prompt(question, maxLen)
+}
+
+// PromptDefault is like Prompt, except the answer is pre-filled with a
+// default, which the user can accept by hitting enter.
+func PromptDefault(question, def string, maxLen int) (answer string, ok bool) {
+	return Prompter{0, 0, ColorWhite}.PromptDefault(question, def, maxLen)
 }
 
 // Button is an Element which runs a callback upon activation.
@@ -122,6 +307,124 @@ func (b *Button) Activate() FormResult {
 	return b.Binding()
 }
 
+// CheckBox is an Element which toggles between checked and unchecked upon
+// activation, calling Changed, if set, with the new state.
+type CheckBox struct {
+	texter
+	Checked bool
+	Changed func(checked bool)
+
+	colorSelect
+}
+
+// NewCheckBox creates a new CheckBox with the given state.
+func NewCheckBox(text string, checked bool, x, y int) *CheckBox {
+	return &CheckBox{texter{text, x, y}, checked, nil, colorSelect{ColorWhite, ColorLightWhite}}
+}
+
+// Update displays the CheckBox, with its state in brackets before the text.
+func (c *CheckBox) Update(selected bool) {
+	mark := " "
+	if c.Checked {
+		mark = "x"
+	}
+	drawString(c.X, c.Y, "["+mark+"] "+c.Text, c.getColor(selected))
+}
+
+// Activate toggles the CheckBox.
+func (c *CheckBox) Activate() FormResult {
+	c.Checked = !c.Checked
+	if c.Changed != nil {
+		c.Changed(c.Checked)
+	}
+	return nil
+}
+
+// Spinner is an Element holding a number between Min and Max. Upon
+// activation, the horizontal directions of KeyMap change the Value by Step
+// until the user hits enter, or escape to restore the old Value. Changed, if
+// set, is called with the new Value once the user hits enter.
+type Spinner struct {
+	texter
+	Value, Min, Max, Step int
+	Changed               func(value int)
+
+	colorSelect
+}
+
+// NewSpinner creates a new Spinner with the given value and range, with a Step
+// of 1.
+func NewSpinner(text string, value, min, max, x, y int) *Spinner {
+	return &Spinner{texter{text, x, y}, value, min, max, 1, nil, colorSelect{ColorWhite, ColorLightWhite}}
+}
+
+// Update displays the Spinner, with its Value after the text, padded to the
+// width of Min or Max so that its end does not move while spinning.
+func (s *Spinner) Update(selected bool) {
+	width := Max(len(fmt.Sprint(s.Min)), len(fmt.Sprint(s.Max)))
+	drawString(s.X, s.Y, fmt.Sprintf("%s < %*d >", s.Text, width, s.Value), s.getColor(selected))
+}
+
+// Activate lets the user change the Value of the Spinner.
+func (s *Spinner) Activate() FormResult {
+	old := s.Value
+	for {
+		s.Update(true)
+		TermRefresh()
+		switch key := GetKey(); key {
+		case KeyEnter:
+			if s.Changed != nil && s.Value != old {
+				s.Changed(s.Value)
+			}
+			return nil
+		case KeyEsc:
+			s.Value = old
+			return nil
+		default:
+			if delta, ok := KeyMap[key]; ok && delta.Y == 0 {
+				s.Value = Clamp(s.Min, s.Value+delta.X*s.Step, s.Max)
+			}
+		}
+	}
+}
+
+// Cycle is an Element which steps to the next of its Options upon activation,
+// wrapping around, and calls Changed, if set, with the new Index.
+type Cycle struct {
+	texter
+	Options []string
+	Index   int
+	Changed func(index int)
+
+	colorSelect
+}
+
+// NewCycle creates a new Cycle showing the option at the given index.
+func NewCycle(text string, options []string, index, x, y int) *Cycle {
+	return &Cycle{texter{text, x, y}, options, index, nil, colorSelect{ColorWhite, ColorLightWhite}}
+}
+
+// Update displays the Cycle, with its current option after the text.
+func (c *Cycle) Update(selected bool) {
+	option := ""
+	if InRange(c.Index, 0, len(c.Options)) {
+		option = c.Options[c.Index]
+	}
+	drawString(c.X, c.Y, c.Text+" "+option, c.getColor(selected))
+}
+
+// Activate steps the Cycle to its next option.
+func (c *Cycle) Activate() FormResult {
+	if len(c.Options) == 0 {
+		return nil
+	}
+	c.Index = Mod(c.Index+1, len(c.Options))
+	if c.Changed != nil {
+		c.Changed(c.Index)
+	}
+	return nil
+}
+
 // colorSelect is used to let an Element have customizable Color selection.
 type colorSelect struct {
 	NormalFg, SelectedFg Color
@@ -143,7 +446,86 @@ type texter struct {
 
 // drawText displays the text of the texter on screen.
 func (t texter) drawText(color Color) {
-	for i, ch := range t.Text {
-		TermDraw(t.X+i, t.Y, Glyph{ch, color})
+	drawString(t.X, t.Y, t.Text, color)
+}
+
+// ellipsis is the rune used to mark text which has been truncated.
+const ellipsis = '…'
+
+// truncate shortens a string to at most width runes, replacing the last rune
+// with an ellipsis if any runes had to be removed.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string(runes[:width-1]) + string(ellipsis)
+}
+
+// wrapText breaks a single line of text into lines of at most width runes.
+// Lines are broken on spaces where possible, but words longer than the width
+// are split across lines.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, line := range wrapGlyphs(textGlyphs(s, 0, false), width) {
+		runes := make([]rune, len(line))
+		for i, g := range line {
+			runes[i] = g.Ch
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// wrapGlyphs is like wrapText, except that it operates on Glyph so that text
+// colored with markup can be wrapped without counting the markup tags.
+func wrapGlyphs(glyphs []Glyph, width int) [][]Glyph {
+	if width <= 0 {
+		return nil
+	}
+
+	var lines [][]Glyph
+	var line []Glyph
+	for _, word := range splitWords(glyphs) {
+		// start a new line if the word will not fit after a space
+		if len(line) > 0 && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, Glyph{' ', line[len(line)-1].Fg})
+		}
+
+		// split words which are too long to fit on any line
+		for len(line)+len(word) > width {
+			n := width - len(line)
+			lines = append(lines, append(line, word[:n]...))
+			line, word = nil, word[n:]
+		}
+		line = append(line, word...)
+	}
+	return append(lines, line)
+}
+
+// splitWords splits Glyph into words separated by spaces.
+func splitWords(glyphs []Glyph) [][]Glyph {
+	var words [][]Glyph
+	start := -1
+	for i, g := range glyphs {
+		if g.Ch == ' ' {
+			if start >= 0 {
+				words = append(words, glyphs[start:i])
+			}
+			start = -1
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, glyphs[start:])
 	}
+	return words
 }
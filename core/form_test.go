@@ -0,0 +1,66 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"Inventory", 20, "Inventory"},
+		{"Inventory", 9, "Inventory"},
+		{"Inventory", 8, "Invento…"},
+		{"Inventory", 1, "…"},
+		{"Inventory", 0, ""},
+		{"", 5, ""},
+	}
+	for _, c := range cases {
+		if actual := truncate(c.s, c.width); actual != c.expected {
+			t.Errorf("truncate(%q, %d) = %q != %q", c.s, c.width, actual, c.expected)
+		}
+	}
+}
+
+func TestAlign_offset(t *testing.T) {
+	cases := []struct {
+		align         Align
+		length, width int
+		expected      int
+	}{
+		{AlignLeft, 4, 10, 0},
+		{AlignCenter, 4, 10, 3},
+		{AlignCenter, 5, 10, 2},
+		{AlignRight, 4, 10, 6},
+		{AlignRight, 10, 10, 0},
+	}
+	for _, c := range cases {
+		if actual := c.align.offset(c.length, c.width); actual != c.expected {
+			t.Errorf("%v.offset(%d, %d) = %d != %d", c.align, c.length, c.width, actual, c.expected)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		s        string
+		width    int
+		expected []string
+	}{
+		{"", 10, []string{""}},
+		{"a sharp stone", 20, []string{"a sharp stone"}},
+		{"a sharp stone", 7, []string{"a sharp", "stone"}},
+		{"a sharp stone", 6, []string{"a", "sharp", "stone"}},
+		{"a  sharp   stone", 7, []string{"a sharp", "stone"}},
+		{"mammoth", 3, []string{"mam", "mot", "h"}},
+		{"a mammoth", 4, []string{"a", "mamm", "oth"}},
+	}
+	for _, c := range cases {
+		if actual := wrapText(c.s, c.width); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("wrapText(%q, %d) = %q != %q", c.s, c.width, actual, c.expected)
+		}
+	}
+}
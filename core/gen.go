@@ -5,39 +5,15 @@ package core
 // generically.
 type MapGen func(o Offset) *Tile
 
-// NewTileGrid creates a 2D grid of Tile using the given MapGen.
+// NewTileGrid creates a 2D grid of Tile using the given MapGen, returning the
+// Tiles of a Grid created by NewGridGen. If either dimension is not positive,
+// no Tiles are returned.
 func NewTileGrid(cols, rows int, origin Offset, f MapGen) []*Tile {
-	backing := make([]*Tile, cols*rows)
-
-	tiles := make([][]*Tile, cols)
-	for x := 0; x < cols; x++ {
-		tiles[x] = backing[x*rows : (x+1)*rows]
-		for y := 0; y < rows; y++ {
-			tiles[x][y] = f(origin.Add(Offset{x, y}))
-		}
-	}
-
-	link := func(x, y, dx, dy int) {
-		nx, ny := x+dx, y+dy
-		if 0 <= nx && nx < cols && 0 <= ny && ny < rows {
-			tiles[x][y].Adjacent[Offset{dx, dy}] = tiles[nx][ny]
-		}
+	g, err := NewGridGen(cols, rows, origin, f)
+	if err != nil {
+		return nil
 	}
-
-	for x := 0; x < cols; x++ {
-		for y := 0; y < rows; y++ {
-			link(x, y, 1, 1)
-			link(x, y, 1, 0)
-			link(x, y, 1, -1)
-			link(x, y, 0, 1)
-			link(x, y, 0, -1)
-			link(x, y, -1, 1)
-			link(x, y, -1, 0)
-			link(x, y, -1, -1)
-		}
-	}
-
-	return backing
+	return g.tiles
 }
 
 // isDiag returns true if the Offset is a single diagonal step.
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"time"
+)
+
+// Generator is a stage of map generation, such as carving caves, adding
+// water or populating a level, which modifies a Grid using the Dice for all
+// random choices, and records what it made in the GenResult, which may be nil
+// if nobody wants it. The options of each built-in generator implement
+// Generator, so that stages may be composed into a Pipeline.
+type Generator interface {
+	Generate(g *Grid, dice Dice, res *GenResult) error
+}
+
+// GenResult collects what the stages of a Pipeline made, so that later stages
+// and the game need not search the Grid for them. Each stage appends to the
+// fields it knows about and leaves the rest alone.
+type GenResult struct {
+	Rooms   []Rect
+	Doors   []*Door
+	Spawned []*Instance
+	Marks   map[string][]*Tile
+}
+
+// GeneratorFunc is an adaptor allowing an ordinary function to be used as a
+// Generator.
+type GeneratorFunc func(g *Grid, dice Dice, res *GenResult) error
+
+// Generate calls the GeneratorFunc.
+func (f GeneratorFunc) Generate(g *Grid, dice Dice, res *GenResult) error {
+	return f(g, dice, res)
+}
+
+// Pipeline is a sequence of Generator run one after another on the same Grid
+// with the same Dice, so that a seeded Dice gives the same map each time.
+type Pipeline []Generator
+
+// Generate runs each stage of the Pipeline in order, passing each the same
+// GenResult, and stops at the first stage which returns an error, returning
+// that error. Since a Pipeline is itself a Generator, Pipelines may be nested.
+func (p Pipeline) Generate(g *Grid, dice Dice, res *GenResult) error {
+	return p.Profile(g, dice, res, nil)
+}
+
+// Profile runs the Pipeline like Generate, calling the hook, if not nil,
+// after each stage with its index, the stage itself and the time it took,
+// including for a stage which fails.
+func (p Pipeline) Profile(g *Grid, dice Dice, res *GenResult, hook func(stage int, gen Generator, elapsed time.Duration)) error {
+	if dice.Rand == nil {
+		dice = globalDice
+	}
+	for i, gen := range p {
+		start := time.Now()
+		err := gen.Generate(g, dice, res)
+		if hook != nil {
+			hook(i, gen, time.Since(start))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrefabPass is a Generator placing Count Prefabs chosen from Prefabs with
+// PlacePrefabs.
+type PrefabPass struct {
+	Prefabs []WeightedPrefab
+	Count   int
+}
+
+// Generate places the Prefabs, adding their marked Tiles to the Marks of the
+// GenResult, and fails with ErrNoFit if none could be placed, or with any
+// error from PlacePrefabs.
+func (p PrefabPass) Generate(g *Grid, dice Dice, res *GenResult) error {
+	placed, marks, err := PlacePrefabs(g, p.Prefabs, p.Count, dice)
+	if res != nil {
+		if res.Marks == nil {
+			res.Marks = make(map[string][]*Tile)
+		}
+		for name, tiles := range marks {
+			res.Marks[name] = append(res.Marks[name], tiles...)
+		}
+	}
+	if err != nil {
+		return err
+	} else if placed == 0 && p.Count > 0 {
+		return ErrNoFit
+	}
+	return nil
+}
+
+// PrunePass is a Generator applying PruneDeadEnds with the given Iterations
+// and Protected predicates.
+type PrunePass struct {
+	Iterations int
+	Protected  []func(*Tile) bool
+}
+
+// Generate prunes the dead ends of the Grid.
+func (p PrunePass) Generate(g *Grid, _ Dice, _ *GenResult) error {
+	PruneDeadEnds(g, p.Iterations, p.Protected...)
+	return nil
+}
+
+// AutotilePass is a Generator applying Autotile with the Tiles glyph set. If
+// IsWall is nil, impassable Tiles are treated as walls.
+type AutotilePass struct {
+	IsWall func(*Tile) bool
+	Tiles  [16]Glyph
+}
+
+// Generate autotiles the walls of the Grid.
+func (p AutotilePass) Generate(g *Grid, _ Dice, _ *GenResult) error {
+	isWall := p.IsWall
+	if isWall == nil {
+		isWall = func(t *Tile) bool { return !t.Pass }
+	}
+	Autotile(g, isWall, p.Tiles)
+	return nil
+}
+
+// PopulatePass is a Generator applying Populate with the SpawnTable and
+// PopulateOpts.
+type PopulatePass struct {
+	Table SpawnTable
+	PopulateOpts
+}
+
+// Generate populates the Grid, adding every Instance to the Spawned of the
+// GenResult. If no Rooms are given, the Rooms of the GenResult are used, such
+// as those carved by an earlier BSPOptions stage.
+func (p PopulatePass) Generate(g *Grid, dice Dice, res *GenResult) error {
+	if p.Rooms == nil && res != nil {
+		p.Rooms = res.Rooms
+	}
+	spawned := Populate(g, dice, p.Table, p.PopulateOpts)
+	if res != nil {
+		res.Spawned = append(res.Spawned, spawned...)
+	}
+	return nil
+}
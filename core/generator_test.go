@@ -0,0 +1,99 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// pipelineCase runs a Pipeline on a new Grid using a Dice with the seed, and
+// returns the Grid and the Face of each Tile.
+func pipelineCase(t *testing.T, p Pipeline, seed int64) (*Grid, []Glyph) {
+	g, _ := NewGrid(40, 20)
+	if err := p.Generate(g, NewDice(rand.NewSource(seed)), nil); err != nil {
+		t.Fatalf("Pipeline.Generate() = %v", err)
+	}
+	var faces []Glyph
+	g.Each(func(_ Offset, tile *Tile) { faces = append(faces, tile.Face) })
+	return g, faces
+}
+
+func TestPipeline(t *testing.T) {
+	table := SpawnTable{{Proto: &Prototype{Name: "rat"}, Weight: 1}}
+	p := Pipeline{
+		DrunkardOptions{Open: .4},
+		WaterOptions{Lakes: 1, LakeSize: 10},
+		PrunePass{Iterations: -1},
+		AutotilePass{Tiles: ASCIIWalls},
+		PopulatePass{Table: table, PopulateOpts: PopulateOpts{Budget: 3}},
+	}
+	g, faces := pipelineCase(t, p, 3)
+	if water := g.Count(func(tile *Tile) bool { return tile.Terrain == TerrainWater }); water == 0 {
+		t.Errorf("Pipeline did not add water")
+	}
+	if rats := g.Count(func(tile *Tile) bool { return tile.Occupant != nil }); rats != 3 {
+		t.Errorf("Pipeline populated %d != 3", rats)
+	}
+	if _, again := pipelineCase(t, p, 3); !reflect.DeepEqual(faces, again) {
+		t.Errorf("Pipeline differs for the same seed")
+	}
+}
+
+func TestPipeline_Error(t *testing.T) {
+	var stages []int
+	var ran bool
+	p := Pipeline{
+		BSPOptions{},
+		GridMazeOptions{},
+		GeneratorFunc(func(*Grid, Dice, *GenResult) error { ran = true; return nil }),
+	}
+	g, _ := NewGrid(20, 20)
+	err := p.Profile(g, Dice{}, nil, func(stage int, gen Generator, elapsed time.Duration) {
+		stages = append(stages, stage)
+		if elapsed < 0 || !reflect.DeepEqual(gen, p[stage]) {
+			t.Errorf("hook(%d) got %v, %v", stage, gen, elapsed)
+		}
+	})
+	if err != ErrInvalidDimensions || ran {
+		t.Errorf("Pipeline.Profile() = %v, ran %v", err, ran)
+	}
+	if !reflect.DeepEqual(stages, []int{0, 1}) {
+		t.Errorf("Pipeline.Profile() called hook for %v", stages)
+	}
+}
+
+func TestPipeline_Result(t *testing.T) {
+	table := SpawnTable{{Proto: &Prototype{Name: "rat"}, Weight: 1}}
+	vault, _ := NewPrefab("###\n#>#\n###", nil)
+	p := Pipeline{
+		BSPOptions{MinLeaf: 6},
+		DoorOptions{},
+		PrefabPass{Prefabs: []WeightedPrefab{{vault, 1}}, Count: 1},
+		PopulatePass{Table: table, PopulateOpts: PopulateOpts{Budget: 4, MaxPerRoom: 1}},
+	}
+	g, _ := NewGrid(40, 20)
+	var res GenResult
+	if err := p.Generate(g, NewDice(rand.NewSource(2)), &res); err != nil {
+		t.Fatalf("Pipeline.Generate() = %v", err)
+	}
+	if len(res.Rooms) == 0 || len(res.Doors) == 0 || len(res.Marks["down"]) != 1 {
+		t.Errorf("Pipeline gave %d rooms, %d doors, marks %v", len(res.Rooms), len(res.Doors), res.Marks)
+	}
+	if len(res.Spawned) != 4 {
+		t.Fatalf("Pipeline spawned %d != 4", len(res.Spawned))
+	}
+	// the spawns are kept to the rooms carved by the earlier stage
+	g.Each(func(o Offset, tile *Tile) {
+		if _, ok := tile.Occupant.(*Instance); !ok {
+			return
+		}
+		inside := false
+		for _, room := range res.Rooms {
+			inside = inside || room.Contains(o)
+		}
+		if !inside {
+			t.Errorf("Pipeline spawned outside rooms at %v", o)
+		}
+	})
+}
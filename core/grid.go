@@ -0,0 +1,212 @@
+package core
+
+// Grid is a rectangular block of Tile, each linked through Adjacent to each of
+// its up to eight neighbors. Tiles on the edge of the Grid simply have no
+// Adjacent entry in the directions leading off the Grid.
+//
+// Changes to the terrain of a Grid should be made with Grid.SetTerrain, Dig or
+// Fill, which publish a TerrainChanged on the EventBus set with SetBus.
+//
+// Every method which visits or returns Tiles in turn, such as Each and Find,
+// does so column by column, from the top of the leftmost column.
+type Grid struct {
+	cols, rows int
+	tiles      []*Tile // column by column
+	bus        *EventBus
+}
+
+// NewGrid creates a Grid of floor Tiles created by NewTile, with the Tile at
+// (x, y) having the Offset {x, y}. If either dimension is not positive,
+// ErrInvalidDimensions is returned.
+func NewGrid(w, h int) (*Grid, error) {
+	return NewGridGen(w, h, Offset{}, NewTile)
+}
+
+// NewGridGen creates a Grid using the given MapGen to create each Tile, with
+// the Tile at (x, y) having the Offset origin + {x, y}. If either dimension is
+// not positive, ErrInvalidDimensions is returned.
+func NewGridGen(w, h int, origin Offset, f MapGen) (*Grid, error) {
+	if w <= 0 || h <= 0 {
+		return nil, ErrInvalidDimensions
+	}
+
+	g := &Grid{w, h, make([]*Tile, w*h), nil}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			g.tiles[x*h+y] = f(origin.Add(Offset{x, y}))
+		}
+	}
+
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			tile := g.At(x, y)
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					if adj := g.At(x+dx, y+dy); adj != nil && adj != tile {
+						tile.Adjacent[Offset{dx, dy}] = adj
+					}
+				}
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// At returns the Tile at (x, y), or nil if (x, y) is outside the Grid.
+func (g *Grid) At(x, y int) *Tile {
+	if x < 0 || x >= g.cols || y < 0 || y >= g.rows {
+		return nil
+	}
+	return g.tiles[x*g.rows+y]
+}
+
+// Width returns the number of columns in the Grid.
+func (g *Grid) Width() int {
+	return g.cols
+}
+
+// Height returns the number of rows in the Grid.
+func (g *Grid) Height() int {
+	return g.rows
+}
+
+// Each calls the given function with the position of each Tile within the
+// Grid, column by column.
+func (g *Grid) Each(f func(Offset, *Tile)) {
+	for x := 0; x < g.cols; x++ {
+		for y := 0; y < g.rows; y++ {
+			f(Offset{x, y}, g.tiles[x*g.rows+y])
+		}
+	}
+}
+
+// Tiles returns every Tile in the Grid, column by column, such as for use
+// with SaveWorld or RandPassTile.
+func (g *Grid) Tiles() []*Tile {
+	return append([]*Tile(nil), g.tiles...)
+}
+
+// Find returns the first Tile, column by column, for which pred is true, or
+// nil if there is none or the Grid is nil.
+func (g *Grid) Find(pred func(*Tile) bool) *Tile {
+	if g == nil {
+		return nil
+	}
+	for _, t := range g.tiles {
+		if pred(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// FindAll returns every Tile for which pred is true, column by column. The
+// result is empty rather than nil if there are none or the Grid is nil.
+func (g *Grid) FindAll(pred func(*Tile) bool) []*Tile {
+	found := []*Tile{}
+	if g == nil {
+		return found
+	}
+	for _, t := range g.tiles {
+		if pred(t) {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// Count returns the number of Tiles for which pred is true, which is zero for
+// a nil Grid.
+func (g *Grid) Count(pred func(*Tile) bool) int {
+	if g == nil {
+		return 0
+	}
+	count := 0
+	for _, t := range g.tiles {
+		if pred(t) {
+			count++
+		}
+	}
+	return count
+}
+
+// TilesInRect returns the Tiles of the Grid inside the Rect, column by column.
+// Parts of the Rect outside the Grid are ignored, so the result is empty rather
+// than nil if they do not overlap or the Grid is nil.
+func (g *Grid) TilesInRect(r Rect) []*Tile {
+	found := []*Tile{}
+	if g == nil {
+		return found
+	}
+	for x := Max(r.X, 0); x < Min(r.X+r.W, g.cols); x++ {
+		for y := Max(r.Y, 0); y < Min(r.Y+r.H, g.rows); y++ {
+			found = append(found, g.tiles[x*g.rows+y])
+		}
+	}
+	return found
+}
+
+// RandomTile selects a random Tile from the Grid for which every predicate is
+// true, such as for placing stairs or monsters. As with Dice.Tile, rejection
+// sampling is tried first, after which the Grid is filtered, so that if no
+// Tile satisfies every predicate, ErrNoTile is returned rather than looping
+// forever. If the Dice is unset, the global Dice is used.
+func RandomTile(g *Grid, dice Dice, preds ...func(*Tile) bool) (*Tile, error) {
+	if dice.Rand == nil {
+		dice = globalDice
+	}
+	match := func(t *Tile) bool {
+		for _, pred := range preds {
+			if !pred(t) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < 100; i++ {
+		if tile := g.tiles[dice.Intn(len(g.tiles))]; match(tile) {
+			return tile, nil
+		}
+	}
+
+	var candidates []*Tile
+	for _, tile := range g.tiles {
+		if match(tile) {
+			candidates = append(candidates, tile)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoTile
+	}
+	return candidates[dice.Intn(len(candidates))], nil
+}
+
+// IsPassable is a predicate for RandomTile which is true for passable Tiles.
+func IsPassable(t *Tile) bool {
+	return t.Pass
+}
+
+// Unoccupied is a predicate for RandomTile which is true for Tiles without an
+// Occupant.
+func Unoccupied(t *Tile) bool {
+	return t.Occupant == nil
+}
+
+// MinDistanceFrom gives a predicate for RandomTile which is true for Tiles at
+// least d steps from the given Tile, measured by Chebyshev distance.
+func MinDistanceFrom(from *Tile, d int) func(*Tile) bool {
+	return func(t *Tile) bool {
+		return t.Offset.Sub(from.Offset).Chebyshev() >= d
+	}
+}
+
+// OutsideFoV gives a predicate for RandomTile which is true for Tiles not in
+// a field of view computed by FoV from the given origin, such as to spawn
+// monsters out of sight of the player.
+func OutsideFoV(fov map[Offset]*Tile, origin *Tile) func(*Tile) bool {
+	return func(t *Tile) bool {
+		return fov[t.Offset.Sub(origin.Offset)] != t
+	}
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNewGrid(t *testing.T) {
+	for _, dims := range [][2]int{{0, 3}, {3, 0}, {-1, 2}} {
+		if g, err := NewGrid(dims[0], dims[1]); g != nil || err != ErrInvalidDimensions {
+			t.Errorf("NewGrid(%d, %d) = %v, %v", dims[0], dims[1], g, err)
+		}
+	}
+
+	g, err := NewGrid(4, 3)
+	if err != nil {
+		t.Fatalf("NewGrid(4, 3) = %v", err)
+	}
+	if g.Width() != 4 || g.Height() != 3 {
+		t.Errorf("NewGrid(4, 3) has size %dx%d", g.Width(), g.Height())
+	}
+	if g.At(-1, 0) != nil || g.At(4, 0) != nil || g.At(0, 3) != nil {
+		t.Errorf("At() outside Grid was not nil")
+	}
+
+	cases := []struct {
+		x, y, adjacent int
+	}{
+		{0, 0, 3}, {3, 2, 3}, {1, 0, 5}, {0, 1, 5}, {1, 1, 8}, {2, 1, 8},
+	}
+	for _, c := range cases {
+		tile := g.At(c.x, c.y)
+		if tile.Offset != (Offset{c.x, c.y}) {
+			t.Errorf("At(%d, %d) has Offset %v", c.x, c.y, tile.Offset)
+		}
+		if len(tile.Adjacent) != c.adjacent {
+			t.Errorf("At(%d, %d) has %d != %d Adjacent", c.x, c.y, len(tile.Adjacent), c.adjacent)
+		}
+	}
+
+	count := 0
+	g.Each(func(o Offset, tile *Tile) {
+		count++
+		if g.At(o.X, o.Y) != tile {
+			t.Errorf("Each() gave wrong Tile for %v", o)
+		}
+		for delta, adj := range tile.Adjacent {
+			if adj.Offset != o.Add(delta) || adj.Adjacent[delta.Neg()] != tile {
+				t.Errorf("Each() Tile %v has bad link %v", o, delta)
+			}
+		}
+	})
+	if count != 12 || len(g.Tiles()) != 12 {
+		t.Errorf("Grid has %d Tiles with Each() and %d with Tiles()", count, len(g.Tiles()))
+	}
+}
+
+func TestNewGridGen(t *testing.T) {
+	origin := Offset{10, -5}
+	g, err := NewGridGen(2, 2, origin, func(o Offset) *Tile {
+		tile := NewTile(o)
+		tile.Pass = false
+		return tile
+	})
+	if err != nil {
+		t.Fatalf("NewGridGen() = %v", err)
+	}
+	if tile := g.At(1, 1); tile.Offset != origin.Add(Offset{1, 1}) || tile.Pass {
+		t.Errorf("NewGridGen() did not use MapGen")
+	}
+}
+
+func TestRandomTile(t *testing.T) {
+	g := gridCase(
+		"#########",
+		"#.......#",
+		"#.......#",
+		"####.####",
+		"#.......#",
+		"#########",
+	)
+	hero := g.At(1, 1)
+	hero.Occupant = &testentity{"hero"}
+	fov := FoV(hero, 20)
+	dice := NewDice(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		tile, err := RandomTile(g, dice, IsPassable, Unoccupied, MinDistanceFrom(hero, 3), OutsideFoV(fov, hero))
+		if err != nil {
+			t.Fatalf("RandomTile() = %v", err)
+		}
+		if !tile.Pass || tile.Occupant != nil || tile.Offset.Y != 4 || tile.Offset.Sub(hero.Offset).Chebyshev() < 3 {
+			t.Errorf("RandomTile() gave %v", tile.Offset)
+		}
+	}
+
+	if tile, err := RandomTile(g, dice, IsPassable, MinDistanceFrom(hero, 10)); tile != nil || err != ErrNoTile {
+		t.Errorf("RandomTile() impossible = %v, %v", tile, err)
+	}
+	if tile, err := RandomTile(g, Dice{}, Unoccupied); tile == nil || err != nil {
+		t.Errorf("RandomTile() with global Dice = %v, %v", tile, err)
+	}
+}
+
+func TestGrid_Find(t *testing.T) {
+	g := gridCase(
+		"#.##",
+		"##.#",
+		".###",
+	)
+	if tile := g.Find(isPass); tile != g.At(0, 2) {
+		t.Errorf("Grid.Find() = %v", tile)
+	}
+	expected := []*Tile{g.At(0, 2), g.At(1, 0), g.At(2, 1)}
+	if found := g.FindAll(isPass); !reflect.DeepEqual(found, expected) {
+		t.Errorf("Grid.FindAll() = %v", found)
+	}
+	if count := g.Count(isPass); count != 3 {
+		t.Errorf("Grid.Count() = %d", count)
+	}
+
+	none := func(*Tile) bool { return false }
+	if tile := g.Find(none); tile != nil {
+		t.Errorf("Grid.Find() matched nothing = %v", tile)
+	}
+	if found := g.FindAll(none); found == nil || len(found) != 0 {
+		t.Errorf("Grid.FindAll() matched nothing = %v", found)
+	}
+
+	var empty *Grid
+	if empty.Find(isPass) != nil || empty.FindAll(isPass) == nil || empty.Count(isPass) != 0 || empty.TilesInRect(Rect{0, 0, 1, 1}) == nil {
+		t.Errorf("nil Grid did not give empty results")
+	}
+}
+
+func TestGrid_TilesInRect(t *testing.T) {
+	g, _ := NewGrid(4, 3)
+	expected := []*Tile{g.At(2, 1), g.At(2, 2), g.At(3, 1), g.At(3, 2)}
+	if tiles := g.TilesInRect(Rect{2, 1, 5, 5}); !reflect.DeepEqual(tiles, expected) {
+		t.Errorf("Grid.TilesInRect() clipped to %v", tiles)
+	}
+	if tiles := g.TilesInRect(Rect{-3, 0, 2, 2}); tiles == nil || len(tiles) != 0 {
+		t.Errorf("Grid.TilesInRect() outside Grid = %v", tiles)
+	}
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// gridVersion is the version byte written by EncodeGrid.
+const gridVersion = 1
+
+// Limits on decoded values, so that corrupt input cannot cause huge
+// allocations.
+const (
+	maxGridTiles   = 1 << 24
+	maxTerrainName = 1 << 10
+)
+
+// Bits of the flags byte of each run written by EncodeGrid.
+const (
+	gridPass = 1 << iota
+	gridLite
+)
+
+// gridRun is a run of Tiles with the same encoded appearance.
+type gridRun struct {
+	terrain int
+	flags   byte
+	face    Glyph
+}
+
+// EncodeGrid writes the terrain of a Grid in a compact format, without any
+// Entity on it, such as for shipping a handcrafted level or comparing
+// generated maps. The format is a version byte, the width and height, a table
+// of the TerrainID used, and then runs of Tiles, column by column, which share
+// the same Terrain, Pass, Lite and Face. All numbers are varints.
+func EncodeGrid(w io.Writer, g *Grid) error {
+	bw := bufio.NewWriter(w)
+	var scratch [binary.MaxVarintLen64]byte
+	uvarint := func(x uint64) {
+		bw.Write(scratch[:binary.PutUvarint(scratch[:], x)])
+	}
+
+	bw.WriteByte(gridVersion)
+	uvarint(uint64(g.cols))
+	uvarint(uint64(g.rows))
+
+	index := make(map[TerrainID]int)
+	var terrains []TerrainID
+	runs := make([]gridRun, len(g.tiles))
+	for i, t := range g.tiles {
+		id, ok := index[t.Terrain]
+		if !ok {
+			id = len(terrains)
+			index[t.Terrain] = id
+			terrains = append(terrains, t.Terrain)
+		}
+		runs[i] = gridRun{id, 0, t.Face}
+		if t.Pass {
+			runs[i].flags |= gridPass
+		}
+		if t.Lite {
+			runs[i].flags |= gridLite
+		}
+	}
+
+	uvarint(uint64(len(terrains)))
+	for _, id := range terrains {
+		uvarint(uint64(len(id)))
+		bw.WriteString(string(id))
+	}
+
+	for i := 0; i < len(runs); {
+		j := i + 1
+		for j < len(runs) && runs[j] == runs[i] {
+			j++
+		}
+		uvarint(uint64(j - i))
+		uvarint(uint64(runs[i].terrain))
+		bw.WriteByte(runs[i].flags)
+		uvarint(uint64(runs[i].face.Ch))
+		uvarint(uint64(runs[i].face.Fg))
+		i = j
+	}
+
+	return bw.Flush()
+}
+
+// DecodeGrid reads a Grid written by EncodeGrid, linking the Tiles as with
+// NewGrid. Each Tile with a Terrain is given it with SetTerrain, and then has
+// its Face, Pass and Lite restored. Truncated input gives io.ErrUnexpectedEOF,
+// any other malformed input gives ErrCorruptGrid, and an unknown version byte
+// gives ErrGridVersion.
+func DecodeGrid(r io.Reader) (*Grid, error) {
+	br := bufio.NewReader(r)
+	var err error
+	uvarint := func(max uint64) uint64 {
+		if err != nil {
+			return 0
+		}
+		var x uint64
+		if x, err = binary.ReadUvarint(br); err == nil && x > max {
+			err = ErrCorruptGrid
+		}
+		return x
+	}
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if version != gridVersion {
+		return nil, ErrGridVersion
+	}
+
+	cols, rows := int(uvarint(maxGridTiles)), int(uvarint(maxGridTiles))
+	if err == nil && (cols == 0 || rows == 0 || cols*rows > maxGridTiles) {
+		return nil, ErrInvalidDimensions
+	}
+	terrains := make([]TerrainID, uvarint(maxGridTiles))
+	for i := range terrains {
+		name := make([]byte, uvarint(maxTerrainName))
+		if err == nil {
+			_, err = io.ReadFull(br, name)
+		}
+		terrains[i] = TerrainID(name)
+	}
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	g, _ := NewGrid(cols, rows)
+	for i := 0; i < len(g.tiles); {
+		n := int(uvarint(uint64(len(g.tiles) - i)))
+		terrain := uvarint(uint64(len(terrains)))
+		var flags byte
+		if err == nil {
+			flags, err = br.ReadByte()
+		}
+		ch, fg := uvarint(1<<31-1), uvarint(1<<16-1)
+		if err == nil && (n == 0 || int(terrain) >= len(terrains)) {
+			err = ErrCorruptGrid
+		}
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		for _, t := range g.tiles[i : i+n] {
+			if id := terrains[terrain]; id != "" {
+				t.SetTerrain(id)
+			}
+			t.Face = Glyph{rune(ch), Color(fg)}
+			t.Pass, t.Lite = flags&gridPass != 0, flags&gridLite != 0
+		}
+		i += n
+	}
+	return g, nil
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, since any EOF while
+// decoding means the input was truncated.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
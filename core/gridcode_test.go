@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// tileLooks gives the encoded appearance of every Tile in a Grid.
+func tileLooks(g *Grid) []gridRun {
+	var looks []gridRun
+	g.Each(func(_ Offset, t *Tile) {
+		look := gridRun{face: t.Face}
+		if t.Pass {
+			look.flags |= gridPass
+		}
+		if t.Lite {
+			look.flags |= gridLite
+		}
+		looks = append(looks, look)
+	})
+	return looks
+}
+
+func TestEncodeGrid(t *testing.T) {
+	g, _ := NewGrid(60, 30)
+	BSPDungeon(g, BSPOptions{Dice: NewDice(rand.NewSource(4))})
+	g.At(5, 5).SetTerrain(TerrainWater)
+
+	var buf bytes.Buffer
+	if err := EncodeGrid(&buf, g); err != nil {
+		t.Fatalf("EncodeGrid() = %v", err)
+	}
+	if buf.Len() >= 60*30/2 {
+		t.Errorf("EncodeGrid() wrote %d bytes for %d Tiles", buf.Len(), 60*30)
+	}
+
+	// custom faces must survive too
+	Autotile(g, func(t *Tile) bool { return t.Terrain == TerrainWall }, UnicodeWalls)
+	buf.Reset()
+	if err := EncodeGrid(&buf, g); err != nil {
+		t.Fatalf("EncodeGrid() = %v", err)
+	}
+	data := buf.Bytes()
+
+	decoded, err := DecodeGrid(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGrid() = %v", err)
+	}
+	if decoded.Width() != 60 || decoded.Height() != 30 {
+		t.Errorf("DecodeGrid() gave %dx%d Grid", decoded.Width(), decoded.Height())
+	}
+	if !reflect.DeepEqual(tileLooks(g), tileLooks(decoded)) {
+		t.Errorf("DecodeGrid() differs from encoded Grid")
+	}
+	if tile := decoded.At(5, 5); tile.Terrain != TerrainWater || tile.Cost != 2 {
+		t.Errorf("DecodeGrid() did not restore Terrain")
+	}
+	if len(decoded.At(1, 1).Adjacent) != 8 {
+		t.Errorf("DecodeGrid() did not link Tiles")
+	}
+
+	// every truncation is an error rather than a panic
+	buf.Reset()
+	EncodeGrid(&buf, gridCase("#####", "#.~.#", "#####"))
+	data = buf.Bytes()
+	for n := 0; n < len(data); n++ {
+		if g, err := DecodeGrid(bytes.NewReader(data[:n])); g != nil || err != io.ErrUnexpectedEOF {
+			t.Errorf("DecodeGrid() truncated to %d = %v", n, err)
+		}
+	}
+}
+
+func TestDecodeGrid_Corrupt(t *testing.T) {
+	cases := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{2, 1, 1}, ErrGridVersion},
+		{[]byte{1, 0, 3, 0}, ErrInvalidDimensions},
+		{[]byte{1, 0xff, 0xff, 0xff, 0xff, 0x0f, 1}, ErrCorruptGrid},
+		{[]byte{1, 2, 1, 1, 0, 3, 0, 3, '.', 7}, ErrCorruptGrid}, // run too long
+		{[]byte{1, 1, 1, 1, 0, 1, 1, 3, '.', 7}, ErrCorruptGrid}, // bad terrain
+		{[]byte{1, 1, 1, 1, 0, 0, 0, 3, '.', 7}, ErrCorruptGrid}, // empty run
+	}
+	for _, c := range cases {
+		if g, err := DecodeGrid(bytes.NewReader(c.data)); g != nil || err != c.err {
+			t.Errorf("DecodeGrid(%v) = %v != %v", c.data, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// InventoryRequest is an Event querying an Entity for the items it carries.
+type InventoryRequest struct {
+	Items []Entity
+}
+
+// ItemRequest is an Event querying an item Entity for its inventory details.
+// A Count of 0 is treated as a single item, and Weight is per item. Fixed
+// items, such as an open Door, cannot be picked up.
+type ItemRequest struct {
+	Name     string
+	Category string
+	Weight   float64
+	Count    int
+	Fixed    bool
+}
+
+// DropItem is an Event requesting that an Entity drop one of its items.
+type DropItem struct {
+	Item Entity
+}
+
+// EquipItem is an Event requesting that an Entity wield or wear an item. If
+// Slot is empty, the item goes in the slots it normally occupies. Done is set
+// to true if the item was equipped.
+type EquipItem struct {
+	Item Entity
+	Slot string
+	Done bool
+}
+
+// ThrowItem is an Event requesting that an Entity throw one of its items.
+type ThrowItem struct {
+	Item Entity
+}
+
+// PickUp is an Event requesting that an Entity pick up an item from a Tile. If
+// From is nil, the current position is used, and if Item is nil, the topmost
+// item which is not Fixed is picked up. Done is set to true if the item was
+// picked up.
+type PickUp struct {
+	From *Tile
+	Item Entity
+	Done bool
+}
+
+// MergeItem is an Event offering an item to be merged into a carried item,
+// such as one arrow being added to a stack of arrows. An item which merges
+// the other into itself should add its Count and set Merged to true.
+type MergeItem struct {
+	Item   Entity
+	Merged bool
+}
+
+// Inventory is a Component which carries items. It handles PickUp, DropItem
+// and InventoryRequest. If picking up an item would exceed the Capacity or
+// MaxWeight, the item is left on the Tile and a Message is sent to Self
+// explaining why. A Capacity or MaxWeight of zero is unlimited. Since Tile can
+// hold any number of items, dropping an item always succeeds as long as the
+// position is known, and the dropped item is placed on top.
+type Inventory struct {
+	Self      Entity
+	Pos       *Tile
+	Items     []Entity
+	Capacity  int
+	MaxWeight float64
+}
+
+// Process implements Component for Inventory.
+func (c *Inventory) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *InventoryRequest:
+		v.Items = append(v.Items, c.Items...)
+	case *PickUp:
+		c.pickup(v)
+	case *DropItem:
+		c.drop(v.Item)
+	}
+}
+
+// Weight returns the total weight of the carried items.
+func (c *Inventory) Weight() float64 {
+	total := 0.0
+	for _, item := range c.Items {
+		info := itemInfo(item)
+		total += info.Weight * float64(info.Count)
+	}
+	return total
+}
+
+// pickup moves an item from a Tile into the Inventory.
+func (c *Inventory) pickup(v *PickUp) {
+	from := v.From
+	if from == nil {
+		from = c.Pos
+	}
+	if from == nil {
+		return
+	}
+
+	item := v.Item
+	if item == nil {
+		for i := len(from.Items) - 1; i >= 0 && item == nil; i-- {
+			if !itemInfo(from.Items[i]).Fixed {
+				item = from.Items[i]
+			}
+		}
+		if item == nil {
+			c.message("There is nothing here to pick up")
+			return
+		}
+	}
+
+	info := itemInfo(item)
+	if info.Fixed {
+		c.message("%s <can> not pick up %o", c.Self, itemName(item, info))
+		return
+	}
+	if c.MaxWeight > 0 && c.Weight()+info.Weight*float64(info.Count) > c.MaxWeight {
+		c.message("%s <be> too heavy", itemName(item, info))
+		return
+	}
+
+	remove := RemoveItem{Item: item}
+	if from.Handle(&remove); !remove.Removed {
+		return
+	}
+
+	merge := MergeItem{Item: item}
+	for _, carried := range c.Items {
+		if carried.Handle(&merge); merge.Merged {
+			v.Done = true
+			return
+		}
+	}
+	if c.Capacity > 0 && len(c.Items) >= c.Capacity {
+		from.Handle(&PlaceItem{item})
+		c.message("%s <can> not carry any more", c.Self)
+		return
+	}
+	c.Items = append(c.Items, item)
+	v.Done = true
+}
+
+// drop moves a carried item onto the current position.
+func (c *Inventory) drop(item Entity) {
+	if c.Pos == nil {
+		return
+	}
+	for i, carried := range c.Items {
+		if sameEntity(carried, item) {
+			c.Items = append(c.Items[:i:i], c.Items[i+1:]...)
+			c.Pos.Handle(&PlaceItem{item})
+			return
+		}
+	}
+}
+
+// message formats a Message with Fmt and sends it to Self, if set.
+func (c *Inventory) message(format string, args ...interface{}) {
+	if c.Self != nil {
+		send(c.Self, Messagef(format, args...))
+	}
+}
+
+// itemName returns the Name from the item details, or the item itself if the
+// item has no Name, for use with Fmt.
+func itemName(item Entity, info ItemRequest) interface{} {
+	if info.Name != "" {
+		return info.Name
+	}
+	return item
+}
+
+// itemInfo queries an item for its details, treating a Count of 0 as 1.
+func itemInfo(item Entity) ItemRequest {
+	info := ItemRequest{}
+	item.Handle(&info)
+	if info.Count == 0 {
+		info.Count = 1
+	}
+	return info
+}
+
+// invitem is a single row of an InventoryScreen.
+type invitem struct {
+	Item  Entity
+	Info  ItemRequest
+	Slots []string
+}
+
+// InventoryScreen displays the items carried by an Entity, grouped by
+// category and indexed by letter. Selecting an item by letter and then
+// pressing an action key sends the Event created by the action to the Owner.
+// While an item is selected, only action keys and escape are accepted, so that
+// an action key such as 'd' is never taken as the letter of an item, and
+// escape cancels the selection rather than closing the screen.
+type InventoryScreen struct {
+	Owner   Entity
+	Title   string
+	Fg      Color
+	Actions map[Key]func(item Entity) Event
+}
+
+// NewInventoryScreen creates a new InventoryScreen with the default actions,
+// which are (d)rop, (w)ield and (t)hrow.
+func NewInventoryScreen(owner Entity) *InventoryScreen {
+	return &InventoryScreen{owner, "Inventory", ColorWhite, map[Key]func(Entity) Event{
+		'd': func(item Entity) Event { return &DropItem{item} },
+		'w': func(item Entity) Event { return &EquipItem{Item: item} },
+		't': func(item Entity) Event { return &ThrowItem{item} },
+	}}
+}
+
+// items queries the Owner for its items, sorted by category. Items within a
+// category retain the order given by the Owner.
+func (s *InventoryScreen) items() []invitem {
+	req := InventoryRequest{}
+	s.Owner.Handle(&req)
+
+	items := make([]invitem, len(req.Items))
+	for i, item := range req.Items {
+		info := ItemRequest{Name: fmt.Sprintf("%v", item)}
+		item.Handle(&info)
+		if info.Count == 0 {
+			info.Count = 1
+		}
+		equipped := EquippedRequest{Item: item}
+		s.Owner.Handle(&equipped)
+		items[i] = invitem{item, info, equipped.Slots}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Info.Category < items[j].Info.Category
+	})
+	return items
+}
+
+// invletters are the keys used to index items in an InventoryScreen.
+const invletters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// invlayout splits items into pages of rows, where each row is either the
+// index of an item or -1 for a category header. Each page starts with the
+// header for its first item.
+func invlayout(items []invitem, perpage int) [][]int {
+	var pages [][]int
+	var page []int
+	category := ""
+	for i, item := range items {
+		header := i == 0 || item.Info.Category != category
+		category = item.Info.Category
+
+		// start a new page if the item (and its header) will not fit
+		if len(page) > 0 && (len(page)+1 > perpage || header && len(page)+2 > perpage) {
+			pages, page = append(pages, page), nil
+			header = true
+		}
+		if header {
+			page = append(page, -1)
+		}
+		page = append(page, i)
+	}
+	return append(pages, page)
+}
+
+// Run displays the InventoryScreen until the user hits escape.
+func (s *InventoryScreen) Run() {
+	state := TermSave()
+	defer state.Restore()
+
+	cursor := invstate{0, -1}
+	for {
+		items := s.items()
+		if len(items) > len(invletters) {
+			items = items[:len(invletters)]
+		}
+		if cursor.selected >= len(items) {
+			cursor.selected = -1
+		}
+
+		_, rows := termbox.Size()
+		pages := invlayout(items, Max(rows-2, 3))
+		cursor.page = Clamp(0, cursor.page, len(pages)-1)
+
+		s.draw(items, pages[cursor.page], cursor.selected)
+		if cursor.selected >= 0 {
+			name := items[cursor.selected].Info.Name
+			drawString(0, rows-1, fmt.Sprintf("%s: choose an action, or escape to cancel", name), s.Fg)
+		} else if len(pages) > 1 {
+			drawString(0, rows-1, fmt.Sprintf("(page %d/%d)", cursor.page+1, len(pages)), s.Fg)
+		}
+		TermRefresh()
+
+		if s.press(items, &cursor, GetKey()) {
+			return
+		}
+	}
+}
+
+// invstate is the current page and selected item of an InventoryScreen, with
+// selected set to -1 if no item is selected.
+type invstate struct {
+	page, selected int
+}
+
+// press updates the state of an InventoryScreen for a key, sending the Owner
+// the Event for any action, and returns true once the screen should close.
+func (s *InventoryScreen) press(items []invitem, state *invstate, key Key) bool {
+	if state.selected >= 0 {
+		if action, ok := s.Actions[key]; ok {
+			s.Owner.Handle(action(items[state.selected].Item))
+			state.selected = -1
+		} else if key == KeyEsc {
+			state.selected = -1
+		}
+		return false
+	}
+
+	if i := indexRune(invletters, rune(key)); 0 <= i && i < len(items) {
+		state.selected = i
+	} else if key == KeyPgdn {
+		state.page++
+	} else if key == KeyPgup {
+		state.page--
+	} else if key == KeyEsc {
+		return true
+	}
+	return false
+}
+
+// draw displays a single page of items, with category headers.
+func (s *InventoryScreen) draw(items []invitem, page []int, selected int) {
+	TermClear()
+	drawString(0, 0, s.Title, s.Fg)
+	if len(items) == 0 {
+		drawString(0, 1, "You are not carrying anything.", s.Fg)
+		return
+	}
+
+	for y, i := range page {
+		if i < 0 {
+			drawString(0, y+1, items[page[y+1]].Info.Category, ColorLightWhite)
+			continue
+		}
+
+		info := items[i].Info
+		fg := s.Fg
+		if i == selected {
+			fg = ColorLightWhite
+		}
+		name := info.Name
+		if info.Count > 1 {
+			name = fmt.Sprintf("%d %s", info.Count, name)
+		}
+		if slots := items[i].Slots; len(slots) > 0 {
+			name = fmt.Sprintf("%s [%s]", name, strings.Join(slots, ", "))
+		}
+		row := fmt.Sprintf("%c) %-40s %6.1f", invletters[i], name, info.Weight*float64(info.Count))
+		drawString(1, y+1, row, fg)
+	}
+}
+
+// indexRune returns the index of the rune in s, or -1 if it is not present.
+func indexRune(s string, r rune) int {
+	for i, ch := range s {
+		if ch == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// drawString draws a string on screen starting at the given location.
+func drawString(x, y int, s string, fg Color) {
+	for _, ch := range s {
+		TermDraw(x, y, Glyph{ch, fg})
+		x++
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvlayout(t *testing.T) {
+	items := []invitem{
+		{nil, ItemRequest{Category: "Weapons"}, nil},
+		{nil, ItemRequest{Category: "Weapons"}, nil},
+		{nil, ItemRequest{Category: "Food"}, nil},
+		{nil, ItemRequest{Category: "Food"}, nil},
+		{nil, ItemRequest{Category: "Food"}, nil},
+	}
+	cases := []struct {
+		items    []invitem
+		perpage  int
+		expected [][]int
+	}{
+		{nil, 5, [][]int{nil}},
+		{items, 10, [][]int{{-1, 0, 1, -1, 2, 3, 4}}},
+		{items, 5, [][]int{{-1, 0, 1, -1, 2}, {-1, 3, 4}}},
+		{items, 3, [][]int{{-1, 0, 1}, {-1, 2, 3}, {-1, 4}}},
+	}
+	for _, c := range cases {
+		if actual := invlayout(c.items, c.perpage); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("invlayout(%d items, %d) = %v != %v", len(c.items), c.perpage, actual, c.expected)
+		}
+	}
+}
+
+// teststack is an item which stacks with other teststack of the same Name.
+type teststack struct {
+	Name   string
+	Weight float64
+	Count  int
+}
+
+func (s *teststack) Handle(v Event) {
+	switch v := v.(type) {
+	case *ItemRequest:
+		v.Name, v.Weight, v.Count = s.Name, s.Weight, s.Count
+	case *MergeItem:
+		if other, ok := v.Item.(*teststack); ok && other.Name == s.Name {
+			s.Count += other.Count
+			v.Merged = true
+		}
+	}
+}
+
+func TestInventory(t *testing.T) {
+	tiles := NewTileGrid(1, 1, Offset{}, NewTile)
+	floor := tiles[0]
+	var messages []string
+	inv := &Inventory{Capacity: 2, MaxWeight: 10}
+	inv.Self = ComponentSlice{inv, testfunc(func(v Event) {
+		if v, ok := v.(*Message); ok {
+			messages = append(messages, v.Text)
+		}
+	})}
+	inv.Self.Handle(&UpdatePos{floor})
+
+	arrows := &teststack{"arrow", .1, 10}
+	more := &teststack{"arrow", .1, 5}
+	sword := &teststack{"sword", 4, 1}
+	shield := &teststack{"shield", 5, 1}
+	anvil := &teststack{"anvil", 50, 1}
+	door := NewDoor(floor)
+	door.Handle(&OpenDoor{})
+	for _, item := range []Entity{arrows, more, sword, shield, anvil} {
+		floor.Handle(&PlaceItem{item})
+	}
+
+	cases := []struct {
+		item     Entity
+		done     bool
+		messages int
+	}{
+		{anvil, false, 1},  // too heavy
+		{arrows, true, 1},  // top item is the anvil, so pick up explicitly
+		{more, true, 1},    // merges with arrows
+		{sword, true, 1},   // fills capacity
+		{shield, false, 2}, // too heavy
+		{door, false, 3},   // fixed
+	}
+	for _, c := range cases {
+		pickup := PickUp{Item: c.item}
+		inv.Self.Handle(&pickup)
+		if pickup.Done != c.done || len(messages) != c.messages {
+			t.Errorf("PickUp(%v) = %v with %v", c.item, pickup.Done, messages)
+		}
+	}
+	if arrows.Count != 15 || len(inv.Items) != 2 || inv.Weight() != 5.5 {
+		t.Errorf("Inventory = %v, weight %v", inv.Items, inv.Weight())
+	}
+
+	// full inventory leaves the item on the floor
+	inv.MaxWeight = 0
+	pickup := PickUp{Item: shield}
+	if inv.Self.Handle(&pickup); pickup.Done || len(messages) != 4 {
+		t.Errorf("PickUp with full inventory = %v", pickup.Done)
+	}
+	items := ItemsAt{}
+	if floor.Handle(&items); len(items.Items) != 3 {
+		t.Errorf("PickUp with full inventory removed item from floor")
+	}
+
+	inv.Self.Handle(&DropItem{sword})
+	req := InventoryRequest{}
+	if inv.Self.Handle(&req); len(req.Items) != 1 || req.Items[0] != arrows {
+		t.Errorf("InventoryRequest after drop = %v", req.Items)
+	}
+	if floor.Items[len(floor.Items)-1] != sword {
+		t.Errorf("DropItem did not place item on top")
+	}
+
+	// the topmost item which is not fixed is picked up by default
+	pickup = PickUp{}
+	if inv.Self.Handle(&pickup); !pickup.Done || inv.Items[1] != sword {
+		t.Errorf("PickUp without item = %v, %v", pickup.Done, inv.Items)
+	}
+}
+
+func TestInventoryScreen_press(t *testing.T) {
+	var items []invitem
+	for i := 0; i < 24; i++ {
+		items = append(items, invitem{&teststack{Name: invletters[i : i+1]}, ItemRequest{}, nil})
+	}
+	var sent []Event
+	s := NewInventoryScreen(ComponentSlice{testfunc(func(v Event) { sent = append(sent, v) })})
+	state := invstate{0, -1}
+
+	cases := []struct {
+		key      Key
+		selected int
+		done     bool
+		sent     Event
+	}{
+		{'d', 3, false, nil},                       // selects item d
+		{'a', 3, false, nil},                       // letters are ignored while selected
+		{'d', -1, false, &DropItem{items[3].Item}}, // drops item d
+		{'w', 22, false, nil},                      // selects item w
+		{KeyEsc, -1, false, nil},                   // cancels the selection
+		{'t', 19, false, nil},                      // selects item t
+		{'t', -1, false, &ThrowItem{items[19].Item}},
+		{'z', -1, false, nil}, // no such item
+		{KeyEsc, -1, true, nil},
+	}
+	for i, c := range cases {
+		sent = nil
+		done := s.press(items, &state, c.key)
+		if state.selected != c.selected || done != c.done {
+			t.Errorf("case %d: press(%q) gave selected %d, done %v", i, c.key, state.selected, done)
+		}
+		if c.sent == nil && sent != nil || c.sent != nil && !reflect.DeepEqual(sent, []Event{c.sent}) {
+			t.Errorf("case %d: press(%q) sent %v != %v", i, c.key, sent, c.sent)
+		}
+	}
+}
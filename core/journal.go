@@ -0,0 +1,395 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"reflect"
+)
+
+// eventTypes maps the name of each Event type known to ReplayJournal to the
+// type itself.
+var eventTypes = make(map[string]reflect.Type)
+
+// RegisterEvent registers an Event type so that ReplayJournal can recreate it.
+// The Events defined by core are already registered, and any Event recorded by
+// a Journal is registered automatically, so this is only needed to replay a
+// journal written by another process.
+func RegisterEvent(v Event) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	eventTypes[typeName(t)] = t
+}
+
+// typeName gives the fully qualified name of a type.
+func typeName(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
+
+func init() {
+	for _, v := range []Event{
+		&Act{}, &Alerted{}, &ApplyEffect{}, &Attack{}, &BlockQuery{},
+		&Bump{}, &BumpQuery{}, &CloseDoor{}, &Collide{}, &Damage{},
+		&Death{}, &DropItem{}, &EffectsRequest{}, &Entered{}, &EquipItem{},
+		&EquippedRequest{}, &FactionQuery{}, &FoVRequest{}, &Heal{},
+		&InventoryRequest{}, &ItemRequest{}, &ItemsAt{}, &KillsRequest{},
+		&Knockback{}, &LastSeen{}, &Loaded{}, &MergeItem{}, &Message{},
+		&MoveEntity{}, &Noise{}, &OpaqueRequest{}, &OpenDoor{}, &PickUp{},
+		&PlaceItem{}, &RangedAttack{}, &RemoveEffect{}, &RemoveItem{},
+		&RenderRequest{}, &SetStat{}, &StatsChanged{}, &StatsRequest{},
+		&SwapEntity{}, &SwimQuery{}, &Tick{}, &Transition{}, &TurnTick{},
+		&UnequipItem{}, &UnlockDoor{}, &UpdatePos{},
+	} {
+		RegisterEvent(v)
+	}
+}
+
+// journalRef is the journaled form of a reference to a Tile or an Entity. A
+// Tile is recorded by Offset and an Entity by EntityID, with a zero EntityID
+// meaning nil or an unregistered Entity.
+type journalRef struct {
+	Tile   bool
+	Offset Offset
+	ID     EntityID
+}
+
+// journalField is the journaled form of a single exported field of an Event.
+// References are stored in Ref, and any other value is gob encoded in Data.
+type journalField struct {
+	Name string
+	Ref  *journalRef
+	Data []byte
+}
+
+// journalEntry is a single delivery of an Event recorded by a Journal.
+type journalEntry struct {
+	Turn   int
+	Depth  int
+	Queued bool
+	Bus    bool
+	Target journalRef
+	Type   string
+	Ptr    bool
+	Fields []journalField
+}
+
+// Journal records every Event delivery to an append-only log, for reproducing
+// bug reports and rewinding the game with ReplayJournal. Install Trace with
+// SetEventTracer to start recording. Each entry holds the turn from Clock, if
+// set, the nesting depth of the delivery, the target, and the Event itself.
+//
+// Events are recorded field by field. A *Tile field is recorded by Offset,
+// and an Entity field by EntityID, so every Entity which needs to be replayed
+// must be in the Registry. Unexported fields, and fields which cannot be saved
+// by gob without following references, such as a map of Tiles or a function,
+// are left out, which is normally harmless since they hold query results.
+type Journal struct {
+	Clock *Clock
+
+	enc *gob.Encoder
+	reg *Registry
+	err error
+}
+
+// NewJournal creates a Journal which appends to w, using the Registry to
+// identify Entity and the Clock, which may be nil, for turn numbers.
+func NewJournal(w io.Writer, reg *Registry, clock *Clock) *Journal {
+	return &Journal{clock, gob.NewEncoder(w), reg, nil}
+}
+
+// Trace records an Event delivery, and is an event tracer for SetEventTracer.
+// Once writing fails, recording stops and the error is given by Err.
+func (j *Journal) Trace(target Entity, v Event, depth int) {
+	if j.err != nil || v == nil {
+		return
+	}
+
+	entry := journalEntry{Depth: depth, Queued: traceQueued, Bus: target == nil}
+	if j.Clock != nil {
+		entry.Turn = j.Clock.Now()
+	}
+	if target != nil {
+		entry.Target = j.ref(target)
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		entry.Ptr = true
+		val = val.Elem()
+	}
+	if _, ok := eventTypes[typeName(val.Type())]; !ok {
+		RegisterEvent(v)
+	}
+	entry.Type = typeName(val.Type())
+	if entry.Fields, j.err = j.fields(val); j.err != nil {
+		return
+	}
+	j.err = j.enc.Encode(&entry)
+}
+
+// Err returns the first error encountered while recording, if any.
+func (j *Journal) Err() error {
+	return j.err
+}
+
+// fields converts the exported fields of an Event into their journaled form.
+func (j *Journal) fields(val reflect.Value) ([]journalField, error) {
+	if val.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var fields []journalField
+	for i := 0; i < val.NumField(); i++ {
+		field, fv := val.Type().Field(i), val.Field(i)
+		if field.PkgPath != "" || field.Anonymous || fv.IsZero() {
+			continue
+		}
+
+		switch {
+		case field.Type == tileType:
+			ref := journalRef{Tile: true, Offset: fv.Interface().(*Tile).Offset}
+			fields = append(fields, journalField{field.Name, &ref, nil})
+		case field.Type == entityType:
+			ref := j.ref(fv.Interface().(Entity))
+			fields = append(fields, journalField{field.Name, &ref, nil})
+		case plainType(field.Type, make(map[reflect.Type]bool)):
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).EncodeValue(fv); err != nil {
+				return nil, err
+			}
+			fields = append(fields, journalField{field.Name, nil, buf.Bytes()})
+		}
+	}
+	return fields, nil
+}
+
+// ref converts a Tile or Entity into its journaled form. An Entity is looked
+// up in the Registry by identity, so a registered ComponentSlice is found as
+// well. Failing that, a ComponentSlice such as the one inside a SortedEntity
+// is matched to the registered Entity which holds the same Components.
+func (j *Journal) ref(e Entity) journalRef {
+	if t, ok := e.(*Tile); ok {
+		return journalRef{Tile: true, Offset: t.Offset}
+	}
+	if id, ok := j.reg.ID(e); ok {
+		return journalRef{ID: id}
+	}
+	if slice, ok := e.(ComponentSlice); ok && len(slice) > 0 {
+		for id, owner := range j.reg.entities {
+			if held := heldComponents(owner); len(held) == len(slice) && &held[0] == &slice[0] {
+				return journalRef{ID: id}
+			}
+		}
+	}
+	return journalRef{}
+}
+
+// heldComponents returns the ComponentSlice an Entity uses to handle Events,
+// without copying it, or nil if there is none.
+func heldComponents(e Entity) ComponentSlice {
+	switch e := e.(type) {
+	case *ComponentSlice:
+		return *e
+	case *SortedEntity:
+		return e.components
+	case *EntityMut:
+		return e.sorted.components
+	}
+	return nil
+}
+
+// Types which are journaled as references.
+var (
+	tileType   = reflect.TypeOf((*Tile)(nil))
+	entityType = reflect.TypeOf((*Entity)(nil)).Elem()
+)
+
+// plainType returns true if values of a type can be gob encoded without
+// following references to Tiles, Entity or other shared values.
+func plainType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128, reflect.String:
+		return true
+	case reflect.Array, reflect.Slice:
+		return plainType(t.Elem(), seen)
+	case reflect.Map:
+		return plainType(t.Key(), seen) && plainType(t.Elem(), seen)
+	case reflect.Struct:
+		exported := false
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.PkgPath == "" {
+				if !plainType(field.Type, seen) {
+					return false
+				}
+				exported = true
+			}
+		}
+		return exported
+	}
+	return false
+}
+
+// JournalWorld is the world which ReplayJournal drives, typically just loaded
+// by LoadWorld from a save made when the Journal was started.
+type JournalWorld struct {
+	Tiles    []*Tile
+	Registry *Registry
+
+	// Bus receives recorded Events which were published on an EventBus. If
+	// nil, those Events are skipped.
+	Bus *EventBus
+
+	// Until, if positive, stops the replay at the first Event recorded on or
+	// after that turn, such as to rewind the game by one turn.
+	Until int
+
+	// Expand decides whether a recorded Event should be replaced by the
+	// Events it directly caused. This is needed for Entity whose response
+	// to an Event is not deterministic, such as a player reading input on an
+	// Act. If nil, no Events are expanded.
+	Expand func(target Entity, v Event) bool
+}
+
+// ReplayJournal reads a log written by a Journal and delivers the recorded
+// Events again. Only the outermost deliveries are repeated, since those cause
+// the rest, unless Expand asks for an Event to be replaced by the Events it
+// caused. If any Events were delivered by an EventQueue while recording, an
+// EventQueue is installed for the replay and drained after each delivery.
+// Recorded Events whose target cannot be found are skipped.
+func ReplayJournal(r io.Reader, world JournalWorld) error {
+	var entries []journalEntry
+	dec := gob.NewDecoder(r)
+	for {
+		var entry journalEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	index := make(map[Offset]*Tile, len(world.Tiles))
+	for _, t := range world.Tiles {
+		index[t.Offset] = t
+	}
+	resolve := func(ref journalRef) Entity {
+		if ref.Tile {
+			if t, ok := index[ref.Offset]; ok {
+				return t
+			}
+			return nil
+		}
+		return world.Registry.Lookup(ref.ID)
+	}
+
+	var queue *EventQueue
+	for _, entry := range entries {
+		if entry.Queued {
+			prev := activeQueue
+			queue = NewEventQueue()
+			SetEventQueue(queue)
+			defer SetEventQueue(prev)
+			break
+		}
+	}
+
+	deliver := func(entry journalEntry) error {
+		v, err := entry.event(resolve)
+		if err != nil {
+			return err
+		}
+		if entry.Bus {
+			if world.Bus != nil {
+				world.Bus.Publish(v)
+			}
+		} else if target := resolve(entry.Target); target != nil {
+			target.Handle(v)
+		}
+		if queue != nil {
+			return queue.Drain()
+		}
+		return nil
+	}
+
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry.Depth != 0 || entry.Queued {
+			continue
+		}
+		if world.Until > 0 && entry.Turn >= world.Until {
+			break
+		}
+
+		if world.Expand != nil && !entry.Bus {
+			v, err := entry.event(resolve)
+			if err != nil {
+				return err
+			}
+			if target := resolve(entry.Target); target != nil && world.Expand(target, v) {
+				for ; i+1 < len(entries) && entries[i+1].Depth > 0; i++ {
+					if entries[i+1].Depth == 1 {
+						if err := deliver(entries[i+1]); err != nil {
+							return err
+						}
+					}
+				}
+				continue
+			}
+		}
+		if err := deliver(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// event recreates the recorded Event, resolving references with the given
+// function.
+func (entry journalEntry) event(resolve func(journalRef) Entity) (Event, error) {
+	t, ok := eventTypes[entry.Type]
+	if !ok {
+		return nil, ErrUnknownEvent
+	}
+
+	val := reflect.New(t).Elem()
+	for _, field := range entry.Fields {
+		fv := val.FieldByName(field.Name)
+		if !fv.IsValid() || !fv.CanSet() {
+			continue
+		}
+		if field.Ref == nil {
+			dec := gob.NewDecoder(bytes.NewReader(field.Data))
+			if err := dec.DecodeValue(fv); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e := resolve(*field.Ref)
+		switch {
+		case e == nil:
+		case fv.Type() == tileType:
+			if tile, ok := e.(*Tile); ok {
+				fv.Set(reflect.ValueOf(tile))
+			}
+		case fv.Type() == entityType:
+			fv.Set(reflect.ValueOf(&e).Elem())
+		}
+	}
+
+	if entry.Ptr {
+		return val.Addr().Interface(), nil
+	}
+	return val.Interface(), nil
+}
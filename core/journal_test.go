@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// testinput moves its Entity by the next of its Moves on each Act, standing in
+// for a player reading keys.
+type testinput struct {
+	Pos   *Tile
+	Moves []Offset
+}
+
+func (c *testinput) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *Act:
+		if len(c.Moves) == 0 {
+			panic("testinput replayed without input")
+		}
+		c.Pos.Handle(&MoveEntity{Delta: c.Moves[0]})
+		c.Moves = c.Moves[1:]
+	}
+}
+
+// journalCase builds a small world with a registered testsaved occupant and a
+// registered player controlled by a testinput.
+func journalCase() (tiles []*Tile, reg *Registry, npc *testsaved, player *testinput) {
+	reg = NewRegistry()
+	player = &testinput{}
+	grid := StrGrid{
+		"#######",
+		"#n....#",
+		"#p....#",
+		"#######",
+	}.Convert(func(t *Tile, ch byte) {
+		switch ch {
+		case '#':
+			t.Pass, t.Lite = false, false
+		case 'n':
+			npc = &testsaved{Name: "npc", Pos: t}
+			t.Occupant = npc
+			reg.Register(npc)
+		case 'p':
+			player.Pos = t
+			t.Occupant = NewEntity(player)
+			reg.Register(t.Occupant)
+		}
+	})
+	for x := range grid {
+		for y := range grid[x] {
+			tiles = append(tiles, &grid[x][y])
+		}
+	}
+	return tiles, reg, npc, player
+}
+
+func TestReplayJournal(t *testing.T) {
+	_, reg, npc, player := journalCase()
+	player.Moves = []Offset{{1, 0}, {1, 0}}
+	clock := NewClock(nil)
+
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, clock)
+	SetEventTracer(journal.Trace)
+	npc.Pos.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	player.Pos.Occupant.Handle(&Act{})
+	clock.Advance(TicksPerTurn)
+	npc.Pos.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	player.Pos.Occupant.Handle(&Act{})
+	SetEventTracer(nil)
+	if err := journal.Err(); err != nil {
+		t.Fatalf("Journal.Err() = %v", err)
+	}
+
+	cases := []struct {
+		until     int
+		npc, play int
+	}{
+		{0, 3, 3},
+		{1, 2, 2},
+	}
+	for _, c := range cases {
+		replayTiles, replayReg, replayNPC, replayPlayer := journalCase()
+		world := JournalWorld{
+			Tiles:    replayTiles,
+			Registry: replayReg,
+			Until:    c.until,
+			Expand: func(e Entity, v Event) bool {
+				_, act := v.(*Act)
+				return act && e == replayPlayer.Pos.Occupant
+			},
+		}
+		if err := ReplayJournal(bytes.NewReader(log.Bytes()), world); err != nil {
+			t.Fatalf("ReplayJournal() = %v", err)
+		}
+		if x := replayNPC.Pos.Offset.X; x != c.npc {
+			t.Errorf("ReplayJournal(until %d) left npc at %d != %d", c.until, x, c.npc)
+		}
+		if x := replayPlayer.Pos.Offset.X; x != c.play {
+			t.Errorf("ReplayJournal(until %d) left player at %d != %d", c.until, x, c.play)
+		}
+	}
+	if x := npc.Pos.Offset.X; x != 3 {
+		t.Errorf("recorded npc at %d != 3", x)
+	}
+}
+
+func TestReplayJournal_Queued(t *testing.T) {
+	_, reg, npc, _ := journalCase()
+	q := NewEventQueue()
+	SetEventQueue(q)
+	defer SetEventQueue(nil)
+
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, nil)
+	SetEventTracer(journal.Trace)
+	npc.Pos.Handle(&MoveEntity{Delta: Offset{1, 1}})
+	q.Drain()
+	npc.Pos.Handle(&Damage{Amount: 3, Type: "fire", Source: npc})
+	q.Drain()
+	SetEventTracer(nil)
+	SetEventQueue(nil)
+
+	replayTiles, replayReg, replayNPC, _ := journalCase()
+	if err := ReplayJournal(&log, JournalWorld{Tiles: replayTiles, Registry: replayReg}); err != nil {
+		t.Fatalf("ReplayJournal() = %v", err)
+	}
+	if replayNPC.Pos.Offset != npc.Pos.Offset {
+		t.Errorf("ReplayJournal() left npc at %v != %v", replayNPC.Pos.Offset, npc.Pos.Offset)
+	}
+	if activeQueue != nil {
+		t.Errorf("ReplayJournal() left its EventQueue installed")
+	}
+}
+
+func TestReplayJournal_ComponentSlice(t *testing.T) {
+	build := func() (*Registry, *Stats) {
+		reg := NewRegistry()
+		e := make(ComponentSlice, 1)
+		stats := NewStats(e, 10)
+		stats.HP, e[0] = 5, stats
+		reg.Register(e)
+		return reg, stats
+	}
+	reg, stats := build()
+
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, nil)
+	SetEventTracer(journal.Trace)
+	reg.Lookup(1).Handle(&Heal{Amount: 3})
+	SetEventTracer(nil)
+	if err := journal.Err(); err != nil {
+		t.Fatalf("Journal.Err() = %v", err)
+	}
+
+	replayReg, replayStats := build()
+	if err := ReplayJournal(&log, JournalWorld{Registry: replayReg}); err != nil {
+		t.Fatalf("ReplayJournal() = %v", err)
+	}
+	if replayStats.HP != stats.HP {
+		t.Errorf("ReplayJournal() gave HP %d != %d", replayStats.HP, stats.HP)
+	}
+}
+
+func TestJournal_fields(t *testing.T) {
+	tiles, reg, npc, _ := journalCase()
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, nil)
+	journal.Trace(npc, &Damage{Amount: 3, Type: "fire", Source: npc}, 0)
+	journal.Trace(npc, Message{Text: "hi", Color: ColorRed, Pos: npc.Pos}, 0)
+	journal.Trace(npc, &FoVRequest{FoV: map[Offset]*Tile{{}: npc.Pos}}, 0)
+
+	// the recorder takes the place of the npc, so references to it follow
+	var seen []Event
+	recorder := &ComponentSlice{testfunc(func(v Event) { seen = append(seen, v) })}
+	replayReg := NewRegistry()
+	replayReg.RegisterID(1, recorder)
+	if err := ReplayJournal(&log, JournalWorld{Tiles: tiles, Registry: replayReg}); err != nil {
+		t.Fatalf("ReplayJournal() = %v", err)
+	}
+	expected := []Event{
+		&Damage{Amount: 3, Type: "fire", Source: recorder},
+		Message{"hi", ColorRed, npc.Pos},
+		&FoVRequest{},
+	}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Errorf("ReplayJournal() delivered %v != %v", seen, expected)
+	}
+}
@@ -0,0 +1,147 @@
+package core
+
+// Large is a Component for an Entity which occupies several Tiles, such as a
+// dragon or a wagon. The Entity occupies the anchor Tile Pos, along with the
+// Tile at each Offset in Footprint relative to the anchor, and is the Occupant
+// of every one of them. Each part of the Entity is rendered using the Glyph in
+// Faces for its Offset, with the anchor at the zero Offset.
+//
+// A MoveEntity sent to any of its Tiles moves every part at once, but only if
+// every destination Tile admits it and is either free or occupied by a
+// non-blocking occupant. Otherwise, the Entity is sent a Bump or Collide for
+// the first part which could not move. Knockback, SwapEntity and Transition
+// leave the Entity in place. On Death, the Entity is removed from each of its
+// Tiles. Self must be comparable, such as a pointer to a ComponentSlice.
+type Large struct {
+	Self      Entity
+	Pos       *Tile
+	Footprint []Offset
+	Faces     map[Offset]Glyph
+
+	tiles []*Tile // occupied Tiles, in the order of parts
+}
+
+// parts returns the Offset of each part, starting with the anchor.
+func (c *Large) parts() []Offset {
+	return append([]Offset{{0, 0}}, c.Footprint...)
+}
+
+// Place puts the Entity on the map with its anchor at the given Tile. If any
+// part would be off the map, on an impassable Tile, or on an occupied Tile,
+// nothing is placed and false is returned.
+func (c *Large) Place(anchor *Tile) bool {
+	tiles := make([]*Tile, 0, len(c.Footprint)+1)
+	for _, part := range c.parts() {
+		t := offsetTile(anchor, part)
+		if t == nil || !t.Pass || t.Occupant != nil {
+			return false
+		}
+		tiles = append(tiles, t)
+	}
+	c.occupy(tiles)
+	return true
+}
+
+// Part returns the Offset of the part of the Entity on the given Tile. If the
+// Entity is not on the Tile, ok is false.
+func (c *Large) Part(t *Tile) (part Offset, ok bool) {
+	for i, occupied := range c.tiles {
+		if occupied == t {
+			return c.parts()[i], true
+		}
+	}
+	return Offset{}, false
+}
+
+// Process implements Component for Large.
+func (c *Large) Process(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		if part, ok := c.Part(v.Pos); ok {
+			if face, ok := c.Faces[part]; ok {
+				v.Render = face
+			}
+		}
+	case *Death:
+		c.vacate()
+		c.tiles = nil
+	}
+}
+
+// move attempts to move every part of the Entity, returning the StepCost of
+// the anchor if the Entity moved, and zero otherwise.
+func (c *Large) move(delta Offset) int {
+	dests := make([]*Tile, 0, len(c.tiles))
+	for _, t := range c.tiles {
+		adj, ok := t.Adjacent[delta]
+		if !ok {
+			send(c.Self, &Collide{})
+			return 0
+		}
+		if o := adj.Occupant; o != nil && !sameEntity(o, c.Self) && blocks(o, c.Self) {
+			send(c.Self, &Bump{o, adj})
+			return 0
+		}
+		if !adj.admits(c.Self) {
+			send(c.Self, &Collide{adj})
+			return 0
+		}
+		dests = append(dests, adj)
+	}
+
+	cost := StepCost(c.tiles[0], dests[0])
+	old := c.tiles
+	c.vacate()
+	c.occupy(dests)
+	for _, t := range dests {
+		if !containsTile(old, t) {
+			t.enter()
+		}
+	}
+	return cost
+}
+
+// occupy makes the Entity the Occupant of each of the given Tiles, and
+// informs it of its new anchor.
+func (c *Large) occupy(tiles []*Tile) {
+	c.tiles = tiles
+	c.Pos = tiles[0]
+	for _, t := range tiles {
+		t.occupy(c.Self)
+		t.large = c
+	}
+	send(c.Self, &UpdatePos{c.Pos})
+}
+
+// vacate removes the Entity from each of its Tiles.
+func (c *Large) vacate() {
+	for _, t := range c.tiles {
+		t.Vacate(c.Self)
+		if t.large == c {
+			t.large = nil
+		}
+	}
+}
+
+// offsetTile follows Adjacent links from the origin to the Tile at the given
+// Offset, first diagonally and then straight. If any link is missing, nil is
+// returned.
+func offsetTile(origin *Tile, o Offset) *Tile {
+	curr := origin
+	for o != (Offset{}) && curr != nil {
+		step := Offset{Signum(o.X), Signum(o.Y)}
+		curr = curr.Adjacent[step]
+		o = o.Sub(step)
+	}
+	return curr
+}
+
+// containsTile returns true if the Tile is in the slice.
+func containsTile(tiles []*Tile, t *Tile) bool {
+	for _, curr := range tiles {
+		if curr == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"testing"
+)
+
+// LargeCase converts a StrGrid into Tiles, placing a 2x2 Large on the 'D' at
+// the top left of its footprint, a testally on the 't', and walls on '#'.
+func LargeCase(g StrGrid) (dragon *Large, ally *testally) {
+	dragon = &Large{
+		Footprint: []Offset{{1, 0}, {0, 1}, {1, 1}},
+		Faces: map[Offset]Glyph{
+			{0, 0}: {'/', ColorRed}, {1, 0}: {'\\', ColorRed},
+			{0, 1}: {'\\', ColorRed}, {1, 1}: {'/', ColorRed},
+		},
+	}
+	dragon.Self = &ComponentSlice{dragon}
+	ally = &testally{}
+	var anchor *Tile
+	g.Convert(func(t *Tile, ch byte) {
+		switch ch {
+		case '#':
+			t.Pass = false
+		case 'D':
+			anchor = t
+		case 't':
+			ally.Pos = t
+			t.Occupant = ally
+		}
+	})
+	if !dragon.Place(anchor) {
+		panic("LargeCase could not place dragon")
+	}
+	return dragon, ally
+}
+
+func TestLarge_Place(t *testing.T) {
+	dragon, _ := LargeCase(StrGrid{
+		"#####",
+		"#D..#",
+		"#...#",
+		"#####",
+	})
+	anchor := dragon.Pos
+	for _, o := range []Offset{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		tile := offsetTile(anchor, o)
+		if tile.Occupant != dragon.Self {
+			t.Errorf("Place() did not occupy %v", o)
+		}
+		if part, ok := dragon.Part(tile); !ok || part != o {
+			t.Errorf("Part() at %v = %v, %v", o, part, ok)
+		}
+		req := RenderRequest{}
+		tile.Handle(&req)
+		if req.Render != dragon.Faces[o] {
+			t.Errorf("Render at %v = %v != %v", o, req.Render, dragon.Faces[o])
+		}
+	}
+
+	other := &Large{Footprint: []Offset{{1, 0}}}
+	other.Self = &ComponentSlice{other}
+	if other.Place(offsetTile(anchor, Offset{2, 0})) {
+		t.Errorf("Place() onto wall succeeded")
+	}
+	if other.Place(offsetTile(anchor, Offset{1, 1})) {
+		t.Errorf("Place() onto occupied Tile succeeded")
+	}
+}
+
+func TestLarge_Move(t *testing.T) {
+	dragon, ally := LargeCase(StrGrid{
+		"#####",
+		"#D..#",
+		"#...#",
+		"#..t#",
+		"#####",
+	})
+	start := dragon.Pos
+
+	// moving from any part moves the whole footprint
+	move := MoveEntity{Delta: Offset{1, 0}}
+	offsetTile(start, Offset{1, 1}).Handle(&move)
+	if dragon.Pos != start.Adjacent[Offset{1, 0}] || move.Cost == 0 {
+		t.Fatalf("MoveEntity did not move anchor")
+	}
+	if start.Occupant != nil || offsetTile(start, Offset{0, 1}).Occupant != nil {
+		t.Errorf("MoveEntity left dragon on old Tiles")
+	}
+	if offsetTile(start, Offset{2, 1}).Occupant != dragon.Self {
+		t.Errorf("MoveEntity did not occupy new Tiles")
+	}
+
+	// any blocked part stops the move
+	var bumped *Bump
+	var collided *Collide
+	dragon.Self = &ComponentSlice{dragon, testfunc(func(v Event) {
+		switch v := v.(type) {
+		case *Bump:
+			bumped = v
+		case *Collide:
+			collided = v
+		}
+	})}
+	for _, tile := range dragon.tiles {
+		tile.Occupant = dragon.Self
+	}
+	anchor := dragon.Pos
+	dragon.Pos.Handle(&MoveEntity{Delta: Offset{0, 1}})
+	if bumped == nil || bumped.Bumped != ally || bumped.Pos != ally.Pos {
+		t.Errorf("MoveEntity into occupant gave Bump %v", bumped)
+	}
+	dragon.Pos.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if collided == nil || collided.Obstacle == nil {
+		t.Errorf("MoveEntity into wall gave Collide %v", collided)
+	}
+	if dragon.Pos != anchor {
+		t.Errorf("blocked MoveEntity moved dragon")
+	}
+
+	// death clears every Tile
+	dragon.Self.Handle(&Death{Victim: dragon.Self})
+	for _, o := range []Offset{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if offsetTile(anchor, o).Occupant != nil {
+			t.Errorf("Death left dragon at %v", o)
+		}
+	}
+}
+
+func TestLarge_Refuse(t *testing.T) {
+	dragon, ally := LargeCase(StrGrid{
+		"######",
+		"#D...#",
+		"#..t.#",
+		"#....#",
+		"######",
+	})
+	anchor := dragon.Pos
+	corner := offsetTile(anchor, Offset{1, 1})
+
+	corner.Handle(&Knockback{Dir: Offset{1, 0}, Distance: 1})
+	corner.Handle(&SwapEntity{Delta: Offset{1, 0}})
+	ally.Pos.Handle(&SwapEntity{Delta: Offset{-1, 0}})
+	transition := Transition{Dest: offsetTile(anchor, Offset{3, 2})}
+	corner.Handle(&transition)
+	if transition.Done {
+		t.Errorf("Transition moved a Large Entity")
+	}
+
+	if dragon.Pos != anchor || ally.Pos.Occupant != ally {
+		t.Fatalf("refused Event moved an Entity")
+	}
+	for _, o := range []Offset{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if offsetTile(anchor, o).Occupant != dragon.Self {
+			t.Errorf("refused Event moved part at %v", o)
+		}
+	}
+}
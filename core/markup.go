@@ -0,0 +1,115 @@
+package core
+
+import (
+	"strings"
+)
+
+// MarkupColors maps the names used in color markup tags to Color values.
+// This dictionary can be edited to add custom color names.
+var MarkupColors = map[string]Color{
+	"red":          ColorRed,
+	"blue":         ColorBlue,
+	"cyan":         ColorCyan,
+	"black":        ColorBlack,
+	"green":        ColorGreen,
+	"white":        ColorWhite,
+	"yellow":       ColorYellow,
+	"magenta":      ColorMagenta,
+	"lightred":     ColorLightRed,
+	"lightblue":    ColorLightBlue,
+	"lightcyan":    ColorLightCyan,
+	"lightblack":   ColorLightBlack,
+	"lightgreen":   ColorLightGreen,
+	"lightwhite":   ColorLightWhite,
+	"lightyellow":  ColorLightYellow,
+	"lightmagenta": ColorLightMagenta,
+}
+
+// markuptoken is either a run of literal text or a color tag.
+type markuptoken struct {
+	Text  string
+	Color Color
+	Open  bool
+	Close bool
+}
+
+// tokenizeMarkup splits a string into literal text and color tags. Tags with
+// unknown color names are treated as literal text.
+func tokenizeMarkup(s string) []markuptoken {
+	var tokens []markuptoken
+	for len(s) > 0 {
+		start := strings.IndexRune(s, '{')
+		end := strings.IndexRune(s[Max(start, 0):], '}') + Max(start, 0)
+		if start < 0 || end < start {
+			tokens = append(tokens, markuptoken{Text: s})
+			break
+		}
+		// a stray '{' before the tag is text, so the tag starts at the last one
+		if inner := strings.LastIndexByte(s[start+1:end], '{'); inner >= 0 {
+			start += inner + 1
+		}
+		if start > 0 {
+			tokens = append(tokens, markuptoken{Text: s[:start]})
+		}
+
+		tag := s[start : end+1]
+		name := tag[1 : len(tag)-1]
+		if name == "/" {
+			tokens = append(tokens, markuptoken{Text: tag, Close: true})
+		} else if color, ok := MarkupColors[name]; ok {
+			tokens = append(tokens, markuptoken{Text: tag, Color: color, Open: true})
+		} else {
+			tokens = append(tokens, markuptoken{Text: tag})
+		}
+		s = s[end+1:]
+	}
+	return tokens
+}
+
+// ParseMarkup converts a string containing color markup into Glyph. Text
+// between a color tag such as "{red}" and a closing "{/}" tag is colored, with
+// the remaining text using the given default Color. Tags may be nested. Tags
+// which are unknown or unbalanced are displayed literally.
+func ParseMarkup(s string, fg Color) []Glyph {
+	tokens := tokenizeMarkup(s)
+
+	// match each close tag with the nearest open tag, leaving the rest literal
+	var stack []int
+	for i, token := range tokens {
+		if token.Open {
+			stack = append(stack, i)
+		} else if token.Close && len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		} else if token.Close {
+			tokens[i].Close = false
+		}
+	}
+	for _, i := range stack {
+		tokens[i].Open = false
+	}
+
+	var glyphs []Glyph
+	colors := []Color{fg}
+	for _, token := range tokens {
+		if token.Open {
+			colors = append(colors, token.Color)
+		} else if token.Close {
+			colors = colors[:len(colors)-1]
+		} else {
+			for _, ch := range token.Text {
+				glyphs = append(glyphs, Glyph{ch, colors[len(colors)-1]})
+			}
+		}
+	}
+	return glyphs
+}
+
+// StripMarkup removes any valid color markup from a string.
+func StripMarkup(s string) string {
+	glyphs := ParseMarkup(s, 0)
+	runes := make([]rune, len(glyphs))
+	for i, g := range glyphs {
+		runes[i] = g.Ch
+	}
+	return string(runes)
+}
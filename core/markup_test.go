@@ -0,0 +1,71 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestStripMarkup(t *testing.T) {
+	cases := []struct {
+		s, expected string
+	}{
+		{"The orc hits you", "The orc hits you"},
+		{"The {red}orc{/} hits you", "The orc hits you"},
+		{"{green}The {red}orc{/} hits{/} you", "The orc hits you"},
+		{"The {red}orc hits you", "The {red}orc hits you"},
+		{"The orc{/} hits you", "The orc{/} hits you"},
+		{"The {plaid}orc{/} hits you", "The {plaid}orc{/} hits you"},
+		{"{red}{/}", ""},
+		{"{}{", "{}{"},
+		{"}{red}x{/}{", "}x{"},
+		{"a { b {red}x{/}", "a { b x"},
+		{"{{red}x{/}", "{x"},
+	}
+	for _, c := range cases {
+		if actual := StripMarkup(c.s); actual != c.expected {
+			t.Errorf("StripMarkup(%q) = %q != %q", c.s, actual, c.expected)
+		}
+	}
+}
+
+func TestParseMarkup(t *testing.T) {
+	glyphs := ParseMarkup("a{red}b{blue}c{/}d{/}e{/}", ColorWhite)
+	expected := []Glyph{
+		{'a', ColorWhite},
+		{'b', ColorRed},
+		{'c', ColorBlue},
+		{'d', ColorRed},
+		{'e', ColorWhite},
+		{'{', ColorWhite},
+		{'/', ColorWhite},
+		{'}', ColorWhite},
+	}
+	if len(glyphs) != len(expected) {
+		t.Fatalf("ParseMarkup() = %v != %v", glyphs, expected)
+	}
+	for i, g := range expected {
+		if glyphs[i] != g {
+			t.Errorf("ParseMarkup()[%d] = %v != %v", i, glyphs[i], g)
+		}
+	}
+}
+
+func TestWrapGlyphs_markup(t *testing.T) {
+	glyphs := ParseMarkup("The {red}saber-tooth{/} hits you", ColorWhite)
+	lines := wrapGlyphs(glyphs, 15)
+	expected := []string{"The saber-tooth", "hits you"}
+	if len(lines) != len(expected) {
+		t.Fatalf("wrapGlyphs() = %v != %v", lines, expected)
+	}
+	for i, line := range lines {
+		runes := make([]rune, len(line))
+		for j, g := range line {
+			runes[j] = g.Ch
+		}
+		if string(runes) != expected[i] {
+			t.Errorf("wrapGlyphs()[%d] = %q != %q", i, string(runes), expected[i])
+		}
+	}
+	if lines[0][4].Fg != ColorRed || lines[0][3].Fg != ColorWhite {
+		t.Errorf("wrapGlyphs() lost markup colors: %v", lines[0])
+	}
+}
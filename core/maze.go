@@ -33,16 +33,8 @@ func (f MapGenBool) HalfBraidMaze(n int, runProb, weaveProb, loopProb float64) [
 }
 
 // defaultMapGenBool is used in the generic versions of each MapGenBool method.
-// It generates white '.' for passable Tile, and a white '#' for wall Tile.
-var defaultMapGenBool = func(o Offset, pass bool) *Tile {
-	t := NewTile(o)
-	t.Pass = pass
-	t.Lite = pass
-	if !pass {
-		t.Face = Glyph{'#', ColorWhite}
-	}
-	return t
-}
+// It generates floor for passable Tile, and wall for impassable Tile.
+var defaultMapGenBool = TerrainMapGenBool(TerrainFloor, TerrainWall)
 
 // PerfectMaze creates a set of Tile which form a perfect maze (meaning the
 // maze has no loops). The value of n specifies the size of the underlying graph
@@ -373,3 +365,160 @@ func findWall(origin *Tile, dest Offset) (tile *Tile, ok bool) {
 // translate maze coordinates to match overworld coordinates
 // fully connect maze entrance with overworld
 // fully connect overworld connection neighbors with maze
+
+// GridMazeOptions configures GridMaze.
+type GridMazeOptions struct {
+	// Braid is the fraction of dead ends which are removed by opening a wall
+	// to a neighboring passage, creating a loop. Zero gives a perfect maze,
+	// and 1 removes every dead end.
+	Braid float64
+
+	// Paint sets up a Tile as either TileTypeCorridor or TileTypeWall. If nil,
+	// passages are set to TerrainFloor and walls to TerrainWall.
+	Paint func(t *Tile, tiletype int)
+
+	// Prune is the number of iterations of PruneDeadEnds applied after
+	// braiding, painting the pruned Tiles as walls, with -1 pruning every
+	// dead end. The entrance is never pruned.
+	Prune int
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// mazeSteps are the directions between maze cells, in a fixed order so that
+// random choices are reproducible.
+var mazeSteps = []Offset{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// GridMaze carves a maze of 1 wide passages into a Grid using a recursive
+// backtracker. Cells of the maze lie at odd coordinates, with walls between
+// them and around the edge of the Grid, so both dimensions of the Grid must
+// be odd and at least 3, or ErrInvalidDimensions is returned and the Grid is
+// unchanged. Once carved, a fraction of the dead ends are braided away. The
+// entrance is the top left cell, and the exit is the cell furthest from it
+// along the passages. Unlike BSPDungeon, GridMaze has no need for
+// ConnectRegions, since the backtracker reaches every cell from the entrance,
+// braiding only opens walls, and pruning only fills dead ends.
+func GridMaze(g *Grid, opts GridMazeOptions) (entrance, exit *Tile, err error) {
+	w, h := g.Width(), g.Height()
+	if w < 3 || h < 3 || w%2 == 0 || h%2 == 0 {
+		return nil, nil, ErrInvalidDimensions
+	}
+	if opts.Paint == nil {
+		opts.Paint = paintTerrain
+	}
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+
+	isCell := func(o Offset) bool {
+		return InRange(o.X, 1, w-1) && InRange(o.Y, 1, h-1)
+	}
+	open := make(map[Offset]bool)
+	cells := make(map[*Tile]Offset)
+	carve := func(o Offset) {
+		open[o] = true
+		cells[g.At(o.X, o.Y)] = o
+		opts.Paint(g.At(o.X, o.Y), TileTypeCorridor)
+	}
+
+	g.Each(func(_ Offset, t *Tile) {
+		opts.Paint(t, TileTypeWall)
+	})
+
+	start := Offset{1, 1}
+	carve(start)
+	stack := []Offset{start}
+	for len(stack) > 0 {
+		curr := stack[len(stack)-1]
+		carved := false
+		for _, i := range opts.Dice.Perm(len(mazeSteps)) {
+			step := mazeSteps[i]
+			next := curr.Add(step.Scale(2))
+			if isCell(next) && !open[next] {
+				carve(curr.Add(step))
+				carve(next)
+				stack = append(stack, next)
+				carved = true
+				break
+			}
+		}
+		if !carved {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	// braid dead ends, preferring to join them to other dead ends
+	deadEnd := func(o Offset) bool {
+		exits := 0
+		for _, step := range mazeSteps {
+			if open[o.Add(step)] {
+				exits++
+			}
+		}
+		return exits == 1
+	}
+	for x := 1; x < w; x += 2 {
+		for y := 1; y < h; y += 2 {
+			cell := Offset{x, y}
+			if !deadEnd(cell) || !opts.Dice.Chance(opts.Braid) {
+				continue
+			}
+			var walls, preferred []Offset
+			for _, step := range mazeSteps {
+				if isCell(cell.Add(step.Scale(2))) && !open[cell.Add(step)] {
+					walls = append(walls, step)
+					if deadEnd(cell.Add(step.Scale(2))) {
+						preferred = append(preferred, step)
+					}
+				}
+			}
+			if len(preferred) > 0 {
+				walls = preferred
+			}
+			if len(walls) > 0 {
+				carve(cell.Add(walls[opts.Dice.Intn(len(walls))]))
+			}
+		}
+	}
+
+	if opts.Prune != 0 {
+		entrance := g.At(start.X, start.Y)
+		fill := func(t *Tile) {
+			open[cells[t]] = false
+			opts.Paint(t, TileTypeWall)
+		}
+		pruneDeadEnds(g, Max(opts.Prune, 0), fill, []func(*Tile) bool{func(t *Tile) bool { return t == entrance }})
+	}
+
+	// the exit is the furthest cell, found by breadth first search
+	dists := map[Offset]int{start: 0}
+	frontier, far := []Offset{start}, start
+	for len(frontier) > 0 {
+		curr := frontier[0]
+		frontier = frontier[1:]
+		if d := dists[curr]; d > dists[far] && curr.X%2 == 1 && curr.Y%2 == 1 {
+			far = curr
+		}
+		for _, step := range mazeSteps {
+			if next := curr.Add(step); open[next] {
+				if _, seen := dists[next]; !seen {
+					dists[next] = dists[curr] + 1
+					frontier = append(frontier, next)
+				}
+			}
+		}
+	}
+
+	return g.At(start.X, start.Y), g.At(far.X, far.Y), nil
+}
+
+// Generate carves a maze with GridMaze, returning any error. The Dice, if
+// set, is used in place of opts.Dice.
+func (opts GridMazeOptions) Generate(g *Grid, dice Dice, _ *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	_, _, err := GridMaze(g, opts)
+	return err
+}
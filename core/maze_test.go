@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// gridMazeCase carves a GridMaze into a new Grid using a Dice with the seed.
+func gridMazeCase(w, h int, seed int64, braid float64) (g *Grid, entrance, exit *Tile, err error) {
+	g, _ = NewGrid(w, h)
+	entrance, exit, err = GridMaze(g, GridMazeOptions{Braid: braid, Dice: NewDice(rand.NewSource(seed))})
+	return g, entrance, exit, err
+}
+
+// mazeExits counts the passable orthogonal neighbors of a Tile.
+func mazeExits(t *Tile) int {
+	exits := 0
+	for _, step := range mazeSteps {
+		if adj, ok := t.Adjacent[step]; ok && adj.Pass {
+			exits++
+		}
+	}
+	return exits
+}
+
+func TestGridMaze_Dimensions(t *testing.T) {
+	for _, dims := range [][2]int{{4, 5}, {5, 4}, {1, 5}, {5, 1}} {
+		g, _ := NewGrid(dims[0], dims[1])
+		if _, _, err := GridMaze(g, GridMazeOptions{}); err != ErrInvalidDimensions {
+			t.Errorf("GridMaze(%dx%d) = %v != %v", dims[0], dims[1], err, ErrInvalidDimensions)
+		}
+		if g.At(0, 0).Terrain != "" {
+			t.Errorf("GridMaze(%dx%d) changed the Grid", dims[0], dims[1])
+		}
+	}
+	if _, entrance, exit, err := gridMazeCase(3, 3, 1, 0); err != nil || entrance != exit {
+		t.Errorf("GridMaze(3x3) = %v, %v, %v", entrance, exit, err)
+	}
+}
+
+func TestGridMaze_Perfect(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, entrance, exit, err := gridMazeCase(21, 15, seed, 0)
+		if err != nil {
+			t.Fatalf("GridMaze() = %v", err)
+		}
+		if !entrance.Pass || !exit.Pass || entrance == exit {
+			t.Errorf("seed %d gave entrance %v and exit %v", seed, entrance.Offset, exit.Offset)
+		}
+
+		// a perfect maze is a tree, so it has one fewer passage than cells
+		cells, open := 10*7, 0
+		g.Each(func(_ Offset, tile *Tile) {
+			if tile.Pass {
+				open++
+			}
+		})
+		if open != 2*cells-1 {
+			t.Errorf("seed %d has %d open Tiles != %d", seed, open, 2*cells-1)
+		}
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+		// the furthest cell of a tree is always a dead end
+		if o := exit.Offset; o.X%2 != 1 || o.Y%2 != 1 || mazeExits(exit) != 1 {
+			t.Errorf("seed %d gave exit %v which is not a dead end cell", seed, o)
+		}
+	}
+}
+
+func TestGridMaze_Braid(t *testing.T) {
+	g, _, _, err := gridMazeCase(21, 15, 3, 1)
+	if err != nil {
+		t.Fatalf("GridMaze() = %v", err)
+	}
+	g.Each(func(o Offset, tile *Tile) {
+		if tile.Pass && mazeExits(tile) < 2 {
+			t.Errorf("braided maze has dead end at %v", o)
+		}
+	})
+}
+
+func TestGridMaze_Deterministic(t *testing.T) {
+	layout := func(g *Grid) []bool {
+		var pass []bool
+		g.Each(func(_ Offset, tile *Tile) { pass = append(pass, tile.Pass) })
+		return pass
+	}
+	g1, _, exit1, _ := gridMazeCase(15, 15, 9, .5)
+	g2, _, exit2, _ := gridMazeCase(15, 15, 9, .5)
+	if !reflect.DeepEqual(layout(g1), layout(g2)) || exit1.Offset != exit2.Offset {
+		t.Errorf("GridMaze() differs for the same seed")
+	}
+}
+
+func TestGridMaze_Stats(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		perfect, _, _, _ := gridMazeCase(21, 15, seed, 0)
+		braided, _, _, _ := gridMazeCase(21, 15, seed, 1)
+		p, b := Analyze(perfect), Analyze(braided)
+		if p.Rooms != 0 || p.Corridors != 1 || b.Rooms != 0 || b.Corridors != 1 {
+			t.Errorf("seed %d gave %+v and %+v", seed, p, b)
+		}
+		// braiding adds loops, which removes choke points
+		if b.ChokePoints >= p.ChokePoints/2 {
+			t.Errorf("seed %d braided has %d choke points, perfect %d", seed, b.ChokePoints, p.ChokePoints)
+		}
+	}
+}
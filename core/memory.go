@@ -0,0 +1,138 @@
+package core
+
+// Sighting records where and on which turn an Entity was last seen.
+type Sighting struct {
+	Pos  *Tile
+	Turn int
+}
+
+// LastSeen is an Event querying a Memory for the last Sighting of the Target.
+// If Target is nil, the most recent Sighting of any Entity is given instead.
+// Found is set to true if there was a Sighting.
+type LastSeen struct {
+	Target Entity
+	Sighting
+	Found bool
+}
+
+// Memory is a Component recording what its Entity has seen. On each TurnTick,
+// Memory computes the field of view of radius Radius, and records a Sighting
+// of each other visible occupant for which Hostile is true, or of every other
+// occupant if Hostile is nil. Turn follows the Turn of each Tick, which the
+// Entity must be sent, such as by subscribing it to the EventBus of a Clock. A
+// Sighting is forgotten once it is more than Decay turns old, unless Decay is
+// zero. The Face of each visible Tile is also recorded in Explored, so that a
+// player Memory can be used to render the parts of the map which have been
+// seen before.
+//
+// Sightings are keyed by EntityID, so only Entity in the Registry are
+// remembered. The Registry is not saved with the Memory, but is restored by
+// the Loaded Event sent by LoadWorld, which also relinks each Sighting to the
+// level of the Memory, and forgets Sightings elsewhere.
+type Memory struct {
+	Pos       *Tile
+	Radius    int
+	Decay     int
+	Turn      int
+	Sightings map[EntityID]Sighting
+	Explored  map[Offset]Glyph
+
+	// Hostile decides whether an Entity is worth remembering. If nil, every
+	// other occupant is remembered.
+	Hostile func(Entity) bool
+
+	reg *Registry
+}
+
+// NewMemory creates an empty Memory which remembers Entity from the Registry.
+func NewMemory(reg *Registry, radius, decay int) *Memory {
+	return &Memory{
+		Radius:    radius,
+		Decay:     decay,
+		Sightings: make(map[EntityID]Sighting),
+		Explored:  make(map[Offset]Glyph),
+		reg:       reg,
+	}
+}
+
+// Process implements Component for Memory.
+func (c *Memory) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		c.Pos = v.Pos
+	case *Tick:
+		c.Turn = v.Turn
+		c.forget()
+	case *TurnTick:
+		c.look()
+	case *LastSeen:
+		c.recall(v)
+	case *Loaded:
+		c.reg = v.Registry
+		tiles := v.TilesWith(c.Pos)
+		for id, s := range c.Sightings {
+			if pos, ok := tiles[s.Pos.Offset]; ok {
+				c.Sightings[id] = Sighting{pos, s.Turn}
+			} else {
+				delete(c.Sightings, id)
+			}
+		}
+	}
+}
+
+// Remembered returns the Face of the Tile at the given Offset when it was last
+// seen. If the Tile has never been seen, ok is false.
+func (c *Memory) Remembered(o Offset) (face Glyph, ok bool) {
+	face, ok = c.Explored[o]
+	return face, ok
+}
+
+// look records everything currently in view.
+func (c *Memory) look() {
+	if c.Pos == nil {
+		return
+	}
+	for _, tile := range FoV(c.Pos, c.Radius) {
+		c.Explored[tile.Offset] = tile.Face
+		o := tile.Occupant
+		if o == nil || tile == c.Pos || c.Hostile != nil && !c.Hostile(o) || c.reg == nil {
+			continue
+		}
+		if id, ok := c.reg.ID(o); ok {
+			c.Sightings[id] = Sighting{tile, c.Turn}
+		}
+	}
+}
+
+// forget removes any Sighting older than Decay turns.
+func (c *Memory) forget() {
+	if c.Decay <= 0 {
+		return
+	}
+	for id, s := range c.Sightings {
+		if c.Turn-s.Turn > c.Decay {
+			delete(c.Sightings, id)
+		}
+	}
+}
+
+// recall answers a LastSeen query. Ties between equally recent Sightings of
+// any Entity are broken by EntityID so that the answer is deterministic.
+func (c *Memory) recall(v *LastSeen) {
+	if v.Target != nil {
+		if c.reg == nil {
+			return
+		}
+		if id, ok := c.reg.ID(v.Target); ok {
+			v.Sighting, v.Found = c.Sightings[id]
+		}
+		return
+	}
+
+	var best EntityID
+	for id, s := range c.Sightings {
+		if !v.Found || s.Turn > v.Turn || s.Turn == v.Turn && id < best {
+			best, v.Sighting, v.Found = id, s, true
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func init() {
+	RegisterComponent(&ComponentSlice{})
+	RegisterComponent(&Memory{})
+	RegisterComponent(&testally{})
+}
+
+// MemoryCase converts a StrGrid into lit Tiles, with a Memory of the given
+// Decay on the 'm', a registered testally on the 't', and walls on '#'.
+func MemoryCase(g StrGrid, decay int) (mem *Memory, target *testally, reg *Registry, tiles []*Tile) {
+	reg = NewRegistry()
+	mem = NewMemory(reg, 10, decay)
+	target = &testally{}
+	g.Convert(func(t *Tile, ch byte) {
+		t.Lite = ch != '#'
+		t.Pass = ch != '#'
+		switch ch {
+		case 'm':
+			mem.Pos = t
+			t.Occupant = &ComponentSlice{mem}
+			reg.Register(t.Occupant)
+		case 't':
+			target.Pos = t
+			t.Occupant = target
+			reg.Register(target)
+		}
+		tiles = append(tiles, t)
+	})
+	return mem, target, reg, tiles
+}
+
+func TestMemory(t *testing.T) {
+	mem, target, _, _ := MemoryCase(StrGrid{
+		"######",
+		"#m..t#",
+		"######",
+	}, 2)
+	seen := target.Pos
+
+	mem.Process(&Tick{Turn: 1})
+	mem.Process(&TurnTick{})
+	query := LastSeen{Target: target}
+	if mem.Process(&query); !query.Found || query.Pos != seen || query.Turn != 1 {
+		t.Errorf("LastSeen = %v, %v", query.Sighting, query.Found)
+	}
+	if face, ok := mem.Remembered(seen.Offset); !ok || face != seen.Face {
+		t.Errorf("Remembered() = %v, %v", face, ok)
+	}
+	if _, ok := mem.Remembered(Offset{10, 10}); ok {
+		t.Errorf("Remembered() unseen Tile")
+	}
+
+	// the target leaves, so the sighting decays after two turns
+	seen.Occupant = nil
+	for turn := 2; turn <= 4; turn++ {
+		mem.Process(&Tick{Turn: turn})
+		mem.Process(&TurnTick{})
+		query := LastSeen{}
+		mem.Process(&query)
+		if expected := turn <= 3; query.Found != expected {
+			t.Errorf("turn %d: LastSeen found = %v != %v", turn, query.Found, expected)
+		}
+	}
+}
+
+func TestMemory_Save(t *testing.T) {
+	mem, target, reg, tiles := MemoryCase(StrGrid{
+		"######",
+		"#m..t#",
+		"######",
+	}, 0)
+	mem.Process(&TurnTick{})
+
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+	loaded, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+
+	id, _ := reg.ID(target)
+	loadedTarget := loadedReg.Lookup(id)
+	var loadedMem *Memory
+	for _, tile := range loaded {
+		if e, ok := tile.Occupant.(*ComponentSlice); ok {
+			loadedMem = (*e)[0].(*Memory)
+		}
+	}
+	query := LastSeen{Target: loadedTarget}
+	if loadedMem.Process(&query); !query.Found || query.Pos.Occupant != loadedTarget {
+		t.Errorf("LastSeen after LoadWorld = %v, %v", query.Sighting, query.Found)
+	}
+}
+
+func TestChaser_Recall(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"########",
+		"#c....t#",
+		"########",
+	})
+	reg := NewRegistry()
+	reg.Register(target)
+	mem := NewMemory(reg, 10, 0)
+	mem.Pos = chaser.Pos
+	chaser.Self = &ComponentSlice{chaser, mem}
+	chaser.Pos.Occupant = chaser.Self
+
+	// the chaser has no memory of its own, but its Memory saw the target
+	mem.Process(&TurnTick{})
+	seen := target.Pos
+	seen.Occupant = nil
+	for i := 0; i < 6; i++ {
+		chaser.Self.Handle(&Act{})
+	}
+	if chaser.Pos != seen {
+		t.Errorf("Chaser did not investigate last sighting, at %v", chaser.Pos.Offset)
+	}
+}
@@ -1,5 +1,10 @@
 package core
 
+import (
+	"reflect"
+	"sort"
+)
+
 // Event is a message sent to an Entity.
 type Event interface{}
 
@@ -8,65 +13,697 @@ type Component interface {
 	Process(Event)
 }
 
+// ComponentFunc is a function which implements Component, for one-off
+// behaviors which do not need their own type.
+type ComponentFunc func(Event)
+
+// Process implements Component for ComponentFunc.
+func (f ComponentFunc) Process(v Event) {
+	f(v)
+}
+
+// On creates a Component which calls the handler with each Event of type T,
+// ignoring every other Event. For example:
+//
+//	On(func(v *Bump) { log.Log("bump!") })
+func On[T Event](handler func(T)) Component {
+	return ComponentFunc(func(v Event) {
+		if v, ok := v.(T); ok {
+			handler(v)
+		}
+	})
+}
+
 // Entity is a single game object, typically a collection of Component.
 type Entity interface {
 	Handle(Event)
 }
 
+// sliceIdentity identifies a slice by its type and the address and length of
+// its elements.
+type sliceIdentity struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// identity gives a key identifying a value, such as an Entity or Component,
+// which unlike the value itself can always be compared or used in a map. A
+// comparable value, such as a pointer, is its own key. A non-empty slice, such
+// as a bare ComponentSlice, is identified by its elements, so copies sharing
+// the same Components are the same. Any other value, such as a func, has no
+// identity, and nil is returned.
+func identity(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Comparable() {
+		return v
+	}
+	if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+		return sliceIdentity{rv.Type(), rv.Pointer(), rv.Len()}
+	}
+	return nil
+}
+
+// sameEntity returns true if a and b are the same Entity. Unlike ==, it does
+// not panic on an Entity which cannot be compared, such as a bare
+// ComponentSlice, but compares them by identity instead. An Entity with no
+// identity is only ever the same as nothing.
+func sameEntity(a, b Entity) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ka, kb := identity(a), identity(b)
+	return ka != nil && ka == kb
+}
+
+// containsEntity returns true if the Entity is in the slice, as compared by
+// sameEntity.
+func containsEntity(entities []Entity, e Entity) bool {
+	for _, o := range entities {
+		if sameEntity(o, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Consumable is an optional interface for Event which allows a Component to
+// consume the Event, so that no later Component will process it. For example,
+// an armor Component could consume a damage Event so the Component tracking
+// health never sees it. Most Consumable Event should simply embed Consumption.
+type Consumable interface {
+	Consume()
+	Consumed() bool
+}
+
+// Consumption implements Consumable, and is intended to be embedded in Event.
+type Consumption struct {
+	consumed bool
+}
+
+// Consume marks the Event as consumed.
+func (c *Consumption) Consume() {
+	c.consumed = true
+}
+
+// Consumed returns true if the Event has been consumed.
+func (c *Consumption) Consumed() bool {
+	return c.consumed
+}
+
+// isConsumed returns true if the Event is Consumable and has been consumed.
+func isConsumed(v Event) bool {
+	c, ok := v.(Consumable)
+	return ok && c.Consumed()
+}
+
 // ComponentSlice is a simple Entity which is a slice of Components.
 type ComponentSlice []Component
 
-// Handle sends an event to each Component in order.
+// Handle sends an event to each Component in order, stopping early if a
+// Component consumes the Event.
 func (e ComponentSlice) Handle(v Event) {
+	if eventTracer != nil {
+		traceEnter(e, v)
+		defer traceExit()
+	}
 	for _, c := range e {
+		if isConsumed(v) {
+			return
+		}
 		c.Process(v)
 	}
 }
 
-// Tile is an Entity representing a single square in a map.
+// Prioritized is an optional interface for Component which need to process
+// Events before or after other Component in a SortedEntity. Component with
+// higher priority process Events first. Component which do not implement
+// Prioritized have a priority of 0.
+type Prioritized interface {
+	Priority() int
+}
+
+// priority gets the priority of a Component.
+func priority(c Component) int {
+	if p, ok := c.(Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// SortedEntity is an Entity whose Components process Events in priority order.
+// Component with equal priority process Events in the order they were added.
+type SortedEntity struct {
+	components ComponentSlice
+}
+
+// NewEntity creates a new SortedEntity with the given Components.
+func NewEntity(components ...Component) *SortedEntity {
+	e := &SortedEntity{}
+	e.Add(components...)
+	return e
+}
+
+// Add inserts Components into the SortedEntity according to their priority.
+func (e *SortedEntity) Add(components ...Component) {
+	for _, c := range components {
+		// insert after every Component with at least the same priority
+		p := priority(c)
+		i := sort.Search(len(e.components), func(i int) bool {
+			return priority(e.components[i]) < p
+		})
+		e.components = append(e.components, nil)
+		copy(e.components[i+1:], e.components[i:])
+		e.components[i] = c
+	}
+}
+
+// Components returns the Components of the SortedEntity in priority order.
+func (e *SortedEntity) Components() []Component {
+	return append([]Component(nil), e.components...)
+}
+
+// setComponents replaces the Components of the SortedEntity with ones already
+// in priority order, such as when loaded by LoadWorld.
+func (e *SortedEntity) setComponents(components []Component) {
+	e.components = components
+}
+
+// Handle sends an Event to each Component in priority order, stopping early
+// if a Component consumes the Event.
+func (e *SortedEntity) Handle(v Event) {
+	e.components.Handle(v)
+}
+
+// remove deletes the first Component for which the predicate is true.
+func (e *SortedEntity) remove(pred func(Component) bool) bool {
+	for i, c := range e.components {
+		if pred(c) {
+			e.components = append(e.components[:i:i], e.components[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// EntityMut is a SortedEntity whose Components can be safely attached and
+// detached at any time, including from within Component.Process. Changes made
+// while an Event is being handled are deferred until the outermost Handle
+// finishes, and are then applied in the order they were requested.
+type EntityMut struct {
+	sorted   SortedEntity
+	depth    int
+	deferred []func()
+}
+
+// NewEntityMut creates a new EntityMut with the given Components.
+func NewEntityMut(components ...Component) *EntityMut {
+	e := &EntityMut{}
+	e.sorted.Add(components...)
+	return e
+}
+
+// mutate applies a change immediately, or defers it if handling an Event.
+func (e *EntityMut) mutate(change func()) {
+	if e.depth > 0 {
+		e.deferred = append(e.deferred, change)
+	} else {
+		change()
+	}
+}
+
+// Attach adds a Component to the EntityMut according to its priority.
+func (e *EntityMut) Attach(c Component) {
+	e.mutate(func() { e.sorted.Add(c) })
+}
+
+// Detach removes a Component from the EntityMut. If the Component is not
+// attached, no action is taken. Components are matched by identity, so a
+// ComponentFunc, including one created by On, has none and can only be
+// removed with DetachFunc.
+func (e *EntityMut) Detach(c Component) {
+	key := identity(c)
+	if key == nil {
+		return
+	}
+	e.mutate(func() { e.sorted.remove(func(o Component) bool { return identity(o) == key }) })
+}
+
+// DetachFunc removes every Component for which the predicate is true, such
+// as every Component of a particular type.
+func (e *EntityMut) DetachFunc(pred func(Component) bool) {
+	e.mutate(func() {
+		for e.sorted.remove(pred) {
+		}
+	})
+}
+
+// Components returns the Components of the EntityMut in priority order. Any
+// deferred changes are not reflected until they are applied.
+func (e *EntityMut) Components() []Component {
+	return e.sorted.Components()
+}
+
+// setComponents replaces the Components of the EntityMut, as with
+// SortedEntity.setComponents.
+func (e *EntityMut) setComponents(components []Component) {
+	e.sorted.setComponents(components)
+}
+
+// Handle sends an Event to each Component in priority order, stopping early
+// if a Component consumes the Event. Once the outermost call to Handle
+// finishes, any deferred changes to the Components are applied.
+func (e *EntityMut) Handle(v Event) {
+	e.depth++
+	e.sorted.Handle(v)
+	e.depth--
+
+	for e.depth == 0 && len(e.deferred) > 0 {
+		change := e.deferred[0]
+		e.deferred = e.deferred[1:]
+		change()
+	}
+}
+
+// Tile is an Entity representing a single square in a map. In addition to a
+// single Occupant, a Tile may hold any number of Items, with the most recently
+// placed Item on top. Cost multiplies the cost of moving onto the Tile, such as
+// 2 for a swamp, with zero treated as 1. The Trigger, if any, is sent an
+// Entered whenever an Entity moves onto the Tile. If Terrain is set, the
+// Terrain with that TerrainID may further restrict or harm Entities moving
+// onto the Tile, and SetTerrain keeps the other fields consistent with it.
+//
+// An occupant which answers a BlockQuery by not blocking, such as a ghost or
+// a cloud of gas, does not stop other Entities from moving onto its Tile.
+// Instead, it is moved to Overlap, and the newcomer becomes the Occupant. Once
+// the Occupant leaves, the most recent Entity in Overlap becomes the Occupant
+// again. MoveEntity moves the Occupant unless it names an Entity in Overlap as
+// its Mover.
+//
+// An Entity spanning several Tiles through Large is moved as a whole by a
+// MoveEntity sent to any of its Tiles. Knockback, SwapEntity and Transition
+// would move only one part of it, so they leave it in place.
 type Tile struct {
 	Face     Glyph
 	Pass     bool
 	Lite     bool
+	Cost     float64
+	Terrain  TerrainID
 	Offset   Offset
 	Adjacent map[Offset]*Tile
 	Occupant Entity
+	Overlap  []Entity
+	Items    []Entity
+	Trigger  Entity
+
+	opaque bool   // true if any of the Items are opaque
+	large  *Large // set while a Large Occupant spans the Tile
 }
 
 // NewTile creates a new Tile with no neighbors or occupant.
 func NewTile(o Offset) *Tile {
-	return &Tile{Glyph{'.', ColorWhite}, true, true, o, make(map[Offset]*Tile), nil}
+	return &Tile{Glyph{'.', ColorWhite}, true, true, 1, "", o, make(map[Offset]*Tile), nil, nil, nil, nil, false, nil}
+}
+
+// Transparent returns true if the Tile can be seen through. A Tile is
+// transparent if it is Lite and none of its Items are opaque.
+func (e *Tile) Transparent() bool {
+	return e.Lite && !e.opaque
 }
 
 // Handle implements Entity for Tile
 func (e *Tile) Handle(v Event) {
+	if eventTracer != nil {
+		traceEnter(e, v)
+		defer traceExit()
+	}
 	switch v := v.(type) {
 	case *RenderRequest:
-		v.Render = e.Face
-		if e.Occupant != nil {
-			e.Occupant.Handle(v)
-		}
+		e.render(v)
 	case *MoveEntity:
-		adj := e.Adjacent[v.Delta]
-		if bumped := adj.Occupant; bumped != nil {
-			e.Occupant.Handle(&Bump{bumped})
-		} else if adj.Pass {
-			e.Occupant, adj.Occupant = nil, e.Occupant
-			adj.Occupant.Handle(&UpdatePos{adj})
+		mover := v.Mover
+		if mover == nil {
+			mover = e.Occupant
+		}
+		occupant := v.Mover == nil || sameEntity(v.Mover, e.Occupant)
+		overlap := -1
+		if !occupant {
+			overlap = e.overlapIndex(mover)
+		}
+		if mover == nil || !occupant && overlap < 0 {
+			return
+		}
+		if large := e.multi(); large != nil && occupant {
+			v.Cost = large.move(v.Delta)
+			return
+		}
+		adj, ok := e.Adjacent[v.Delta]
+		if !ok {
+			// moving off the edge of the map is a collision with no obstacle
+			send(mover, &Collide{})
+		} else if bumped := adj.Occupant; bumped != nil && blocks(bumped, mover) {
+			// only the Occupant can swap, since the bumped Entity could not
+			// share the Tile with the Occupant
+			query := BumpQuery{Bumper: mover, Swap: occupant && RelationBetween(mover, bumped) == Friendly}
+			bumped.Handle(&query)
+			if query.Swap && occupant && e.swap(adj) {
+				v.Cost = StepCost(e, adj)
+			} else {
+				send(mover, &Bump{bumped, adj})
+			}
+		} else if adj.admits(mover) {
+			v.Cost = StepCost(e, adj)
+			if occupant {
+				e.leave()
+			} else {
+				e.Overlap = append(e.Overlap[:overlap:overlap], e.Overlap[overlap+1:]...)
+			}
+			adj.occupy(mover)
+			send(mover, &UpdatePos{adj})
+			adj.enter()
+		} else {
+			send(mover, &Collide{adj})
+		}
+	case *Knockback:
+		if e.Occupant == nil {
+			return
+		}
+		if e.Occupant.Handle(v); isConsumed(v) || e.multi() != nil {
+			return
+		}
+		e.knockback(v)
+	case *Noise:
+		if v.Source == nil {
+			v.Source = e
+		}
+		v.broadcast()
+	case *Transition:
+		if e.Occupant == nil || v.Dest == nil || e.multi() != nil {
+			return
+		}
+		if dest := freeTile(v.Dest); dest != nil {
+			mover := e.Occupant
+			e.leave()
+			dest.Occupant = mover
+			send(mover, &UpdatePos{dest})
+			v.Done = true
+			if v.Bus != nil {
+				v.Bus.Publish(v)
+			}
+		}
+	case *SwapEntity:
+		if adj, ok := e.Adjacent[v.Delta]; ok {
+			e.swap(adj)
+		}
+	case *PlaceItem:
+		e.Items = append(e.Items, v.Item)
+		e.updateOpaque()
+	case *RemoveItem:
+		for i, item := range e.Items {
+			if sameEntity(item, v.Item) {
+				e.Items = append(e.Items[:i:i], e.Items[i+1:]...)
+				v.Removed = true
+				break
+			}
+		}
+		e.updateOpaque()
+	case *ItemsAt:
+		v.Items = append(v.Items, e.Items...)
+	}
+}
+
+// knockback pushes the Occupant along the line of a Knockback, stopping early
+// with a Bump or Collide if something is in the way.
+func (e *Tile) knockback(v *Knockback) {
+	mover, dest := e.Occupant, e
+	var stop Event
+	for _, step := range traceSteps(v.Dir, v.Distance) {
+		adj, ok := dest.Adjacent[step]
+		if !ok {
+			stop = &Collide{}
+		} else if o := adj.Occupant; o != nil && blocks(o, mover) {
+			stop = &Bump{o, adj}
+		} else if !adj.admits(mover) {
+			stop = &Collide{adj}
 		} else {
-			e.Occupant.Handle(&Collide{adj})
+			dest = adj
+			v.Moved++
+			continue
+		}
+		break
+	}
+
+	if dest != e {
+		e.leave()
+		dest.occupy(mover)
+		send(mover, &UpdatePos{dest})
+		dest.enter()
+	}
+	if stop != nil {
+		send(mover, stop)
+		if v.Impact > 0 {
+			send(mover, &Damage{Amount: v.Impact, Type: "impact", Source: v.Source})
+		}
+	}
+}
+
+// occupy makes an Entity the Occupant of the Tile, moving any existing
+// Occupant to Overlap.
+func (e *Tile) occupy(who Entity) {
+	if e.Occupant != nil {
+		e.Overlap = append(e.Overlap, e.Occupant)
+	}
+	e.Occupant = who
+}
+
+// overlapIndex returns the index of the Entity in Overlap, or -1 if it is not
+// there.
+func (e *Tile) overlapIndex(who Entity) int {
+	for i, o := range e.Overlap {
+		if sameEntity(o, who) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Vacate removes an Entity from the Tile, whether it is the Occupant or in
+// Overlap. If the Occupant is removed, the most recent Entity in Overlap (if
+// any) becomes the Occupant.
+func (e *Tile) Vacate(who Entity) {
+	if sameEntity(e.Occupant, who) {
+		e.leave()
+	} else if i := e.overlapIndex(who); i >= 0 {
+		e.Overlap = append(e.Overlap[:i:i], e.Overlap[i+1:]...)
+	}
+}
+
+// leave removes the Occupant, replacing it with the most recent Entity in
+// Overlap, if any.
+func (e *Tile) leave() {
+	e.Occupant = nil
+	if last := len(e.Overlap) - 1; last >= 0 {
+		e.Occupant = e.Overlap[last]
+		e.Overlap = e.Overlap[:last:last]
+	}
+}
+
+// blocks asks an occupant whether it blocks the mover from entering its Tile.
+func blocks(occupant, mover Entity) bool {
+	query := BlockQuery{Mover: mover, Block: true}
+	occupant.Handle(&query)
+	return query.Block
+}
+
+// swap exchanges the occupants of two Tile, informing each of its new
+// position. Passability is not checked, since each occupant moves onto a Tile
+// which was already occupied. If either occupant spans several Tiles, nothing
+// is swapped and false is returned.
+func (e *Tile) swap(adj *Tile) bool {
+	if e.multi() != nil || adj.multi() != nil {
+		return false
+	}
+	e.Occupant, adj.Occupant = adj.Occupant, e.Occupant
+	if adj.Occupant != nil {
+		send(adj.Occupant, &UpdatePos{adj})
+		adj.enter()
+	}
+	if e.Occupant != nil {
+		send(e.Occupant, &UpdatePos{e})
+		e.enter()
+	}
+	return true
+}
+
+// multi returns the Large of the Occupant if it spans several Tiles, and nil
+// otherwise.
+func (e *Tile) multi() *Large {
+	if e.large != nil && e.Occupant != nil && sameEntity(e.Occupant, e.large.Self) {
+		return e.large
+	}
+	return nil
+}
+
+// render answers a RenderRequest for the Tile. Each Entity on the Tile is
+// asked for a Glyph from the top down, stopping once one consumes its
+// RenderRequest, and the Glyph on the highest Layer is kept, with ties going
+// to the Entity nearer the top.
+func (e *Tile) render(v *RenderRequest) {
+	v.Render, v.Layer = e.Face, LayerTerrain
+	found := false
+	ask := func(entity Entity, layer int) bool {
+		req := RenderRequest{Layer: layer, Pos: e}
+		entity.Handle(&req)
+		if req.Render.Ch != 0 && (req.Layer > v.Layer || !found && req.Layer == v.Layer) {
+			v.Render, v.Layer, found = req.Render, req.Layer, true
+		}
+		return req.Consumed()
+	}
+
+	if e.Occupant != nil && ask(e.Occupant, LayerOccupant) {
+		return
+	}
+	for i := len(e.Overlap) - 1; i >= 0; i-- {
+		if ask(e.Overlap[i], LayerOccupant) {
+			return
+		}
+	}
+	for i := len(e.Items) - 1; i >= 0; i-- {
+		if ask(e.Items[i], LayerItem) {
+			return
+		}
+	}
+	if e.Trigger != nil {
+		ask(e.Trigger, LayerTerrain)
+	}
+}
+
+// enter informs the Trigger, if any, that the Occupant has entered the Tile,
+// and deals any Damage from the terrain to the Occupant.
+func (e *Tile) enter() {
+	if e.Trigger != nil {
+		send(e.Trigger, &Entered{e.Occupant, e})
+	}
+	if info := e.terrain(); info.Damage > 0 {
+		send(e.Occupant, &Damage{Amount: info.Damage, Type: info.DamageType})
+	}
+}
+
+// freeRadius bounds the search of freeTile, so that a crowded or enclosed
+// origin does not cost a search of the whole map.
+const freeRadius = 8
+
+// freeTile finds the nearest Tile to the origin which is passable and
+// unoccupied, searching outward through Adjacent in a fixed order. Only Tiles
+// within freeRadius of the origin are searched, and nil is returned if none of
+// them are free.
+func freeTile(origin *Tile) *Tile {
+	seen := map[*Tile]struct{}{origin: {}}
+	for frontier := []*Tile{origin}; len(frontier) > 0; frontier = frontier[1:] {
+		curr := frontier[0]
+		if curr.Pass && curr.Occupant == nil {
+			return curr
+		}
+		for _, delta := range descentOrder {
+			adj, ok := curr.Adjacent[delta]
+			if !ok || adj.Offset.Sub(origin.Offset).Chebyshev() > freeRadius {
+				continue
+			}
+			if _, ok := seen[adj]; !ok {
+				seen[adj] = struct{}{}
+				frontier = append(frontier, adj)
+			}
 		}
 	}
+	return nil
+}
+
+// updateOpaque queries the Items to determine if any of them are opaque.
+func (e *Tile) updateOpaque() {
+	e.opaque = false
+	for _, item := range e.Items {
+		req := OpaqueRequest{}
+		item.Handle(&req)
+		e.opaque = e.opaque || req.Opaque
+	}
 }
 
 // RenderRequest is an Event querying an Entity for a Glyph to render.
+//
+// A Tile collects a Glyph from each Entity on it, and renders the one on the
+// highest Layer, preferring the occupant, then the topmost item, then the
+// Trigger, and finally the Face of the Tile itself. Each Entity is sent its own
+// RenderRequest, with Render unset and Layer set to the default for where the
+// Entity is on the Tile. An Entity may leave Render unset to not be drawn, or
+// change the Layer, such as a cloud of gas item which draws over occupants
+// using LayerEffect. Pos is the Tile making the request, so that an Entity on
+// several Tiles can render each one differently. An Entity which consumes its
+// RenderRequest hides every Entity beneath it on the Tile, which is then not
+// asked at all, such as a rug covering a trapdoor.
 type RenderRequest struct {
+	Consumption
 	Render Glyph
+	Layer  int
+	Pos    *Tile
 }
 
-// MoveEntity is an Event attempting to move an occupant to a new position.
+// Standard Layer values for RenderRequest, from lowest to highest.
+const (
+	LayerTerrain = iota * 10
+	LayerItem
+	LayerOccupant
+	LayerEffect
+	LayerMark
+)
+
+// MoveEntity is an Event attempting to move an occupant to a new position. If
+// the occupant moves, Cost is set to the StepCost of the move, and otherwise is
+// left as zero. A Tile does not pass the MoveEntity on to its occupant; use
+// Move to let the occupant alter or cancel the move first.
 type MoveEntity struct {
+	Consumption
 	Delta Offset
+	Cost  int
+
+	// Mover is the Entity to move, which must be on the Tile, either as the
+	// Occupant or in Overlap. If nil, the Occupant is moved.
+	Mover Entity
+}
+
+// Move attempts to move the occupant of the Tile by the given Delta, returning
+// the Cost of the move. The MoveEntity is first sent to the occupant, which may
+// alter the Delta or consume it to cancel the move, such as while confused,
+// and then to the Tile.
+func Move(pos *Tile, delta Offset) int {
+	if pos.Occupant == nil {
+		return 0
+	}
+	move := MoveEntity{Delta: delta}
+	if pos.Occupant.Handle(&move); isConsumed(&move) {
+		return 0
+	}
+	pos.Handle(&move)
+	return move.Cost
+}
+
+// Knockback is an Event sent to a Tile to push its occupant up to Distance
+// Tiles along the line in the direction of Dir, such as from a shield bash or
+// an explosion. The occupant handles the Knockback first, and may consume it
+// to resist being pushed. The occupant moves as if by MoveEntity, but only
+// receives an UpdatePos once it reaches its final Tile, and Triggers along the
+// way are skipped. If something blocks the occupant before it has moved the
+// full Distance, it is sent a Bump or Collide, followed by a Damage from the
+// Source for the Impact, if any. Moved is set to the number of Tiles moved.
+type Knockback struct {
+	Consumption
+	Dir      Offset
+	Distance int
+	Impact   int
+	Source   Entity
+	Moved    int
 }
 
 // UpdatePos is an Event informing an Entity of its new position.
@@ -74,14 +711,112 @@ type UpdatePos struct {
 	Pos *Tile
 }
 
-// Bump is an Event in which one Entity bumps another.
+// Entered is an Event informing the Trigger of a Tile that an Entity has
+// moved onto the Tile, either by MoveEntity or by swapping places.
+type Entered struct {
+	Who Entity
+	Pos *Tile
+}
+
+// Bump is an Event in which one Entity bumps another. Pos is the Tile which
+// was bumped, so that for an Entity on several Tiles, the part which was hit
+// can be determined.
 type Bump struct {
 	Bumped Entity
+	Pos    *Tile
 }
 
-// Collide is an Event in which an Entity collides with an obstacle.
+// Collide is an Event in which an Entity collides with an obstacle. The
+// Obstacle is nil if the Entity tried to move off the edge of the map.
 type Collide struct {
 	Obstacle Entity
 }
 
+// Transition is an Event sent to a Tile to move its occupant to the Dest Tile,
+// which may be on another map entirely. If Dest is not free, the nearest free
+// Tile is used instead. The Trigger of the destination is not sent an Entered,
+// so arriving on a pair of Stairs does not immediately leave again. Done is
+// set to true if the occupant was moved, and only then is the Transition
+// published on Bus, if set.
+type Transition struct {
+	Dest *Tile
+	Done bool
+	Bus  *EventBus
+}
+
+// SwapEntity is an Event exchanging the occupant of a Tile with the occupant
+// of an adjacent Tile.
+type SwapEntity struct {
+	Delta Offset
+}
+
+// BlockQuery is an Event asking an occupant whether it blocks the Mover from
+// moving onto its Tile during a MoveEntity. Occupants block by default, so
+// only non-blocking occupants need to handle BlockQuery, by setting Block to
+// false. Whether an occupant blocks movement has no effect on sight, which is
+// only blocked by the Tile and its Items.
+type BlockQuery struct {
+	Mover Entity
+	Block bool
+}
+
+// BumpQuery is an Event asking an occupant how to respond to being bumped by
+// another Entity during a MoveEntity. If Swap is set to true, the two
+// occupants exchange places instead of the Bumper receiving a Bump. Swap
+// starts as true if RelationBetween the two occupants is Friendly.
+type BumpQuery struct {
+	Bumper Entity
+	Swap   bool
+}
+
+// Message is an Event carrying text which should be shown to the player.
+// Components should send a Message to their own Entity, or publish it on an
+// EventBus, rather than logging it directly, so that game logic does not
+// depend on the UI. Pos is where the described event happened, if anywhere,
+// so that a Filter can drop messages the player could not have seen. A zero
+// Color is the default.
+type Message struct {
+	Text  string
+	Color Color
+	Pos   *Tile
+}
+
+// Messagef creates a Message formatted by Fmt.
+func Messagef(format string, args ...interface{}) *Message {
+	return &Message{Text: Fmt(format, args...)}
+}
+
+// PlaceItem is an Event placing an item Entity on top of a Tile.
+type PlaceItem struct {
+	Item Entity
+}
+
+// RemoveItem is an Event removing an item Entity from a Tile. Removed is set
+// to true if the Tile held the item.
+type RemoveItem struct {
+	Item    Entity
+	Removed bool
+}
+
+// ItemsAt is an Event querying a Tile for the items on it, from bottom to top.
+type ItemsAt struct {
+	Items []Entity
+}
+
+// FoVRequest is an Event querying an Entity for a field of view. The handling
+// Entity fills FoV with the Tiles it can see, keyed by their Offset relative to
+// its own Tile, as computed by FoV. Radius is the requested view radius, with
+// zero meaning the Entity should use its own default radius. Sight is a simple
+// Component which handles FoVRequest.
+type FoVRequest struct {
+	Radius int
+	FoV    map[Offset]*Tile
+}
+
+// OpaqueRequest is an Event querying an item Entity for whether it blocks
+// sight through the Tile it lies on. Items are transparent by default.
+type OpaqueRequest struct {
+	Opaque bool
+}
+
 // TODO Add data drive Entity construction
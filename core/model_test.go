@@ -0,0 +1,503 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testevent struct {
+	Consumption
+	Seen []string
+}
+
+type testcomponent struct {
+	Name    string
+	Consume bool
+}
+
+func (c *testcomponent) Process(v Event) {
+	if v, ok := v.(*testevent); ok {
+		v.Seen = append(v.Seen, c.Name)
+		if c.Consume {
+			v.Consume()
+		}
+	}
+}
+
+func TestComponentSlice_Handle(t *testing.T) {
+	e := ComponentSlice{
+		&testcomponent{"a", false},
+		&testcomponent{"b", true},
+		&testcomponent{"c", false},
+	}
+	v := testevent{}
+	e.Handle(&v)
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() delivered to %v != %v", v.Seen, expected)
+	}
+	if !v.Consumed() {
+		t.Errorf("Handle() did not consume event")
+	}
+}
+
+type testprioritized struct {
+	testcomponent
+	priority int
+}
+
+func (c *testprioritized) Priority() int {
+	return c.priority
+}
+
+func TestOn(t *testing.T) {
+	var seen []string
+	e := ComponentSlice{
+		On(func(v *Bump) { seen = append(seen, "bump") }),
+		ComponentFunc(func(v Event) { seen = append(seen, "any") }),
+		On(func(v *Collide) { seen = append(seen, "collide") }),
+		&testcomponent{"c", false},
+	}
+	e.Handle(&Bump{})
+	e.Handle(&Collide{})
+	e.Handle(&UpdatePos{})
+	if expected := []string{"bump", "any", "any", "collide", "any"}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("Handle() delivered %v != %v", seen, expected)
+	}
+
+	v := testevent{}
+	e = ComponentSlice{
+		On(func(v *testevent) { v.Seen = append(v.Seen, "a"); v.Consume() }),
+		&testcomponent{"b", false},
+	}
+	e.Handle(&v)
+	if expected := []string{"a"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() with consuming On delivered to %v != %v", v.Seen, expected)
+	}
+}
+
+func TestSortedEntity_Handle(t *testing.T) {
+	e := NewEntity(
+		&testcomponent{"a", false},
+		&testprioritized{testcomponent{"b", false}, -1},
+		&testprioritized{testcomponent{"c", false}, 5},
+		&testcomponent{"d", false},
+	)
+	e.Add(&testprioritized{testcomponent{"e", false}, 5}, &testprioritized{testcomponent{"f", false}, -2})
+
+	v := testevent{}
+	e.Handle(&v)
+	if expected := []string{"c", "e", "a", "d", "b", "f"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() delivered to %v != %v", v.Seen, expected)
+	}
+	if n := len(e.Components()); n != 6 {
+		t.Errorf("len(Components()) = %d != 6", n)
+	}
+}
+
+// testmutator attaches and detaches components as it processes events.
+type testmutator struct {
+	testcomponent
+	entity *EntityMut
+	attach Component
+	detach Component
+}
+
+func (c *testmutator) Process(v Event) {
+	c.testcomponent.Process(v)
+	if c.attach != nil {
+		c.entity.Attach(c.attach)
+		c.attach = nil
+	}
+	if c.detach != nil {
+		c.entity.Detach(c.detach)
+		c.detach = nil
+		// a nested event should not apply the deferred changes early
+		c.entity.Handle(&testevent{})
+	}
+}
+
+func TestEntityMut_Handle(t *testing.T) {
+	e := NewEntityMut()
+	a, b := &testcomponent{"a", false}, &testcomponent{"b", false}
+	c := &testprioritized{testcomponent{"c", false}, 10}
+	m := &testmutator{testcomponent{"m", false}, e, c, b}
+	e.Attach(m)
+	e.Attach(a)
+	e.Attach(b)
+
+	// changes made while handling are deferred until afterwards
+	v := testevent{}
+	e.Handle(&v)
+	if expected := []string{"m", "a", "b"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() delivered to %v != %v", v.Seen, expected)
+	}
+
+	v = testevent{}
+	e.Handle(&v)
+	if expected := []string{"c", "m", "a"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() after mutation delivered to %v != %v", v.Seen, expected)
+	}
+
+	e.DetachFunc(func(c Component) bool {
+		_, ok := c.(*testcomponent)
+		return ok
+	})
+	e.Detach(b)
+	v = testevent{}
+	e.Handle(&v)
+	if expected := []string{"c", "m"}; !reflect.DeepEqual(v.Seen, expected) {
+		t.Errorf("Handle() after DetachFunc() delivered to %v != %v", v.Seen, expected)
+	}
+}
+
+func TestEntityMut_DetachFunc(t *testing.T) {
+	var seen []string
+	f := ComponentFunc(func(Event) { seen = append(seen, "f") })
+	g := On(func(*testevent) { seen = append(seen, "g") })
+	e := NewEntityMut(f, g)
+
+	// a ComponentFunc has no identity, so Detach leaves it alone
+	e.Detach(f)
+	e.Detach(g)
+	if e.Handle(&testevent{}); !reflect.DeepEqual(seen, []string{"f", "g"}) {
+		t.Errorf("Handle() after Detach() delivered to %v", seen)
+	}
+
+	e.DetachFunc(func(c Component) bool {
+		_, ok := c.(ComponentFunc)
+		return ok
+	})
+	seen = nil
+	if e.Handle(&testevent{}); seen != nil {
+		t.Errorf("Handle() after DetachFunc() delivered to %v", seen)
+	}
+}
+
+type testitem struct {
+	Face   Glyph
+	Opaque bool
+}
+
+func (i *testitem) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		v.Render = i.Face
+	case *OpaqueRequest:
+		v.Opaque = i.Opaque
+	}
+}
+
+func TestTile_HandleItems(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	coin := &testitem{Glyph{'$', ColorYellow}, false}
+	boulder := &testitem{Glyph{'0', ColorWhite}, true}
+	tile := tiles[1]
+
+	tile.Handle(&PlaceItem{coin})
+	tile.Handle(&PlaceItem{boulder})
+	req := ItemsAt{}
+	tile.Handle(&req)
+	if !reflect.DeepEqual(req.Items, []Entity{coin, boulder}) {
+		t.Errorf("ItemsAt = %v", req.Items)
+	}
+	render := RenderRequest{}
+	if tile.Handle(&render); render.Render != boulder.Face {
+		t.Errorf("RenderRequest with items = %v != %v", render.Render, boulder.Face)
+	}
+	if tile.Transparent() {
+		t.Errorf("Transparent() with opaque item is true")
+	}
+
+	// moving onto the items leaves them in place, and hides them
+	mover := &testitem{Glyph{'@', ColorWhite}, false}
+	tiles[0].Occupant = mover
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tile.Occupant != mover || len(tile.Items) != 2 {
+		t.Errorf("MoveEntity onto items did not leave items untouched")
+	}
+	render = RenderRequest{}
+	if tile.Handle(&render); render.Render != mover.Face {
+		t.Errorf("RenderRequest with occupant = %v != %v", render.Render, mover.Face)
+	}
+
+	remove := RemoveItem{Item: boulder}
+	if tile.Handle(&remove); !remove.Removed {
+		t.Errorf("RemoveItem did not remove item")
+	}
+	if !tile.Transparent() {
+		t.Errorf("Transparent() after removing opaque item is false")
+	}
+	remove = RemoveItem{Item: boulder}
+	if tile.Handle(&remove); remove.Removed {
+		t.Errorf("RemoveItem removed missing item")
+	}
+
+	// items which cannot be compared with == are removed by identity
+	gem, key := ComponentSlice{&testcomponent{}}, ComponentSlice{&testcomponent{}}
+	tile.Handle(&PlaceItem{gem})
+	tile.Handle(&PlaceItem{key})
+	remove = RemoveItem{Item: key}
+	if tile.Handle(&remove); !remove.Removed || len(tile.Items) != 2 {
+		t.Errorf("RemoveItem of a ComponentSlice left %d items", len(tile.Items))
+	}
+}
+
+// testally is an occupant which tracks its position, and is willing to swap
+// places if friendly.
+type testally struct {
+	Pos      *Tile
+	Friendly bool
+	Bumped   bool
+}
+
+func (a *testally) Handle(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		a.Pos = v.Pos
+	case *BumpQuery:
+		v.Swap = a.Friendly
+	case *Bump:
+		a.Bumped = true
+	}
+}
+
+func TestTile_HandleSwap(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	tiles[1].Pass = false
+	hero := &testally{tiles[0], false, false}
+	ally := &testally{tiles[1], true, false}
+	foe := &testally{tiles[2], false, false}
+	tiles[0].Occupant, tiles[1].Occupant, tiles[2].Occupant = hero, ally, foe
+
+	// friendly occupants swap, even on impassable tiles
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != hero || hero.Pos != tiles[1] || tiles[0].Occupant != ally || ally.Pos != tiles[0] {
+		t.Errorf("MoveEntity into friendly occupant did not swap")
+	}
+	if hero.Bumped {
+		t.Errorf("MoveEntity into friendly occupant sent Bump")
+	}
+
+	// unfriendly occupants are bumped
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != hero || tiles[2].Occupant != foe || !hero.Bumped {
+		t.Errorf("MoveEntity into unfriendly occupant did not bump")
+	}
+
+	// explicit swaps ignore friendliness
+	tiles[1].Handle(&SwapEntity{Offset{1, 0}})
+	if tiles[2].Occupant != hero || hero.Pos != tiles[2] || tiles[1].Occupant != foe || foe.Pos != tiles[1] {
+		t.Errorf("SwapEntity did not swap")
+	}
+}
+
+func TestTile_HandleEdge(t *testing.T) {
+	tiles := NewTileGrid(1, 1, Offset{}, NewTile)
+	var collisions []*Collide
+	hero := ComponentSlice{testfunc(func(v Event) {
+		if v, ok := v.(*Collide); ok {
+			collisions = append(collisions, v)
+		}
+	})}
+	tiles[0].Occupant = hero
+
+	for _, delta := range []Offset{{1, 0}, {-1, -1}, {0, 1}} {
+		move := MoveEntity{Delta: delta}
+		tiles[0].Handle(&move)
+		if move.Cost != 0 {
+			t.Errorf("MoveEntity off edge cost %d", move.Cost)
+		}
+	}
+	if len(collisions) != 3 || collisions[0].Obstacle != nil {
+		t.Errorf("MoveEntity off edge gave collisions %v", collisions)
+	}
+	if tiles[0].Occupant == nil {
+		t.Errorf("MoveEntity off edge removed occupant")
+	}
+
+	// moving an empty Tile does nothing
+	tiles[0].Occupant = nil
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+}
+
+// testghost is an occupant which tracks its position and does not block.
+type testghost struct {
+	Pos *Tile
+}
+
+func (g *testghost) Handle(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		g.Pos = v.Pos
+	case *BlockQuery:
+		v.Block = false
+	}
+}
+
+func TestTile_HandleOverlap(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	hero := &testally{tiles[0], false, false}
+	ghost := &testghost{tiles[1]}
+	tiles[0].Occupant, tiles[1].Occupant = hero, ghost
+
+	// moving onto a non-blocking occupant shares the Tile
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != hero || hero.Pos != tiles[1] || tiles[0].Occupant != nil {
+		t.Errorf("MoveEntity onto non-blocking occupant did not move")
+	}
+	if !reflect.DeepEqual(tiles[1].Overlap, []Entity{ghost}) || ghost.Pos != tiles[1] {
+		t.Errorf("MoveEntity onto non-blocking occupant gave Overlap %v", tiles[1].Overlap)
+	}
+	if hero.Bumped {
+		t.Errorf("MoveEntity onto non-blocking occupant sent Bump")
+	}
+
+	// leaving restores the non-blocking occupant
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[2].Occupant != hero || tiles[1].Occupant != ghost || len(tiles[1].Overlap) != 0 {
+		t.Errorf("MoveEntity off shared Tile did not restore occupant")
+	}
+
+	// non-blocking occupants are still bumped by blocking ones
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if tiles[1].Occupant != ghost || tiles[2].Occupant != hero || len(tiles[2].Overlap) != 0 {
+		t.Errorf("MoveEntity from non-blocking occupant onto blocking occupant moved")
+	}
+
+	// vacating an overlapped Entity leaves the Occupant alone
+	tiles[1].Overlap = []Entity{ghost}
+	tiles[1].Occupant = hero
+	tiles[1].Vacate(ghost)
+	if tiles[1].Occupant != hero || len(tiles[1].Overlap) != 0 {
+		t.Errorf("Vacate() of overlapped Entity gave %v, %v", tiles[1].Occupant, tiles[1].Overlap)
+	}
+
+	// Entities which cannot be compared with == are vacated by identity
+	mist, fog := ComponentSlice{&testcomponent{}}, ComponentSlice{&testcomponent{}}
+	tiles[1].Occupant, tiles[1].Overlap = mist, []Entity{fog}
+	if tiles[1].Vacate(fog); !sameEntity(tiles[1].Occupant, mist) || len(tiles[1].Overlap) != 0 {
+		t.Errorf("Vacate() of overlapped ComponentSlice gave %v, %v", tiles[1].Occupant, tiles[1].Overlap)
+	}
+	if tiles[1].Vacate(mist); tiles[1].Occupant != nil {
+		t.Errorf("Vacate() of occupying ComponentSlice left %v", tiles[1].Occupant)
+	}
+	tiles[1].Occupant = hero
+
+	// an overlapped Entity moves itself, not the Occupant
+	tiles[1].Overlap = []Entity{ghost}
+	tiles[2].Occupant, hero.Pos = nil, tiles[1]
+	move := MoveEntity{Delta: Offset{-1, 0}, Mover: ghost}
+	tiles[1].Handle(&move)
+	if tiles[0].Occupant != ghost || ghost.Pos != tiles[0] || move.Cost == 0 {
+		t.Errorf("MoveEntity of overlapped Entity did not move it")
+	}
+	if tiles[1].Occupant != hero || hero.Pos != tiles[1] || len(tiles[1].Overlap) != 0 {
+		t.Errorf("MoveEntity of overlapped Entity moved the Occupant")
+	}
+}
+
+// testpushed is an occupant which records the Events it handles.
+type testpushed struct {
+	testswimmer
+	Seen []Event
+}
+
+func (p *testpushed) Handle(v Event) {
+	p.testswimmer.Handle(v)
+	p.Seen = append(p.Seen, v)
+}
+
+func TestTile_HandleKnockback(t *testing.T) {
+	tiles := NewTileGrid(6, 1, Offset{}, NewTile)
+	tiles[4].Pass = false
+	pushed := &testpushed{testswimmer: testswimmer{Pos: tiles[0]}}
+	tiles[0].Occupant = pushed
+
+	// a full knockback moves with a single UpdatePos
+	push := Knockback{Dir: Offset{1, 0}, Distance: 2, Impact: 3}
+	tiles[0].Handle(&push)
+	if pushed.Pos != tiles[2] || tiles[2].Occupant != pushed || tiles[0].Occupant != nil || push.Moved != 2 {
+		t.Errorf("Knockback moved to %v", pushed.Pos.Offset)
+	}
+	if len(pushed.Seen) != 2 || pushed.Taken != 0 {
+		t.Errorf("Knockback sent %v", pushed.Seen)
+	}
+
+	// blocked knockback stops early with Collide and impact
+	pushed.Seen = nil
+	push = Knockback{Dir: Offset{1, 0}, Distance: 5, Impact: 3}
+	tiles[2].Handle(&push)
+	if pushed.Pos != tiles[3] || push.Moved != 1 {
+		t.Errorf("blocked Knockback moved to %v", pushed.Pos.Offset)
+	}
+	if collide, ok := pushed.Seen[2].(*Collide); !ok || collide.Obstacle != tiles[4] || pushed.Taken != 3 {
+		t.Errorf("blocked Knockback sent %v", pushed.Seen)
+	}
+
+	// knockback into an occupant bumps instead of overlapping
+	other := &testally{Pos: tiles[2]}
+	tiles[2].Occupant = other
+	pushed.Seen = nil
+	push = Knockback{Dir: Offset{-1, 0}, Distance: 3}
+	tiles[3].Handle(&push)
+	if pushed.Pos != tiles[3] || push.Moved != 0 {
+		t.Errorf("Knockback into occupant moved to %v", pushed.Pos.Offset)
+	}
+	if bump, ok := pushed.Seen[1].(*Bump); !ok || bump.Bumped != other {
+		t.Errorf("Knockback into occupant sent %v", pushed.Seen)
+	}
+}
+
+// testlayered renders on a fixed Layer, or at the default Layer if negative.
+type testlayered struct {
+	Face  Glyph
+	Layer int
+}
+
+func (l *testlayered) Handle(v Event) {
+	if v, ok := v.(*RenderRequest); ok {
+		v.Render = l.Face
+		if l.Layer >= 0 {
+			v.Layer = l.Layer
+		}
+	}
+}
+
+func TestTile_HandleRender(t *testing.T) {
+	floor := Glyph{'.', ColorWhite}
+	trap := &Trap{Face: Glyph{'^', ColorRed}, Discovered: true}
+	sword := &testlayered{Glyph{'|', ColorWhite}, -1}
+	gas := &testlayered{Glyph{'*', ColorGreen}, LayerEffect}
+	orc := &testlayered{Glyph{'o', ColorGreen}, -1}
+	hiding := &testlayered{Glyph{'s', ColorWhite}, LayerTerrain}
+	unseen := &testlayered{Glyph{}, -1}
+	rugFace := Glyph{'=', ColorRed}
+	rug := ComponentSlice{On(func(v *RenderRequest) { v.Render = rugFace; v.Consume() })}
+
+	cases := []struct {
+		trigger  Entity
+		items    []Entity
+		occupant Entity
+		expected Glyph
+		layer    int
+	}{
+		{nil, nil, nil, floor, LayerTerrain},
+		{trap, nil, nil, trap.Face, LayerTerrain},
+		{trap, []Entity{sword}, nil, sword.Face, LayerItem},
+		{nil, []Entity{sword}, orc, orc.Face, LayerOccupant},
+		{nil, []Entity{gas, sword}, orc, gas.Face, LayerEffect},
+		{nil, []Entity{sword}, hiding, sword.Face, LayerItem},
+		{nil, nil, hiding, hiding.Face, LayerTerrain},
+		{nil, []Entity{sword}, unseen, sword.Face, LayerItem},
+		{trap, []Entity{gas, rug}, nil, rugFace, LayerItem},
+		{trap, []Entity{gas, rug}, orc, orc.Face, LayerOccupant},
+	}
+	for i, c := range cases {
+		tile := NewTile(Offset{})
+		tile.Trigger, tile.Items, tile.Occupant = c.trigger, c.items, c.occupant
+		req := RenderRequest{}
+		if tile.Handle(&req); req.Render != c.expected || req.Layer != c.layer {
+			t.Errorf("case %d: rendered %v at %d != %v at %d", i, req.Render, req.Layer, c.expected, c.layer)
+		}
+	}
+}
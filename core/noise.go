@@ -0,0 +1,91 @@
+package core
+
+import (
+	"sort"
+)
+
+// Noise is an Event sent to a Tile to make a sound there, such as a shout, a
+// fight, or a door being smashed. The sound floods out from the Tile using
+// DijkstraMap, losing volume according to Cost, and every occupant of a Tile
+// reached with residual volume left is sent an Alerted with that volume. The
+// occupant of the Source itself is not alerted. If Source is nil, the Tile
+// receiving the Noise is used. If Cost is nil, NoiseCost is used.
+type Noise struct {
+	Source *Tile
+	Volume int
+	Cost   func(*Tile) int
+}
+
+// Alerted is an Event informing an Entity that it heard a Noise made at
+// Source, with Volume giving the volume left once the sound reached it.
+type Alerted struct {
+	Source *Tile
+	Volume int
+}
+
+// Volume lost by a sound entering a Tile for the default NoiseCost.
+const (
+	NoiseOpenCost = 1
+	NoiseDoorCost = 4
+	NoiseWallCost = 8
+)
+
+// NoiseCost is the default cost function for Noise. Passable Tiles cost
+// NoiseOpenCost, impassable Tiles with an occupant, such as a closed Door, cost
+// NoiseDoorCost, and other impassable Tiles cost NoiseWallCost.
+func NoiseCost(t *Tile) int {
+	switch {
+	case t.Pass:
+		return NoiseOpenCost
+	case t.Occupant != nil:
+		return NoiseDoorCost
+	default:
+		return NoiseWallCost
+	}
+}
+
+// Hears computes the residual volume at which each Tile hears the Noise,
+// leaving out Tiles which the sound does not reach.
+func (n *Noise) Hears() map[*Tile]int {
+	if n.Source == nil || n.Volume <= 0 {
+		return nil
+	}
+	cost := n.Cost
+	if cost == nil {
+		cost = NoiseCost
+	}
+
+	heard := make(map[*Tile]int)
+	for t, d := range DijkstraMap([]*Tile{n.Source}, cost, n.Volume-1) {
+		heard[t] = n.Volume - d
+	}
+	return heard
+}
+
+// broadcast sends an Alerted to the occupants of every Tile which hears the
+// Noise, in order of Offset so that delivery is deterministic.
+func (n *Noise) broadcast() {
+	heard := n.Hears()
+	tiles := make([]*Tile, 0, len(heard))
+	for t := range heard {
+		if t != n.Source {
+			tiles = append(tiles, t)
+		}
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		a, b := tiles[i].Offset, tiles[j].Offset
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Y < b.Y
+	})
+
+	for _, t := range tiles {
+		if t.Occupant != nil {
+			send(t.Occupant, &Alerted{n.Source, heard[t]})
+		}
+		for _, o := range t.Overlap {
+			send(o, &Alerted{n.Source, heard[t]})
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"testing"
+)
+
+// testlistener records the volume of each Alerted it handles.
+type testlistener struct {
+	Heard []int
+}
+
+func (l *testlistener) Handle(v Event) {
+	if v, ok := v.(*Alerted); ok {
+		l.Heard = append(l.Heard, v.Volume)
+	}
+}
+
+func TestNoise(t *testing.T) {
+	var source *Tile
+	shouter, near, far := &testlistener{}, &testlistener{}, &testlistener{}
+	StrGrid{
+		"#######",
+		"#s.a+b#",
+		"#######",
+	}.Convert(func(t *Tile, ch byte) {
+		switch ch {
+		case '#':
+			t.Pass, t.Lite = false, false
+		case '+':
+			NewDoor(t)
+		case 's':
+			source, t.Occupant = t, shouter
+		case 'a':
+			t.Occupant = near
+		case 'b':
+			t.Occupant = far
+		}
+	})
+
+	cases := []struct {
+		volume    int
+		near, far int
+	}{
+		{10, 8, 3},
+		{8, 6, 1},
+		{7, 5, 0},
+		{2, 0, 0},
+	}
+	for _, c := range cases {
+		shouter.Heard, near.Heard, far.Heard = nil, nil, nil
+		source.Handle(&Noise{Volume: c.volume})
+		if len(shouter.Heard) != 0 {
+			t.Errorf("Noise{%d} alerted its source", c.volume)
+		}
+		for _, l := range []struct {
+			name     string
+			listener *testlistener
+			expected int
+		}{{"near", near, c.near}, {"far", far, c.far}} {
+			switch {
+			case l.expected == 0 && len(l.listener.Heard) != 0:
+				t.Errorf("Noise{%d} alerted %s listener", c.volume, l.name)
+			case l.expected != 0 && (len(l.listener.Heard) != 1 || l.listener.Heard[0] != l.expected):
+				t.Errorf("Noise{%d} gave %s listener %v != [%d]", c.volume, l.name, l.listener.Heard, l.expected)
+			}
+		}
+	}
+}
+
+func TestChaser_Alerted(t *testing.T) {
+	chaser, target := AICase(StrGrid{
+		"########",
+		"#c.#...#",
+		"#..#..t#",
+		"#......#",
+		"########",
+	})
+	chaser.Memory = 10
+
+	// the target is out of sight, so the chaser idles until it hears something
+	chaser.Self.Handle(&Act{})
+	if chaser.HasTarget() {
+		t.Fatalf("Chaser has target before Noise")
+	}
+
+	target.Pos.Handle(&Noise{Volume: 20})
+	if !chaser.HasTarget() {
+		t.Fatalf("Chaser ignored Alerted")
+	}
+	start := chaser.Pos.Offset
+	chaser.Self.Handle(&Act{})
+	if d0, d1 := start.Sub(target.Pos.Offset).Chebyshev(), chaser.Pos.Offset.Sub(target.Pos.Offset).Chebyshev(); d1 >= d0 {
+		t.Errorf("Chaser did not approach Noise, at %v", chaser.Pos.Offset)
+	}
+}
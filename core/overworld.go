@@ -0,0 +1,190 @@
+package core
+
+// OverworldOptions configures GenerateOverworld and OverworldStart. Zero
+// values are replaced with defaults.
+type OverworldOptions struct {
+	// SeaLevel is the elevation, from 0 to 1, below which Tiles are water,
+	// with a default of .4. Tiles below BeachLevel are beach, with a default
+	// of .05 above SeaLevel, and Tiles at or above MountainLevel are
+	// mountain, with a default of .9. Other Tiles are forest if their
+	// moisture, also from 0 to 1, is at least ForestLevel, with a default of
+	// .6, and grass otherwise.
+	SeaLevel      float64
+	BeachLevel    float64
+	MountainLevel float64
+	ForestLevel   float64
+
+	// WrapX makes the elevation and moisture wrap around east to west, so
+	// that the map may be joined at its edges.
+	WrapX bool
+
+	// MinLand is the fewest Tiles the largest walkable landmass may have,
+	// with a default of a quarter of the Grid.
+	MinLand int
+
+	// Water, Beach, Grass, Forest and Mountain give the terrain of each
+	// biome, and so its glyph and passability, with defaults of
+	// TerrainWater, TerrainSand, TerrainMeadow, TerrainTree and
+	// TerrainMountain. The default grass is short enough to see over.
+	Water, Beach, Grass, Forest, Mountain TerrainID
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// withDefaults returns the OverworldOptions with zero values replaced.
+func (opts OverworldOptions) withDefaults(g *Grid) OverworldOptions {
+	if opts.SeaLevel == 0 {
+		opts.SeaLevel = .4
+	}
+	if opts.BeachLevel == 0 {
+		opts.BeachLevel = opts.SeaLevel + .05
+	}
+	if opts.MountainLevel == 0 {
+		opts.MountainLevel = .9
+	}
+	if opts.ForestLevel == 0 {
+		opts.ForestLevel = .6
+	}
+	if opts.MinLand == 0 {
+		opts.MinLand = g.Width() * g.Height() / 4
+	}
+	opts.MinLand = Min(opts.MinLand, g.Width()*g.Height())
+	defaults := []struct {
+		id  *TerrainID
+		def TerrainID
+	}{
+		{&opts.Water, TerrainWater},
+		{&opts.Beach, TerrainSand},
+		{&opts.Grass, TerrainMeadow},
+		{&opts.Forest, TerrainTree},
+		{&opts.Mountain, TerrainMountain},
+	}
+	for _, d := range defaults {
+		if *d.id == "" {
+			*d.id = d.def
+		}
+	}
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+	return opts
+}
+
+// walkable returns true if the Tile can be walked on without swimming.
+func walkable(t *Tile) bool {
+	return t.Pass && !t.terrain().Swim
+}
+
+// GenerateOverworld sets the terrain of every Tile of the Grid from an
+// elevation Heightmap and a moisture Heightmap, and returns the largest
+// walkable landmass. If that landmass has fewer than MinLand Tiles, the sea
+// is lowered and the mountains raised until it is large enough, so that the
+// player always has somewhere to go. The edge of the Grid is always wall, so
+// that nothing can walk or see off the Grid.
+func GenerateOverworld(g *Grid, opts OverworldOptions) []*Tile {
+	opts = opts.withDefaults(g)
+	heightmap := func() *Heightmap {
+		h := NewHeightmap(g.Width(), g.Height())
+		h.RadiusX, h.RadiusY = Max(h.RadiusX, 1), Max(h.RadiusY, 1)
+		h.WrapX, h.Dice = opts.WrapX, opts.Dice
+		h.Generate()
+		return h
+	}
+	elevation, moisture := heightmap(), heightmap()
+
+	sea, beach, mountain := opts.SeaLevel, opts.BeachLevel, opts.MountainLevel
+	for {
+		g.Each(func(o Offset, t *Tile) {
+			height, wet := elevation.Read(o.X, o.Y), moisture.Read(o.X, o.Y)
+			switch {
+			case o.X == 0 || o.Y == 0 || o.X == g.Width()-1 || o.Y == g.Height()-1:
+				t.SetTerrain(TerrainWall)
+			case height < sea:
+				t.SetTerrain(opts.Water)
+			case height < beach:
+				t.SetTerrain(opts.Beach)
+			case height >= mountain:
+				t.SetTerrain(opts.Mountain)
+			case wet >= opts.ForestLevel:
+				t.SetTerrain(opts.Forest)
+			default:
+				t.SetTerrain(opts.Grass)
+			}
+		})
+
+		var land []*Tile
+		for _, region := range Regions(g, walkable) {
+			if len(region) > len(land) {
+				land = region
+			}
+		}
+		if len(land) >= opts.MinLand || sea <= 0 && beach <= 0 && mountain > 1 {
+			return land
+		}
+		sea, beach, mountain = sea-.05, beach-.05, mountain+.05
+	}
+}
+
+// Generate makes an overworld with GenerateOverworld. The Dice, if set, is
+// used in place of opts.Dice.
+func (opts OverworldOptions) Generate(g *Grid, dice Dice, _ *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	GenerateOverworld(g, opts)
+	return nil
+}
+
+// OverworldStart picks a starting Tile for the player on an overworld made by
+// GenerateOverworld with the same options. The Tile is grass near the coast,
+// meaning within two steps of water or beach, on a walkable landmass of at
+// least MinLand Tiles, so the player does not begin stranded on a tiny
+// island. Failing that, any grass or walkable Tile of the largest landmass is
+// used, and ErrNoTile is returned if there is no walkable Tile at all.
+func OverworldStart(g *Grid, opts OverworldOptions) (*Tile, error) {
+	opts = opts.withDefaults(g)
+
+	var largest []*Tile
+	var coastal, grass []*Tile
+	for _, region := range Regions(g, walkable) {
+		if len(region) > len(largest) {
+			largest = region
+		}
+		if len(region) < opts.MinLand {
+			continue
+		}
+		for _, t := range region {
+			if t.Terrain != opts.Grass {
+				continue
+			}
+			grass = append(grass, t)
+			if overworldCoastal(t, opts) {
+				coastal = append(coastal, t)
+			}
+		}
+	}
+
+	for _, candidates := range [][]*Tile{coastal, grass, largest} {
+		if len(candidates) > 0 {
+			return candidates[opts.Dice.Intn(len(candidates))], nil
+		}
+	}
+	return nil, ErrNoTile
+}
+
+// overworldCoastal returns true if the Tile is within two steps of water or
+// beach.
+func overworldCoastal(t *Tile, opts OverworldOptions) bool {
+	for _, adj := range t.Adjacent {
+		if adj.Terrain == opts.Water || adj.Terrain == opts.Beach {
+			return true
+		}
+		for _, far := range adj.Adjacent {
+			if far.Terrain == opts.Water || far.Terrain == opts.Beach {
+				return true
+			}
+		}
+	}
+	return false
+}
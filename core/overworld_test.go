@@ -0,0 +1,104 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// overworldCase generates an overworld into a new Grid with a seeded Dice.
+func overworldCase(seed int64, opts OverworldOptions) (*Grid, []*Tile) {
+	g, _ := NewGrid(80, 40)
+	opts.Dice = NewDice(rand.NewSource(seed))
+	return g, GenerateOverworld(g, opts)
+}
+
+func TestGenerateOverworld(t *testing.T) {
+	biomes := map[TerrainID]int{}
+	for seed := int64(0); seed < 5; seed++ {
+		g, land := overworldCase(seed, OverworldOptions{WrapX: true})
+		if len(land) < 80*40/4 {
+			t.Errorf("seed %d gave landmass of %d", seed, len(land))
+		}
+		for _, tile := range land {
+			if !tile.Pass || tile.Terrain == TerrainWater {
+				t.Errorf("seed %d gave unwalkable land %v", seed, tile.Terrain)
+				break
+			}
+		}
+		g.Each(func(_ Offset, tile *Tile) {
+			biomes[tile.Terrain]++
+		})
+	}
+	for _, id := range []TerrainID{TerrainWall, TerrainWater, TerrainSand, TerrainMeadow, TerrainTree, TerrainMountain} {
+		if biomes[id] == 0 {
+			t.Errorf("GenerateOverworld() gave no %s", id)
+		}
+	}
+	if len(biomes) != 6 {
+		t.Errorf("GenerateOverworld() gave biomes %v", biomes)
+	}
+}
+
+func TestGenerateOverworld_Deterministic(t *testing.T) {
+	a, _ := overworldCase(3, OverworldOptions{})
+	b, _ := overworldCase(3, OverworldOptions{})
+	a.Each(func(o Offset, tile *Tile) {
+		if tile.Terrain != b.At(o.X, o.Y).Terrain {
+			t.Fatalf("GenerateOverworld() differed at %v", o)
+		}
+	})
+}
+
+func TestGenerateOverworld_MinLand(t *testing.T) {
+	_, land := overworldCase(1, OverworldOptions{SeaLevel: .95, MinLand: 80 * 40 / 2})
+	if len(land) < 80*40/2 {
+		t.Errorf("GenerateOverworld() gave landmass of %d", len(land))
+	}
+}
+
+func TestOverworldStart(t *testing.T) {
+	g, _, _ := ParseGrid(`
+		~~~~~~~~~~~~
+		~"""~~~~~~~~
+		~~~~~.""""~~
+		~~~~~.""""~~
+		~~~~~.""""~~
+		~~~~~~~~~~~~
+	`, nil)
+	opts := OverworldOptions{Beach: TerrainFloor, Grass: TerrainGrass, MinLand: 10, Dice: NewDice(rand.NewSource(1))}
+	for i := 0; i < 20; i++ {
+		start, err := OverworldStart(g, opts)
+		if err != nil {
+			t.Fatalf("OverworldStart() = %v", err)
+		}
+		if start.Terrain != TerrainGrass || start.Offset.X < 6 {
+			t.Errorf("OverworldStart() = %v", start.Offset)
+		}
+	}
+
+	g, _, _ = ParseGrid("~~\n~~", nil)
+	if _, err := OverworldStart(g, opts); err != ErrNoTile {
+		t.Errorf("OverworldStart() on water = %v", err)
+	}
+}
+
+func TestOverworldStart_FoV(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := NewGrid(80, 50)
+		opts := OverworldOptions{Dice: NewDice(rand.NewSource(seed))}
+		GenerateOverworld(g, opts)
+		g.Each(func(o Offset, tile *Tile) {
+			edge := o.X == 0 || o.Y == 0 || o.X == g.Width()-1 || o.Y == g.Height()-1
+			if edge && (tile.Pass || tile.Transparent()) {
+				t.Fatalf("seed %d left open edge %v", seed, o)
+			}
+		})
+		start, err := OverworldStart(g, opts)
+		if err != nil {
+			t.Fatalf("seed %d: OverworldStart() = %v", seed, err)
+		}
+		if fov := FoV(start, 20); len(fov) < 2 {
+			t.Errorf("seed %d: FoV() from start saw %d Tiles", seed, len(fov))
+		}
+	}
+}
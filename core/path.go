@@ -103,9 +103,61 @@ func (q *tilequeue) Pop() interface{} {
 // it never underestimates the final path cost, then the resulting path will be
 // optimal with respect to cost.
 func GraphSearch(origin, goal *Tile, cost, heuristic DistFn) []*Tile {
+	return FindPath(origin, goal, PathOptions{Cost: cost, Heuristic: heuristic})
+}
+
+// PathOptions configures FindPath.
+type PathOptions struct {
+	// Cost and Heuristic are as for GraphSearch, with defaults of MoveCost
+	// and Euclidean distance, as used by AStarPath.
+	Cost, Heuristic DistFn
+
+	// Weight multiplies the Heuristic, so that a Weight above 1 searches far
+	// fewer Tiles, at the price of paths up to Weight times the optimal cost
+	// when the Heuristic is admissible. Values below 1 are treated as 1.
+	Weight float64
+
+	// Budget limits the number of Tiles expanded by the search. If the goal
+	// is not found within the Budget, the path to the expanded Tile with the
+	// lowest Heuristic is returned instead, which at least heads toward the
+	// goal. Zero means no limit.
+	Budget int
+
+	// Shared, if set, is searched backward from the goal and kept between
+	// calls, so that many searches to the same goal, such as every monster
+	// pathing to the player on one turn, share a single closed set. The
+	// Heuristic and Weight are not used, and the search is only valid while
+	// the map is unchanged, so a new PathCache should be made each turn. If
+	// the Budget runs out first, the search falls back to a forward search.
+	Shared *PathCache
+}
+
+// FindPath searches for a path from the origin to the goal as configured by
+// the PathOptions. The path excludes the origin and includes the goal, and is
+// nil if there is no path.
+func FindPath(origin, goal *Tile, opts PathOptions) []*Tile {
+	if opts.Cost == nil {
+		opts.Cost = MoveCost
+	}
+	if opts.Heuristic == nil {
+		opts.Heuristic = euclidean
+	}
+	if opts.Shared != nil {
+		if path, ok := opts.Shared.find(origin, goal, opts.Cost, opts.Budget); ok {
+			return path
+		}
+	}
+	heuristic := opts.Heuristic
+	if opts.Weight > 1 {
+		heuristic = func(a, b *Tile) float64 {
+			return opts.Heuristic(a, b) * opts.Weight
+		}
+	}
+
 	scores := newscorer(origin, goal, heuristic)
 	frontier := &tilequeue{[]*Tile{origin}, scores}
 	closed := make(map[*Tile]struct{})
+	best := origin
 
 	for frontier.Len() > 0 {
 		// get the next tile to explore, skip if we've already closed it
@@ -114,8 +166,16 @@ func GraphSearch(origin, goal *Tile, cost, heuristic DistFn) []*Tile {
 			continue
 		}
 
-		// mark current as seen
+		// if the budget is spent, settle for the closest we got
+		if opts.Budget > 0 && len(closed) >= opts.Budget {
+			return scores.Path(best)
+		}
+
+		// mark current as seen, remembering the closest to the goal
 		closed[curr] = struct{}{}
+		if scores.Score(curr).HEst < scores.Score(best).HEst {
+			best = curr
+		}
 
 		// if we find the goal, we've already found the best path
 		if curr == goal {
@@ -131,7 +191,7 @@ func GraphSearch(origin, goal *Tile, cost, heuristic DistFn) []*Tile {
 
 			if _, seen := closed[adj]; !seen {
 				// compute the cost of that path to adj going through curr
-				cost := currscore.GCost + cost(curr, adj)
+				cost := currscore.GCost + opts.Cost(curr, adj)
 
 				// we found a better path for the adjacent tile
 				if adjscore := scores.Score(adj); cost < adjscore.GCost {
@@ -147,6 +207,74 @@ func GraphSearch(origin, goal *Tile, cost, heuristic DistFn) []*Tile {
 	return nil
 }
 
+// PathCache holds a search backward from a goal which is shared by many calls
+// to FindPath, as described by PathOptions.
+type PathCache struct {
+	goal     *Tile
+	scores   *scorer
+	frontier *tilequeue
+	closed   map[*Tile]struct{}
+}
+
+// NewPathCache creates an empty PathCache. It starts searching from the goal
+// of the first FindPath using it, and starts over if the goal changes.
+func NewPathCache() *PathCache {
+	return &PathCache{}
+}
+
+// find continues the backward search until the origin is closed, returning
+// false if the Budget of expansions for this call runs out first. Since the
+// search runs backward, the Prev of each score is the next step toward the
+// goal.
+func (c *PathCache) find(origin, goal *Tile, cost DistFn, budget int) ([]*Tile, bool) {
+	if c.goal != goal {
+		c.goal = goal
+		c.scores = newscorer(goal, nil, zero)
+		c.frontier = &tilequeue{[]*Tile{goal}, c.scores}
+		c.closed = make(map[*Tile]struct{})
+	}
+
+	for expanded := 0; ; expanded++ {
+		if _, done := c.closed[origin]; done {
+			break
+		}
+		if c.frontier.Len() == 0 {
+			return nil, true
+		}
+		if budget > 0 && expanded >= budget {
+			return nil, false
+		}
+
+		curr := heap.Pop(c.frontier).(*Tile)
+		if _, seen := c.closed[curr]; seen {
+			continue
+		}
+		c.closed[curr] = struct{}{}
+
+		currscore := c.scores.Score(curr)
+		for _, adj := range curr.Adjacent {
+			if !adj.Pass {
+				continue
+			}
+			if _, seen := c.closed[adj]; !seen {
+				// the step being considered is from adj onto curr
+				cost := currscore.GCost + cost(adj, curr)
+				if adjscore := c.scores.Score(adj); cost < adjscore.GCost {
+					adjscore.GCost = cost
+					adjscore.Prev = curr
+					heap.Push(c.frontier, adj)
+				}
+			}
+		}
+	}
+
+	var path []*Tile
+	for t := c.scores.Score(origin).Prev; t != nil; t = c.scores.Score(t).Prev {
+		path = append(path, t)
+	}
+	return path, true
+}
+
 // NewGraphSearch creates a GraphSearch function with the given DistFns.
 func NewGraphSearch(cost, heuristic DistFn) func(*Tile, *Tile) []*Tile {
 	return func(a, b *Tile) []*Tile {
@@ -169,12 +297,244 @@ func zero(*Tile, *Tile) float64 {
 	return 0
 }
 
-// AStarPath computes a minimum cost path between two Tiles.
+// MoveCost is a DistFn giving the cost of moving between adjacent Tiles. An
+// orthogonal step costs 1 and a diagonal step costs the square root of 2, each
+// multiplied by the Cost of the destination Tile.
+func MoveCost(from, to *Tile) float64 {
+	cost := to.Cost
+	if cost == 0 {
+		cost = 1
+	}
+	return to.Offset.Sub(from.Offset).Euclidean() * cost
+}
+
+// StepCost converts the MoveCost between adjacent Tiles into Scheduler energy,
+// where an orthogonal step onto a normal Tile costs 100.
+func StepCost(from, to *Tile) int {
+	return int(math.Floor(MoveCost(from, to)*100 + .5))
+}
+
+// AStarPath computes a minimum cost path between two Tiles, using the same
+// MoveCost charged for actual movement. The path is only guaranteed to be
+// optimal if no Tile has a Cost less than 1.
 func AStarPath(origin, goal *Tile) []*Tile {
-	return GraphSearch(origin, goal, euclidean, euclidean)
+	return GraphSearch(origin, goal, MoveCost, euclidean)
 }
 
 // GreedyPath computes a greedy path between two Tiles.
 func GreedyPath(origin, goal *Tile) []*Tile {
 	return GraphSearch(origin, goal, zero, euclidean)
 }
+
+// distqueue implements heap.Interface for Tiles sorted by distance.
+type distqueue struct {
+	queue []*Tile
+	dists map[*Tile]int
+}
+
+// Len returns the number of Tiles in the queue.
+func (q *distqueue) Len() int {
+	return len(q.queue)
+}
+
+// Less returns true if the ith Tile is closer than the jth Tile.
+func (q *distqueue) Less(i, j int) bool {
+	return q.dists[q.queue[i]] < q.dists[q.queue[j]]
+}
+
+// Swap switches the values of the ith and jth Tile in the queue.
+func (q *distqueue) Swap(i, j int) {
+	q.queue[i], q.queue[j] = q.queue[j], q.queue[i]
+}
+
+// Push pushes a *Tile onto the queue, panicing if the data is not a *Tile.
+func (q *distqueue) Push(x interface{}) {
+	q.queue = append(q.queue, x.(*Tile))
+}
+
+// Pop removes and returns the last *Tile in the queue as an interface{}.
+func (q *distqueue) Pop() interface{} {
+	n := len(q.queue) - 1
+	x := q.queue[n]
+	q.queue = q.queue[:n]
+	return x
+}
+
+// DijkstraMap computes the distance from every reachable Tile to the nearest
+// of the goals. The cost function gives the cost of entering a Tile, with a
+// negative cost meaning the Tile cannot be entered. If cost is nil, passable
+// Tiles cost 1 and impassable Tiles cannot be entered. Tiles further than
+// limit are left out, unless limit is zero or less, in which case the map is
+// unbounded.
+//
+// Monsters approach the goals by following the map with Descend. Negating
+// the distances, or scaling them by a negative factor, gives a map which
+// leads away from the goals instead.
+func DijkstraMap(goals []*Tile, cost func(*Tile) int, limit int) map[*Tile]int {
+	if cost == nil {
+		cost = passCost
+	}
+
+	dists := make(map[*Tile]int)
+	frontier := &distqueue{nil, dists}
+	closed := make(map[*Tile]struct{})
+	for _, goal := range goals {
+		dists[goal] = 0
+		heap.Push(frontier, goal)
+	}
+
+	for frontier.Len() > 0 {
+		curr := heap.Pop(frontier).(*Tile)
+		if _, seen := closed[curr]; seen {
+			continue
+		}
+		closed[curr] = struct{}{}
+
+		for _, adj := range curr.Adjacent {
+			c := cost(adj)
+			if c < 0 {
+				continue
+			}
+			d := dists[curr] + c
+			if limit > 0 && d > limit {
+				continue
+			}
+			if prev, ok := dists[adj]; !ok || d < prev {
+				dists[adj] = d
+				heap.Push(frontier, adj)
+			}
+		}
+	}
+
+	return dists
+}
+
+// FleeMap computes a map which leads away from the threats. The DijkstraMap of
+// the threats is scaled by the coefficient, which should be negative, with
+// around -1.2 being typical, and then relaxed so that each Tile is at most the
+// cost of entering it more than any adjacent Tile. Unlike simply negating the
+// distances, descending the result will lead past a threat toward open space
+// when that is safer than cowering in a dead end. The cost function is as for
+// DijkstraMap.
+func FleeMap(threats []*Tile, cost func(*Tile) int, coefficient float64) map[*Tile]int {
+	if cost == nil {
+		cost = passCost
+	}
+
+	dists := DijkstraMap(threats, cost, 0)
+	frontier := &distqueue{nil, dists}
+	for tile, d := range dists {
+		dists[tile] = int(float64(d) * coefficient)
+		frontier.queue = append(frontier.queue, tile)
+	}
+	heap.Init(frontier)
+
+	closed := make(map[*Tile]struct{})
+	for frontier.Len() > 0 {
+		curr := heap.Pop(frontier).(*Tile)
+		if _, seen := closed[curr]; seen {
+			continue
+		}
+		closed[curr] = struct{}{}
+
+		for _, adj := range curr.Adjacent {
+			c := cost(adj)
+			if c < 0 {
+				continue
+			}
+			if d := dists[curr] + c; d < dists[adj] {
+				dists[adj] = d
+				heap.Push(frontier, adj)
+			}
+		}
+	}
+
+	return dists
+}
+
+// SmoothPath removes every waypoint of a path which can be skipped by a
+// straight line, checked by clear, from an earlier waypoint to a later one.
+// The first and last Tiles are always kept. If clear is nil, PassLine is used.
+// The result suits movement which follows the line between each waypoint,
+// such as travel or a projectile, rather than the staircase of a search.
+func SmoothPath(path []*Tile, clear func(a, b *Tile) bool) []*Tile {
+	if len(path) < 3 {
+		return path
+	}
+	if clear == nil {
+		clear = PassLine
+	}
+
+	smooth := []*Tile{path[0]}
+	for i := 0; i < len(path)-1; {
+		// find the furthest Tile reachable in a straight line, falling back
+		// on the next step of the path itself
+		next := i + 1
+		for j := len(path) - 1; j > i+1; j-- {
+			if clear(path[i], path[j]) {
+				next = j
+				break
+			}
+		}
+		smooth = append(smooth, path[next])
+		i = next
+	}
+	return smooth
+}
+
+// PassLine returns true if each Tile of the line from a to b given by Trace is
+// passable. A diagonal step squeezing between two impassable Tiles is not
+// allowed, so that the line never cuts through the corner of a wall.
+func PassLine(a, b *Tile) bool {
+	prev, curr := Offset{}, a
+	for _, o := range Trace(b.Offset.Sub(a.Offset)) {
+		step := o.Sub(prev)
+		if step.X != 0 && step.Y != 0 {
+			h, hok := curr.Adjacent[Offset{step.X, 0}]
+			v, vok := curr.Adjacent[Offset{0, step.Y}]
+			if (!hok || !h.Pass) && (!vok || !v.Pass) {
+				return false
+			}
+		}
+		next, ok := curr.Adjacent[step]
+		if !ok || !next.Pass {
+			return false
+		}
+		prev, curr = o, next
+	}
+	return curr == b
+}
+
+// passCost is the default cost function for DijkstraMap.
+func passCost(t *Tile) int {
+	if t.Pass {
+		return 1
+	}
+	return -1
+}
+
+// descentOrder is the order in which Descend considers adjacent Tiles, so that
+// ties are broken deterministically, preferring orthogonal steps.
+var descentOrder = []Offset{
+	{0, -1}, {1, 0}, {0, 1}, {-1, 0},
+	{-1, -1}, {1, -1}, {1, 1}, {-1, 1},
+}
+
+// Descend returns the adjacent Tile with the lowest distance in the given
+// DijkstraMap, or nil if no adjacent Tile is closer than the current one.
+func Descend(from *Tile, dm map[*Tile]int) *Tile {
+	var best *Tile
+	bestDist, ok := dm[from]
+	if !ok {
+		bestDist = math.MaxInt
+	}
+
+	for _, delta := range descentOrder {
+		if adj, ok := from.Adjacent[delta]; ok {
+			if d, ok := dm[adj]; ok && d < bestDist {
+				best, bestDist = adj, d
+			}
+		}
+	}
+	return best
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -195,3 +196,309 @@ func TestCustomSearch(t *testing.T) {
 		RunCase(t, "GraphSearch", i, search, c)
 	}
 }
+
+func TestMoveCost(t *testing.T) {
+	tiles := NewTileGrid(2, 2, Offset{}, NewTile)
+	swamp := tiles[3]
+	swamp.Cost = 2
+
+	cases := []struct {
+		from, to *Tile
+		expected int
+	}{
+		{tiles[0], tiles[1], 100},
+		{tiles[0], tiles[2], 100},
+		{tiles[0], swamp, 283},
+		{tiles[1], swamp, 200},
+		{swamp, tiles[0], 141},
+	}
+	for _, c := range cases {
+		if actual := StepCost(c.from, c.to); actual != c.expected {
+			t.Errorf("StepCost(%v, %v) = %d != %d", c.from.Offset, c.to.Offset, actual, c.expected)
+		}
+	}
+
+	hero := &testally{}
+	tiles[0].Occupant = hero
+	move := MoveEntity{Delta: Offset{1, 1}}
+	if tiles[0].Handle(&move); move.Cost != 283 || hero.Pos != swamp {
+		t.Errorf("MoveEntity onto swamp cost %d", move.Cost)
+	}
+	move = MoveEntity{Delta: Offset{-1, 0}}
+	if swamp.Handle(&move); move.Cost != 100 {
+		t.Errorf("MoveEntity off swamp cost %d", move.Cost)
+	}
+}
+
+func TestDijkstraMap(t *testing.T) {
+	var goals []*Tile
+	var origin *Tile
+	tiles := StrGrid{
+		"#######",
+		"#$...$#",
+		"#.###.#",
+		"#..@..#",
+		"#######",
+	}.Convert(func(t *Tile, c byte) {
+		switch c {
+		case '#':
+			t.Pass = false
+		case '$':
+			goals = append(goals, t)
+		case '@':
+			origin = t
+		}
+	})
+
+	dm := DijkstraMap(goals, nil, 0)
+	cases := []struct {
+		x, y     int
+		expected int
+	}{
+		{1, 1, 0}, {5, 1, 0}, {3, 1, 2}, {2, 1, 1},
+		{1, 3, 2}, {3, 3, 3}, {4, 3, 2},
+	}
+	for _, c := range cases {
+		if actual := dm[&tiles[c.x][c.y]]; actual != c.expected {
+			t.Errorf("DijkstraMap at (%d, %d) = %d != %d", c.x, c.y, actual, c.expected)
+		}
+	}
+	if _, ok := dm[&tiles[0][0]]; ok {
+		t.Errorf("DijkstraMap included wall")
+	}
+
+	if limited := DijkstraMap(goals, nil, 1); len(limited) != 6 {
+		t.Errorf("DijkstraMap with limit 1 has %d Tiles", len(limited))
+	}
+
+	// both (2, 3) and (4, 3) are equally close, so Descend picks consistently
+	for i := 0; i < 10; i++ {
+		step := Descend(origin, dm)
+		if step != &tiles[4][3] {
+			t.Errorf("Descend(%v) = %v", origin.Offset, step.Offset)
+		}
+	}
+	if step := Descend(goals[0], dm); step != nil {
+		t.Errorf("Descend from goal = %v", step.Offset)
+	}
+}
+
+func TestFleeMap(t *testing.T) {
+	var threat, origin *Tile
+	StrGrid{
+		"########################################",
+		"#.....@.T..............................#",
+		"#......................................#",
+		"########################################",
+	}.Convert(func(t *Tile, c byte) {
+		switch c {
+		case '#':
+			t.Pass = false
+		case 'T':
+			threat = t
+		case '@':
+			origin = t
+		}
+	})
+
+	negated := DijkstraMap([]*Tile{threat}, nil, 0)
+	for tile, d := range negated {
+		negated[tile] = -d
+	}
+	if next := Descend(origin, negated); next == nil || next.Offset.X >= origin.Offset.X {
+		t.Fatalf("negated DijkstraMap did not lead into the dead end")
+	}
+
+	// cornered against the dead end, the FleeMap leads past the threat to
+	// the open corridor beyond
+	cost := func(t *Tile) int {
+		if !t.Pass || t == threat {
+			return -1
+		}
+		return 1
+	}
+	fm := FleeMap([]*Tile{threat}, cost, -1.2)
+	pos := origin
+	for i := 0; i < 4; i++ {
+		if pos = Descend(pos, fm); pos == nil || pos == threat {
+			t.Fatalf("FleeMap led to %v", pos)
+		}
+	}
+	if pos.Offset.X <= threat.Offset.X {
+		t.Errorf("FleeMap led to %v", pos.Offset)
+	}
+}
+
+// pathCost sums the MoveCost along a path from the origin.
+func pathCost(origin *Tile, path []*Tile) float64 {
+	cost, prev := 0.0, origin
+	for _, t := range path {
+		cost, prev = cost+MoveCost(prev, t), t
+	}
+	return cost
+}
+
+// findPathCase generates an 80x50 BSPDungeon with a goal and n origins on
+// random passable Tiles.
+func findPathCase(seed int64, n int) (goal *Tile, origins []*Tile) {
+	g, _ := bspCase(80, 50, seed, BSPOptions{MinLeaf: 6, Jitter: .3})
+	dice := NewDice(rand.NewSource(seed))
+	goal, _ = RandomTile(g, dice, IsPassable)
+	for i := 0; i < n; i++ {
+		origin, _ := RandomTile(g, dice, IsPassable)
+		origins = append(origins, origin)
+	}
+	return goal, origins
+}
+
+func TestFindPath_Weighted(t *testing.T) {
+	for seed := int64(0); seed < 5; seed++ {
+		goal, origins := findPathCase(seed, 10)
+		for _, origin := range origins {
+			exact := FindPath(origin, goal, PathOptions{})
+			weighted := FindPath(origin, goal, PathOptions{Weight: 2})
+			if origin == goal {
+				continue
+			}
+			if !PathValid(weighted) || len(weighted) == 0 || weighted[len(weighted)-1] != goal {
+				t.Errorf("seed %d gave invalid weighted path", seed)
+				continue
+			}
+			if pathCost(origin, weighted) > 2*pathCost(origin, exact)+1e-9 {
+				t.Errorf("seed %d gave weighted path of cost %v for exact %v",
+					seed, pathCost(origin, weighted), pathCost(origin, exact))
+			}
+		}
+	}
+}
+
+func TestFindPath_Budget(t *testing.T) {
+	g, _ := NewGrid(40, 40)
+	for y := 0; y < 30; y++ {
+		g.At(20, y).Pass = false
+	}
+	origin, goal := g.At(0, 0), g.At(39, 0)
+
+	partial := FindPath(origin, goal, PathOptions{Budget: 100})
+	if len(partial) == 0 || !PathValid(append([]*Tile{origin}, partial...)) {
+		t.Fatalf("FindPath gave invalid partial path")
+	}
+	end := partial[len(partial)-1]
+	if end == goal {
+		t.Errorf("FindPath found goal within Budget")
+	}
+	if end.Offset.Sub(goal.Offset).Euclidean() >= origin.Offset.Sub(goal.Offset).Euclidean() {
+		t.Errorf("FindPath partial path led away from goal to %v", end.Offset)
+	}
+
+	if full := FindPath(origin, goal, PathOptions{Budget: 2000}); len(full) == 0 || full[len(full)-1] != goal {
+		t.Errorf("FindPath did not find goal within large Budget")
+	}
+}
+
+func TestFindPath_Shared(t *testing.T) {
+	goal, origins := findPathCase(1, 30)
+	cache := NewPathCache()
+	for _, origin := range origins {
+		exact := FindPath(origin, goal, PathOptions{})
+		shared := FindPath(origin, goal, PathOptions{Shared: cache})
+		if !PathValid(append([]*Tile{origin}, shared...)) {
+			t.Errorf("FindPath gave invalid shared path")
+		}
+		if origin != goal && (len(shared) == 0 || shared[len(shared)-1] != goal) {
+			t.Errorf("FindPath shared path did not reach goal")
+		}
+		if math.Abs(pathCost(origin, shared)-pathCost(origin, exact)) > 1e-9 {
+			t.Errorf("FindPath shared path cost %v != %v", pathCost(origin, shared), pathCost(origin, exact))
+		}
+	}
+
+	// with the Budget spent, the search falls back to a forward search
+	origin := origins[0]
+	if len(FindPath(origin, goal, PathOptions{})) < 10 {
+		t.Fatalf("FindPath case too short to test Budget")
+	}
+	path := FindPath(origin, goal, PathOptions{Shared: NewPathCache(), Budget: 5})
+	if len(path) == 0 || path[len(path)-1] == goal {
+		t.Errorf("FindPath fallback gave path of %d Tiles", len(path))
+	}
+}
+
+// benchmarkFindPath paths 100 monsters to one goal on each iteration.
+func benchmarkFindPath(b *testing.B, opts func() PathOptions) {
+	goal, origins := findPathCase(1, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o := opts()
+		for _, origin := range origins {
+			FindPath(origin, goal, o)
+		}
+	}
+}
+
+func BenchmarkFindPath_Exact(b *testing.B) {
+	benchmarkFindPath(b, func() PathOptions { return PathOptions{} })
+}
+
+func BenchmarkFindPath_Weighted(b *testing.B) {
+	benchmarkFindPath(b, func() PathOptions { return PathOptions{Weight: 2} })
+}
+
+func BenchmarkFindPath_Shared(b *testing.B) {
+	benchmarkFindPath(b, func() PathOptions { return PathOptions{Shared: NewPathCache()} })
+}
+
+func TestSmoothPath(t *testing.T) {
+	tiles := StrGrid{
+		"........",
+		"........",
+		"....#...",
+		"........",
+	}.Convert(func(t *Tile, c byte) {
+		t.Pass = c != '#'
+	})
+	at := func(x, y int) *Tile { return &tiles[x][y] }
+
+	staircase := []*Tile{at(0, 0), at(1, 1), at(2, 1), at(3, 1), at(4, 1), at(5, 1)}
+	if smooth := SmoothPath(staircase, nil); len(smooth) != 2 || smooth[0] != at(0, 0) || smooth[1] != at(5, 1) {
+		t.Errorf("SmoothPath kept %d waypoints of open staircase", len(smooth))
+	}
+
+	// the straight line from (2, 3) to (6, 1) crosses the wall at (4, 2)
+	around := []*Tile{at(2, 3), at(3, 3), at(4, 3), at(5, 2), at(6, 1)}
+	if smooth := SmoothPath(around, nil); len(smooth) != 3 {
+		t.Errorf("SmoothPath kept %d waypoints around wall", len(smooth))
+	}
+
+	if short := SmoothPath(staircase[:2], nil); len(short) != 2 {
+		t.Errorf("SmoothPath changed two step path")
+	}
+}
+
+func TestSmoothPath_CornerCutting(t *testing.T) {
+	tiles := StrGrid{
+		".....",
+		".a#..",
+		".#b..",
+		".....",
+	}.Convert(func(t *Tile, c byte) {
+		t.Pass = c != '#'
+	})
+	at := func(x, y int) *Tile { return &tiles[x][y] }
+	a, b := at(1, 1), at(2, 2)
+
+	if PassLine(a, b) || PassLine(b, a) {
+		t.Errorf("PassLine squeezed diagonally between two walls")
+	}
+
+	path := []*Tile{a, at(2, 0), at(3, 1), b}
+	smooth := SmoothPath(path, nil)
+	for i := 0; i < len(smooth)-1; i++ {
+		if smooth[i] == a && smooth[i+1] == b {
+			t.Errorf("SmoothPath cut the corner between two walls")
+		}
+	}
+	if smooth[0] != a || smooth[len(smooth)-1] != b {
+		t.Errorf("SmoothPath lost its ends")
+	}
+}
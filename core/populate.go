@@ -0,0 +1,179 @@
+package core
+
+// SpawnEntry is a kind of monster or item which Populate may place, as a
+// group of Group Instances of the Prototype, or a single Instance if Group is
+// less than 2. Entries are chosen in proportion to their Weight, and only on
+// depths from MinDepth to MaxDepth, with a MaxDepth of zero meaning no limit.
+// Each group takes Cost from the budget, with a Cost below 1 counting as 1.
+type SpawnEntry struct {
+	Proto              *Prototype
+	Weight             int
+	MinDepth, MaxDepth int
+	Cost               int
+	Group              int
+}
+
+// SpawnTable lists the SpawnEntry which Populate chooses from.
+type SpawnTable []SpawnEntry
+
+// PopulateOpts configures Populate.
+type PopulateOpts struct {
+	// Depth selects the entries of the SpawnTable which apply, and scales the
+	// budget, which is Budget plus DepthBudget for each level of Depth.
+	Depth       int
+	Budget      int
+	DepthBudget int
+
+	// Start, if set, is the player start, and nothing is placed within
+	// MinDistance of it by Chebyshev distance.
+	Start       *Tile
+	MinDistance int
+
+	// Rooms, if set, are the rooms of the Grid, such as from BSPDungeon. Each
+	// group is placed together in a single room, and no more than MaxPerRoom
+	// Instances are placed in any room, unless MaxPerRoom is zero. Without
+	// Rooms, groups are placed around a random Tile.
+	Rooms      []Rect
+	MaxPerRoom int
+
+	// Where holds additional predicates every chosen Tile must satisfy, such
+	// as OutsideFoV to restock a level out of sight of the player.
+	Where []func(*Tile) bool
+
+	// Registry, if set, registers each spawned Instance.
+	Registry *Registry
+}
+
+// populateTries is the number of failed placements after which Populate gives
+// up on the remaining budget.
+const populateTries = 20
+
+// Populate spends a depth scaled budget placing groups chosen from the
+// SpawnTable onto passable, unoccupied Tiles of the Grid using Spawn, and
+// returns every Instance spawned. It may be used while generating a level, or
+// later to restock one, with the Dice used for all random choices, or the
+// global Dice if unset. Placement stops once the budget is spent, nothing
+// affordable remains, or there is repeatedly nowhere left to put a group.
+func Populate(g *Grid, dice Dice, table SpawnTable, opts PopulateOpts) []*Instance {
+	if dice.Rand == nil {
+		dice = globalDice
+	}
+
+	preds := []func(*Tile) bool{IsPassable, Unoccupied}
+	if opts.Start != nil {
+		preds = append(preds, MinDistanceFrom(opts.Start, opts.MinDistance))
+	}
+	preds = append(preds, opts.Where...)
+	match := func(t *Tile) bool {
+		for _, pred := range preds {
+			if !pred(t) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var spawned []*Instance
+	perRoom := make([]int, len(opts.Rooms))
+	budget := opts.Budget + opts.Depth*opts.DepthBudget
+	for failures := 0; failures < populateTries; {
+		entry, ok := chooseSpawn(table, opts.Depth, budget, dice)
+		if !ok {
+			break
+		}
+
+		tiles, room := populateTiles(g, opts, perRoom, match, dice)
+		if len(tiles) == 0 {
+			failures++
+			continue
+		}
+
+		budget -= Max(entry.Cost, 1)
+		for i := 0; i < Max(entry.Group, 1) && i < len(tiles); i++ {
+			e, err := Spawn(entry.Proto, tiles[i], opts.Registry)
+			if err != nil {
+				break
+			}
+			spawned = append(spawned, e)
+			if room >= 0 {
+				perRoom[room]++
+			}
+		}
+	}
+	return spawned
+}
+
+// chooseSpawn picks an entry of the SpawnTable by Weight from those allowed at
+// the depth and within the budget.
+func chooseSpawn(table SpawnTable, depth, budget int, dice Dice) (SpawnEntry, bool) {
+	total := 0
+	allowed := func(e SpawnEntry) bool {
+		return e.Weight > 0 && Max(e.Cost, 1) <= budget && depth >= e.MinDepth && (e.MaxDepth == 0 || depth <= e.MaxDepth)
+	}
+	for _, e := range table {
+		if allowed(e) {
+			total += e.Weight
+		}
+	}
+	if total == 0 {
+		return SpawnEntry{}, false
+	}
+
+	roll := dice.Intn(total)
+	for _, e := range table {
+		if allowed(e) {
+			if roll -= e.Weight; roll < 0 {
+				return e, true
+			}
+		}
+	}
+	return SpawnEntry{}, false
+}
+
+// populateTiles returns Tiles for a group in random order, either the
+// matching Tiles of a random room with space left, limited to the space
+// remaining, or a random matching Tile followed by matching Tiles around it.
+// The index of the room is also returned, or -1 without Rooms.
+func populateTiles(g *Grid, opts PopulateOpts, perRoom []int, match func(*Tile) bool, dice Dice) ([]*Tile, int) {
+	var tiles []*Tile
+	if len(opts.Rooms) == 0 {
+		leader, err := RandomTile(g, dice, match)
+		if err != nil {
+			return nil, -1
+		}
+		tiles = append(tiles, leader)
+		for _, delta := range descentOrder {
+			if adj, ok := leader.Adjacent[delta]; ok && match(adj) {
+				tiles = append(tiles, adj)
+			}
+		}
+		dice.Shuffle(len(tiles)-1, func(i, j int) {
+			tiles[i+1], tiles[j+1] = tiles[j+1], tiles[i+1]
+		})
+		return tiles, -1
+	}
+
+	var open []int
+	for r := range opts.Rooms {
+		if opts.MaxPerRoom == 0 || perRoom[r] < opts.MaxPerRoom {
+			open = append(open, r)
+		}
+	}
+	dice.Shuffle(len(open), func(i, j int) { open[i], open[j] = open[j], open[i] })
+	for _, r := range open {
+		for _, t := range g.TilesInRect(opts.Rooms[r]) {
+			if match(t) {
+				tiles = append(tiles, t)
+			}
+		}
+		if len(tiles) == 0 {
+			continue
+		}
+		dice.Shuffle(len(tiles), func(i, j int) { tiles[i], tiles[j] = tiles[j], tiles[i] })
+		if opts.MaxPerRoom > 0 && len(tiles) > opts.MaxPerRoom-perRoom[r] {
+			tiles = tiles[:opts.MaxPerRoom-perRoom[r]]
+		}
+		return tiles, r
+	}
+	return nil, -1
+}
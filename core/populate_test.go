@@ -0,0 +1,85 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPopulate_Budget(t *testing.T) {
+	shallow := &Prototype{Name: "rat"}
+	deep := &Prototype{Name: "dragon"}
+	table := SpawnTable{
+		{Proto: shallow, Weight: 1, MaxDepth: 2},
+		{Proto: deep, Weight: 10, MinDepth: 3},
+	}
+	g, _ := NewGrid(10, 10)
+	start := g.At(0, 0)
+	reg := NewRegistry()
+	opts := PopulateOpts{Depth: 1, Budget: 4, DepthBudget: 1, Start: start, MinDistance: 5, Registry: reg}
+
+	spawned := Populate(g, NewDice(rand.NewSource(1)), table, opts)
+	if len(spawned) != 5 {
+		t.Fatalf("Populate() spawned %d != 5", len(spawned))
+	}
+	for _, e := range spawned {
+		if e.Proto != shallow {
+			t.Errorf("Populate() spawned %v at depth 1", e)
+		}
+		if _, ok := reg.ID(e); !ok {
+			t.Errorf("Populate() did not register %v", e)
+		}
+	}
+	g.Each(func(o Offset, tile *Tile) {
+		if tile.Occupant != nil && o.Chebyshev() < 5 {
+			t.Errorf("Populate() spawned within %d of start", o.Chebyshev())
+		}
+	})
+}
+
+func TestPopulate_Rooms(t *testing.T) {
+	table := SpawnTable{{Proto: &Prototype{Name: "orc"}, Weight: 1, Group: 2}}
+	g, _ := NewGrid(20, 10)
+	rooms := []Rect{{1, 1, 4, 4}, {10, 1, 4, 4}}
+	opts := PopulateOpts{Budget: 100, Rooms: rooms, MaxPerRoom: 3}
+
+	spawned := Populate(g, NewDice(rand.NewSource(1)), table, opts)
+	if len(spawned) != 6 {
+		t.Errorf("Populate() spawned %d != 6", len(spawned))
+	}
+	counts := make([]int, len(rooms))
+	g.Each(func(o Offset, tile *Tile) {
+		if tile.Occupant == nil {
+			return
+		}
+		placed := false
+		for r, room := range rooms {
+			if room.Contains(o) {
+				counts[r]++
+				placed = true
+			}
+		}
+		if !placed {
+			t.Errorf("Populate() spawned outside rooms at %v", o)
+		}
+	})
+	if counts[0] != 3 || counts[1] != 3 {
+		t.Errorf("Populate() spawned %v per room", counts)
+	}
+}
+
+func TestPopulate_Where(t *testing.T) {
+	table := SpawnTable{{Proto: &Prototype{Name: "orc"}, Weight: 1, Cost: 2, Group: 3}}
+	g, _ := NewGrid(10, 10)
+	east := func(tile *Tile) bool { return tile.Offset.X >= 8 }
+	opts := PopulateOpts{Budget: 5, Where: []func(*Tile) bool{east}}
+
+	spawned := Populate(g, NewDice(rand.NewSource(1)), table, opts)
+	if len(spawned) != 6 {
+		t.Errorf("Populate() spawned %d != 6", len(spawned))
+	}
+	g.Each(func(o Offset, tile *Tile) {
+		if tile.Occupant != nil && !east(tile) {
+			t.Errorf("Populate() ignored Where at %v", o)
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"strings"
+)
+
+// TileSpec describes how ParseGrid sets up the Tile for a rune. If Terrain is
+// set, the Tile is given that Terrain, and otherwise Face, Pass and Lite are
+// used as is, with a Cost of 1. If Mark is set, the Tile is collected under
+// that name, such as "start" for the player. Setup, if any, is called last,
+// such as to place a Door on the Tile.
+type TileSpec struct {
+	Terrain TerrainID
+	Face    Glyph
+	Pass    bool
+	Lite    bool
+	Mark    string
+	Setup   func(*Tile)
+}
+
+// apply sets up a Tile according to the TileSpec, ignoring Mark.
+func (spec TileSpec) apply(t *Tile) {
+	if spec.Terrain != "" {
+		t.SetTerrain(spec.Terrain)
+	} else {
+		t.Terrain, t.Cost = "", 1
+		t.Face, t.Pass, t.Lite = spec.Face, spec.Pass, spec.Lite
+	}
+	if spec.Setup != nil {
+		spec.Setup(t)
+	}
+}
+
+// DefaultLegend is the legend used by ParseGrid when none is given, with '#'
+// for wall, '.' for floor, '+' for a closed Door, '@' for the player start,
+// which is marked "start", '>' and '<' for stairs, marked "down" and "up",
+// and the Terrain glyphs '~' for water, '"' for grass and ':' for rubble.
+var DefaultLegend = map[rune]TileSpec{
+	'#': {Terrain: TerrainWall},
+	'.': {Terrain: TerrainFloor},
+	'+': {Terrain: TerrainFloor, Setup: func(t *Tile) { NewDoor(t) }},
+	'@': {Terrain: TerrainFloor, Mark: "start"},
+	'>': {Terrain: TerrainFloor, Mark: "down"},
+	'<': {Terrain: TerrainFloor, Mark: "up"},
+	'~': {Terrain: TerrainWater},
+	'"': {Terrain: TerrainGrass},
+	':': {Terrain: TerrainRubble},
+}
+
+// ParseGrid builds a Grid from a map drawn as text, with one line per row,
+// such as a raw string literal in a test or a prefab room. Blank lines before
+// and after the map are ignored, as are tabs at the start of each line, so the
+// map may be indented along with the surrounding code. Each rune is looked up
+// in the legend, or DefaultLegend if nil, and the Tiles of every marked rune
+// are returned by name in the order of Grid.Each.
+//
+// If the rows differ in length or there are none, ErrInvalidDimensions is
+// returned, and if a rune is missing from the legend, ErrUnknownRune.
+func ParseGrid(s string, legend map[rune]TileSpec) (*Grid, map[string][]*Tile, error) {
+	if legend == nil {
+		legend = DefaultLegend
+	}
+
+	rows, err := parseRows(s, legend)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g, _ := NewGrid(len(rows[0]), len(rows))
+	marks := make(map[string][]*Tile)
+	g.Each(func(o Offset, t *Tile) {
+		spec := legend[rows[o.Y][o.X]]
+		spec.apply(t)
+		if spec.Mark != "" {
+			marks[spec.Mark] = append(marks[spec.Mark], t)
+		}
+	})
+	return g, marks, nil
+}
+
+// parseRows splits a map drawn as text into rows of runes as described by
+// ParseGrid, checking that the rows are the same length and that every rune
+// is in the legend.
+func parseRows(s string, legend map[rune]TileSpec) ([][]rune, error) {
+	var rows [][]rune
+	for _, line := range strings.Split(s, "\n") {
+		rows = append(rows, []rune(strings.TrimRight(strings.TrimLeft(line, "\t"), "\r")))
+	}
+	for len(rows) > 0 && len(strings.TrimSpace(string(rows[0]))) == 0 {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && len(strings.TrimSpace(string(rows[len(rows)-1]))) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) == 0 {
+		return nil, ErrInvalidDimensions
+	}
+	for _, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, ErrInvalidDimensions
+		}
+		for _, r := range row {
+			if _, ok := legend[r]; !ok {
+				return nil, ErrUnknownRune
+			}
+		}
+	}
+	return rows, nil
+}
+
+// Prefab is a hand drawn piece of map, such as a vault, which a generator can
+// stamp into a Grid with PlacePrefab. Spaces in the drawing are transparent,
+// leaving the Tile beneath unchanged.
+type Prefab struct {
+	rows   [][]rune
+	legend map[rune]TileSpec
+
+	// Rotate and Mirror allow the Prefab to be turned by quarter turns and
+	// flipped left to right when placed, to give more variety and more
+	// chances to fit.
+	Rotate bool
+	Mirror bool
+
+	// Fits, if set, accepts Tiles which may be overwritten in addition to
+	// solid rock, such as the floor of a room the Prefab is meant to
+	// furnish.
+	Fits func(*Tile) bool
+}
+
+// NewPrefab parses a Prefab as with ParseGrid, using DefaultLegend if the
+// legend is nil. A space is always transparent, whatever the legend says.
+func NewPrefab(s string, legend map[rune]TileSpec) (*Prefab, error) {
+	if legend == nil {
+		legend = DefaultLegend
+	}
+	check := map[rune]TileSpec{' ': {}}
+	for r, spec := range legend {
+		if r != ' ' {
+			check[r] = spec
+		}
+	}
+	rows, err := parseRows(s, check)
+	if err != nil {
+		return nil, err
+	}
+	return &Prefab{rows: rows, legend: legend}, nil
+}
+
+// Width returns the number of columns of the Prefab as drawn.
+func (p *Prefab) Width() int {
+	return len(p.rows[0])
+}
+
+// Height returns the number of rows of the Prefab as drawn.
+func (p *Prefab) Height() int {
+	return len(p.rows)
+}
+
+// prefabCell is a non-transparent cell of a Prefab after a transform.
+type prefabCell struct {
+	Offset Offset
+	Spec   TileSpec
+}
+
+// cells returns the non-transparent cells of the Prefab turned clockwise by
+// the given number of quarter turns, after mirroring if requested.
+func (p *Prefab) cells(turns int, mirror bool) []prefabCell {
+	var cells []prefabCell
+	w, h := p.Width(), p.Height()
+	for y, row := range p.rows {
+		for x, r := range row {
+			if r == ' ' {
+				continue
+			}
+			o := Offset{x, y}
+			if mirror {
+				o.X = w - 1 - o.X
+			}
+			cw, ch := w, h
+			for i := 0; i < turns; i++ {
+				o = Offset{ch - 1 - o.Y, o.X}
+				cw, ch = ch, cw
+			}
+			cells = append(cells, prefabCell{o, p.legend[r]})
+		}
+	}
+	return cells
+}
+
+// fits reports whether a Tile may be overwritten by the Prefab.
+func (p *Prefab) fits(t *Tile) bool {
+	if t == nil || t.Occupant != nil {
+		return false
+	}
+	return !t.Pass || p.Fits != nil && p.Fits(t)
+}
+
+// PlacePrefab stamps a Prefab into a Grid with its top left corner at the
+// given Offset, setting each Tile with Grid.SetTerrain. Every Tile the Prefab
+// covers must be solid rock, meaning impassable and unoccupied, or be
+// accepted by the Fits of the Prefab. The allowed rotations and mirrorings
+// are tried in a random order from the Dice, or the global Dice if unset, and
+// the first which fits is used. The marked Tiles are returned by name, as with
+// ParseGrid. If no transform fits, ErrNoFit is returned and the Grid is
+// unchanged. If SetTerrain fails, such as because a Setup put an Occupant on
+// a Tile the Prefab then walls over, its error is returned, and the Tiles
+// already set are left as they are.
+func PlacePrefab(g *Grid, p *Prefab, at Offset, dice Dice) (map[string][]*Tile, error) {
+	if dice.Rand == nil {
+		dice = globalDice
+	}
+
+	var transforms []int
+	for i := 0; i < 8; i++ {
+		turns, mirror := i%4, i >= 4
+		if (turns == 0 || p.Rotate) && (!mirror || p.Mirror) {
+			transforms = append(transforms, i)
+		}
+	}
+	dice.Shuffle(len(transforms), func(i, j int) {
+		transforms[i], transforms[j] = transforms[j], transforms[i]
+	})
+
+	for _, i := range transforms {
+		cells := p.cells(i%4, i >= 4)
+		fit := true
+		for _, c := range cells {
+			if !p.fits(g.At(at.X+c.Offset.X, at.Y+c.Offset.Y)) {
+				fit = false
+				break
+			}
+		}
+		if !fit {
+			continue
+		}
+
+		marks := make(map[string][]*Tile)
+		for _, c := range cells {
+			t := g.At(at.X+c.Offset.X, at.Y+c.Offset.Y)
+			if err := g.SetTerrain(t, c.Spec); err != nil {
+				return nil, err
+			}
+			if c.Spec.Mark != "" {
+				marks[c.Spec.Mark] = append(marks[c.Spec.Mark], t)
+			}
+		}
+		return marks, nil
+	}
+	return nil, ErrNoFit
+}
+
+// WeightedPrefab pairs a Prefab with its relative chance of being chosen by
+// PlacePrefabs.
+type WeightedPrefab struct {
+	*Prefab
+	Weight int
+}
+
+// prefabTries is the number of random positions PlacePrefabs tries for each
+// Prefab before giving up on it.
+const prefabTries = 50
+
+// PlacePrefabs places up to n Prefabs chosen from the list by Weight at random
+// positions in the Grid, using PlacePrefab. Prefabs are only placed inside the
+// edge of the Grid, so the edge is left as it was. Each chosen Prefab is given
+// a number of tries to fit before moving on to the next, so fewer than n may
+// be placed in a crowded Grid. The number placed and the marked Tiles of all
+// placed Prefabs are returned, along with any error from PlacePrefab other
+// than ErrNoFit, which stops any further placement.
+func PlacePrefabs(g *Grid, prefabs []WeightedPrefab, n int, dice Dice) (int, map[string][]*Tile, error) {
+	if dice.Rand == nil {
+		dice = globalDice
+	}
+
+	total := 0
+	for _, p := range prefabs {
+		total += Max(p.Weight, 0)
+	}
+	marks := make(map[string][]*Tile)
+	placed := 0
+	if total == 0 {
+		return placed, marks, nil
+	}
+
+	for i := 0; i < n; i++ {
+		var p *Prefab
+		roll := dice.Intn(total)
+		for _, wp := range prefabs {
+			if roll -= Max(wp.Weight, 0); roll < 0 {
+				p = wp.Prefab
+				break
+			}
+		}
+
+		// a Prefab which may be rotated is kept to positions where it fits
+		// either way up, and clear of the edge of the Grid
+		w, h := p.Width(), p.Height()
+		if p.Rotate {
+			w, h = Max(w, h), Max(w, h)
+		}
+		xs, ys := g.Width()-w-1, g.Height()-h-1
+		if xs < 1 || ys < 1 {
+			continue
+		}
+		for try := 0; try < prefabTries; try++ {
+			at := Offset{1 + dice.Intn(xs), 1 + dice.Intn(ys)}
+			m, err := PlacePrefab(g, p, at, dice)
+			if err == ErrNoFit {
+				continue
+			} else if err != nil {
+				return placed, marks, err
+			}
+			for name, tiles := range m {
+				marks[name] = append(marks[name], tiles...)
+			}
+			placed++
+			break
+		}
+	}
+	return placed, marks, nil
+}
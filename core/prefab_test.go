@@ -0,0 +1,182 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseGrid(t *testing.T) {
+	g, marks, err := ParseGrid(`
+		#######
+		#@..+>#
+		#~~"..#
+		#######
+	`, nil)
+	if err != nil {
+		t.Fatalf("ParseGrid() = %v", err)
+	}
+	if g.Width() != 7 || g.Height() != 4 {
+		t.Errorf("ParseGrid() gave %dx%d Grid", g.Width(), g.Height())
+	}
+	if start := marks["start"]; len(start) != 1 || start[0] != g.At(1, 1) {
+		t.Errorf("ParseGrid() marked start %v", start)
+	}
+	if down := marks["down"]; len(down) != 1 || down[0] != g.At(5, 1) {
+		t.Errorf("ParseGrid() marked down %v", down)
+	}
+	if g.At(0, 0).Pass || !g.At(2, 1).Pass || g.At(1, 2).Terrain != TerrainWater {
+		t.Errorf("ParseGrid() did not set terrain")
+	}
+	if _, ok := g.At(4, 1).Occupant.(*Door); !ok || g.At(4, 1).Pass {
+		t.Errorf("ParseGrid() did not place closed Door")
+	}
+	if len(g.At(1, 1).Adjacent) != 8 || len(g.At(0, 0).Adjacent) != 3 {
+		t.Errorf("ParseGrid() did not link Tiles")
+	}
+}
+
+func TestParseGrid_Legend(t *testing.T) {
+	legend := map[rune]TileSpec{
+		'.': {Face: Glyph{'.', ColorGreen}, Pass: true, Lite: true},
+		'o': {Face: Glyph{'.', ColorGreen}, Pass: true, Lite: true, Mark: "orc"},
+	}
+	g, marks, err := ParseGrid("o..\n.o.\n..o", legend)
+	if err != nil {
+		t.Fatalf("ParseGrid() = %v", err)
+	}
+	if orcs := marks["orc"]; len(orcs) != 3 || orcs[1] != g.At(1, 1) {
+		t.Errorf("ParseGrid() marked orcs %v", orcs)
+	}
+	if tile := g.At(2, 0); tile.Face != (Glyph{'.', ColorGreen}) || !tile.Pass {
+		t.Errorf("ParseGrid() did not use legend")
+	}
+}
+
+func TestParseGrid_Errors(t *testing.T) {
+	cases := []struct {
+		s   string
+		err error
+	}{
+		{"", ErrInvalidDimensions},
+		{"\n\t\n", ErrInvalidDimensions},
+		{"###\n##\n###", ErrInvalidDimensions},
+		{"#?#", ErrUnknownRune},
+	}
+	for _, c := range cases {
+		if g, _, err := ParseGrid(c.s, nil); g != nil || err != c.err {
+			t.Errorf("ParseGrid(%q) = %v, %v != %v", c.s, g, err, c.err)
+		}
+	}
+}
+
+func TestPlacePrefab_Mirror(t *testing.T) {
+	g, _, _ := ParseGrid("#.\n##", nil)
+	p, err := NewPrefab(" <\n>.", nil)
+	if err != nil {
+		t.Fatalf("NewPrefab() = %v", err)
+	}
+	if _, err := PlacePrefab(g, p, Offset{0, 0}, Dice{}); err != ErrNoFit {
+		t.Errorf("PlacePrefab() without Mirror = %v", err)
+	}
+
+	p.Mirror = true
+	marks, err := PlacePrefab(g, p, Offset{0, 0}, Dice{})
+	if err != nil {
+		t.Fatalf("PlacePrefab() = %v", err)
+	}
+	if up := marks["up"]; len(up) != 1 || up[0] != g.At(0, 0) {
+		t.Errorf("PlacePrefab() marked up %v", up)
+	}
+	if down := marks["down"]; len(down) != 1 || down[0] != g.At(1, 1) {
+		t.Errorf("PlacePrefab() marked down %v", down)
+	}
+	if !g.At(0, 1).Pass || g.At(1, 0).Terrain != TerrainFloor {
+		t.Errorf("PlacePrefab() did not set terrain")
+	}
+}
+
+func TestPlacePrefab_Rotate(t *testing.T) {
+	g, _, _ := ParseGrid("#\n#\n#", nil)
+	p, _ := NewPrefab("<.>", nil)
+	if _, err := PlacePrefab(g, p, Offset{0, 0}, Dice{}); err != ErrNoFit {
+		t.Errorf("PlacePrefab() without Rotate = %v", err)
+	}
+
+	p.Rotate = true
+	marks, err := PlacePrefab(g, p, Offset{0, 0}, Dice{})
+	if err != nil {
+		t.Fatalf("PlacePrefab() = %v", err)
+	}
+	ends := []*Tile{marks["up"][0], marks["down"][0]}
+	if !(ends[0] == g.At(0, 0) && ends[1] == g.At(0, 2)) && !(ends[0] == g.At(0, 2) && ends[1] == g.At(0, 0)) {
+		t.Errorf("PlacePrefab() marked ends %v", ends)
+	}
+	if !g.At(0, 1).Pass {
+		t.Errorf("PlacePrefab() did not set terrain")
+	}
+}
+
+func TestPlacePrefab_Fits(t *testing.T) {
+	g, _, _ := ParseGrid("...\n.@.", nil)
+	p, _ := NewPrefab("~~", nil)
+	if _, err := PlacePrefab(g, p, Offset{0, 0}, Dice{}); err != ErrNoFit {
+		t.Errorf("PlacePrefab() over floor = %v", err)
+	}
+	if _, err := PlacePrefab(g, p, Offset{2, 0}, Dice{}); err != ErrNoFit {
+		t.Errorf("PlacePrefab() off Grid = %v", err)
+	}
+	if g.At(0, 0).Terrain != TerrainFloor {
+		t.Errorf("PlacePrefab() changed Grid without fitting")
+	}
+
+	p.Fits = func(t *Tile) bool { return t.Terrain == TerrainFloor }
+	g.At(1, 1).Occupant = &testentity{}
+	if _, err := PlacePrefab(g, p, Offset{0, 1}, Dice{}); err != ErrNoFit {
+		t.Errorf("PlacePrefab() over Occupant = %v", err)
+	}
+	if _, err := PlacePrefab(g, p, Offset{1, 0}, Dice{}); err != nil {
+		t.Errorf("PlacePrefab() with Fits = %v", err)
+	}
+	if g.At(1, 0).Terrain != TerrainWater || g.At(2, 0).Terrain != TerrainWater {
+		t.Errorf("PlacePrefab() did not set terrain")
+	}
+}
+
+func TestPlacePrefabs(t *testing.T) {
+	vault, _ := NewPrefab("#####\n#.>.#\n#####", nil)
+	vault.Rotate = true
+	pool, _ := NewPrefab("~~", nil)
+	prefabs := []WeightedPrefab{{vault, 1}, {pool, 3}, {pool, 0}}
+
+	g, _ := NewGrid(30, 20)
+	g.Each(func(_ Offset, tile *Tile) { tile.SetTerrain(TerrainWall) })
+	placed, marks, err := PlacePrefabs(g, prefabs, 4, NewDice(rand.NewSource(1)))
+	if placed != 4 || err != nil {
+		t.Errorf("PlacePrefabs() placed %d, %v", placed, err)
+	}
+	water := g.Count(func(tile *Tile) bool { return tile.Terrain == TerrainWater })
+	if vaults := len(marks["down"]); water != 2*(placed-vaults) {
+		t.Errorf("PlacePrefabs() gave %d water with %d vaults", water, vaults)
+	}
+	g.Each(func(o Offset, tile *Tile) {
+		edge := o.X == 0 || o.Y == 0 || o.X == g.Width()-1 || o.Y == g.Height()-1
+		if edge && tile.Terrain != TerrainWall {
+			t.Errorf("PlacePrefabs() changed edge %v", o)
+		}
+	})
+}
+
+func TestPlacePrefabs_Occupied(t *testing.T) {
+	g, _ := NewGrid(4, 3)
+	g.Each(func(_ Offset, tile *Tile) { tile.SetTerrain(TerrainWall) })
+	// the Setup of 'o' puts an Occupant where the following '#' goes
+	legend := map[rune]TileSpec{
+		'o': {Terrain: TerrainFloor, Setup: func(*Tile) { g.At(2, 1).Occupant = &testentity{} }},
+		'#': {Terrain: TerrainWall},
+	}
+	p, _ := NewPrefab("o#", legend)
+	_, _, err := PlacePrefabs(g, []WeightedPrefab{{p, 1}}, 1, NewDice(rand.NewSource(1)))
+	if err != ErrOccupied {
+		t.Errorf("PlacePrefabs() over new Occupant = %v", err)
+	}
+}
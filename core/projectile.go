@@ -0,0 +1,104 @@
+package core
+
+// Projectile is an Entity which flies along a fixed path, such as a thrown
+// potion or an arrow. On each Act, the Projectile advances up to Speed Tiles
+// along its path. If the next Tile is occupied, the Payload is sent to the
+// occupant. If the next Tile is impassable, or the path runs out, the Payload
+// is sent to the current Tile instead, so that a Blast can be centered there.
+// Once the Payload is delivered, the Projectile removes itself from the
+// Scheduler, if any.
+//
+// The path is fixed when the Projectile is created, so if the target moves
+// out of the way, the Projectile simply continues along the original line.
+type Projectile struct {
+	Pos       *Tile
+	Path      []Offset
+	Face      Glyph
+	Speed     int
+	Payload   Event
+	Scheduler *Scheduler
+	Done      bool
+
+	// Show is called with each Tile the Projectile passes through, so that
+	// the flight can be drawn, such as by sending a Mark to a CameraWidget.
+	Show func(pos *Tile, face Glyph)
+}
+
+// NewProjectile creates a Projectile which flies from the origin toward the
+// goal along the line computed by Trace, continuing past the goal until it has
+// flown rng Tiles. If the goal is the origin, the path is empty, and the
+// Payload is delivered to the origin on the first Act.
+func NewProjectile(origin, goal *Tile, rng int, face Glyph, speed int, payload Event) *Projectile {
+	path := traceSteps(goal.Offset.Sub(origin.Offset), rng)
+	return &Projectile{Pos: origin, Path: path, Face: face, Speed: speed, Payload: payload}
+}
+
+// traceSteps computes the single steps along the line computed by Trace in the
+// direction of delta, continuing past delta until the line is rng Tiles long.
+func traceSteps(delta Offset, rng int) []Offset {
+	if dist := delta.Chebyshev(); dist > 0 && dist < rng {
+		delta = delta.Scale((rng + dist - 1) / dist)
+	}
+
+	var path []Offset
+	prev := Offset{}
+	for _, o := range Trace(delta) {
+		if o.Chebyshev() > rng {
+			break
+		}
+		path = append(path, o.Sub(prev))
+		prev = o
+	}
+	return path
+}
+
+// Handle implements Entity for Projectile.
+func (p *Projectile) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		v.Render = p.Face
+	case *Act:
+		for i := 0; i < Max(p.Speed, 1) && !p.Done; i++ {
+			p.advance()
+		}
+	}
+}
+
+// advance moves the Projectile a single Tile, delivering the Payload if it
+// hits something.
+func (p *Projectile) advance() {
+	if len(p.Path) == 0 {
+		p.deliver(p.Pos)
+		return
+	}
+
+	next, ok := p.Pos.Adjacent[p.Path[0]]
+	switch {
+	case !ok || !next.Pass:
+		p.deliver(p.Pos)
+	case next.Occupant != nil:
+		p.show(next)
+		p.deliver(next.Occupant)
+	default:
+		p.Pos, p.Path = next, p.Path[1:]
+		p.show(next)
+	}
+}
+
+// show calls Show, if set.
+func (p *Projectile) show(pos *Tile) {
+	if p.Show != nil {
+		p.Show(pos, p.Face)
+	}
+}
+
+// deliver sends the Payload to the target and removes the Projectile.
+func (p *Projectile) deliver(target Entity) {
+	p.Done = true
+	if p.Payload != nil {
+		send(target, p.Payload)
+	}
+	if p.Scheduler != nil {
+		p.Scheduler.Remove(p)
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestProjectile(t *testing.T) {
+	tiles := NewTileGrid(6, 1, Offset{}, NewTile)
+	tiles[5].Pass = false
+	target := newtestfighter(0, 0, 10)
+
+	cases := []struct {
+		origin, goal, occupied *Tile
+		rng                    int
+		moves                  bool // whether the target moves after firing
+		hp                     int
+		pos                    *Tile
+	}{
+		{tiles[0], tiles[3], tiles[3], 5, false, 7, tiles[2]},
+		{tiles[0], tiles[3], tiles[3], 5, true, 7, tiles[3]},
+		{tiles[0], tiles[2], tiles[4], 5, false, 7, tiles[3]},
+		{tiles[0], tiles[1], tiles[4], 2, false, 10, tiles[2]},
+		{tiles[2], tiles[5], tiles[1], 5, false, 10, tiles[4]},
+		{tiles[0], tiles[0], tiles[1], 5, false, 10, tiles[0]},
+	}
+	for i, c := range cases {
+		for _, tile := range tiles {
+			tile.Occupant = nil
+		}
+		target.health.HP = 10
+		c.occupied.Occupant = target
+
+		scheduler := NewScheduler()
+		p := NewProjectile(c.origin, c.goal, c.rng, Glyph{')', ColorWhite}, 2, &Damage{Amount: 3})
+		p.Scheduler = scheduler
+		var shown []*Tile
+		p.Show = func(pos *Tile, face Glyph) { shown = append(shown, pos) }
+		scheduler.Add(p, 100)
+
+		if c.moves {
+			c.occupied.Occupant, tiles[4].Occupant = nil, target
+		}
+		for j := 0; j < 5; j++ {
+			scheduler.Tick()
+		}
+
+		if !p.Done || scheduler.Contains(p) {
+			t.Errorf("case %d: Projectile not removed", i)
+		}
+		if target.health.HP != c.hp || p.Pos != c.pos {
+			t.Errorf("case %d: hp %d at %v", i, target.health.HP, p.Pos.Offset)
+		}
+		if len(shown) > 0 && shown[0] != c.origin.Adjacent[Offset{1, 0}] {
+			t.Errorf("case %d: shown %v", i, shown)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+)
+
+// ComponentFactory creates a fresh Component for a newly spawned Entity.
+type ComponentFactory func(self Entity) Component
+
+// ProtoComponent is a named ComponentFactory in a Prototype. A Prototype which
+// extends another replaces any ProtoComponent of the same Name.
+type ProtoComponent struct {
+	Name string
+	New  ComponentFactory
+}
+
+// Prototype describes a kind of Entity, such as an orc, so that many of them
+// can be created with Spawn. A Prototype may extend a Parent, inheriting its
+// Components, and the rune and Color of its Face if they are unset. Components
+// of the child replace those of the Parent with the same Name, and any others
+// are added after those of the Parent.
+type Prototype struct {
+	Name       string
+	Face       Glyph
+	Parent     *Prototype
+	Components []ProtoComponent
+}
+
+// face returns the Face of the Prototype, with the rune and Color each
+// inherited from the Parent if unset.
+func (p *Prototype) face() Glyph {
+	face := p.Face
+	if p.Parent != nil {
+		parent := p.Parent.face()
+		if face.Ch == 0 {
+			face.Ch = parent.Ch
+		}
+		if face.Fg == 0 {
+			face.Fg = parent.Fg
+		}
+	}
+	return face
+}
+
+// components returns the resolved Components of the Prototype.
+func (p *Prototype) components() []ProtoComponent {
+	var resolved []ProtoComponent
+	if p.Parent != nil {
+		resolved = p.Parent.components()
+	}
+	for _, c := range p.Components {
+		replaced := false
+		for i := range resolved {
+			if resolved[i].Name == c.Name {
+				resolved[i], replaced = c, true
+			}
+		}
+		if !replaced {
+			resolved = append(resolved, c)
+		}
+	}
+	return resolved
+}
+
+// Instance is an Entity spawned from a Prototype. It renders as the Face of
+// its Prototype unless one of its Components renders otherwise.
+type Instance struct {
+	EntityMut
+	Proto *Prototype
+	Face  Glyph
+}
+
+// Handle implements Entity for Instance.
+func (e *Instance) Handle(v Event) {
+	if v, ok := v.(*RenderRequest); ok {
+		v.Render = e.Face
+	}
+	e.EntityMut.Handle(v)
+}
+
+// String implements fmt.Stringer for Instance.
+func (e *Instance) String() string {
+	return e.Proto.Name
+}
+
+// Spawn creates a new Instance of a Prototype with fresh Components, and
+// registers it with the Registry, if any. If a Tile is given, the Instance is
+// placed on it, or on the nearest free Tile if it is occupied, just as if it
+// had moved there with MoveEntity, so it is sent an UpdatePos and the Trigger
+// of the Tile, if any, is sent an Entered. If there is no free Tile nearby,
+// nothing is registered and ErrNoTile is returned.
+func Spawn(proto *Prototype, at *Tile, reg *Registry) (*Instance, error) {
+	var pos *Tile
+	if at != nil {
+		if pos = freeTile(at); pos == nil {
+			return nil, ErrNoTile
+		}
+	}
+
+	e := &Instance{Proto: proto, Face: proto.face()}
+	for _, c := range proto.components() {
+		e.Attach(c.New(e))
+	}
+	if reg != nil {
+		reg.Register(e)
+	}
+	if pos != nil {
+		pos.occupy(e)
+		send(e, &UpdatePos{pos})
+		pos.enter()
+	}
+	return e, nil
+}
+
+// ComponentConstructor parses the parameters of a Component from a data file,
+// and returns a ComponentFactory which creates it.
+type ComponentConstructor func(params json.RawMessage) (ComponentFactory, error)
+
+// constructors holds the ComponentConstructor available to LoadPrototypes.
+var constructors = make(map[string]ComponentConstructor)
+
+// RegisterConstructor makes a ComponentConstructor available to LoadPrototypes
+// under the given name.
+func RegisterConstructor(name string, c ComponentConstructor) {
+	constructors[name] = c
+}
+
+// protodata is the data file form of a Prototype.
+type protodata struct {
+	Name       string
+	Extends    string
+	Glyph      string
+	Color      string
+	Components []struct {
+		Name   string
+		Params json.RawMessage
+	}
+}
+
+// LoadPrototypes reads a JSON object mapping keys to Prototypes, such as:
+//
+// 	{
+// 		"orc": {"name": "orc", "glyph": "o", "color": "green",
+// 			"components": [{"name": "health", "params": {"max": 10}}]},
+// 		"elite orc": {"extends": "orc", "name": "elite orc", "color": "red",
+// 			"components": [{"name": "health", "params": {"max": 20}}]}
+// 	}
+//
+// Each component is created by the ComponentConstructor registered under its
+// name. Colors are named as in MarkupColors. If the name of a Prototype is
+// omitted, its key is used. A Prototype may extend any other Prototype in the
+// same file by its key. ErrUnknownPrototype is returned if it extends a key
+// which is not in the file, and ErrPrototypeCycle if it extends itself, either
+// directly or through other Prototypes.
+func LoadPrototypes(r io.Reader) (map[string]*Prototype, error) {
+	var data map[string]protodata
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	protos := make(map[string]*Prototype, len(data))
+	for key, d := range data {
+		proto := &Prototype{Name: d.Name}
+		if proto.Name == "" {
+			proto.Name = key
+		}
+		if d.Glyph != "" {
+			proto.Face.Ch, _ = utf8.DecodeRuneInString(d.Glyph)
+			proto.Face.Fg = ColorWhite
+		}
+		if d.Color != "" {
+			color, ok := MarkupColors[d.Color]
+			if !ok {
+				return nil, ErrUnknownColor
+			}
+			proto.Face.Fg = color
+		}
+		for _, c := range d.Components {
+			constructor, ok := constructors[c.Name]
+			if !ok {
+				return nil, ErrUnknownConstructor
+			}
+			factory, err := constructor(c.Params)
+			if err != nil {
+				return nil, err
+			}
+			proto.Components = append(proto.Components, ProtoComponent{c.Name, factory})
+		}
+		protos[key] = proto
+	}
+
+	// link parents only once every Prototype exists, checking for cycles
+	for key, d := range data {
+		if d.Extends == "" {
+			continue
+		}
+		parent, ok := protos[d.Extends]
+		if !ok {
+			return nil, ErrUnknownPrototype
+		}
+		for p := parent; p != nil; p = p.Parent {
+			if p == protos[key] {
+				return nil, ErrPrototypeCycle
+			}
+		}
+		protos[key].Parent = parent
+	}
+	return protos, nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterConstructor("health", func(params json.RawMessage) (ComponentFactory, error) {
+		var p struct{ Max int }
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return func(self Entity) Component { return NewHealth(p.Max) }, nil
+	})
+	RegisterConstructor("combat", func(params json.RawMessage) (ComponentFactory, error) {
+		var p struct{ Power int }
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return func(self Entity) Component { return &Combat{Self: self, Power: p.Power} }, nil
+	})
+}
+
+// protoHealth finds the Health of a spawned Instance.
+func protoHealth(e *Instance) *Health {
+	for _, c := range e.Components() {
+		if h, ok := c.(*Health); ok {
+			return h
+		}
+	}
+	return nil
+}
+
+func TestLoadPrototypes(t *testing.T) {
+	protos, err := LoadPrototypes(strings.NewReader(`{
+		"orc": {"glyph": "o", "color": "green", "components": [
+			{"name": "health", "params": {"max": 10}},
+			{"name": "combat", "params": {"power": 2}}
+		]},
+		"elite": {"extends": "orc", "name": "elite orc", "color": "red", "components": [
+			{"name": "health", "params": {"max": 20}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPrototypes() = %v", err)
+	}
+
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	reg := NewRegistry()
+	orc1, _ := Spawn(protos["orc"], tiles[1], reg)
+	orc2, _ := Spawn(protos["orc"], tiles[1], reg)
+	elite, _ := Spawn(protos["elite"], tiles[1], reg)
+	if e, err := Spawn(protos["orc"], tiles[1], reg); e != nil || err != ErrNoTile {
+		t.Errorf("Spawn() onto full map = %v, %v", e, err)
+	}
+
+	if tiles[1].Occupant != orc1 || tiles[2].Occupant != orc2 || tiles[0].Occupant != elite {
+		t.Errorf("Spawn placed %v", []Entity{tiles[0].Occupant, tiles[1].Occupant, tiles[2].Occupant})
+	}
+	if reg.Len() != 3 {
+		t.Errorf("Spawn registered %d entities", reg.Len())
+	}
+	if protoHealth(orc1) == protoHealth(orc2) || protoHealth(orc1).Max != 10 {
+		t.Errorf("Spawn did not create fresh components")
+	}
+	if protoHealth(elite).Max != 20 || len(elite.Components()) != 2 {
+		t.Errorf("elite orc did not override health")
+	}
+
+	cases := []struct {
+		e        *Instance
+		name     string
+		expected Glyph
+	}{
+		{orc1, "orc", Glyph{'o', ColorGreen}},
+		{elite, "elite orc", Glyph{'o', ColorRed}},
+	}
+	for _, c := range cases {
+		render := RenderRequest{}
+		if c.e.Handle(&render); render.Render != c.expected || c.e.String() != c.name {
+			t.Errorf("%s rendered as %v", c.e, render.Render)
+		}
+	}
+}
+
+func TestSpawn_Trigger(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	var entered []Entity
+	tiles[1].Trigger = ComponentSlice{On(func(v *Entered) { entered = append(entered, v.Who) })}
+
+	orc, err := Spawn(&Prototype{Name: "orc"}, tiles[1], nil)
+	if err != nil || tiles[1].Occupant != orc {
+		t.Fatalf("Spawn() = %v, %v", orc, err)
+	}
+	if len(entered) != 1 || entered[0] != orc {
+		t.Errorf("Spawn() sent Entered for %v", entered)
+	}
+}
+
+func TestLoadPrototypes_Errors(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected error
+	}{
+		{`{"a": {"extends": "b"}}`, ErrUnknownPrototype},
+		{`{"a": {"extends": "a"}}`, ErrPrototypeCycle},
+		{`{"a": {"extends": "b"}, "b": {"extends": "a"}}`, ErrPrototypeCycle},
+		{`{"a": {"components": [{"name": "wings"}]}}`, ErrUnknownConstructor},
+		{`{"a": {"color": "plaid"}}`, ErrUnknownColor},
+	}
+	for _, c := range cases {
+		if _, err := LoadPrototypes(strings.NewReader(c.data)); err != c.expected {
+			t.Errorf("LoadPrototypes(%s) = %v != %v", c.data, err, c.expected)
+		}
+	}
+}
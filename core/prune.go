@@ -0,0 +1,69 @@
+package core
+
+// PruneDeadEnds fills in dead ends with Grid.Fill, shortening each dead end
+// corridor by one Tile per iteration, and returns the number of Tiles filled.
+// If iterations is zero or less, dead ends are pruned until none remain, which
+// leaves only loops and the passages between them.
+//
+// A dead end is a passable Tile with a single passable orthogonal neighbor,
+// whose passable diagonal neighbors, if any, are next to that neighbor as well,
+// so filling it never cuts off anything else. Tiles with an Occupant, Items,
+// an Overlap or a Trigger, such as Doors and Stairs, are never filled, nor are
+// any for which a protected predicate is true.
+func PruneDeadEnds(g *Grid, iterations int, protected ...func(*Tile) bool) int {
+	return pruneDeadEnds(g, iterations, func(t *Tile) { g.Fill(t) }, protected)
+}
+
+// pruneDeadEnds implements PruneDeadEnds using the given fill function, so
+// that generators can fill using their Paint.
+func pruneDeadEnds(g *Grid, iterations int, fill func(*Tile), protected []func(*Tile) bool) int {
+	deadEnd := func(t *Tile) bool {
+		if !t.Pass || t.Occupant != nil || len(t.Items) > 0 || len(t.Overlap) > 0 || t.Trigger != nil {
+			return false
+		}
+		for _, pred := range protected {
+			if pred(t) {
+				return false
+			}
+		}
+
+		var exit *Tile
+		for _, delta := range descentOrder[:4] {
+			if adj, ok := t.Adjacent[delta]; ok && adj.Pass {
+				if exit != nil {
+					return false
+				}
+				exit = adj
+			}
+		}
+		if exit == nil {
+			return false
+		}
+		for _, delta := range descentOrder[4:] {
+			if adj, ok := t.Adjacent[delta]; ok && adj.Pass && adj.Offset.Sub(exit.Offset).Chebyshev() > 1 {
+				return false
+			}
+		}
+		return true
+	}
+
+	filled := 0
+	for i := 0; iterations <= 0 || i < iterations; i++ {
+		// every dead end is found before any is filled, so that each
+		// iteration shortens each dead end by exactly one Tile
+		ends := g.FindAll(deadEnd)
+		if len(ends) == 0 {
+			break
+		}
+		progress := false
+		for _, t := range ends {
+			if fill(t); !t.Pass {
+				filled, progress = filled+1, true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return filled
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPruneDeadEnds(t *testing.T) {
+	g := gridCase(
+		"#########",
+		"#...#####",
+		"#.#....##",
+		"#...#####",
+		"#########",
+	)
+	if filled := PruneDeadEnds(g, 1); filled != 1 || g.At(6, 2).Pass || !g.At(5, 2).Pass {
+		t.Errorf("PruneDeadEnds(1) filled %d", filled)
+	}
+	// the loop has no dead ends, so is left alone
+	if filled := PruneDeadEnds(g, 0); filled != 2 || g.At(4, 2).Pass {
+		t.Errorf("PruneDeadEnds(0) filled %d", filled)
+	}
+	if count := g.Count(isPass); count != 8 {
+		t.Errorf("PruneDeadEnds() left %d passable Tiles", count)
+	}
+}
+
+func TestPruneDeadEnds_Protected(t *testing.T) {
+	g := gridCase(
+		"#######",
+		"#.....#",
+		"#######",
+	)
+	g.At(1, 1).Occupant = &testentity{}
+	protected := func(tile *Tile) bool { return tile == g.At(5, 1) }
+	if filled := PruneDeadEnds(g, 0, protected); filled != 0 {
+		t.Errorf("PruneDeadEnds() filled protected ends")
+	}
+
+	g.At(1, 1).Occupant = nil
+	if filled := PruneDeadEnds(g, 0, protected); filled != 4 || !g.At(5, 1).Pass {
+		t.Errorf("PruneDeadEnds() filled %d", filled)
+	}
+}
+
+func TestGridMaze_Prune(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := NewGrid(21, 15)
+		opts := GridMazeOptions{Braid: .5, Prune: -1, Dice: NewDice(rand.NewSource(seed))}
+		entrance, exit, err := GridMaze(g, opts)
+		if err != nil {
+			t.Fatalf("GridMaze() = %v", err)
+		}
+		if !entrance.Pass || !exit.Pass {
+			t.Errorf("seed %d pruned entrance or exit", seed)
+		}
+		ends := g.Count(func(tile *Tile) bool { return tile.Pass && mazeExits(tile) == 1 && tile != entrance })
+		if ends != 0 {
+			t.Errorf("seed %d left %d dead ends", seed, ends)
+		}
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+	}
+}
+
+func TestBSPDungeon_Prune(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, rooms := bspCase(60, 40, seed, BSPOptions{MinLeaf: 6, Jitter: .3, Prune: -1})
+		if regions := Regions(g, isPass); len(regions) != 1 {
+			t.Errorf("seed %d left %d regions", seed, len(regions))
+		}
+		for _, room := range rooms {
+			for _, tile := range g.TilesInRect(room) {
+				if !tile.Pass {
+					t.Errorf("seed %d pruned room %v", seed, room)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+// posted is an Event waiting in an EventQueue along with its target.
+type posted struct {
+	Target Entity
+	Event  Event
+}
+
+// EventQueue defers delivery of Events, so that handling one Event can
+// trigger another without recursively calling Handle while an outer Handle is
+// still running. Events are delivered in the order they are posted.
+type EventQueue struct {
+	events []posted
+
+	// Limit is the maximum number of Events delivered by a single Drain, and
+	// is used to catch Events which endlessly trigger each other.
+	Limit int
+}
+
+// NewEventQueue creates an empty EventQueue with a default Limit.
+func NewEventQueue() *EventQueue {
+	return &EventQueue{nil, 10000}
+}
+
+// Post adds an Event to the queue to be delivered to the target by Drain.
+func (q *EventQueue) Post(target Entity, v Event) {
+	q.events = append(q.events, posted{target, v})
+}
+
+// Len returns the number of Events waiting in the queue.
+func (q *EventQueue) Len() int {
+	return len(q.events)
+}
+
+// Drain delivers queued Events until the queue is empty, including any Events
+// posted while draining. If more than Limit Events are delivered, the rest of
+// the queue is discarded and ErrEventOverflow is returned.
+func (q *EventQueue) Drain() error {
+	for delivered := 0; len(q.events) > 0; delivered++ {
+		if q.Limit > 0 && delivered >= q.Limit {
+			q.events = nil
+			return ErrEventOverflow
+		}
+
+		next := q.events[0]
+		q.events[0] = posted{}
+		q.events = q.events[1:]
+		q.deliver(next)
+	}
+	return nil
+}
+
+// deliver sends a posted Event to its target.
+func (q *EventQueue) deliver(next posted) {
+	if eventTracer != nil {
+		traceQueued = true
+		traceEnter(next.Target, next.Event)
+		defer traceExit()
+	}
+	next.Target.Handle(next.Event)
+}
+
+// activeQueue is the EventQueue used by core Entity to send Events.
+var activeQueue *EventQueue
+
+// SetEventQueue installs an EventQueue which core Entity, such as Tile, use to
+// post the Events they send instead of handling them immediately. The game
+// loop is then responsible for calling Drain. Passing nil restores immediate
+// delivery.
+func SetEventQueue(q *EventQueue) {
+	activeQueue = q
+}
+
+// send delivers an Event to the target, either immediately or through the
+// active EventQueue if one is installed.
+func send(target Entity, v Event) {
+	if activeQueue != nil {
+		activeQueue.Post(target, v)
+	} else {
+		target.Handle(v)
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testrecorder records the type of each Event it handles.
+type testrecorder struct {
+	Seen []string
+}
+
+func (r *testrecorder) Handle(v Event) {
+	switch v.(type) {
+	case *Bump:
+		r.Seen = append(r.Seen, "bump")
+	case *Collide:
+		r.Seen = append(r.Seen, "collide")
+	case *UpdatePos:
+		r.Seen = append(r.Seen, "updatepos")
+	default:
+		r.Seen = append(r.Seen, "other")
+	}
+}
+
+func TestEventQueue_Drain(t *testing.T) {
+	q := NewEventQueue()
+	r := &testrecorder{}
+	q.Post(r, &Bump{})
+	q.Post(ComponentSlice{testfunc(func(v Event) { q.Post(r, &Collide{}) })}, &Bump{})
+	q.Post(r, &UpdatePos{})
+
+	if err := q.Drain(); err != nil {
+		t.Errorf("Drain() = %v", err)
+	}
+	if expected := []string{"bump", "updatepos", "collide"}; !reflect.DeepEqual(r.Seen, expected) {
+		t.Errorf("Drain() delivered %v != %v", r.Seen, expected)
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() after Drain() = %d != 0", q.Len())
+	}
+}
+
+func TestEventQueue_overflow(t *testing.T) {
+	q := NewEventQueue()
+	q.Limit = 10
+	var loop ComponentSlice
+	loop = ComponentSlice{testfunc(func(v Event) { q.Post(loop, v) })}
+	q.Post(loop, &Bump{})
+
+	if err := q.Drain(); err != ErrEventOverflow {
+		t.Errorf("Drain() = %v != %v", err, ErrEventOverflow)
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() after overflow = %d != 0", q.Len())
+	}
+}
+
+func TestTile_HandleQueued(t *testing.T) {
+	q := NewEventQueue()
+	SetEventQueue(q)
+	defer SetEventQueue(nil)
+
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	r := &testrecorder{}
+	tiles[0].Occupant = r
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+
+	if tiles[1].Occupant != r || len(r.Seen) != 0 {
+		t.Errorf("MoveEntity with queue should move immediately but defer UpdatePos")
+	}
+	q.Drain()
+	if expected := []string{"updatepos"}; !reflect.DeepEqual(r.Seen, expected) {
+		t.Errorf("Drain() delivered %v != %v", r.Seen, expected)
+	}
+}
+
+// testfunc adapts a function to a Component.
+type testfunc func(Event)
+
+func (f testfunc) Process(v Event) { f(v) }
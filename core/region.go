@@ -0,0 +1,114 @@
+package core
+
+// FloodFill finds every Tile reachable from start through Adjacent Tiles for
+// which the predicate is true, including start itself. If the predicate is
+// false for start, the result is empty.
+func FloodFill(start *Tile, pred func(*Tile) bool) map[*Tile]struct{} {
+	filled := make(map[*Tile]struct{})
+	if !pred(start) {
+		return filled
+	}
+
+	filled[start] = struct{}{}
+	frontier := []*Tile{start}
+	for len(frontier) > 0 {
+		curr := frontier[0]
+		frontier = frontier[1:]
+		for _, delta := range descentOrder {
+			adj, ok := curr.Adjacent[delta]
+			if !ok {
+				continue
+			}
+			if _, seen := filled[adj]; !seen && pred(adj) {
+				filled[adj] = struct{}{}
+				frontier = append(frontier, adj)
+			}
+		}
+	}
+	return filled
+}
+
+// Regions labels the connected components of the Tiles in a Grid for which
+// the predicate is true, such as the separate caves of a cave map. Regions are
+// ordered by their first Tile in the order of Grid.Each, as are the Tiles in
+// each region, so the result is deterministic.
+func Regions(g *Grid, pred func(*Tile) bool) [][]*Tile {
+	label := make(map[*Tile]int)
+	var regions [][]*Tile
+	g.Each(func(_ Offset, t *Tile) {
+		if _, done := label[t]; done || !pred(t) {
+			return
+		}
+		for member := range FloodFill(t, pred) {
+			label[member] = len(regions)
+		}
+		regions = append(regions, nil)
+	})
+	g.Each(func(_ Offset, t *Tile) {
+		if i, ok := label[t]; ok {
+			regions[i] = append(regions[i], t)
+		}
+	})
+	return regions
+}
+
+// ConnectRegions joins the regions into one by digging corridors. Starting
+// from the first region, it repeatedly finds the nearest Tile of any region
+// not yet joined, and calls carve on each Tile of the shortest path between
+// them, which typically makes the Tile passable. Since the path is found over
+// every Tile of the Grid, regions can be joined through walls.
+func ConnectRegions(g *Grid, regions [][]*Tile, carve func(*Tile)) {
+	if len(regions) < 2 {
+		return
+	}
+
+	owner := make(map[*Tile]int)
+	for i, region := range regions {
+		for _, t := range region {
+			owner[t] = i
+		}
+	}
+	joined := map[int]bool{0: true}
+
+	for len(joined) < len(regions) {
+		// breadth first search outward from every joined Tile
+		parent := make(map[*Tile]*Tile)
+		var frontier []*Tile
+		g.Each(func(_ Offset, t *Tile) {
+			if i, ok := owner[t]; ok && joined[i] {
+				parent[t] = nil
+				frontier = append(frontier, t)
+			}
+		})
+
+		var found *Tile
+		for len(frontier) > 0 && found == nil {
+			curr := frontier[0]
+			frontier = frontier[1:]
+			for _, delta := range descentOrder {
+				adj, ok := curr.Adjacent[delta]
+				if !ok {
+					continue
+				}
+				if _, seen := parent[adj]; seen {
+					continue
+				}
+				parent[adj] = curr
+				if i, ok := owner[adj]; ok && !joined[i] {
+					found = adj
+					break
+				}
+				frontier = append(frontier, adj)
+			}
+		}
+		if found == nil {
+			return // the remaining regions cannot be reached at all
+		}
+
+		for t := parent[found]; parent[t] != nil; t = parent[t] {
+			carve(t)
+			owner[t] = 0
+		}
+		joined[owner[found]] = true
+	}
+}
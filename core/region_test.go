@@ -0,0 +1,83 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// gridCase creates a Grid from rows of text using DefaultLegend.
+func gridCase(rows ...string) *Grid {
+	g, _, err := ParseGrid(strings.Join(rows, "\n"), nil)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+func isPass(t *Tile) bool {
+	return t.Pass
+}
+
+func TestFloodFill(t *testing.T) {
+	g := gridCase(
+		"..#..",
+		"..#..",
+		"###..",
+		"....#",
+	)
+	if filled := FloodFill(g.At(0, 0), isPass); len(filled) != 4 {
+		t.Errorf("FloodFill() from corner filled %d != 4", len(filled))
+	}
+	// regions touching diagonally are connected
+	if filled := FloodFill(g.At(4, 0), isPass); len(filled) != 10 {
+		t.Errorf("FloodFill() from right filled %d != 10", len(filled))
+	}
+	if filled := FloodFill(g.At(2, 0), isPass); len(filled) != 0 {
+		t.Errorf("FloodFill() from wall filled %d != 0", len(filled))
+	}
+}
+
+func TestRegions(t *testing.T) {
+	g := gridCase(
+		"..#..#.",
+		"..#..#.",
+		"######.",
+		"...####",
+	)
+	regions := Regions(g, isPass)
+	sizes := []int{4, 3, 4, 3}
+	if len(regions) != len(sizes) {
+		t.Fatalf("Regions() found %d != %d regions", len(regions), len(sizes))
+	}
+	// regions are ordered column by column, by their first Tile
+	for i, region := range regions {
+		if len(region) != sizes[i] {
+			t.Errorf("Regions()[%d] has %d != %d Tiles", i, len(region), sizes[i])
+		}
+	}
+	if regions[1][0] != g.At(0, 3) {
+		t.Errorf("Regions()[1] starts at %v", regions[1][0].Offset)
+	}
+}
+
+func TestConnectRegions(t *testing.T) {
+	g := gridCase(
+		"..###....",
+		"..###....",
+		"#########",
+		"#########",
+		"......###",
+	)
+	var carved []Offset
+	ConnectRegions(g, Regions(g, isPass), func(t *Tile) {
+		t.SetTerrain(TerrainFloor)
+		carved = append(carved, t.Offset)
+	})
+	if regions := Regions(g, isPass); len(regions) != 1 {
+		t.Errorf("ConnectRegions() left %d regions", len(regions))
+	}
+	// each region is two walls away from the bottom one
+	if len(carved) != 4 {
+		t.Errorf("ConnectRegions() carved %v", carved)
+	}
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"sort"
+)
+
+// EntityID is a stable identifier for an Entity in a Registry. Unlike a
+// pointer, an EntityID can be saved and later used to find the same Entity.
+// The zero EntityID never refers to an Entity.
+type EntityID uint64
+
+// Registry assigns stable EntityID values to Entity. IDs are never reused,
+// even after the Entity they refer to is removed. An Entity is found again by
+// its identity, so a pointer or a bare ComponentSlice can be registered. An
+// Entity with no identity, such as an empty ComponentSlice, is given a new
+// EntityID each time it is registered, and ID never finds it.
+//
+// Registered Entity may also be given string tags, such as "undead", which can
+// later be used to find them with WithTag.
+type Registry struct {
+	entities map[EntityID]Entity
+	ids      map[any]EntityID // keyed by identity
+	tags     map[EntityID]map[string]struct{}
+	last     EntityID
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		make(map[EntityID]Entity),
+		make(map[any]EntityID),
+		make(map[EntityID]map[string]struct{}),
+		0,
+	}
+}
+
+// Register adds an Entity to the Registry, returning its new EntityID. If the
+// Entity was already registered, its existing EntityID is returned instead.
+func (r *Registry) Register(e Entity) EntityID {
+	key := identity(e)
+	if id, ok := r.ids[key]; ok {
+		return id
+	}
+	r.last++
+	r.entities[r.last] = e
+	if key != nil {
+		r.ids[key] = r.last
+	}
+	return r.last
+}
+
+// RegisterID adds an Entity to the Registry using a specific EntityID, such as
+// one restored from a save file. If the EntityID is already in use by another
+// Entity, or the Entity already has a different EntityID, ErrDuplicateID is
+// returned and the Registry is unchanged.
+func (r *Registry) RegisterID(id EntityID, e Entity) error {
+	if existing, ok := r.entities[id]; ok && !sameEntity(existing, e) {
+		return ErrDuplicateID
+	}
+	key := identity(e)
+	if existing, ok := r.ids[key]; ok && existing != id {
+		return ErrDuplicateID
+	}
+	if id == 0 {
+		return ErrInvalidID
+	}
+	r.entities[id] = e
+	if key != nil {
+		r.ids[key] = id
+	}
+	if id > r.last {
+		r.last = id
+	}
+	return nil
+}
+
+// Lookup returns the Entity with the given EntityID, or nil if there is none.
+func (r *Registry) Lookup(id EntityID) Entity {
+	return r.entities[id]
+}
+
+// ID returns the EntityID of a registered Entity. If the Entity is not
+// registered, the result is not ok.
+func (r *Registry) ID(e Entity) (id EntityID, ok bool) {
+	id, ok = r.ids[identity(e)]
+	return id, ok
+}
+
+// Remove deletes the Entity with the given EntityID from the Registry, along
+// with its tags. If there is no such Entity, no action is taken.
+func (r *Registry) Remove(id EntityID) {
+	if e, ok := r.entities[id]; ok {
+		delete(r.entities, id)
+		delete(r.ids, identity(e))
+		delete(r.tags, id)
+	}
+}
+
+// Tag adds tags to the Entity with the given EntityID. If there is no such
+// Entity, no action is taken.
+func (r *Registry) Tag(id EntityID, tags ...string) {
+	if _, ok := r.entities[id]; !ok {
+		return
+	}
+	set, ok := r.tags[id]
+	if !ok {
+		set = make(map[string]struct{})
+		r.tags[id] = set
+	}
+	for _, tag := range tags {
+		set[tag] = struct{}{}
+	}
+}
+
+// Untag removes tags from the Entity with the given EntityID. Tags which the
+// Entity does not have are ignored.
+func (r *Registry) Untag(id EntityID, tags ...string) {
+	set, ok := r.tags[id]
+	if !ok {
+		return
+	}
+	for _, tag := range tags {
+		delete(set, tag)
+	}
+	if len(set) == 0 {
+		delete(r.tags, id)
+	}
+}
+
+// HasTag returns true if the Entity with the given EntityID has the tag.
+func (r *Registry) HasTag(id EntityID, tag string) bool {
+	_, ok := r.tags[id][tag]
+	return ok
+}
+
+// Tags returns the tags of the Entity with the given EntityID in sorted order.
+func (r *Registry) Tags(id EntityID) []string {
+	var tags []string
+	for tag := range r.tags[id] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// WithTag returns every registered Entity with the given tag in ascending
+// EntityID order.
+func (r *Registry) WithTag(tag string) []Entity {
+	return r.Filter(func(id EntityID, _ Entity) bool { return r.HasTag(id, tag) })
+}
+
+// Filter returns every registered Entity for which the predicate is true in
+// ascending EntityID order. As with Each, the predicate may safely modify the
+// Registry.
+func (r *Registry) Filter(pred func(EntityID, Entity) bool) []Entity {
+	var matches []Entity
+	r.Each(func(id EntityID, e Entity) {
+		if pred(id, e) {
+			matches = append(matches, e)
+		}
+	})
+	return matches
+}
+
+// WithComponent returns every registered Entity with a Component of type T in
+// ascending EntityID order. Only Entity whose Components can be listed, such as
+// ComponentSlice, SortedEntity and EntityMut, are considered.
+func WithComponent[T Component](r *Registry) []Entity {
+	return r.Filter(func(_ EntityID, e Entity) bool {
+		for _, c := range components(e) {
+			if _, ok := c.(T); ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// components lists the Components of an Entity, or returns nil if the Entity
+// is not made of Components.
+func components(e Entity) []Component {
+	switch e := e.(type) {
+	case ComponentSlice:
+		return e
+	case *ComponentSlice:
+		return *e
+	case interface{ Components() []Component }:
+		return e.Components()
+	}
+	return nil
+}
+
+// Len returns the number of Entity in the Registry.
+func (r *Registry) Len() int {
+	return len(r.entities)
+}
+
+// IDs returns the EntityID of every registered Entity in ascending order.
+func (r *Registry) IDs() []EntityID {
+	ids := make([]EntityID, 0, len(r.entities))
+	for id := range r.entities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Each calls the given function on every registered Entity in ascending
+// EntityID order. The Registry may be safely modified by the function;
+// removed Entity which have not yet been visited will be skipped, and newly
+// added Entity will not be visited.
+func (r *Registry) Each(f func(EntityID, Entity)) {
+	for _, id := range r.IDs() {
+		if e, ok := r.entities[id]; ok {
+			f(id, e)
+		}
+	}
+}
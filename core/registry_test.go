@@ -0,0 +1,198 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testentity struct {
+	Name string
+}
+
+func (e *testentity) Handle(Event) {}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	a, b := &testentity{"a"}, &testentity{"b"}
+
+	ida := r.Register(a)
+	idb := r.Register(b)
+	if ida == 0 || idb == 0 || ida == idb {
+		t.Errorf("Register() gave invalid ids %d and %d", ida, idb)
+	}
+	if again := r.Register(a); again != ida {
+		t.Errorf("Register() twice = %d != %d", again, ida)
+	}
+	if r.Len() != 2 {
+		t.Errorf("Len() = %d != 2", r.Len())
+	}
+	if r.Lookup(ida) != a || r.Lookup(idb) != b {
+		t.Errorf("Lookup() did not return registered entities")
+	}
+	if id, ok := r.ID(b); !ok || id != idb {
+		t.Errorf("ID(b) = %d, %v != %d, true", id, ok, idb)
+	}
+}
+
+func TestRegistry_RegisterSlice(t *testing.T) {
+	r := NewRegistry()
+	a := ComponentSlice{&testcomponent{}}
+	b := ComponentSlice{&testcomponent{}}
+
+	ida, idb := r.Register(a), r.Register(b)
+	if ida == idb {
+		t.Errorf("Register() gave the same id %d to different slices", ida)
+	}
+	if again := r.Register(a); again != ida {
+		t.Errorf("Register() twice = %d != %d", again, ida)
+	}
+	if id, ok := r.ID(r.Lookup(idb)); !ok || id != idb {
+		t.Errorf("ID(b) = %d, %v != %d, true", id, ok, idb)
+	}
+	if err := r.RegisterID(ida, a); err != nil {
+		t.Errorf("RegisterID(a) = %v", err)
+	}
+
+	// an empty ComponentSlice has no identity, so is never found again
+	empty := r.Register(ComponentSlice{})
+	if again := r.Register(ComponentSlice{}); again == empty {
+		t.Errorf("Register() found an empty ComponentSlice")
+	}
+	if _, ok := r.ID(ComponentSlice{}); ok {
+		t.Errorf("ID() found an empty ComponentSlice")
+	}
+
+	r.Remove(ida)
+	if _, ok := r.ID(a); ok {
+		t.Errorf("ID() after Remove() is ok")
+	}
+}
+
+func TestRegistry_Remove(t *testing.T) {
+	r := NewRegistry()
+	a, b := &testentity{"a"}, &testentity{"b"}
+
+	ida := r.Register(a)
+	r.Remove(ida)
+	if r.Lookup(ida) != nil {
+		t.Errorf("Lookup() after Remove() = %v != nil", r.Lookup(ida))
+	}
+	if _, ok := r.ID(a); ok {
+		t.Errorf("ID() after Remove() is ok")
+	}
+
+	// ids must not be reused, even for the same entity
+	if idb := r.Register(b); idb == ida {
+		t.Errorf("Register() after Remove() reused id %d", idb)
+	}
+	if again := r.Register(a); again == ida {
+		t.Errorf("Register() after Remove() reused id %d", again)
+	}
+	r.Remove(12345)
+}
+
+func TestRegistry_RegisterID(t *testing.T) {
+	r := NewRegistry()
+	a, b, c := &testentity{"a"}, &testentity{"b"}, &testentity{"c"}
+
+	if err := r.RegisterID(10, a); err != nil {
+		t.Errorf("RegisterID(10, a) = %v", err)
+	}
+	if err := r.RegisterID(10, a); err != nil {
+		t.Errorf("RegisterID(10, a) twice = %v", err)
+	}
+	if err := r.RegisterID(10, b); err != ErrDuplicateID {
+		t.Errorf("RegisterID(10, b) = %v != %v", err, ErrDuplicateID)
+	}
+	if err := r.RegisterID(11, a); err != ErrDuplicateID {
+		t.Errorf("RegisterID(11, a) = %v != %v", err, ErrDuplicateID)
+	}
+	if err := r.RegisterID(0, b); err != ErrInvalidID {
+		t.Errorf("RegisterID(0, b) = %v != %v", err, ErrInvalidID)
+	}
+	if id := r.Register(c); id <= 10 {
+		t.Errorf("Register() after RegisterID(10) = %d", id)
+	}
+}
+
+func TestRegistry_Each(t *testing.T) {
+	r := NewRegistry()
+	entities := []*testentity{{"a"}, {"b"}, {"c"}, {"d"}}
+	var ids []EntityID
+	for _, e := range entities {
+		ids = append(ids, r.Register(e))
+	}
+
+	var visited []EntityID
+	r.Each(func(id EntityID, e Entity) {
+		visited = append(visited, id)
+		if id == ids[0] {
+			r.Remove(ids[2])
+			r.Register(&testentity{"e"})
+		}
+	})
+	if expected := []EntityID{ids[0], ids[1], ids[3]}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Each() visited %v != %v", visited, expected)
+	}
+}
+
+func TestRegistry_Tag(t *testing.T) {
+	r := NewRegistry()
+	a, b, c := &testentity{"a"}, &testentity{"b"}, &testentity{"c"}
+	ida, idb, idc := r.Register(a), r.Register(b), r.Register(c)
+
+	r.Tag(idc, "undead", "orc")
+	r.Tag(ida, "undead")
+	r.Tag(idb, "orc")
+	r.Tag(12345, "undead")
+	if undead := r.WithTag("undead"); !reflect.DeepEqual(undead, []Entity{a, c}) {
+		t.Errorf("WithTag(undead) = %v != [a c]", undead)
+	}
+	if tags := r.Tags(idc); !reflect.DeepEqual(tags, []string{"orc", "undead"}) {
+		t.Errorf("Tags(c) = %v != [orc undead]", tags)
+	}
+
+	r.Untag(idc, "undead", "missing")
+	if undead := r.WithTag("undead"); !reflect.DeepEqual(undead, []Entity{a}) {
+		t.Errorf("WithTag(undead) after Untag() = %v != [a]", undead)
+	}
+	r.Remove(idb)
+	if orcs := r.WithTag("orc"); !reflect.DeepEqual(orcs, []Entity{c}) {
+		t.Errorf("WithTag(orc) after Remove() = %v != [c]", orcs)
+	}
+}
+
+func TestRegistry_Filter(t *testing.T) {
+	r := NewRegistry()
+	var ids []EntityID
+	for _, name := range []string{"a", "b", "c"} {
+		ids = append(ids, r.Register(&testentity{name}))
+	}
+
+	// removing an entity mid-query skips it rather than panicking
+	matches := r.Filter(func(id EntityID, e Entity) bool {
+		r.Remove(ids[1])
+		return true
+	})
+	if len(matches) != 2 || matches[1] != r.Lookup(ids[2]) {
+		t.Errorf("Filter() with Remove() = %v", matches)
+	}
+}
+
+func TestWithComponent(t *testing.T) {
+	r := NewRegistry()
+	slice := &ComponentSlice{&Stats{}}
+	sorted := NewEntity(&Chaser{})
+	mut := NewEntityMut(&Stats{}, &Chaser{})
+	r.Register(slice)
+	r.Register(sorted)
+	r.Register(mut)
+	r.Register(&testentity{"a"})
+
+	if stats := WithComponent[*Stats](r); !reflect.DeepEqual(stats, []Entity{slice, mut}) {
+		t.Errorf("WithComponent[*Stats]() = %v", stats)
+	}
+	if chasers := WithComponent[*Chaser](r); !reflect.DeepEqual(chasers, []Entity{sorted, mut}) {
+		t.Errorf("WithComponent[*Chaser]() = %v", chasers)
+	}
+}
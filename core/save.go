@@ -0,0 +1,755 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"reflect"
+)
+
+// componentTypes holds every type registered with RegisterComponent by name.
+var componentTypes = make(map[string]reflect.Type)
+
+// RegisterComponent registers a concrete Entity or Component type so that it
+// can be saved by SaveWorld. Every type stored in an Entity interface,
+// including each Component inside a ComponentSlice, SortedEntity or
+// EntityMut, must be registered.
+func RegisterComponent(v interface{}) {
+	gob.Register(v)
+	t := reflect.TypeOf(v)
+	componentTypes[componentName(t)] = t
+}
+
+// componentName gives the name under which a type is saved, which is the same
+// name gob gives it.
+func componentName(t reflect.Type) string {
+	star := ""
+	if t.Name() == "" && t.Kind() == reflect.Pointer {
+		star, t = "*", t.Elem()
+	}
+	if t.Name() == "" {
+		return t.String()
+	} else if t.PkgPath() == "" {
+		return star + t.Name()
+	}
+	return star + t.PkgPath() + "." + t.Name()
+}
+
+// OpaqueEntity is a saved Entity which could not be loaded, because its type
+// is no longer registered with RegisterComponent or it holds a Component which
+// is not, such as one removed in a later version of the game. Rather than
+// failing the whole load, LoadWorld puts an OpaqueEntity in its place, which
+// ignores every Event and is saved again unchanged, so that a migration
+// registered with RegisterMigration may later replace it. A ComponentSlice is
+// never made opaque as a whole; only its unknown Components are.
+type OpaqueEntity struct {
+	Type string
+	Data []byte
+
+	components []savedComponent
+}
+
+// Handle ignores every Event.
+func (e *OpaqueEntity) Handle(v Event) {}
+
+// Decode decodes the saved Entity into v, which should point to a type with
+// fields of the same names, such as the type which replaced it. Fields missing
+// from v, including any holding unregistered Components, are skipped. The
+// Components of an Entity such as an EntityMut are not decoded.
+func (e *OpaqueEntity) Decode(v any) error {
+	return gob.NewDecoder(bytes.NewReader(e.Data)).Decode(v)
+}
+
+// OpaqueComponent is a saved Component of a ComponentSlice which could not be
+// loaded, because its type is no longer registered with RegisterComponent.
+// LoadWorld puts an OpaqueComponent in its place, which ignores every Event
+// and is saved again unchanged, so that the rest of the Entity still works and
+// a migration may later replace it.
+type OpaqueComponent struct {
+	Type string
+	Data []byte
+}
+
+// Process ignores every Event.
+func (c *OpaqueComponent) Process(v Event) {}
+
+// Decode decodes the saved Component into v, just as OpaqueEntity.Decode.
+func (c *OpaqueComponent) Decode(v any) error {
+	return gob.NewDecoder(bytes.NewReader(c.Data)).Decode(v)
+}
+
+// entityRef is saved in place of a reference from inside an Entity to any
+// Entity in the same Registry, such as the Self of a Component, which gob
+// would otherwise save as a copy or never finish saving. It is replaced by
+// the loaded Entity with the same EntityID.
+type entityRef struct {
+	ID EntityID
+}
+
+// Handle ignores every Event.
+func (r *entityRef) Handle(v Event) {}
+
+// entityRefType is the type of *entityRef.
+var entityRefType = reflect.TypeOf(&entityRef{})
+
+func init() {
+	gob.Register(&entityRef{})
+}
+
+// componentHolder is an Entity which holds its Components where gob cannot
+// reach them, such as a SortedEntity, an EntityMut, or a type which embeds
+// one, like Instance.
+type componentHolder interface {
+	Entity
+	Components() []Component
+	setComponents(components []Component)
+}
+
+// componentHolderType is the type of componentHolder.
+var componentHolderType = reflect.TypeOf((*componentHolder)(nil)).Elem()
+
+// encodeEntity gives the saved form of an Entity, which is encoded separately
+// from every other Entity so that one which cannot be decoded does not prevent
+// the rest from loading. Likewise, each Component of a ComponentSlice or a
+// componentHolder is encoded separately, along with the other exported fields
+// of a componentHolder. References to Entities in the Registry are saved as
+// entityRef.
+func encodeEntity(e Entity, reg *Registry) (savedEntity, error) {
+	if opaque, ok := e.(*OpaqueEntity); ok {
+		return savedEntity{Type: opaque.Type, Data: opaque.Data, Components: opaque.components}, nil
+	}
+	name := componentName(reflect.TypeOf(e))
+	if _, ok := componentTypes[name]; !ok {
+		return savedEntity{}, ErrUnregisteredType
+	}
+	var undo []func()
+	defer func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}()
+	seen := make(map[visit]bool)
+	unlink(reflect.ValueOf(e), reg, seen, &undo)
+	if cyclic(reflect.ValueOf(e), make(map[visit]bool), make(map[visit]bool)) {
+		return savedEntity{}, ErrSaveCycle
+	}
+
+	if holder, ok := e.(componentHolder); ok {
+		components := holder.Components()
+		unlink(reflect.ValueOf(components), reg, seen, &undo)
+		if cyclic(reflect.ValueOf(components), make(map[visit]bool), make(map[visit]bool)) {
+			return savedEntity{}, ErrSaveCycle
+		}
+		data, err := encodeFields(holder)
+		if err != nil {
+			return savedEntity{}, err
+		}
+		return encodeComponents(name, data, components)
+	}
+	if slice, ok := componentSlice(e); ok {
+		return encodeComponents(name, nil, slice)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return savedEntity{}, err
+	}
+	return savedEntity{Type: name, Data: buf.Bytes()}, nil
+}
+
+// encodeComponents gives the saved form of an Entity with the given
+// Components, each encoded separately, and its other fields already encoded
+// as data.
+func encodeComponents(name string, data []byte, components []Component) (savedEntity, error) {
+	saved := savedEntity{Type: name, Data: data, Components: make([]savedComponent, 0, len(components))}
+	for _, c := range components {
+		component, err := encodeComponent(c)
+		if err != nil {
+			return savedEntity{}, err
+		}
+		saved.Components = append(saved.Components, component)
+	}
+	return saved, nil
+}
+
+// encodeFields encodes the exported fields of a componentHolder, other than
+// any embedded componentHolder, such as the EntityMut inside an Instance, or
+// gives nil if there are none.
+func encodeFields(holder componentHolder) ([]byte, error) {
+	v := reflect.ValueOf(holder)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, nil
+	}
+	v = v.Elem()
+	var fields []reflect.StructField
+	var values []reflect.Value
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if !f.IsExported() || f.Anonymous && reflect.PointerTo(f.Type).Implements(componentHolderType) {
+			continue
+		}
+		fields = append(fields, reflect.StructField{Name: f.Name, Type: f.Type})
+		values = append(values, v.Field(i))
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	// gob matches fields by name, so the fields decode into the holder itself
+	exported := reflect.New(reflect.StructOf(fields)).Elem()
+	for i, value := range values {
+		exported.Field(i).Set(value)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).EncodeValue(exported); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// componentSlice gives the Components of a ComponentSlice or *ComponentSlice.
+func componentSlice(e Entity) (ComponentSlice, bool) {
+	switch e := e.(type) {
+	case ComponentSlice:
+		return e, true
+	case *ComponentSlice:
+		return *e, true
+	}
+	return nil, false
+}
+
+// encodeComponent gives the saved form of a single Component of a
+// ComponentSlice.
+func encodeComponent(c Component) (savedComponent, error) {
+	if opaque, ok := c.(*OpaqueComponent); ok {
+		return savedComponent{opaque.Type, opaque.Data}, nil
+	}
+	name := componentName(reflect.TypeOf(c))
+	if _, ok := componentTypes[name]; !ok {
+		return savedComponent{}, ErrUnregisteredType
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return savedComponent{}, err
+	}
+	return savedComponent{name, buf.Bytes()}, nil
+}
+
+// visit identifies a pointer, slice or map by its address and type.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// gobEncoderType is the type of gob.GobEncoder, whose values, such as *Tile,
+// encode themselves.
+var gobEncoderType = reflect.TypeOf((*gob.GobEncoder)(nil)).Elem()
+
+// cyclic returns true if the value refers back to itself through the exported
+// fields, elements and pointers which gob would follow, since gob would never
+// finish encoding it. The path holds what is being visited, and done what is
+// known to be acyclic.
+func cyclic(v reflect.Value, path, done map[visit]bool) bool {
+	if !v.IsValid() || v.Type().Implements(gobEncoderType) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return false
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if path[key] {
+			return true
+		}
+		if done[key] {
+			return false
+		}
+		path[key] = true
+		defer func() {
+			delete(path, key)
+			done[key] = true
+		}()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return cyclic(v.Elem(), path, done)
+	case reflect.Slice, reflect.Array:
+		if !composite(v.Type().Elem()) {
+			return false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if cyclic(v.Index(i), path, done) {
+				return true
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			if cyclic(iter.Key(), path, done) || cyclic(iter.Value(), path, done) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && cyclic(v.Field(i), path, done) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// composite returns true if values of the type can refer to other values.
+func composite(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// unlink replaces each reference to an Entity in the Registry reachable from
+// the value through exported fields, elements and pointers with an entityRef,
+// appending to undo a function restoring each. Only values which can be set
+// to an entityRef, such as a field of type Entity, are replaced, so the value
+// itself is always left alone.
+func unlink(v reflect.Value, reg *Registry, seen map[visit]bool, undo *[]func()) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if v.IsNil() || v.Type().Implements(gobEncoderType) {
+			return
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		unlink(v.Elem(), reg, seen, undo)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		old := v.Elem()
+		e, ok := old.Interface().(Entity)
+		if !ok || !v.CanSet() || !entityRefType.AssignableTo(v.Type()) {
+			unlink(old, reg, seen, undo)
+		} else if id, ok := reg.ID(e); ok {
+			v.Set(reflect.ValueOf(&entityRef{id}))
+			*undo = append(*undo, func() { v.Set(old) })
+		} else {
+			unlink(old, reg, seen, undo)
+		}
+	case reflect.Slice, reflect.Array:
+		if !composite(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			unlink(v.Index(i), reg, seen, undo)
+		}
+	case reflect.Map:
+		if !composite(v.Type().Elem()) || !v.CanInterface() {
+			return
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			key, old := iter.Key(), iter.Value()
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(old)
+			n := len(*undo)
+			if unlink(value, reg, seen, undo); len(*undo) > n {
+				v.SetMapIndex(key, value)
+				*undo = append(*undo, func() { v.SetMapIndex(key, old) })
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				unlink(v.Field(i), reg, seen, undo)
+			}
+		}
+	}
+}
+
+// relink replaces each placeholder *Tile reachable from the value through
+// exported fields, elements and pointers with the loaded Tile at its Offset,
+// if there is one, and each entityRef with the Entity in the Registry with its
+// EntityID. Values which cannot be set, such as those held directly by an
+// interface, are left alone.
+func relink(v reflect.Value, tiles map[Offset]*Tile, reg *Registry, seen map[visit]bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		if v.Type() == tileType {
+			if t, ok := tiles[v.Interface().(*Tile).Offset]; ok && v.CanSet() {
+				v.Set(reflect.ValueOf(t))
+			}
+			return
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		relink(v.Elem(), tiles, reg, seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		switch elem := v.Elem(); elem.Type() {
+		case tileType:
+			if t, ok := tiles[elem.Interface().(*Tile).Offset]; ok && v.CanSet() {
+				v.Set(reflect.ValueOf(t))
+			}
+		case entityRefType:
+			if e := reg.Lookup(elem.Interface().(*entityRef).ID); e != nil && v.CanSet() {
+				v.Set(reflect.ValueOf(e))
+			}
+		default:
+			relink(elem, tiles, reg, seen)
+		}
+	case reflect.Slice, reflect.Array:
+		if !composite(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			relink(v.Index(i), tiles, reg, seen)
+		}
+	case reflect.Map:
+		if !composite(v.Type().Elem()) || !v.CanInterface() {
+			return
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			relink(value, tiles, reg, seen)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				relink(v.Field(i), tiles, reg, seen)
+			}
+		}
+	}
+}
+
+// decodeEntity restores an Entity saved by encodeEntity, or gives an
+// OpaqueEntity if it cannot. A ComponentSlice or componentHolder is restored
+// with an OpaqueComponent in place of each Component which cannot be decoded.
+// Saves from before entities were encoded separately hold the Entity itself.
+func decodeEntity(saved savedEntity) Entity {
+	if saved.Type == "" {
+		return saved.Entity
+	}
+	t, ok := componentTypes[saved.Type]
+	switch {
+	case ok && t.Kind() == reflect.Pointer && t.Implements(componentHolderType):
+		holder := reflect.New(t.Elem()).Interface().(componentHolder)
+		if saved.Data == nil || gob.NewDecoder(bytes.NewReader(saved.Data)).Decode(holder) == nil {
+			holder.setComponents(decodeComponents(saved.Components))
+			return holder
+		}
+	case saved.Type == componentName(reflect.TypeOf(ComponentSlice{})):
+		return ComponentSlice(decodeComponents(saved.Components))
+	case saved.Type == componentName(reflect.TypeOf(&ComponentSlice{})):
+		slice := ComponentSlice(decodeComponents(saved.Components))
+		return &slice
+	case saved.Data != nil:
+		if e, ok := decodeValue(saved.Type, saved.Data).(Entity); ok {
+			return e
+		}
+	}
+	return &OpaqueEntity{saved.Type, saved.Data, saved.Components}
+}
+
+// decodeComponents restores the Components saved by encodeComponents, with an
+// OpaqueComponent in place of each which cannot be decoded.
+func decodeComponents(saved []savedComponent) []Component {
+	components := make([]Component, 0, len(saved))
+	for _, c := range saved {
+		if component, ok := decodeValue(c.Type, c.Data).(Component); ok {
+			components = append(components, component)
+		} else {
+			components = append(components, &OpaqueComponent{c.Type, c.Data})
+		}
+	}
+	return components
+}
+
+// decodeValue decodes a value of the registered type with the given name, or
+// returns nil if it cannot.
+func decodeValue(name string, data []byte) any {
+	t, ok := componentTypes[name]
+	if !ok {
+		return nil
+	}
+	v := reflect.New(t)
+	if gob.NewDecoder(bytes.NewReader(data)).Decode(v.Interface()) != nil {
+		return nil
+	}
+	return v.Elem().Interface()
+}
+
+// savedTile is the saved form of a Tile, with Adjacent and Entity links
+// replaced by Offset and EntityID.
+type savedTile struct {
+	Face     Glyph
+	Pass     bool
+	Lite     bool
+	Cost     float64
+	Terrain  TerrainID
+	Offset   Offset
+	Adjacent map[Offset]Offset
+	Occupant EntityID
+	Overlap  []EntityID
+	Items    []EntityID
+	Trigger  EntityID
+}
+
+// savedEntity is the saved form of a registered Entity, encoded separately
+// as Data with its Type name, with Components for a ComponentSlice or a
+// componentHolder. Entity holds the Entity itself in older saves.
+type savedEntity struct {
+	ID         EntityID
+	Entity     Entity
+	Type       string
+	Data       []byte
+	Tags       []string
+	Components []savedComponent
+}
+
+// savedComponent is the saved form of a Component of a ComponentSlice or a
+// componentHolder, encoded separately as Data with its Type name.
+type savedComponent struct {
+	Type string
+	Data []byte
+}
+
+// savedWorld is the saved form of a set of Tiles and a Registry.
+type savedWorld struct {
+	Tiles    []savedTile
+	Entities []savedEntity
+}
+
+// SaveWorld writes a set of Tiles and the Registry of every Entity on them
+// using gob. Each Tile must have a unique Offset, and every Entity on a Tile
+// must be registered, or ErrUnregistered is returned. Entity types must be
+// registered with RegisterComponent, or ErrUnregisteredType is returned. Only
+// exported fields are saved, except that the Components of a ComponentSlice,
+// SortedEntity or EntityMut, including one embedded in a type such as
+// Instance, are saved one by one, and loaded into the same type again.
+//
+// Any *Tile stored inside an Entity is saved as only its Offset, and any
+// reference to a registered Entity held in a field of type Entity, such as the
+// Self of a Component, as only its EntityID. When loaded, each occupant is sent
+// an UpdatePos, and every such *Tile or Entity reachable through the exported
+// fields of an Entity is replaced by the loaded Tile at its Offset or the
+// loaded Entity with its EntityID. A *Tile whose Offset is not among the
+// loaded Tiles remains a placeholder with only its Offset set. Any other
+// reference cycle, such as through an unregistered Entity, cannot be saved by
+// gob, so ErrSaveCycle is returned instead.
+func SaveWorld(w io.Writer, tiles []*Tile, reg *Registry) error {
+	lookup := func(e Entity) (EntityID, error) {
+		if e == nil {
+			return 0, nil
+		}
+		if id, ok := reg.ID(e); ok {
+			return id, nil
+		}
+		return 0, ErrUnregistered
+	}
+
+	var world savedWorld
+	for _, t := range tiles {
+		saved := savedTile{t.Face, t.Pass, t.Lite, t.Cost, t.Terrain, t.Offset, make(map[Offset]Offset), 0, nil, nil, 0}
+		for delta, adj := range t.Adjacent {
+			saved.Adjacent[delta] = adj.Offset
+		}
+		var err error
+		if saved.Occupant, err = lookup(t.Occupant); err != nil {
+			return err
+		}
+		if saved.Trigger, err = lookup(t.Trigger); err != nil {
+			return err
+		}
+		for _, o := range t.Overlap {
+			id, err := lookup(o)
+			if err != nil {
+				return err
+			}
+			saved.Overlap = append(saved.Overlap, id)
+		}
+		for _, item := range t.Items {
+			id, err := lookup(item)
+			if err != nil {
+				return err
+			}
+			saved.Items = append(saved.Items, id)
+		}
+		world.Tiles = append(world.Tiles, saved)
+	}
+	for _, id := range reg.IDs() {
+		saved, err := encodeEntity(reg.Lookup(id), reg)
+		if err != nil {
+			return err
+		}
+		saved.ID, saved.Tags = id, reg.Tags(id)
+		world.Entities = append(world.Entities, saved)
+	}
+
+	return gob.NewEncoder(w).Encode(&world)
+}
+
+// LoadWorld reads a set of Tiles and a Registry written by SaveWorld. The Tiles
+// are returned in the order they were saved, with Adjacent, Occupant, Overlap,
+// Items and Trigger restored, any *Tile or registered Entity inside an Entity
+// relinked, and each occupant sent an UpdatePos. Finally, every Entity in the
+// Registry is sent a Loaded. An Entity which cannot be decoded is loaded as an
+// OpaqueEntity.
+func LoadWorld(r io.Reader) ([]*Tile, *Registry, error) {
+	tiles, reg, index, err := loadWorld(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	reg.Each(func(_ EntityID, e Entity) {
+		e.Handle(&Loaded{Registry: reg, Tiles: index})
+	})
+	return tiles, reg, nil
+}
+
+// loadWorld does the work of LoadWorld except for sending Loaded, and also
+// returns each loaded Tile by its Offset.
+func loadWorld(r io.Reader) ([]*Tile, *Registry, map[Offset]*Tile, error) {
+	var world savedWorld
+	if err := gob.NewDecoder(r).Decode(&world); err != nil {
+		return nil, nil, nil, err
+	}
+
+	reg := NewRegistry()
+	for _, saved := range world.Entities {
+		if err := reg.RegisterID(saved.ID, decodeEntity(saved)); err != nil {
+			return nil, nil, nil, err
+		}
+		reg.Tag(saved.ID, saved.Tags...)
+	}
+	lookup := func(id EntityID) (Entity, error) {
+		if id == 0 {
+			return nil, nil
+		}
+		if e := reg.Lookup(id); e != nil {
+			return e, nil
+		}
+		return nil, ErrInvalidID
+	}
+
+	tiles := make([]*Tile, len(world.Tiles))
+	index := make(map[Offset]*Tile, len(world.Tiles))
+	for i, saved := range world.Tiles {
+		t := NewTile(saved.Offset)
+		t.Face, t.Pass, t.Lite, t.Cost, t.Terrain = saved.Face, saved.Pass, saved.Lite, saved.Cost, saved.Terrain
+		var err error
+		if t.Occupant, err = lookup(saved.Occupant); err != nil {
+			return nil, nil, nil, err
+		}
+		if t.Trigger, err = lookup(saved.Trigger); err != nil {
+			return nil, nil, nil, err
+		}
+		for _, id := range saved.Overlap {
+			o, err := lookup(id)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			t.Overlap = append(t.Overlap, o)
+		}
+		for _, id := range saved.Items {
+			item, err := lookup(id)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			t.Items = append(t.Items, item)
+		}
+		t.updateOpaque()
+		tiles[i] = t
+		index[t.Offset] = t
+	}
+	for i, saved := range world.Tiles {
+		for delta, o := range saved.Adjacent {
+			adj, ok := index[o]
+			if !ok {
+				return nil, nil, nil, ErrInvalidTile
+			}
+			tiles[i].Adjacent[delta] = adj
+		}
+	}
+	seen := make(map[visit]bool)
+	reg.Each(func(_ EntityID, e Entity) {
+		relink(reflect.ValueOf(e), index, reg, seen)
+		if holder, ok := e.(componentHolder); ok {
+			relink(reflect.ValueOf(holder.Components()), index, reg, seen)
+		}
+	})
+	for _, t := range tiles {
+		if t.Occupant != nil {
+			t.Occupant.Handle(&UpdatePos{t})
+		}
+		for _, o := range t.Overlap {
+			o.Handle(&UpdatePos{t})
+		}
+	}
+	return tiles, reg, index, nil
+}
+
+// Loaded is an Event sent by LoadWorld or LoadLevels to every loaded Entity
+// once everything is restored, so that Components can restore references
+// which are not saved directly. Registry holds every loaded Entity.
+//
+// After LoadWorld, Tiles gives each loaded Tile by its Offset. After
+// LoadLevels, Tiles is nil, since every level has Tiles at the same Offsets,
+// and Levels instead gives the Tiles of each level by depth.
+type Loaded struct {
+	Registry *Registry
+	Tiles    map[Offset]*Tile
+	Levels   map[int]map[Offset]*Tile
+}
+
+// TilesWith returns the loaded Tiles by Offset of the level holding the given
+// Tile, or nil if it is not a loaded Tile, so that a placeholder *Tile can be
+// replaced by the loaded Tile on the same level as pos.
+func (v *Loaded) TilesWith(pos *Tile) map[Offset]*Tile {
+	if pos == nil {
+		return nil
+	}
+	if v.Tiles[pos.Offset] == pos {
+		return v.Tiles
+	}
+	for _, tiles := range v.Levels {
+		if tiles[pos.Offset] == pos {
+			return tiles
+		}
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder for Tile, so that an Entity holding a
+// *Tile can be saved. Only the Offset of the Tile is encoded.
+func (e *Tile) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(e.Offset)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder for Tile, restoring a placeholder Tile
+// which has only its Offset set.
+func (e *Tile) GobDecode(data []byte) error {
+	e.Adjacent = make(map[Offset]*Tile)
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(&e.Offset)
+}
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testsaved is a simple saveable occupant.
+type testsaved struct {
+	Name string
+	Face Glyph
+	Pos  *Tile
+}
+
+func (e *testsaved) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		v.Render = e.Face
+	case *UpdatePos:
+		e.Pos = v.Pos
+	}
+}
+
+func init() {
+	RegisterComponent(&testsaved{})
+	RegisterComponent(&testitem{})
+	RegisterComponent(&Trap{})
+	RegisterComponent(&testlinked{})
+	RegisterComponent(&Stats{})
+	RegisterComponent(&Combat{})
+	RegisterComponent(&SortedEntity{})
+	RegisterComponent(&Instance{})
+}
+
+// worldView renders every Tile and computes a field of view from every
+// occupant, so that two worlds can be compared.
+func worldView(tiles []*Tile) (renders []Glyph, fovs [][]Offset) {
+	for _, t := range tiles {
+		req := RenderRequest{}
+		t.Handle(&req)
+		renders = append(renders, req.Render)
+		if t.Occupant != nil {
+			var fov []Offset
+			for _, tile := range FoV(t, 6) {
+				fov = append(fov, tile.Offset)
+			}
+			sort.Slice(fov, func(i, j int) bool {
+				return fov[i].X < fov[j].X || fov[i].X == fov[j].X && fov[i].Y < fov[j].Y
+			})
+			fovs = append(fovs, fov)
+		}
+	}
+	return renders, fovs
+}
+
+func TestSaveWorld(t *testing.T) {
+	grid := StrGrid{
+		"##############",
+		"#......#.....#",
+		"#......#.....#",
+		"#............#",
+		"#......#.....#",
+		"##############",
+	}.Convert(func(t *Tile, ch byte) {
+		t.Lite = ch != '#'
+		if ch == '#' {
+			t.Face = Glyph{'#', ColorWhite}
+			t.Pass = false
+		}
+	})
+	var tiles []*Tile
+	for x := range grid {
+		for y := range grid[x] {
+			tiles = append(tiles, &grid[x][y])
+		}
+	}
+
+	reg := NewRegistry()
+	var occupants []*testsaved
+	for i := 0; i < 3; i++ {
+		e := &testsaved{Name: "orc", Face: Glyph{'o', ColorGreen}}
+		pos := RandTile(tiles, func(t *Tile) bool { return t.Pass && t.Occupant == nil })
+		pos.Occupant, e.Pos = e, pos
+		reg.Tag(reg.Register(e), "orc")
+		occupants = append(occupants, e)
+	}
+	boulder := &testitem{Glyph{'0', ColorWhite}, true}
+	RandPassTile(tiles).Handle(&PlaceItem{boulder})
+	reg.Register(boulder)
+	trap := &Trap{Face: Glyph{'^', ColorRed}, Discovered: true}
+	RandPassTile(tiles).Trigger = trap
+	reg.Register(trap)
+
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+	loaded, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+
+	if loadedReg.Len() != reg.Len() {
+		t.Errorf("LoadWorld() registry has %d != %d entities", loadedReg.Len(), reg.Len())
+	}
+	if orcs := loadedReg.WithTag("orc"); len(orcs) != len(occupants) {
+		t.Errorf("LoadWorld() registry has %d != %d tagged entities", len(orcs), len(occupants))
+	}
+	renders, fovs := worldView(tiles)
+	loadedRenders, loadedFovs := worldView(loaded)
+	if !reflect.DeepEqual(renders, loadedRenders) {
+		t.Errorf("LoadWorld() renders differently")
+	}
+	if !reflect.DeepEqual(fovs, loadedFovs) {
+		t.Errorf("LoadWorld() has different field of view")
+	}
+	for i, e := range occupants {
+		id, _ := reg.ID(e)
+		loadedE := loadedReg.Lookup(id).(*testsaved)
+		if loadedE.Pos.Occupant != loadedE || loadedE.Pos.Offset != occupants[i].Pos.Offset {
+			t.Errorf("LoadWorld() did not restore occupant position")
+		}
+	}
+
+	tiles[0].Items = append(tiles[0].Items, &testsaved{})
+	if err := SaveWorld(&buf, tiles, reg); err != ErrUnregistered {
+		t.Errorf("SaveWorld() with unregistered item = %v", err)
+	}
+}
+
+// testlinked is a saveable Entity holding references to other Entities and
+// Tiles.
+type testlinked struct {
+	Self Entity
+	Home *Tile
+}
+
+func (e *testlinked) Handle(v Event) {}
+
+func TestSaveWorld_links(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	reg := NewRegistry()
+	item := &testlinked{Home: tiles[2]}
+	tiles[0].Handle(&PlaceItem{item})
+	id := reg.Register(item)
+
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+	loaded, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+	if home := loadedReg.Lookup(id).(*testlinked).Home; home != loaded[2] {
+		t.Errorf("LoadWorld() gave placeholder Tile %p != %p", home, loaded[2])
+	}
+
+	// a reference to a registered Entity is saved by EntityID
+	item.Self = item
+	buf.Reset()
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() with Self = %v", err)
+	}
+	if item.Self != item {
+		t.Errorf("SaveWorld() did not restore Self")
+	}
+	if _, loadedReg, err = LoadWorld(&buf); err != nil {
+		t.Fatalf("LoadWorld() with Self = %v", err)
+	}
+	if loaded := loadedReg.Lookup(id).(*testlinked); loaded.Self != loaded {
+		t.Errorf("LoadWorld() gave Self %v != %v", loaded.Self, loaded)
+	}
+
+	// but a cycle through an unregistered Entity cannot be saved
+	other := &testlinked{}
+	other.Self, item.Self = other, other
+	if err := SaveWorld(&buf, tiles, reg); err != ErrSaveCycle {
+		t.Errorf("SaveWorld() with Self cycle = %v", err)
+	}
+}
+
+func TestSaveWorld_components(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	reg := NewRegistry()
+	orc := &ComponentSlice{}
+	*orc = ComponentSlice{NewStats(orc, 10), &Combat{Self: orc, Power: 3}}
+	tiles[1].Occupant = orc
+	id := reg.Register(orc)
+
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+	if stats := (*orc)[0].(*Stats); stats.Self != orc {
+		t.Errorf("SaveWorld() did not restore Self")
+	}
+	loaded, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+	e := loadedReg.Lookup(id).(*ComponentSlice)
+	stats, combat := (*e)[0].(*Stats), (*e)[1].(*Combat)
+	if stats.Self != e || combat.Self != e || loaded[1].Occupant != e {
+		t.Errorf("LoadWorld() did not relink Self to the loaded Entity")
+	}
+
+	// the loaded Entity still fights as before
+	e.Handle(&Attack{Target: e, Amount: 4})
+	if stats.HP != 6 {
+		t.Errorf("HP after Attack = %d != 6", stats.HP)
+	}
+	hit := &Attack{}
+	combat.Process(&Bump{Bumped: ComponentSlice{On(func(v *Attack) { *hit = *v })}})
+	if hit.Amount != 3 || hit.Attacker != e {
+		t.Errorf("loaded Combat gave Attack %+v", hit)
+	}
+}
+
+func TestSaveWorld_instance(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	reg := NewRegistry()
+	proto := &Prototype{Name: "orc", Face: Glyph{'o', ColorGreen}, Components: []ProtoComponent{
+		{"stats", func(self Entity) Component { return NewStats(self, 10) }},
+		{"combat", func(self Entity) Component { return &Combat{Self: self, Power: 3} }},
+	}}
+	orc, err := Spawn(proto, tiles[1], reg)
+	if err != nil {
+		t.Fatalf("Spawn() = %v", err)
+	}
+	id, _ := reg.ID(orc)
+	sortedID := reg.Register(NewEntity(NewStats(nil, 5)))
+
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+	loaded, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+	e, ok := loadedReg.Lookup(id).(*Instance)
+	if !ok || e.Proto.Name != "orc" || e.Face != orc.Face || e.String() != "orc" {
+		t.Fatalf("LoadWorld() gave %#v", loadedReg.Lookup(id))
+	}
+	components := e.Components()
+	if len(components) != 2 {
+		t.Fatalf("LoadWorld() gave Components %v", components)
+	}
+	stats, combat := components[0].(*Stats), components[1].(*Combat)
+	if stats.Self != e || combat.Self != e || loaded[1].Occupant != e {
+		t.Errorf("LoadWorld() did not relink Self to the loaded Instance")
+	}
+	if e.Handle(&Attack{Target: e, Amount: 4}); stats.HP != 6 {
+		t.Errorf("HP after Attack = %d != 6", stats.HP)
+	}
+	req := RenderRequest{}
+	if loaded[1].Handle(&req); req.Render != proto.Face {
+		t.Errorf("loaded Instance renders as %v != %v", req.Render, proto.Face)
+	}
+
+	sorted, ok := loadedReg.Lookup(sortedID).(*SortedEntity)
+	if !ok || len(sorted.Components()) != 1 || sorted.Components()[0].(*Stats).HP != 5 {
+		t.Errorf("LoadWorld() gave %#v", loadedReg.Lookup(sortedID))
+	}
+}
+
+// testrune is a Component which a later version of a game might remove.
+type testrune struct {
+	Glow int
+}
+
+func (c *testrune) Process(v Event) {}
+
+func TestLoadWorld_opaqueComponent(t *testing.T) {
+	RegisterComponent(&testrune{})
+	name := componentName(reflect.TypeOf(&testrune{}))
+	defer func() { componentTypes[name] = reflect.TypeOf(&testrune{}) }()
+
+	tiles := NewTileGrid(1, 1, Offset{}, NewTile)
+	reg := NewRegistry()
+	orc := &ComponentSlice{}
+	*orc = ComponentSlice{&testrune{3}, NewStats(orc, 10)}
+	tiles[0].Occupant = orc
+	id := reg.Register(orc)
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, tiles, reg); err != nil {
+		t.Fatalf("SaveWorld() = %v", err)
+	}
+
+	// only the unknown Component is opaque, so the rest still works
+	delete(componentTypes, name)
+	_, loadedReg, err := LoadWorld(&buf)
+	if err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+	e, ok := loadedReg.Lookup(id).(*ComponentSlice)
+	if !ok || len(*e) != 2 {
+		t.Fatalf("LoadWorld() gave %#v", loadedReg.Lookup(id))
+	}
+	opaque, ok := (*e)[0].(*OpaqueComponent)
+	stats := (*e)[1].(*Stats)
+	if !ok || stats.Self != e {
+		t.Fatalf("LoadWorld() gave Components %v", *e)
+	}
+	if e.Handle(&Damage{Amount: 4}); stats.HP != 6 {
+		t.Errorf("HP after Damage = %d != 6", stats.HP)
+	}
+
+	// the OpaqueComponent survives a save, and loads once known again
+	buf.Reset()
+	if err := SaveWorld(&buf, nil, loadedReg); err != nil {
+		t.Fatalf("SaveWorld() with OpaqueComponent = %v", err)
+	}
+	componentTypes[name] = reflect.TypeOf(&testrune{})
+	if _, loadedReg, err = LoadWorld(&buf); err != nil {
+		t.Fatalf("LoadWorld() = %v", err)
+	}
+	if c := (*loadedReg.Lookup(id).(*ComponentSlice))[0]; !reflect.DeepEqual(c, &testrune{3}) {
+		t.Errorf("LoadWorld() gave %v != %v", c, &testrune{3})
+	}
+	var decoded testrune
+	if err := opaque.Decode(&decoded); err != nil || decoded.Glow != 3 {
+		t.Errorf("Decode() = %v, %v", decoded, err)
+	}
+}
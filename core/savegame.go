@@ -0,0 +1,272 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// SaveVersion is the format version written by SaveGame. LoadGame also reads
+// saves from version 2, whose header lacks the SchemaVersion, and rejects any
+// other version.
+const SaveVersion = 3
+
+// SchemaVersion is the version of the game's own save schema, written in the
+// header of every save by SaveGame. A game increments it whenever a change,
+// such as adding a Component, calls for older saves to be updated when loaded,
+// and registers a migration for the change with RegisterMigration. Saves from
+// format version 2 have schema version 1.
+var SchemaVersion = 1
+
+// SaveData is a loaded save being migrated by the functions given to
+// RegisterMigration. Schema is the schema version the save has reached so far.
+// Extra holds the gob encoding of the extra data given to SaveGame, which a
+// migration may decode in its old form and encode again in its new form
+// before LoadGame decodes it.
+type SaveData struct {
+	Schema   int
+	World    *World
+	Registry *Registry
+	Extra    []byte
+}
+
+// Replace replaces the registered Entity with the given EntityID, such as an
+// OpaqueEntity, wherever it is held by the Registry or a Tile of the World,
+// keeping its EntityID and tags. An Entity placed on a Tile is sent an
+// UpdatePos.
+func (d *SaveData) Replace(id EntityID, e Entity) error {
+	old := d.Registry.Lookup(id)
+	if old == nil {
+		return ErrInvalidID
+	}
+	if existing, ok := d.Registry.ID(e); ok && existing != id {
+		return ErrDuplicateID
+	}
+	tags := d.Registry.Tags(id)
+	d.Registry.Remove(id)
+	d.Registry.RegisterID(id, e)
+	d.Registry.Tag(id, tags...)
+
+	for _, depth := range d.World.Depths() {
+		d.World.Level(depth).Each(func(_ Offset, t *Tile) {
+			if sameEntity(t.Occupant, old) {
+				t.Occupant = e
+				e.Handle(&UpdatePos{t})
+			}
+			if sameEntity(t.Trigger, old) {
+				t.Trigger = e
+			}
+			for i, o := range t.Overlap {
+				if sameEntity(o, old) {
+					t.Overlap[i] = e
+					e.Handle(&UpdatePos{t})
+				}
+			}
+			for i, item := range t.Items {
+				if sameEntity(item, old) {
+					t.Items[i] = e
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// migration is a function registered with RegisterMigration.
+type migration struct {
+	to int
+	fn func(*SaveData) error
+}
+
+// migrations holds every migration by the schema version it migrates from.
+var migrations = make(map[int]migration)
+
+// RegisterMigration registers a function which updates a save from schema
+// version from to the later version to. When LoadGame reads a save from an
+// older SchemaVersion, it runs each migration in sequence, starting from the
+// version of the save, until SchemaVersion is reached. Registering a second
+// migration from the same version, or one which does not move forward,
+// panics.
+func RegisterMigration(from, to int, fn func(*SaveData) error) {
+	if to <= from {
+		panic(fmt.Sprintf("save: migration from %d to %d does not move forward", from, to))
+	}
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("save: duplicate migration from %d", from))
+	}
+	migrations[from] = migration{to, fn}
+}
+
+// migrate runs the migrations from the schema version of the save up to
+// SchemaVersion.
+func (d *SaveData) migrate() error {
+	for d.Schema < SchemaVersion {
+		m, ok := migrations[d.Schema]
+		if !ok || m.to > SchemaVersion {
+			return &SchemaError{d.Schema, SchemaVersion, ErrNoMigration}
+		}
+		if err := m.fn(d); err != nil {
+			return err
+		}
+		d.Schema = m.to
+	}
+	return nil
+}
+
+// SchemaError reports a save whose schema version cannot be loaded. Err is
+// ErrSaveSchema for a save from a newer version of the game, or ErrNoMigration
+// for an older save with no migration leading to the Current version.
+type SchemaError struct {
+	Schema, Current int
+	Err             error
+}
+
+// Error describes the problem along with both schema versions.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%v: save schema %d, game schema %d", e.Err, e.Schema, e.Current)
+}
+
+// Unwrap returns Err, so that errors.Is can check the kind of problem.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// saveMagic begins every save written by SaveGame.
+const saveMagic = "stones save\n"
+
+// savedGame is the body of a save written by SaveGame, with each subsystem
+// encoded in the order LoadGame restores it.
+type savedGame struct {
+	Levels []byte
+	Dice   []byte
+	Extra  []byte
+}
+
+// SaveGame writes an entire game as a single save: the levels of the World
+// and every Entity in the Registry, as with SaveLevels, then the exact state of
+// the global Dice, so that random choices continue identically once loaded,
+// and finally extra, which may hold anything else the game needs, such as its
+// Clock, its DiceStreams and the EntityID of the player, and which may be
+// nil. The save begins with a header holding SaveVersion and SchemaVersion,
+// and ends with a checksum of the rest.
+func SaveGame(w io.Writer, world *World, reg *Registry, extra any) error {
+	var game savedGame
+	var levels bytes.Buffer
+	if err := world.SaveLevels(&levels, reg); err != nil {
+		return err
+	}
+	game.Levels = levels.Bytes()
+	dice, err := globalDice.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	game.Dice = dice
+	if extra != nil {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(extra); err != nil {
+			return err
+		}
+		game.Extra = buf.Bytes()
+	}
+
+	var body bytes.Buffer
+	if err := gob.NewEncoder(&body).Encode(&game); err != nil {
+		return err
+	}
+	header := make([]byte, len(saveMagic)+16)
+	copy(header, saveMagic)
+	binary.BigEndian.PutUint32(header[len(saveMagic):], SaveVersion)
+	binary.BigEndian.PutUint32(header[len(saveMagic)+4:], uint32(SchemaVersion))
+	binary.BigEndian.PutUint64(header[len(saveMagic)+8:], uint64(body.Len()))
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(body.Bytes()))
+
+	for _, part := range [][]byte{header, body.Bytes(), checksum} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadGame reads a save written by SaveGame, restoring the state of the
+// global Dice, decoding the extra data into extra if it is not nil, and
+// returning the World, using gen for levels not yet generated, and the
+// Registry. A save from an older SchemaVersion is first updated by the
+// migrations registered with RegisterMigration, and any Entity which cannot
+// be decoded is loaded as an OpaqueEntity, or for a ComponentSlice, any such
+// Component as an OpaqueComponent.
+//
+// A save which is not from SaveGame gives ErrNotSave, one from another format
+// version ErrSaveVersion, one which ends early ErrTruncatedSave, and one which
+// has been altered ErrSaveChecksum or ErrCorruptSave. A save from a newer
+// SchemaVersion, or an older one with no migration, gives a *SchemaError. The
+// global Dice is left alone unless the whole save loads.
+func LoadGame(r io.Reader, gen LevelGen, extra any) (*World, *Registry, error) {
+	magic := make([]byte, len(saveMagic)+4)
+	if n, err := io.ReadFull(r, magic); n < len(saveMagic) || string(magic[:len(saveMagic)]) != saveMagic {
+		return nil, nil, ErrNotSave
+	} else if err != nil {
+		return nil, nil, ErrTruncatedSave
+	}
+	version := binary.BigEndian.Uint32(magic[len(saveMagic):])
+	if version != SaveVersion && version != 2 {
+		return nil, nil, ErrSaveVersion
+	}
+	// Saves from version 2 have no SchemaVersion, and so are from schema 1.
+	schema, header := 1, make([]byte, 8)
+	if version == SaveVersion {
+		header = make([]byte, 12)
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, ErrTruncatedSave
+	}
+	if version == SaveVersion {
+		schema, header = int(binary.BigEndian.Uint32(header)), header[4:]
+	}
+	if schema > SchemaVersion {
+		return nil, nil, &SchemaError{schema, SchemaVersion, ErrSaveSchema}
+	}
+
+	size := binary.BigEndian.Uint64(header)
+	body, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, nil, err
+	}
+	checksum := make([]byte, 4)
+	if uint64(len(body)) != size {
+		return nil, nil, ErrTruncatedSave
+	}
+	if _, err := io.ReadFull(r, checksum); err != nil {
+		return nil, nil, ErrTruncatedSave
+	}
+	if binary.BigEndian.Uint32(checksum) != crc32.ChecksumIEEE(body) {
+		return nil, nil, ErrSaveChecksum
+	}
+
+	var game savedGame
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&game); err != nil {
+		return nil, nil, ErrCorruptSave
+	}
+	if new(xorshift).UnmarshalBinary(game.Dice) != nil {
+		return nil, nil, ErrCorruptSave
+	}
+	world, reg, err := LoadLevels(bytes.NewReader(game.Levels), gen)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := &SaveData{schema, world, reg, game.Extra}
+	if err := data.migrate(); err != nil {
+		return nil, nil, err
+	}
+	if extra != nil && data.Extra != nil {
+		if err := gob.NewDecoder(bytes.NewReader(data.Extra)).Decode(extra); err != nil {
+			return nil, nil, err
+		}
+	}
+	globalDice.UnmarshalBinary(game.Dice)
+	return world, reg, nil
+}
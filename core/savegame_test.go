@@ -0,0 +1,379 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// chaseCase is a LevelGen placing two Chasers made from Stats and Combat in a
+// corridor, registered with whichever Registry reg points to when it runs.
+func chaseCase(reg **Registry, generated *int) LevelGen {
+	return func(depth int, dice Dice) Level {
+		*generated++
+		g, marks, _ := ParseGrid("#########\n#<.....>#\n#########", nil)
+		for _, x := range []int{2, dice.Range(5, 6)} {
+			pos := g.At(x, 1)
+			e := &ComponentSlice{}
+			*e = ComponentSlice{
+				NewStats(e, 30),
+				&Combat{Self: e, Power: 2 + depth},
+				&Chaser{Self: e, Pos: pos, Radius: 8, Memory: 3},
+			}
+			pos.Occupant = e
+			(*reg).Register(e)
+		}
+		return Level{g, marks["up"][0], marks["down"][0]}
+	}
+}
+
+func init() {
+	RegisterComponent(&Chaser{})
+}
+
+// chaseTurns sends every registered Entity an Act for each of the given number
+// of turns, returning the Journal of what happened.
+func chaseTurns(t *testing.T, reg *Registry, clock *Clock, turns int) []byte {
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, clock)
+	SetEventTracer(journal.Trace)
+	defer SetEventTracer(nil)
+	for i := 0; i < turns; i++ {
+		reg.Each(func(_ EntityID, e Entity) { e.Handle(&Act{}) })
+		clock.Advance(TicksPerTurn)
+	}
+	if err := journal.Err(); err != nil {
+		t.Fatalf("Journal.Err() = %v", err)
+	}
+	return log.Bytes()
+}
+
+// chaseHP lists the HP of every registered Entity made by chaseCase or
+// fightCase.
+func chaseHP(reg *Registry) []int {
+	var hp []int
+	reg.Each(func(_ EntityID, e Entity) {
+		hp = append(hp, (*e.(*ComponentSlice))[0].(*Stats).HP)
+	})
+	return hp
+}
+
+func TestSaveGame(t *testing.T) {
+	reg := NewRegistry()
+	generated := 0
+	world := NewWorld(7, chaseCase(&reg, &generated))
+	world.Level(0)
+	world.Level(1)
+	clock := NewClock(nil)
+	clock.Advance(3 * TicksPerTurn)
+
+	var save bytes.Buffer
+	if err := SaveGame(&save, world, reg, clock); err != nil {
+		t.Fatalf("SaveGame() = %v", err)
+	}
+	expected := chaseTurns(t, reg, clock, 10)
+	expectedHP := chaseHP(reg)
+	if reflect.DeepEqual(expectedHP, []int{30, 30, 30, 30}) {
+		t.Fatalf("chaseTurns() never fought")
+	}
+
+	// Levels generated during the replay must join the loaded Registry.
+	var loadedReg *Registry
+	loadedClock := &Clock{}
+	loadedWorld, loadedReg, err := LoadGame(bytes.NewReader(save.Bytes()), chaseCase(&loadedReg, &generated), loadedClock)
+	if err != nil {
+		t.Fatalf("LoadGame() = %v", err)
+	}
+	if !loadedWorld.Generated(1) || loadedWorld.Generated(2) {
+		t.Errorf("LoadGame() gave levels %v", loadedWorld.Depths())
+	}
+	if loadedClock.Ticks != 3*TicksPerTurn {
+		t.Errorf("LoadGame() gave Clock at %d", loadedClock.Ticks)
+	}
+	loadedReg.Each(func(_ EntityID, e Entity) {
+		if stats := (*e.(*ComponentSlice))[0].(*Stats); stats.Self != e {
+			t.Errorf("LoadGame() did not relink Self")
+		}
+	})
+	if actual := chaseTurns(t, loadedReg, loadedClock, 10); !bytes.Equal(actual, expected) {
+		t.Errorf("LoadGame() replayed differently:\n%s\n!=\n%s", actual, expected)
+	}
+	if actual := chaseHP(loadedReg); !reflect.DeepEqual(actual, expectedHP) {
+		t.Errorf("LoadGame() fought to HP %v != %v", actual, expectedHP)
+	}
+
+	// replaying the Journal onto another load gives the same fight
+	replayWorld, replayReg, err := LoadGame(bytes.NewReader(save.Bytes()), chaseCase(&loadedReg, &generated), &Clock{})
+	if err != nil {
+		t.Fatalf("LoadGame() = %v", err)
+	}
+	tiles := replayWorld.Level(0).tiles
+	if err := ReplayJournal(bytes.NewReader(expected), JournalWorld{Tiles: tiles, Registry: replayReg}); err != nil {
+		t.Fatalf("ReplayJournal() = %v", err)
+	}
+	if actual := chaseHP(replayReg); !reflect.DeepEqual(actual, expectedHP) {
+		t.Errorf("ReplayJournal() fought to HP %v != %v", actual, expectedHP)
+	}
+}
+
+func TestLoadGame_Errors(t *testing.T) {
+	reg := NewRegistry()
+	generated := 0
+	world := NewWorld(7, worldCase(reg, &generated))
+	world.Level(0)
+	var buf bytes.Buffer
+	if err := SaveGame(&buf, world, reg, nil); err != nil {
+		t.Fatalf("SaveGame() = %v", err)
+	}
+	save := buf.Bytes()
+	version := len(saveMagic) + 3
+
+	cases := []struct {
+		alter func(b []byte) []byte
+		err   error
+	}{
+		{func(b []byte) []byte { return []byte("not a save") }, ErrNotSave},
+		{func(b []byte) []byte { return b[:len(saveMagic)-1] }, ErrNotSave},
+		{func(b []byte) []byte { return b[:len(saveMagic)+2] }, ErrTruncatedSave},
+		{func(b []byte) []byte { b[version]++; return b }, ErrSaveVersion},
+		{func(b []byte) []byte { return b[:len(b)/2] }, ErrTruncatedSave},
+		{func(b []byte) []byte { return b[:len(b)-2] }, ErrTruncatedSave},
+		{func(b []byte) []byte { b[len(b)-5] ^= 0xff; return b }, ErrSaveChecksum},
+		{func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }, ErrSaveChecksum},
+	}
+	for i, c := range cases {
+		altered := c.alter(append([]byte(nil), save...))
+		if _, _, err := LoadGame(bytes.NewReader(altered), worldCase(reg, &generated), nil); err != c.err {
+			t.Errorf("LoadGame() case %d = %v, expected %v", i, err, c.err)
+		}
+	}
+
+	// A save from a later version of the game cannot be loaded.
+	future := append([]byte(nil), save...)
+	future[version+4]++
+	_, _, err := LoadGame(bytes.NewReader(future), worldCase(reg, &generated), nil)
+	var schemaErr *SchemaError
+	if !errors.Is(err, ErrSaveSchema) || !errors.As(err, &schemaErr) || schemaErr.Schema != SchemaVersion+1 {
+		t.Errorf("LoadGame() from a newer schema = %v", err)
+	}
+}
+
+// testwand replaces a type from an older schema which is no longer registered.
+type testwand struct {
+	Name    string
+	Charges int
+}
+
+func (*testwand) Handle(v Event) {}
+
+// testextra is the extra data saved by the current schema.
+type testextra struct {
+	Turn       int
+	Difficulty string
+}
+
+func init() {
+	RegisterComponent(&testwand{})
+}
+
+func TestLoadGame_migration(t *testing.T) {
+	defer func(schema int) {
+		SchemaVersion = schema
+		delete(migrations, 1)
+	}(SchemaVersion)
+	// The fixture is from schema 1, with a "wand of digging" of a type which
+	// has since been removed lying at 1,1 of the top level, and the Turn as
+	// the only extra data.
+	fixture, err := os.ReadFile("testdata/schema1.sav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := worldCase(NewRegistry(), new(int))
+
+	// Without a migration, the removed type is kept as an OpaqueEntity.
+	world, reg, err := LoadGame(bytes.NewReader(fixture), gen, nil)
+	if err != nil {
+		t.Fatalf("LoadGame() of fixture = %v", err)
+	}
+	opaque, ok := world.Level(0).At(1, 1).Items[0].(*OpaqueEntity)
+	if !ok || reg.WithTag("relic")[0] != opaque {
+		t.Fatalf("LoadGame() did not keep the unknown type as an OpaqueEntity")
+	}
+	var buf bytes.Buffer
+	SaveGame(&buf, world, reg, nil)
+	world, _, err = LoadGame(&buf, gen, nil)
+	if err != nil || !reflect.DeepEqual(world.Level(0).At(1, 1).Items[0], opaque) {
+		t.Errorf("OpaqueEntity did not survive a save, %v", err)
+	}
+
+	SchemaVersion = 2
+	_, _, err = LoadGame(bytes.NewReader(fixture), gen, nil)
+	if !errors.Is(err, ErrNoMigration) {
+		t.Errorf("LoadGame() without a migration = %v", err)
+	}
+
+	RegisterMigration(1, 2, func(d *SaveData) error {
+		for _, e := range d.Registry.WithTag("relic") {
+			wand := &testwand{}
+			if err := e.(*OpaqueEntity).Decode(wand); err != nil {
+				return err
+			}
+			id, _ := d.Registry.ID(e)
+			if err := d.Replace(id, wand); err != nil {
+				return err
+			}
+		}
+		var old struct{ Turn int }
+		if err := gob.NewDecoder(bytes.NewReader(d.Extra)).Decode(&old); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(&testextra{old.Turn, "normal"})
+		d.Extra = buf.Bytes()
+		return err
+	})
+	var extra testextra
+	world, reg, err = LoadGame(bytes.NewReader(fixture), gen, &extra)
+	if err != nil {
+		t.Fatalf("LoadGame() with a migration = %v", err)
+	}
+	wand := &testwand{"wand of digging", 3}
+	if item := world.Level(0).At(1, 1).Items[0]; !reflect.DeepEqual(item, wand) || reg.WithTag("relic")[0] != item {
+		t.Errorf("migration gave %v != %v", item, wand)
+	}
+	if extra != (testextra{42, "normal"}) {
+		t.Errorf("migration gave extra %v", extra)
+	}
+	if orcs := reg.Filter(func(_ EntityID, e Entity) bool { _, ok := e.(*testsaved); return ok }); len(orcs) != 1 {
+		t.Errorf("migration lost the other entities")
+	}
+
+	// Once saved again, the save is from the current schema.
+	buf.Reset()
+	SaveGame(&buf, world, reg, &extra)
+	world, _, err = LoadGame(&buf, gen, nil)
+	if err != nil || !reflect.DeepEqual(world.Level(0).At(1, 1).Items[0], wand) {
+		t.Errorf("LoadGame() after migration = %v", err)
+	}
+}
+
+func TestLoadGame_format2(t *testing.T) {
+	// The fixture was written by SaveGame at format version 2, before each
+	// Entity was encoded on its own, from a World with seed 7 and levels 0
+	// and 1 of worldCase generated, an "amulet" tagged "carried" on no
+	// level, and a Turn of 42 as the extra data.
+	fixture, err := os.ReadFile("testdata/format2.sav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extra struct{ Turn int }
+	world, reg, err := LoadGame(bytes.NewReader(fixture), worldCase(NewRegistry(), new(int)), &extra)
+	if err != nil {
+		t.Fatalf("LoadGame() of format 2 = %v", err)
+	}
+	if world.Seed != 7 || !reflect.DeepEqual(world.Depths(), []int{0, 1}) || extra.Turn != 42 {
+		t.Errorf("LoadGame() gave seed %d, levels %v, extra %v", world.Seed, world.Depths(), extra)
+	}
+
+	// every Entity comes from the legacy Entity field, not as an OpaqueEntity
+	for _, depth := range world.Depths() {
+		orcs := world.Level(depth).FindAll(func(tile *Tile) bool { return tile.Occupant != nil })
+		if len(orcs) != 1 {
+			t.Fatalf("LoadGame() gave %d occupants at depth %d", len(orcs), depth)
+		}
+		orc, ok := orcs[0].Occupant.(*testsaved)
+		if !ok || orc.Name != "orc" || orc.Pos != orcs[0] {
+			t.Errorf("LoadGame() gave occupant %v at depth %d", orcs[0].Occupant, depth)
+		}
+		if _, ok := reg.ID(orc); !ok {
+			t.Errorf("LoadGame() did not register the orc at depth %d", depth)
+		}
+	}
+	carried := reg.WithTag("carried")
+	if len(carried) != 1 || !reflect.DeepEqual(carried[0], &testsaved{Name: "amulet", Face: Glyph{'"', ColorYellow}}) {
+		t.Errorf("LoadGame() gave carried %v", carried)
+	}
+
+	// the Stairs are placed again, and lead between the loaded levels
+	if up, down := world.Stairs(0); up == nil || down.Trigger == nil {
+		t.Errorf("LoadGame() did not place the Stairs")
+	}
+}
+
+// fightCase is a LevelGen placing two adjacent Chasers made from Stats and
+// Combat in a corridor, registered with whichever Registry reg points to when
+// it runs.
+func fightCase(reg **Registry) LevelGen {
+	return func(depth int, dice Dice) Level {
+		g, marks, _ := ParseGrid("#######\n#<...>#\n#######", nil)
+		for _, x := range []int{2, 3} {
+			pos := g.At(x, 1)
+			e := &ComponentSlice{}
+			*e = ComponentSlice{NewStats(e, 500), &Combat{Self: e}, &Chaser{Self: e, Pos: pos, Radius: 4}}
+			pos.Occupant = e
+			(*reg).Register(e)
+		}
+		return Level{g, marks["up"][0], marks["down"][0]}
+	}
+}
+
+// fightState is the extra data saved with a fight.
+type fightState struct {
+	Clock   *Clock
+	Streams DiceStreams
+}
+
+// fightTurns has each fighter Act, attacking the other with a Power rolled
+// from the combat stream, while the ui Dice is used in between as cosmetic
+// randomness would be, returning the Journal of what happened.
+func fightTurns(t *testing.T, reg *Registry, state *fightState, ui Dice, turns int) []byte {
+	var log bytes.Buffer
+	journal := NewJournal(&log, reg, state.Clock)
+	SetEventTracer(journal.Trace)
+	defer SetEventTracer(nil)
+	for i := 0; i < turns; i++ {
+		reg.Each(func(_ EntityID, e Entity) {
+			for n := ui.Intn(5); n > 0; n-- {
+				ui.Int63()
+			}
+			(*e.(*ComponentSlice))[1].(*Combat).Power = state.Streams["combat"].Range(1, 6)
+			e.Handle(&Act{})
+		})
+		state.Clock.Advance(TicksPerTurn)
+	}
+	if err := journal.Err(); err != nil {
+		t.Fatalf("Journal.Err() = %v", err)
+	}
+	return log.Bytes()
+}
+
+func TestSaveGame_replay(t *testing.T) {
+	reg := NewRegistry()
+	world := NewWorld(3, fightCase(&reg))
+	world.Level(0)
+	state := &fightState{NewClock(nil), NewDiceStreams(9, "map", "combat")}
+	fightTurns(t, reg, state, NewSeededDice(1), 10)
+
+	var save bytes.Buffer
+	if err := SaveGame(&save, world, reg, state); err != nil {
+		t.Fatalf("SaveGame() = %v", err)
+	}
+	expected := fightTurns(t, reg, state, NewSeededDice(2), 50)
+	expectedHP := chaseHP(reg)
+
+	var loadedReg *Registry
+	loaded := &fightState{}
+	_, loadedReg, err := LoadGame(&save, fightCase(&loadedReg), loaded)
+	if err != nil {
+		t.Fatalf("LoadGame() = %v", err)
+	}
+	if actual := fightTurns(t, loadedReg, loaded, NewSeededDice(3), 50); !bytes.Equal(actual, expected) {
+		t.Errorf("LoadGame() fought differently:\n%s\n!=\n%s", actual, expected)
+	}
+	if actual := chaseHP(loadedReg); !reflect.DeepEqual(actual, expectedHP) || actual[0] == 500 {
+		t.Errorf("LoadGame() fought to HP %v != %v", actual, expectedHP)
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// Scene is a single screen of a game, such as a menu or the main map view,
+// which handles its own drawing and input.
+type Scene interface {
+	Draw()
+	HandleKey(Key) SceneResult
+}
+
+// SceneResizer is an optional interface for a Scene which needs to know when
+// the terminal is resized.
+type SceneResizer interface {
+	Resize(cols, rows int)
+}
+
+// SceneAction describes how a SceneResult changes a SceneStack.
+type SceneAction int
+
+// SceneAction constants for use in a SceneResult.
+const (
+	SceneStay SceneAction = iota
+	ScenePush
+	ScenePop
+	SceneReplace
+	SceneQuit
+)
+
+// SceneResult is returned by Scene.HandleKey to manipulate the SceneStack.
+// The Scene is only used by ScenePush and SceneReplace.
+type SceneResult struct {
+	Action SceneAction
+	Scene  Scene
+}
+
+// SceneStack runs a stack of Scene, with only the top Scene being drawn or
+// receiving input. The terminal buffer is saved when a Scene is pushed, and
+// restored when it is popped.
+type SceneStack struct {
+	scenes []Scene
+	states []State
+}
+
+// NewSceneStack creates a new SceneStack with the given initial Scene.
+func NewSceneStack(scenes ...Scene) *SceneStack {
+	s := &SceneStack{}
+	for _, scene := range scenes {
+		s.Push(scene)
+	}
+	return s
+}
+
+// Len returns the number of Scene on the stack.
+func (s *SceneStack) Len() int {
+	return len(s.scenes)
+}
+
+// Busy returns true if a Scene is pushed on top of the first, such as a menu
+// or a targeting prompt, so that an Autosaver can use it as its Busy.
+func (s *SceneStack) Busy() bool {
+	return len(s.scenes) > 1
+}
+
+// Top returns the Scene on the top of the stack, or nil if it is empty.
+func (s *SceneStack) Top() Scene {
+	if len(s.scenes) == 0 {
+		return nil
+	}
+	return s.scenes[len(s.scenes)-1]
+}
+
+// Push places a new Scene on top of the stack.
+func (s *SceneStack) Push(scene Scene) {
+	s.states = append(s.states, TermSave())
+	s.scenes = append(s.scenes, scene)
+}
+
+// Pop removes the top Scene from the stack, restoring the terminal buffer to
+// what it was when the Scene was pushed.
+func (s *SceneStack) Pop() Scene {
+	top := s.Top()
+	if top == nil {
+		return nil
+	}
+	n := len(s.scenes) - 1
+	s.states[n].Restore()
+	s.scenes, s.states = s.scenes[:n], s.states[:n]
+	return top
+}
+
+// Replace swaps the top Scene for a new Scene.
+func (s *SceneStack) Replace(scene Scene) {
+	s.Pop()
+	s.Push(scene)
+}
+
+// Resize informs each Scene which implements SceneResizer of a new size.
+func (s *SceneStack) Resize(cols, rows int) {
+	for _, scene := range s.scenes {
+		if r, ok := scene.(SceneResizer); ok {
+			r.Resize(cols, rows)
+		}
+	}
+}
+
+// Apply updates the stack according to a SceneResult.
+func (s *SceneStack) Apply(result SceneResult) {
+	switch result.Action {
+	case ScenePush:
+		s.Push(result.Scene)
+	case ScenePop:
+		s.Pop()
+	case SceneReplace:
+		s.Replace(result.Scene)
+	case SceneQuit:
+		for s.Len() > 0 {
+			s.Pop()
+		}
+	}
+}
+
+// Run draws the top Scene and passes it keypresses until the stack is empty.
+func (s *SceneStack) Run() {
+	for s.Len() > 0 {
+		s.Top().Draw()
+		TermRefresh()
+
+		switch event := termbox.PollEvent(); event.Type {
+		case termbox.EventKey:
+			s.Apply(s.Top().HandleKey(Key(event.Ch) | Key(event.Key)))
+		case termbox.EventResize:
+			s.Resize(event.Width, event.Height)
+		}
+	}
+	TermRefresh()
+}
+
+// RunScene runs a single Scene until it pops itself.
+func RunScene(scene Scene) {
+	NewSceneStack(scene).Run()
+}
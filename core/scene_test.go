@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+)
+
+type testscene string
+
+func (s testscene) Draw() {}
+
+func (s testscene) HandleKey(Key) SceneResult { return SceneResult{} }
+
+func checkScenes(t *testing.T, s *SceneStack, expected ...Scene) {
+	if s.Len() != len(expected) {
+		t.Errorf("SceneStack.Len() = %d != %d", s.Len(), len(expected))
+		return
+	}
+	for i, scene := range expected {
+		if s.scenes[i] != scene {
+			t.Errorf("SceneStack.scenes[%d] = %v != %v", i, s.scenes[i], scene)
+		}
+	}
+}
+
+func TestSceneStack_Apply(t *testing.T) {
+	a, b, c := testscene("a"), testscene("b"), testscene("c")
+	s := NewSceneStack(a)
+	checkScenes(t, s, a)
+	if s.Busy() {
+		t.Errorf("SceneStack.Busy() with one Scene")
+	}
+
+	s.Apply(SceneResult{})
+	checkScenes(t, s, a)
+
+	s.Apply(SceneResult{ScenePush, b})
+	checkScenes(t, s, a, b)
+	if !s.Busy() {
+		t.Errorf("SceneStack.Busy() with pushed Scene")
+	}
+
+	s.Apply(SceneResult{SceneReplace, c})
+	checkScenes(t, s, a, c)
+
+	s.Apply(SceneResult{ScenePop, nil})
+	checkScenes(t, s, a)
+
+	s.Apply(SceneResult{ScenePush, b})
+	s.Apply(SceneResult{SceneQuit, nil})
+	checkScenes(t, s)
+
+	if top := s.Pop(); top != nil {
+		t.Errorf("SceneStack.Pop() on empty stack = %v != nil", top)
+	}
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"sort"
+)
+
+// Act is an Event asking an actor to take an action. Cost starts at the
+// Threshold of the Scheduler, and may be changed to reflect the energy the
+// action actually required. A Cost of zero or less is a free action, and the
+// actor is immediately asked to act again, unless it has already taken
+// MaxFreeActs free actions in a row that Tick, in which case its turn ends
+// until the next Tick. A Component which takes an action should consume the
+// Act, so that lower priority behaviors do not also act.
+type Act struct {
+	Consumption
+	Cost int
+}
+
+// MaxFreeActs is the number of free actions an actor may take in a row during
+// a single Tick of a Scheduler, so that an actor which never pays for its
+// actions cannot stall the game.
+const MaxFreeActs = 64
+
+// TurnTick is an Event sent by a Scheduler to an actor after each action which
+// had a Cost, marking the end of the actor's turn. A fast actor is sent more
+// TurnTick than a slow one, so durations should instead count each Tick of a
+// Clock.
+type TurnTick struct{}
+
+// actor stores the scheduling state of an Entity in a Scheduler.
+type actor struct {
+	entity  Entity
+	speed   int
+	energy  int
+	order   uint64
+	removed bool
+}
+
+// Scheduler determines whose turn it is using energy. Each Tick, every actor
+// gains energy equal to its speed, and each actor with at least Threshold
+// energy is sent an Act, paying the Cost of the action from its energy.
+//
+// Ready actors act in order of most energy, with ties broken by the order in
+// which the actors were added, so that the turn order is deterministic.
+// Actors may be added or removed at any time, including while acting. Actors
+// added during a Tick first gain energy on the following Tick.
+//
+// A Threshold of zero or less is treated as TicksPerTurn, so the zero
+// Scheduler is ready to use. If Clock is set, it is advanced by a turn at the
+// end of each Tick.
+type Scheduler struct {
+	actors    []*actor
+	index     map[any]*actor // keyed by identity
+	added     uint64
+	Threshold int
+	Clock     *Clock
+}
+
+// NewScheduler creates an empty Scheduler with a Threshold of TicksPerTurn.
+func NewScheduler() *Scheduler {
+	return &Scheduler{nil, make(map[any]*actor), 0, TicksPerTurn, nil}
+}
+
+// Add registers an actor with the given speed. If the actor is already
+// registered, only its speed is changed.
+func (s *Scheduler) Add(e Entity, speed int) {
+	key := identity(e)
+	if a, ok := s.index[key]; ok {
+		a.speed = speed
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[any]*actor)
+	}
+	a := &actor{e, speed, 0, s.added, false}
+	s.added++
+	s.actors = append(s.actors, a)
+	s.index[key] = a
+}
+
+// Remove unregisters an actor. If the actor is not registered, no action is
+// taken.
+func (s *Scheduler) Remove(e Entity) {
+	key := identity(e)
+	if a, ok := s.index[key]; ok {
+		a.removed = true
+		delete(s.index, key)
+	}
+}
+
+// Contains returns true if the actor is registered.
+func (s *Scheduler) Contains(e Entity) bool {
+	_, ok := s.index[identity(e)]
+	return ok
+}
+
+// Len returns the number of registered actors.
+func (s *Scheduler) Len() int {
+	return len(s.index)
+}
+
+// Tick gives every actor energy, and sends an Act to each actor which can
+// afford to act.
+func (s *Scheduler) Tick() {
+	s.compact()
+	actors := append([]*actor(nil), s.actors...)
+
+	for _, a := range actors {
+		a.energy += a.speed
+	}
+	sort.SliceStable(actors, func(i, j int) bool {
+		if actors[i].energy != actors[j].energy {
+			return actors[i].energy > actors[j].energy
+		}
+		return actors[i].order < actors[j].order
+	})
+
+	threshold := s.Threshold
+	if threshold <= 0 {
+		threshold = TicksPerTurn
+	}
+	for _, a := range actors {
+		for free := 0; !a.removed && a.energy >= threshold && free < MaxFreeActs; {
+			act := Act{Cost: threshold}
+			a.entity.Handle(&act)
+			if act.Cost > 0 {
+				free = 0
+				a.energy -= act.Cost
+				if !a.removed {
+					a.entity.Handle(&TurnTick{})
+				}
+			} else {
+				free++
+			}
+		}
+	}
+	if s.Clock != nil {
+		s.Clock.Advance(TicksPerTurn)
+	}
+}
+
+// compact discards removed actors.
+func (s *Scheduler) compact() {
+	actors := s.actors[:0]
+	for _, a := range s.actors {
+		if !a.removed {
+			actors = append(actors, a)
+		}
+	}
+	for i := len(actors); i < len(s.actors); i++ {
+		s.actors[i] = nil
+	}
+	s.actors = actors
+}
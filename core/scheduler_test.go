@@ -0,0 +1,91 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testactor records its turns in a shared log.
+type testactor struct {
+	Name  string
+	Log   *[]string
+	Cost  int
+	OnAct func()
+}
+
+func (a *testactor) Handle(v Event) {
+	if v, ok := v.(*Act); ok {
+		*a.Log = append(*a.Log, a.Name)
+		if a.Cost != 0 {
+			v.Cost = a.Cost
+		}
+		if a.OnAct != nil {
+			a.OnAct()
+		}
+	}
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	var log []string
+	s := NewScheduler()
+	s.Add(&testactor{Name: "a", Log: &log}, 50)
+	s.Add(&testactor{Name: "b", Log: &log}, 100)
+	s.Add(&testactor{Name: "c", Log: &log}, 100)
+	s.Add(&testactor{Name: "d", Log: &log, Cost: 50}, 50)
+
+	for i := 0; i < 4; i++ {
+		s.Tick()
+	}
+	expected := []string{
+		"b", "c",
+		"a", "b", "c", "d",
+		"b", "c", "d",
+		"a", "b", "c", "d",
+	}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Tick order %v != %v", log, expected)
+	}
+}
+
+func TestScheduler_Mutation(t *testing.T) {
+	var log []string
+	s := NewScheduler()
+	victim := &testactor{Name: "victim", Log: &log}
+	summon := &testactor{Name: "summon", Log: &log}
+	killer := &testactor{Name: "killer", Log: &log}
+	killer.OnAct = func() {
+		s.Remove(victim)
+		s.Add(summon, 100)
+	}
+	s.Add(killer, 100)
+	s.Add(victim, 100)
+
+	s.Tick()
+	s.Tick()
+	expected := []string{"killer", "killer", "summon"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Tick order %v != %v", log, expected)
+	}
+	if s.Len() != 2 || s.Contains(victim) {
+		t.Errorf("Remove did not unregister actor")
+	}
+}
+
+func TestScheduler_Free(t *testing.T) {
+	// the zero Scheduler uses a Threshold of TicksPerTurn
+	var log []string
+	var s Scheduler
+	s.Add(&testactor{Name: "a", Log: &log}, TicksPerTurn)
+	s.Tick()
+	if !reflect.DeepEqual(log, []string{"a"}) {
+		t.Errorf("zero Scheduler Tick order %v", log)
+	}
+
+	// an actor which never pays ends its turn after MaxFreeActs
+	log = nil
+	s.Add(&testactor{Name: "free", Log: &log, Cost: -1}, TicksPerTurn)
+	s.Tick()
+	if len(log) != 1+MaxFreeActs {
+		t.Errorf("Tick with free actor acted %d times", len(log))
+	}
+}
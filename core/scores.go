@@ -0,0 +1,226 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ScoreEntry is a single game recorded in Scores.
+type ScoreEntry struct {
+	Name  string
+	Score int
+	Depth int
+	Turns int
+	Cause string
+	Time  time.Time
+}
+
+// scoresVersion is the format version of a scores file.
+const scoresVersion = 1
+
+// scoresFile is the contents of a scores file.
+type scoresFile struct {
+	Version int
+	Entries []ScoreEntry
+}
+
+// DefaultScoreCapacity is the Capacity of Scores given as zero.
+const DefaultScoreCapacity = 100
+
+// Scores is a high score table kept in a file, ordered from highest to lowest
+// Score, with ties kept in the order they were added so an older entry ranks
+// above a newer one with the same Score. Only the best Capacity entries are
+// kept, with the lowest evicted once the table is full.
+//
+// Each Add holds a lock file beside the scores file while it rereads, updates
+// and rewrites the table, so several running games can share a file without
+// losing each other's entries. The file is rewritten through a temporary file,
+// so a crash mid-write leaves the old table intact.
+type Scores struct {
+	Path     string
+	Capacity int
+
+	// Quarantined is the path to which a corrupt scores file was moved when
+	// loading, if any, so that the game may tell the player.
+	Quarantined string
+
+	// LockTimeout is how long Add waits for another game to release the lock,
+	// and zero gives a default of five seconds. A lock older than LockStale,
+	// which defaults to a minute, is assumed to be left by a crashed game and
+	// is broken.
+	LockTimeout, LockStale time.Duration
+
+	entries []ScoreEntry
+}
+
+// LoadScores reads the Scores from the file at path, which need not exist yet.
+// A corrupt file is moved aside to Quarantined rather than causing an error,
+// so a damaged table never prevents the game from starting, and an empty table
+// is used in its place. A capacity of zero gives DefaultScoreCapacity.
+func LoadScores(path string, capacity int) (*Scores, error) {
+	s := &Scores{Path: path, Capacity: capacity}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload rereads the file, quarantining it if it is corrupt.
+func (s *Scores) reload() error {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.entries = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var file scoresFile
+	if json.Unmarshal(data, &file) != nil || file.Version != scoresVersion {
+		quarantine := s.Path + ".corrupt." + strconv.FormatInt(time.Now().Unix(), 10)
+		if err := os.Rename(s.Path, quarantine); err != nil {
+			return err
+		}
+		s.Quarantined, s.entries = quarantine, nil
+		return nil
+	}
+	s.entries = file.Entries
+	s.sort()
+	return nil
+}
+
+// capacity gives the Capacity, or its default.
+func (s *Scores) capacity() int {
+	if s.Capacity <= 0 {
+		return DefaultScoreCapacity
+	}
+	return s.Capacity
+}
+
+// sort orders the entries and evicts any beyond the capacity.
+func (s *Scores) sort() {
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		return s.entries[i].Score > s.entries[j].Score
+	})
+	if len(s.entries) > s.capacity() {
+		s.entries = s.entries[:s.capacity()]
+	}
+}
+
+// Add records an entry, first rereading the file so that entries added by
+// other games are kept, and returns its rank counting from 1, or 0 if it was
+// too low to make the table. A zero Time is set to now.
+func (s *Scores) Add(entry ScoreEntry) (rank int, err error) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := s.reload(); err != nil {
+		return 0, err
+	}
+	// The sort is stable, so the entry ranks below any with an equal Score.
+	for _, e := range s.entries {
+		if e.Score >= entry.Score {
+			rank++
+		}
+	}
+	if rank++; rank > s.capacity() {
+		rank = 0
+	}
+	s.entries = append(s.entries, entry)
+	s.sort()
+	return rank, s.save()
+}
+
+// Top returns a copy of the best n entries, or all of them if there are
+// fewer than n.
+func (s *Scores) Top(n int) []ScoreEntry {
+	return append([]ScoreEntry(nil), s.entries[:Clamp(0, n, len(s.entries))]...)
+}
+
+// Len returns the number of entries.
+func (s *Scores) Len() int {
+	return len(s.entries)
+}
+
+// save writes the entries to a temporary file beside the scores file and
+// renames it into place.
+func (s *Scores) save() error {
+	data, err := json.MarshalIndent(scoresFile{scoresVersion, s.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// lock creates the lock file, waiting for any other holder to remove it, and
+// returns a function which removes it.
+func (s *Scores) lock() (unlock func(), err error) {
+	timeout, stale := s.LockTimeout, s.LockStale
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	if stale == 0 {
+		stale = time.Minute
+	}
+	path := s.Path + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > stale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrScoresLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Table returns a TableWidget listing the best entries that fit in its height,
+// below a header, with the entry of the given rank highlighted, such as the
+// rank returned by Add.
+func (s *Scores) Table(highlight, x, y, w, h int) *TableWidget {
+	var rows [][]string
+	for i, e := range s.Top(h - 1) {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1), strconv.Itoa(e.Score), e.Name,
+			strconv.Itoa(e.Depth), strconv.Itoa(e.Turns),
+			e.Cause, e.Time.Format("2006-01-02"),
+		})
+	}
+	table := NewTableWidget([]string{"#", "Score", "Name", "Depth", "Turns", "Cause", "Date"}, rows, x, y, w, h)
+	table.Align = []Align{AlignRight, AlignRight, AlignLeft, AlignRight, AlignRight}
+	table.Highlight = highlight - 1
+	return table
+}
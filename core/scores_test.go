@@ -0,0 +1,113 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scoreNames lists the names of the entries in order.
+func scoreNames(entries []ScoreEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestScores_Add(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores")
+	s, err := LoadScores(path, 3)
+	if err != nil || s.Len() != 0 {
+		t.Fatalf("LoadScores() of missing file = %v, %v", s, err)
+	}
+	// A second game shares the file, and must see entries the first adds.
+	other, _ := LoadScores(path, 3)
+
+	cases := []struct {
+		scores *Scores
+		entry  ScoreEntry
+		rank   int
+		top    []string
+	}{
+		{s, ScoreEntry{Name: "a", Score: 10}, 1, []string{"a"}},
+		{other, ScoreEntry{Name: "b", Score: 20}, 1, []string{"b", "a"}},
+		{s, ScoreEntry{Name: "c", Score: 10}, 3, []string{"b", "a", "c"}},
+		{other, ScoreEntry{Name: "d", Score: 5}, 0, []string{"b", "a", "c"}},
+		{s, ScoreEntry{Name: "e", Score: 15}, 2, []string{"b", "e", "a"}},
+	}
+	for _, c := range cases {
+		rank, err := c.scores.Add(c.entry)
+		if err != nil || rank != c.rank {
+			t.Errorf("Add(%s) = %d, %v != %d", c.entry.Name, rank, err, c.rank)
+		}
+		if top := scoreNames(c.scores.Top(5)); !reflect.DeepEqual(top, c.top) {
+			t.Errorf("Add(%s) gave %v != %v", c.entry.Name, top, c.top)
+		}
+	}
+
+	loaded, err := LoadScores(path, 3)
+	if err != nil || !reflect.DeepEqual(scoreNames(loaded.Top(2)), []string{"b", "e"}) {
+		t.Errorf("LoadScores() = %v, %v", loaded.Top(2), err)
+	}
+	if loaded.Top(1)[0].Time.IsZero() {
+		t.Errorf("Add() did not set the Time")
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Add() left its lock file")
+	}
+}
+
+func TestScores_corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores")
+	os.WriteFile(path, []byte("{\"Version\": 1, \"Entries\": [{\"Na"), 0644)
+	s, err := LoadScores(path, 0)
+	if err != nil || s.Len() != 0 || s.Quarantined == "" {
+		t.Fatalf("LoadScores() of corrupt file = %v, %v", s, err)
+	}
+	if data, err := os.ReadFile(s.Quarantined); err != nil || len(data) == 0 {
+		t.Errorf("corrupt file not kept at %s", s.Quarantined)
+	}
+	if rank, err := s.Add(ScoreEntry{Name: "a"}); rank != 1 || err != nil {
+		t.Errorf("Add() after quarantine = %d, %v", rank, err)
+	}
+}
+
+func TestScores_lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores")
+	s, _ := LoadScores(path, 0)
+	s.LockTimeout = 20 * time.Millisecond
+	os.WriteFile(path+".lock", nil, 0644)
+	if _, err := s.Add(ScoreEntry{Name: "a"}); err != ErrScoresLocked {
+		t.Errorf("Add() while locked = %v", err)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path+".lock", old, old)
+	if rank, err := s.Add(ScoreEntry{Name: "a"}); rank != 1 || err != nil {
+		t.Errorf("Add() with stale lock = %d, %v", rank, err)
+	}
+}
+
+func TestScores_Table(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores")
+	s, _ := LoadScores(path, 0)
+	date := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	s.Add(ScoreEntry{"Grok", 1200, 7, 5321, "killed by a troll", date})
+	s.Add(ScoreEntry{"Ug", 40, 1, 88, "starved", date})
+	s.Add(ScoreEntry{"Ka", 30, 1, 12, "fell", date})
+
+	table := s.Table(2, 0, 0, 80, 3)
+	expected := [][]string{
+		{"1", "1200", "Grok", "7", "5321", "killed by a troll", "2020-01-02"},
+		{"2", "40", "Ug", "1", "88", "starved", "2020-01-02"},
+	}
+	if !reflect.DeepEqual(table.Rows, expected) || table.Highlight != 1 {
+		t.Errorf("Table() = %v, highlight %d", table.Rows, table.Highlight)
+	}
+	if widths := table.widths(); !reflect.DeepEqual(widths, []int{1, 5, 4, 5, 5, 17, 10}) {
+		t.Errorf("TableWidget.widths() = %v", widths)
+	}
+}
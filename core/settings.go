@@ -0,0 +1,410 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// setting is implemented by each typed setting declared with Settings.
+type setting interface {
+	name() string
+	format() string
+	// parse checks a value read by Load, giving a function which applies it,
+	// so that nothing changes unless the whole file is valid.
+	parse(s string) (apply func(), err error)
+	element(label string, x, y int) Element
+}
+
+// Settings is a registry of game options, such as autopickup or a color
+// theme. Each option is declared once, usually at startup, with one of Bool,
+// Int, Enum or String, which return a typed handle that the game keeps to read
+// and change the value, so a misspelled option is a compile error rather than a
+// silent default. Settings can be edited with Form, and persisted with Save
+// and Load.
+type Settings struct {
+	settings []setting
+	byName   map[string]setting
+}
+
+// NewSettings creates an empty Settings.
+func NewSettings() *Settings {
+	return &Settings{byName: make(map[string]setting)}
+}
+
+// declare adds a setting, panicking if its name is taken or not usable in a
+// settings file, since either is a bug in the game rather than bad input.
+func (s *Settings) declare(v setting) {
+	name := v.name()
+	if _, ok := s.byName[name]; ok {
+		panic("settings: duplicate setting " + name)
+	}
+	if name == "" || strings.ContainsAny(name, "=#\n") || strings.TrimSpace(name) != name {
+		panic("settings: invalid setting name " + strconv.Quote(name))
+	}
+	s.settings = append(s.settings, v)
+	s.byName[name] = v
+}
+
+// BoolSetting is an on or off option declared with Settings.Bool.
+type BoolSetting struct {
+	Name    string
+	Default bool
+	value   bool
+}
+
+// Bool declares an on or off option with the given default.
+func (s *Settings) Bool(name string, def bool) *BoolSetting {
+	v := &BoolSetting{name, def, def}
+	s.declare(v)
+	return v
+}
+
+// Get returns the current value.
+func (v *BoolSetting) Get() bool { return v.value }
+
+// Set changes the current value.
+func (v *BoolSetting) Set(value bool) { v.value = value }
+
+func (v *BoolSetting) name() string   { return v.Name }
+func (v *BoolSetting) format() string { return strconv.FormatBool(v.value) }
+
+func (v *BoolSetting) parse(s string) (func(), error) {
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, ErrInvalidSetting
+	}
+	return func() { v.Set(value) }, nil
+}
+
+func (v *BoolSetting) element(label string, x, y int) Element {
+	box := NewCheckBox(label, v.value, x, y)
+	box.Changed = v.Set
+	return box
+}
+
+// IntSetting is a numeric option between Min and Max declared with
+// Settings.Int. If Validate is set, it must also accept any new value.
+type IntSetting struct {
+	Name     string
+	Default  int
+	Min, Max int
+	Validate func(int) error
+	value    int
+}
+
+// Int declares a numeric option with the given default and inclusive range,
+// panicking if the default is out of range.
+func (s *Settings) Int(name string, def, min, max int) *IntSetting {
+	v := &IntSetting{Name: name, Default: def, Min: min, Max: max, value: def}
+	if err := v.check(def); err != nil {
+		panic("settings: invalid default for " + name)
+	}
+	s.declare(v)
+	return v
+}
+
+// Get returns the current value.
+func (v *IntSetting) Get() int { return v.value }
+
+// Set changes the current value, unless it is out of range, giving
+// ErrInvalidSetting, or rejected by Validate, giving its error.
+func (v *IntSetting) Set(value int) error {
+	if err := v.check(value); err != nil {
+		return err
+	}
+	v.value = value
+	return nil
+}
+
+// check returns the error Set would give for the value.
+func (v *IntSetting) check(value int) error {
+	if value < v.Min || value > v.Max {
+		return ErrInvalidSetting
+	}
+	if v.Validate != nil {
+		return v.Validate(value)
+	}
+	return nil
+}
+
+func (v *IntSetting) name() string   { return v.Name }
+func (v *IntSetting) format() string { return strconv.Itoa(v.value) }
+
+func (v *IntSetting) parse(s string) (func(), error) {
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, ErrInvalidSetting
+	}
+	if err := v.check(value); err != nil {
+		return nil, err
+	}
+	return func() { v.value = value }, nil
+}
+
+func (v *IntSetting) element(label string, x, y int) Element {
+	spinner := NewSpinner(label, v.value, v.Min, v.Max, x, y)
+	spinner.Changed = func(value int) {
+		if v.Set(value) != nil {
+			spinner.Value = v.value
+		}
+	}
+	return spinner
+}
+
+// EnumSetting is an option which takes one of a fixed list of Options,
+// declared with Settings.Enum.
+type EnumSetting struct {
+	Name    string
+	Default string
+	Options []string
+	value   string
+}
+
+// Enum declares an option which takes one of the given options, panicking if
+// the default is not one of them.
+func (s *Settings) Enum(name, def string, options ...string) *EnumSetting {
+	v := &EnumSetting{name, def, options, def}
+	if v.index(def) < 0 {
+		panic("settings: invalid default for " + name)
+	}
+	s.declare(v)
+	return v
+}
+
+// Get returns the current value.
+func (v *EnumSetting) Get() string { return v.value }
+
+// Set changes the current value, giving ErrInvalidSetting if it is not one of
+// the Options.
+func (v *EnumSetting) Set(value string) error {
+	if v.index(value) < 0 {
+		return ErrInvalidSetting
+	}
+	v.value = value
+	return nil
+}
+
+// index gives the position of the value among the Options, or -1.
+func (v *EnumSetting) index(value string) int {
+	for i, option := range v.Options {
+		if option == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func (v *EnumSetting) name() string   { return v.Name }
+func (v *EnumSetting) format() string { return v.value }
+
+func (v *EnumSetting) parse(s string) (func(), error) {
+	if v.index(s) < 0 {
+		return nil, ErrInvalidSetting
+	}
+	return func() { v.value = s }, nil
+}
+
+func (v *EnumSetting) element(label string, x, y int) Element {
+	cycle := NewCycle(label, v.Options, v.index(v.value), x, y)
+	cycle.Changed = func(index int) { v.value = v.Options[index] }
+	return cycle
+}
+
+// StringSetting is a free text option declared with Settings.String. If
+// Validate is set, it must accept any new value. Since settings files trim
+// each value, leading and trailing spaces do not survive Save and Load.
+type StringSetting struct {
+	Name     string
+	Default  string
+	Validate func(string) error
+	value    string
+}
+
+// String declares a free text option with the given default and validation,
+// which may be nil, panicking if the default fails validation.
+func (s *Settings) String(name, def string, validate func(string) error) *StringSetting {
+	v := &StringSetting{name, def, validate, def}
+	if v.check(def) != nil {
+		panic("settings: invalid default for " + name)
+	}
+	s.declare(v)
+	return v
+}
+
+// Get returns the current value.
+func (v *StringSetting) Get() string { return v.value }
+
+// Set changes the current value, unless it is rejected by Validate, giving
+// its error, or holds a line break, giving ErrInvalidSetting.
+func (v *StringSetting) Set(value string) error {
+	if err := v.check(value); err != nil {
+		return err
+	}
+	v.value = value
+	return nil
+}
+
+// check returns the error Set would give for the value.
+func (v *StringSetting) check(value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return ErrInvalidSetting
+	}
+	if v.Validate != nil {
+		return v.Validate(value)
+	}
+	return nil
+}
+
+func (v *StringSetting) name() string   { return v.Name }
+func (v *StringSetting) format() string { return v.value }
+
+func (v *StringSetting) parse(s string) (func(), error) {
+	if err := v.check(s); err != nil {
+		return nil, err
+	}
+	return func() { v.value = s }, nil
+}
+
+func (v *StringSetting) element(label string, x, y int) Element {
+	return &settingTextBox{NewTextBox(v.value, 20, x+len([]rune(label))+1, y), label, v}
+}
+
+// settingTextBox is a TextBox for a StringSetting, which is labeled and only
+// keeps text which the StringSetting accepts.
+type settingTextBox struct {
+	*TextBox
+	label   string
+	setting *StringSetting
+}
+
+// Update draws the label before the TextBox.
+func (t *settingTextBox) Update(selected bool) {
+	drawString(t.X-len([]rune(t.label))-1, t.Y, t.label, t.getColor(selected))
+	t.TextBox.Update(selected)
+}
+
+// Activate edits the text, then sets the StringSetting, or restores the text
+// if the StringSetting rejects it.
+func (t *settingTextBox) Activate() FormResult {
+	t.TextBox.Activate()
+	if t.setting.Set(t.Text) != nil {
+		t.Text = t.setting.value
+	}
+	return nil
+}
+
+// ResultSettingsDone is the result from running a Form made by Settings.Form
+// when the user activates its done button.
+var ResultSettingsDone = NewFormResult("DONE")
+
+// Form returns a Form for editing every setting, one per row starting at the
+// given location in order of declaration, followed by a button which gives
+// ResultSettingsDone. Bool settings are shown as a CheckBox, Int settings as
+// a Spinner, Enum settings as a Cycle and String settings as a TextBox. Each
+// change is applied as soon as it is made, so escaping the Form does not undo
+// earlier changes.
+func (s *Settings) Form(x, y int) Form {
+	width := 0
+	for _, v := range s.settings {
+		width = Max(width, len([]rune(v.name())))
+	}
+	var form Form
+	for i, v := range s.settings {
+		label := v.name() + strings.Repeat(" ", width-len([]rune(v.name())))
+		form.Elements = append(form.Elements, v.element(label, x, y+i))
+	}
+	form.Elements = append(form.Elements, NewSubmit("Done", x, y+len(s.settings)+1, ResultSettingsDone))
+	return form
+}
+
+// SettingError reports a problem with a line of a settings file. Err is
+// ErrSettingSyntax, ErrUnknownSetting, ErrInvalidSetting or an error from the
+// Validate function of the setting.
+type SettingError struct {
+	Line int
+	Err  error
+}
+
+// Error describes the problem along with its line number.
+func (e *SettingError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns Err, so that errors.Is can check the kind of problem.
+func (e *SettingError) Unwrap() error {
+	return e.Err
+}
+
+// Load reads settings written by Save, with one "name = value" per line.
+// Blank lines and lines starting with '#' are ignored, and settings which are
+// not given keep their current value. Problems are reported as a
+// *SettingError, in which case no setting is changed.
+func (s *Settings) Load(r io.Reader) error {
+	var changes []func()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return &SettingError{n, ErrSettingSyntax}
+		}
+		v, ok := s.byName[strings.TrimSpace(name)]
+		if !ok {
+			return &SettingError{n, ErrUnknownSetting}
+		}
+		apply, err := v.parse(strings.TrimSpace(value))
+		if err != nil {
+			return &SettingError{n, err}
+		}
+		changes = append(changes, apply)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, apply := range changes {
+		apply()
+	}
+	return nil
+}
+
+// Save writes every setting with one "name = value" per line, in order of
+// declaration, for Load to read back.
+func (s *Settings) Save(w io.Writer) error {
+	for _, v := range s.settings {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", v.name(), v.format()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile reads settings from the file at path, as with Load.
+func (s *Settings) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Load(f)
+}
+
+// SaveFile writes settings to the file at path, as with Save, replacing the
+// file if it exists.
+func (s *Settings) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
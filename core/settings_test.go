@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// settingsCase declares one setting of each type.
+func settingsCase() (s *Settings, pickup *BoolSetting, speed *IntSetting, theme *EnumSetting, name *StringSetting) {
+	s = NewSettings()
+	pickup = s.Bool("autopickup", true)
+	speed = s.Int("animation speed", 5, 0, 10)
+	speed.Validate = func(v int) error {
+		if v == 7 {
+			return ErrNoTarget
+		}
+		return nil
+	}
+	theme = s.Enum("theme", "dark", "dark", "light", "mono")
+	name = s.String("name", "Ug", func(v string) error {
+		if v == "" {
+			return ErrInvalidSetting
+		}
+		return nil
+	})
+	return s, pickup, speed, theme, name
+}
+
+func TestSettings(t *testing.T) {
+	_, pickup, speed, theme, name := settingsCase()
+	if !pickup.Get() || speed.Get() != 5 || theme.Get() != "dark" || name.Get() != "Ug" {
+		t.Errorf("declared defaults %v %v %v %v", pickup.Get(), speed.Get(), theme.Get(), name.Get())
+	}
+	if err := speed.Set(11); err != ErrInvalidSetting || speed.Get() != 5 {
+		t.Errorf("IntSetting.Set(11) = %v, left %d", err, speed.Get())
+	}
+	if err := speed.Set(7); err != ErrNoTarget {
+		t.Errorf("IntSetting.Set(7) = %v", err)
+	}
+	if err := theme.Set("pink"); err != ErrInvalidSetting || theme.Get() != "dark" {
+		t.Errorf("EnumSetting.Set(pink) = %v, left %s", err, theme.Get())
+	}
+	if err := name.Set(""); err != ErrInvalidSetting || name.Get() != "Ug" {
+		t.Errorf("StringSetting.Set(\"\") = %v, left %s", err, name.Get())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate declaration did not panic")
+		}
+	}()
+	s, _, _, _, _ := settingsCase()
+	s.Bool("theme", false)
+}
+
+func TestSettings_persist(t *testing.T) {
+	s, pickup, speed, theme, name := settingsCase()
+	pickup.Set(false)
+	speed.Set(2)
+	theme.Set("mono")
+	name.Set("Grok the Great")
+	var buf bytes.Buffer
+	if err := s.Save(&buf); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	loaded, pickup, speed, theme, name := settingsCase()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if pickup.Get() || speed.Get() != 2 || theme.Get() != "mono" || name.Get() != "Grok the Great" {
+		t.Errorf("Load() gave %v %v %v %v", pickup.Get(), speed.Get(), theme.Get(), name.Get())
+	}
+
+	cases := []struct {
+		text string
+		line int
+		err  error
+	}{
+		{"theme = light\nspeed = 3", 2, ErrUnknownSetting},
+		{"# comment\n\ntheme light", 3, ErrSettingSyntax},
+		{"autopickup = maybe", 1, ErrInvalidSetting},
+		{"theme = light\nanimation speed = 7", 2, ErrNoTarget},
+		{"name =", 1, ErrInvalidSetting},
+	}
+	for _, c := range cases {
+		err := loaded.Load(strings.NewReader(c.text))
+		var serr *SettingError
+		if !errors.As(err, &serr) || serr.Line != c.line || !errors.Is(err, c.err) {
+			t.Errorf("Load(%q) = %v", c.text, err)
+		}
+	}
+	if theme.Get() != "mono" {
+		t.Errorf("failed Load() changed theme to %s", theme.Get())
+	}
+}
+
+func TestSettings_Form(t *testing.T) {
+	s, pickup, _, theme, _ := settingsCase()
+	form := s.Form(2, 1)
+	if len(form.Elements) != 5 {
+		t.Fatalf("Form() gave %d elements", len(form.Elements))
+	}
+	box, ok := form.Elements[0].(*CheckBox)
+	if !ok || box.Text != "autopickup     " {
+		t.Fatalf("Form() gave %#v for a Bool", form.Elements[0])
+	}
+	box.Activate()
+	if pickup.Get() {
+		t.Errorf("CheckBox did not change autopickup")
+	}
+	if _, ok := form.Elements[1].(*Spinner); !ok {
+		t.Errorf("Form() gave %#v for an Int", form.Elements[1])
+	}
+	cycle, ok := form.Elements[2].(*Cycle)
+	if !ok {
+		t.Fatalf("Form() gave %#v for an Enum", form.Elements[2])
+	}
+	for _, expected := range []string{"light", "mono", "dark"} {
+		cycle.Activate()
+		if theme.Get() != expected {
+			t.Errorf("Cycle gave theme %s != %s", theme.Get(), expected)
+		}
+	}
+	if result := form.Elements[4].Activate(); result != ResultSettingsDone {
+		t.Errorf("done button gave %v", result)
+	}
+}
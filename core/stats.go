@@ -0,0 +1,153 @@
+package core
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Heal is an Event restoring hit points to an Entity, up to its maximum.
+type Heal struct {
+	Amount int
+}
+
+// StatsChanged is an Event informing an Entity that its Stats have changed,
+// so that displays such as a status bar can be updated.
+type StatsChanged struct {
+	HP    int
+	MaxHP int
+	Delta int
+}
+
+// StatsRequest is an Event querying an Entity for its Stats. Values holds a
+// copy of any additional stats, so it may be freely modified.
+type StatsRequest struct {
+	HP     int
+	MaxHP  int
+	Values map[string]int
+}
+
+// SetStat is an Event setting one of the named stats of an Entity.
+type SetStat struct {
+	Name  string
+	Value int
+}
+
+// Stats is a Component holding the hit points of its Entity, along with any
+// other named stats. Stats handles Damage, Heal, SetStat and StatsRequest, and
+// sends a StatsChanged to Self whenever the hit points change.
+//
+// Hit points never go below zero, and a negative Amount of Damage or Heal is
+// treated as zero, so Damage never heals. The Damage which first reduces the
+// hit points to zero sends a Death to Self, with the Source of that Damage as
+// the Killer, and any later Damage is ignored. Since each Damage is handled
+// separately, Damage from several sources in one turn resolves in the order
+// the Damage is handled.
+type Stats struct {
+	Self   Entity
+	HP     int
+	MaxHP  int
+	Values map[string]int
+}
+
+// NewStats creates a Stats with full hit points.
+func NewStats(self Entity, maxHP int) *Stats {
+	return &Stats{self, maxHP, maxHP, make(map[string]int)}
+}
+
+// Process implements Component for Stats.
+func (c *Stats) Process(v Event) {
+	switch v := v.(type) {
+	case *Damage:
+		if c.HP <= 0 {
+			return
+		}
+		c.change(-Min(Max(v.Amount, 0), c.HP))
+		if c.HP == 0 {
+			send(c.Self, &Death{c.Self, v.Source})
+		}
+	case *Heal:
+		if c.HP > 0 {
+			c.change(Min(Max(v.Amount, 0), c.MaxHP-c.HP))
+		}
+	case *SetStat:
+		if c.Values == nil {
+			c.Values = make(map[string]int)
+		}
+		c.Values[v.Name] = v.Value
+	case *StatsRequest:
+		v.HP, v.MaxHP = c.HP, c.MaxHP
+		v.Values = make(map[string]int, len(c.Values))
+		for name, value := range c.Values {
+			v.Values[name] = value
+		}
+	}
+}
+
+// Names returns the names of the additional stats in sorted order.
+func (c *Stats) Names() []string {
+	names := make([]string, 0, len(c.Values))
+	for name := range c.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// change adjusts the hit points, and sends StatsChanged if they changed.
+func (c *Stats) change(delta int) {
+	if delta == 0 {
+		return
+	}
+	c.HP += delta
+	send(c.Self, &StatsChanged{c.HP, c.MaxHP, delta})
+}
+
+// Regen is a Component which restores hit points over time, by sending its
+// Entity a Heal of Amount every Interval turns, as counted by Tick. The Entity
+// must be sent each Tick, such as by subscribing it to the EventBus of a
+// Clock. Whenever its Entity loses hit points, as reported by StatsChanged,
+// Regen pauses for Pause turns before counting again. Since Regen relies on
+// Heal, hit points never exceed the maximum of the Stats.
+type Regen struct {
+	Self     Entity
+	Amount   int
+	Interval int
+	Pause    int
+
+	waited int // turns counted toward the next Heal
+	paused int // turns remaining in the pause
+}
+
+// Process implements Component for Regen.
+func (c *Regen) Process(v Event) {
+	switch v := v.(type) {
+	case *Tick:
+		if c.paused > 0 {
+			c.paused--
+			return
+		}
+		if c.waited++; c.waited >= c.Interval {
+			c.waited = 0
+			send(c.Self, &Heal{c.Amount})
+		}
+	case *StatsChanged:
+		if v.Delta < 0 {
+			c.waited, c.paused = 0, c.Pause
+		}
+	}
+}
+
+// RegenConstructor is a ComponentConstructor for Regen, which can be made
+// available to LoadPrototypes with RegisterConstructor. The parameters give
+// the amount, interval and pause, such as:
+//
+//	{"name": "regen", "params": {"amount": 1, "interval": 5, "pause": 10}}
+func RegenConstructor(params json.RawMessage) (ComponentFactory, error) {
+	var p struct{ Amount, Interval, Pause int }
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return func(self Entity) Component {
+		return &Regen{Self: self, Amount: p.Amount, Interval: p.Interval, Pause: p.Pause}
+	}, nil
+}
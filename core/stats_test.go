@@ -0,0 +1,99 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	var changes []int
+	var deaths []Death
+	stats := NewStats(nil, 10)
+	entity := NewEntity(stats, testfunc(func(v Event) {
+		switch v := v.(type) {
+		case *StatsChanged:
+			changes = append(changes, v.Delta)
+		case *Death:
+			deaths = append(deaths, *v)
+		}
+	}))
+	stats.Self = entity
+
+	goblin, wolf := &testentity{"goblin"}, &testentity{"wolf"}
+	events := []Event{
+		&Damage{Amount: 4, Source: goblin},
+		&Heal{Amount: 1},
+		&Heal{Amount: 10},
+		&Damage{Amount: 0, Source: goblin},
+		&Damage{Amount: -5, Source: goblin}, // never heals
+		&Heal{Amount: -5},                   // never harms
+		&Damage{Amount: 7, Source: goblin},
+		&Damage{Amount: 8, Source: wolf},   // overkill
+		&Damage{Amount: 3, Source: goblin}, // already dead
+		&Heal{Amount: 5},                   // dead stay dead
+	}
+	for _, v := range events {
+		entity.Handle(v)
+	}
+
+	if expected := []int{-4, 1, 3, -7, -3}; !reflect.DeepEqual(changes, expected) {
+		t.Errorf("StatsChanged deltas %v != %v", changes, expected)
+	}
+	if len(deaths) != 1 || deaths[0].Victim != entity || deaths[0].Killer != wolf {
+		t.Errorf("Death = %v", deaths)
+	}
+
+	entity.Handle(&SetStat{"str", 12})
+	req := StatsRequest{}
+	entity.Handle(&req)
+	req.Values["str"] = 1
+	if req.HP != 0 || req.MaxHP != 10 || stats.Values["str"] != 12 {
+		t.Errorf("StatsRequest = %v", req)
+	}
+}
+
+func TestRegen(t *testing.T) {
+	stats := NewStats(nil, 10)
+	regen := &Regen{Amount: 2, Interval: 3, Pause: 2}
+	entity := NewEntity(stats, regen)
+	stats.Self, regen.Self = entity, entity
+	bus := NewEventBus()
+	Subscribe(bus, func(v *Tick) { entity.Handle(v) })
+	clock := NewClock(bus)
+
+	entity.Handle(&Damage{Amount: 7})
+	cases := []struct {
+		turns int
+		hp    int
+	}{
+		{2, 3}, // paused after damage
+		{2, 3}, // counting toward the interval
+		{1, 5},
+		{3, 7},
+		{6, 10}, // capped at the maximum
+	}
+	for i, c := range cases {
+		clock.Advance(c.turns * TicksPerTurn)
+		if stats.HP != c.hp {
+			t.Errorf("case %d: HP = %d != %d", i, stats.HP, c.hp)
+		}
+	}
+}
+
+func TestRegenConstructor(t *testing.T) {
+	RegisterConstructor("regen", RegenConstructor)
+	protos, err := LoadPrototypes(strings.NewReader(`{
+		"troll": {"glyph": "T", "components": [
+			{"name": "regen", "params": {"amount": 3, "interval": 2, "pause": 4}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPrototypes() = %v", err)
+	}
+	troll, _ := Spawn(protos["troll"], nil, nil)
+	regen := troll.Components()[0].(*Regen)
+	if regen.Self != troll || regen.Amount != 3 || regen.Interval != 2 || regen.Pause != 4 {
+		t.Errorf("Spawn() gave Regen %v", regen)
+	}
+}
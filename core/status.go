@@ -0,0 +1,137 @@
+package core
+
+// ApplyEffect is an Event applying a named status effect to an Entity for a
+// number of turns. If the effect is already active, Stack determines whether
+// the durations add together, or the remaining duration is simply refreshed.
+//
+// Each turn, OnTick is called with the affected Entity, such as to deal poison
+// Damage. While active, Intercept is called with every other Event handled by
+// the Entity, such as to scramble the Delta of a MoveEntity while confused.
+// When the effect expires, Expire is sent to the Entity as a Message.
+type ApplyEffect struct {
+	Name      string
+	Duration  int
+	Stack     bool
+	OnTick    func(Entity)
+	Intercept func(Event)
+	Expire    string
+}
+
+// ActiveEffect describes a status effect in an EffectsRequest.
+type ActiveEffect struct {
+	Name      string
+	Remaining int
+	Stacks    int
+}
+
+// EffectsRequest is an Event querying an Entity for its active status effects,
+// in the order they were applied.
+type EffectsRequest struct {
+	Effects []ActiveEffect
+}
+
+// RemoveEffect is an Event removing a status effect early, without an Expire
+// Message. Done is set to true if the effect was active.
+type RemoveEffect struct {
+	Name string
+	Done bool
+}
+
+// effect is a single active status effect.
+type effect struct {
+	ApplyEffect
+	remaining int
+	stacks    int
+}
+
+// StatusEffects is a Component tracking status effects on its Entity. Effects
+// count down on each Tick, so they last the same time however fast the Entity
+// acts, and the Entity must be sent each Tick, such as by subscribing it to
+// the EventBus of a Clock. StatusEffects has a priority of 2, so in a
+// SortedEntity its effects intercept Events before most other Component.
+type StatusEffects struct {
+	Self    Entity
+	effects []*effect
+}
+
+// Priority implements Prioritized for StatusEffects.
+func (c *StatusEffects) Priority() int {
+	return 2
+}
+
+// Process implements Component for StatusEffects.
+func (c *StatusEffects) Process(v Event) {
+	switch v := v.(type) {
+	case *ApplyEffect:
+		c.apply(v)
+	case *RemoveEffect:
+		for i, e := range c.effects {
+			if e.Name == v.Name {
+				c.effects = append(c.effects[:i:i], c.effects[i+1:]...)
+				v.Done = true
+				return
+			}
+		}
+	case *EffectsRequest:
+		for _, e := range c.effects {
+			v.Effects = append(v.Effects, ActiveEffect{e.Name, e.remaining, e.stacks})
+		}
+	case *Tick:
+		c.tick()
+	default:
+		for _, e := range c.effects {
+			if e.Intercept != nil {
+				e.Intercept(v)
+			}
+		}
+	}
+}
+
+// Has returns true if the named effect is active.
+func (c *StatusEffects) Has(name string) bool {
+	for _, e := range c.effects {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// apply adds an effect, or stacks or refreshes it if already active.
+func (c *StatusEffects) apply(v *ApplyEffect) {
+	for _, e := range c.effects {
+		if e.Name == v.Name {
+			if v.Stack {
+				e.remaining += v.Duration
+				e.stacks++
+			} else if v.Duration > e.remaining {
+				e.remaining = v.Duration
+			}
+			return
+		}
+	}
+	c.effects = append(c.effects, &effect{*v, v.Duration, 1})
+}
+
+// tick applies each effect for a turn, and removes any which have expired.
+func (c *StatusEffects) tick() {
+	for _, e := range append([]*effect(nil), c.effects...) {
+		if e.OnTick != nil {
+			e.OnTick(c.Self)
+		}
+		e.remaining--
+	}
+
+	effects := c.effects[:0]
+	for _, e := range c.effects {
+		if e.remaining > 0 {
+			effects = append(effects, e)
+		} else if e.Expire != "" && c.Self != nil {
+			send(c.Self, &Message{Text: e.Expire})
+		}
+	}
+	for i := len(effects); i < len(c.effects); i++ {
+		c.effects[i] = nil
+	}
+	c.effects = effects
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatusEffects(t *testing.T) {
+	var messages []string
+	status := &StatusEffects{}
+	health := NewHealth(10)
+	entity := NewEntity(health, status, testfunc(func(v Event) {
+		if v, ok := v.(*Message); ok {
+			messages = append(messages, v.Text)
+		}
+	}))
+	status.Self = entity
+
+	poison := ApplyEffect{
+		Name:     "poison",
+		Duration: 2,
+		Stack:    true,
+		OnTick:   func(e Entity) { e.Handle(&Damage{Amount: 1}) },
+		Expire:   "You feel better.",
+	}
+	haste := ApplyEffect{Name: "haste", Duration: 3}
+
+	entity.Handle(&poison)
+	entity.Handle(&haste)
+	entity.Handle(&poison)
+	entity.Handle(&ApplyEffect{Name: "haste", Duration: 1})
+
+	req := EffectsRequest{}
+	entity.Handle(&req)
+	expected := []ActiveEffect{{"poison", 4, 2}, {"haste", 3, 1}}
+	if !reflect.DeepEqual(req.Effects, expected) {
+		t.Errorf("EffectsRequest = %v != %v", req.Effects, expected)
+	}
+
+	for i := 0; i < 4; i++ {
+		entity.Handle(&Tick{})
+	}
+	if health.HP != 6 {
+		t.Errorf("poison left %d hp", health.HP)
+	}
+	if status.Has("poison") || status.Has("haste") {
+		t.Errorf("effects did not expire")
+	}
+	if !reflect.DeepEqual(messages, []string{"You feel better."}) {
+		t.Errorf("expiry messages = %v", messages)
+	}
+}
+
+func TestStatusEffects_Intercept(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	hero := &testally{}
+	status := &StatusEffects{}
+	entity := NewEntity(status, testfunc(hero.Handle))
+	status.Self = entity
+	tiles[1].Occupant = entity
+	hero.Pos = tiles[1]
+
+	// confusion reverses every move
+	entity.Handle(&ApplyEffect{Name: "confusion", Duration: 1, Intercept: func(v Event) {
+		if v, ok := v.(*MoveEntity); ok {
+			v.Delta = v.Delta.Neg()
+		}
+	}})
+	Move(tiles[1], Offset{1, 0})
+	if hero.Pos != tiles[0] {
+		t.Errorf("confusion did not scramble move")
+	}
+
+	entity.Handle(&Tick{})
+	Move(tiles[0], Offset{1, 0})
+	if hero.Pos != tiles[1] {
+		t.Errorf("confusion did not expire")
+	}
+}
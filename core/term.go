@@ -1,6 +1,9 @@
 package core
 
 import (
+	"strings"
+	"time"
+
 	"github.com/nsf/termbox-go"
 )
 
@@ -63,6 +66,30 @@ func TermSave() State {
 	return state
 }
 
+// SnapshotText returns the characters of the internal buffer as text, one line
+// per row, such as for a character dump. Colors are dropped, trailing spaces
+// are trimmed from each line, and trailing blank lines are omitted, so the
+// result is empty if nothing has been drawn.
+func SnapshotText() string {
+	cols, rows := termbox.Size()
+	cells := termbox.CellBuffer()
+
+	lines := make([]string, 0, rows)
+	for y := 0; y < rows && (y+1)*cols <= len(cells); y++ {
+		line := make([]rune, cols)
+		for x, cell := range cells[y*cols : (y+1)*cols] {
+			if line[x] = cell.Ch; cell.Ch == 0 {
+				line[x] = ' '
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Restore reverts the state of the buffer to the previously saved state.
 func (s State) Restore() {
 	for y, row := range s {
@@ -82,6 +109,58 @@ func GetKey() Key {
 	}
 }
 
+// TermMouse enables or disables mouse input, reported by GetInput.
+func TermMouse(enable bool) {
+	mode := termbox.InputEsc
+	if enable {
+		mode |= termbox.InputMouse
+	}
+	termbox.SetInputMode(mode)
+}
+
+// Input is a keypress or mouse event. For mouse events, Mouse is true, Key is
+// the button, such as KeyMouseLeft or KeyMouseRelease, and X and Y give the
+// screen location. Motion is true if the mouse moved rather than clicked,
+// which most terminals only report while a button is held.
+type Input struct {
+	Key    Key
+	Mouse  bool
+	Motion bool
+	X, Y   int
+}
+
+// GetInput returns the next keypress or, if enabled with TermMouse, mouse
+// event. It blocks until there is one.
+func GetInput() Input {
+	for {
+		event := termbox.PollEvent()
+		switch event.Type {
+		case termbox.EventKey:
+			return Input{Key: Key(event.Ch) | Key(event.Key)}
+		case termbox.EventMouse:
+			motion := event.Mod&termbox.ModMotion != 0
+			return Input{Key: Key(event.Key), Mouse: true, Motion: motion, X: event.MouseX, Y: event.MouseY}
+		}
+	}
+}
+
+// PollKey returns the next keypress, waiting at most the given timeout for
+// one. If no key is pressed before the timeout, ok will be false.
+func PollKey(timeout time.Duration) (key Key, ok bool) {
+	timer := time.AfterFunc(timeout, termbox.Interrupt)
+	defer timer.Stop()
+
+	for {
+		event := termbox.PollEvent()
+		switch event.Type {
+		case termbox.EventKey:
+			return Key(event.Ch) | Key(event.Key), true
+		case termbox.EventInterrupt:
+			return 0, false
+		}
+	}
+}
+
 // Visual represents something which can be drawn in the terminal.
 type Visual interface {
 	Update()
@@ -0,0 +1,117 @@
+package core
+
+// TerrainID identifies a kind of terrain in the Terrains table. The zero
+// TerrainID means a Tile has no terrain, and its properties are simply given
+// by its own fields.
+type TerrainID string
+
+// Standard TerrainID values, each of which has an entry in Terrains.
+const (
+	TerrainFloor    TerrainID = "floor"
+	TerrainWall     TerrainID = "wall"
+	TerrainWater    TerrainID = "water"
+	TerrainLava     TerrainID = "lava"
+	TerrainGrass    TerrainID = "grass"
+	TerrainMeadow   TerrainID = "meadow"
+	TerrainRubble   TerrainID = "rubble"
+	TerrainSand     TerrainID = "sand"
+	TerrainTree     TerrainID = "tree"
+	TerrainMountain TerrainID = "mountain"
+)
+
+// Terrain describes the properties shared by every Tile of a kind of terrain.
+// Face, Pass, Lite and Cost are copied to the Tile by SetTerrain. If Swim is
+// true, only an Entity which answers a SwimQuery by swimming can move onto the
+// Tile. If Damage is positive, each Entity entering the Tile is sent a Damage
+// of that Amount and DamageType.
+type Terrain struct {
+	Face       Glyph
+	Pass       bool
+	Lite       bool
+	Cost       float64
+	Swim       bool
+	Damage     int
+	DamageType string
+}
+
+// Terrains is the table of Terrain for each TerrainID. Games may freely add
+// or change entries, but should do so before any Tile uses them.
+var Terrains = map[TerrainID]Terrain{
+	TerrainFloor:    {Glyph{'.', ColorWhite}, true, true, 1, false, 0, ""},
+	TerrainWall:     {Glyph{'#', ColorWhite}, false, false, 1, false, 0, ""},
+	TerrainWater:    {Glyph{'~', ColorBlue}, true, true, 2, true, 0, ""},
+	TerrainLava:     {Glyph{'~', ColorRed}, true, true, 1, false, 10, "fire"},
+	TerrainGrass:    {Glyph{'"', ColorGreen}, true, false, 1, false, 0, ""},
+	TerrainMeadow:   {Glyph{'.', ColorGreen}, true, true, 1, false, 0, ""},
+	TerrainRubble:   {Glyph{':', ColorLightBlack}, true, true, 2, false, 0, ""},
+	TerrainSand:     {Glyph{'.', ColorYellow}, true, true, 1, false, 0, ""},
+	TerrainTree:     {Glyph{'♣', ColorGreen}, true, false, 2, false, 0, ""},
+	TerrainMountain: {Glyph{'^', ColorWhite}, false, false, 1, false, 0, ""},
+}
+
+// NewTerrainTile creates a new Tile with the given terrain.
+func NewTerrainTile(o Offset, id TerrainID) *Tile {
+	t := NewTile(o)
+	t.SetTerrain(id)
+	return t
+}
+
+// SetTerrain changes the terrain of the Tile, such as when digging through a
+// wall or freezing water, updating its Face, Pass, Lite and Cost to match the
+// entry in Terrains. If the TerrainID has no entry, only the Terrain field is
+// changed.
+func (e *Tile) SetTerrain(id TerrainID) {
+	e.Terrain = id
+	if info, ok := Terrains[id]; ok {
+		e.Face, e.Pass, e.Lite, e.Cost = info.Face, info.Pass, info.Lite, info.Cost
+	}
+}
+
+// terrain gets the Terrain of the Tile, which is the zero Terrain if the Tile
+// has no terrain.
+func (e *Tile) terrain() Terrain {
+	return Terrains[e.Terrain]
+}
+
+// admits returns true if the mover is able to move onto the Tile, ignoring
+// any occupant. The Tile must be passable, and if its terrain requires
+// swimming, the mover must be able to swim.
+func (e *Tile) admits(mover Entity) bool {
+	if !e.Pass {
+		return false
+	}
+	if e.terrain().Swim {
+		query := SwimQuery{}
+		mover.Handle(&query)
+		return query.Swim
+	}
+	return true
+}
+
+// SwimQuery is an Event asking an Entity whether it can swim, and so move onto
+// Tiles whose terrain requires swimming. Entities cannot swim by default.
+type SwimQuery struct {
+	Swim bool
+}
+
+// TerrainMapGenBool creates a MapGenBool which uses the given terrain for
+// passable and impassable Tiles.
+func TerrainMapGenBool(pass, impass TerrainID) MapGenBool {
+	return func(o Offset, isPass bool) *Tile {
+		if isPass {
+			return NewTerrainTile(o, pass)
+		}
+		return NewTerrainTile(o, impass)
+	}
+}
+
+// TerrainMapGenInt creates a MapGenInt which uses the terrain given for each
+// tile type, such as TileTypeWall. Tile types with no terrain given are floor.
+func TerrainMapGenInt(types map[int]TerrainID) MapGenInt {
+	return func(o Offset, tiletype int) *Tile {
+		if id, ok := types[tiletype]; ok {
+			return NewTerrainTile(o, id)
+		}
+		return NewTerrainTile(o, TerrainFloor)
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"testing"
+)
+
+// testswimmer is an occupant which tracks its position, whether it can swim,
+// and the Damage it has taken.
+type testswimmer struct {
+	Pos   *Tile
+	Swim  bool
+	Taken int
+}
+
+func (s *testswimmer) Handle(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		s.Pos = v.Pos
+	case *SwimQuery:
+		v.Swim = s.Swim
+	case *Damage:
+		s.Taken += v.Amount
+	}
+}
+
+func TestTile_SetTerrain(t *testing.T) {
+	tile := NewTerrainTile(Offset{}, TerrainWall)
+	if tile.Pass || tile.Transparent() || tile.Face != Terrains[TerrainWall].Face {
+		t.Errorf("NewTerrainTile(TerrainWall) gave %v", tile)
+	}
+	tile.SetTerrain(TerrainRubble)
+	if !tile.Pass || !tile.Transparent() || tile.Cost != 2 || tile.Terrain != TerrainRubble {
+		t.Errorf("SetTerrain(TerrainRubble) gave %v", tile)
+	}
+	tile.SetTerrain(TerrainGrass)
+	if !tile.Pass || tile.Transparent() {
+		t.Errorf("SetTerrain(TerrainGrass) gave %v", tile)
+	}
+	tile.SetTerrain(TerrainMeadow)
+	if !tile.Pass || !tile.Transparent() {
+		t.Errorf("SetTerrain(TerrainMeadow) gave %v", tile)
+	}
+}
+
+func TestTile_HandleTerrain(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	tiles[1].SetTerrain(TerrainWater)
+	tiles[2].SetTerrain(TerrainLava)
+	walker := &testswimmer{tiles[0], false, 0}
+	tiles[0].Occupant = walker
+
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if walker.Pos != tiles[0] {
+		t.Errorf("MoveEntity moved non-swimmer into water")
+	}
+
+	walker.Swim = true
+	move := MoveEntity{Delta: Offset{1, 0}}
+	tiles[0].Handle(&move)
+	if walker.Pos != tiles[1] || move.Cost != 200 {
+		t.Errorf("MoveEntity moved swimmer to %v with cost %d", walker.Pos, move.Cost)
+	}
+
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if walker.Pos != tiles[2] || walker.Taken != Terrains[TerrainLava].Damage {
+		t.Errorf("MoveEntity into lava dealt %d damage", walker.Taken)
+	}
+}
+
+func TestTerrainMapGenBool(t *testing.T) {
+	gen := TerrainMapGenBool(TerrainGrass, TerrainWater)
+	if tile := gen(Offset{}, true); tile.Terrain != TerrainGrass || !tile.Pass {
+		t.Errorf("passable Tile has terrain %v", tile.Terrain)
+	}
+	if tile := gen(Offset{}, false); tile.Terrain != TerrainWater {
+		t.Errorf("impassable Tile has terrain %v", tile.Terrain)
+	}
+}
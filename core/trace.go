@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// eventTracer is the function set by SetEventTracer, or nil if disabled.
+var eventTracer func(target Entity, v Event, depth int)
+
+// traceDepth is the nesting depth of the dispatch currently being traced.
+var traceDepth int
+
+// traceQueued is true while the tracer is reporting a delivery by EventQueue.
+var traceQueued bool
+
+// SetEventTracer installs a function which is called each time an Event is
+// dispatched by ComponentSlice, Tile, EventQueue or EventBus, for debugging
+// which Entity saw which Event. The depth is the number of dispatches already
+// in progress, so Events sent while handling another Event are nested below
+// it. Events published on an EventBus have a nil target. Passing nil disables
+// tracing.
+func SetEventTracer(tracer func(target Entity, v Event, depth int)) {
+	eventTracer = tracer
+	traceDepth = 0
+}
+
+// traceEnter reports a dispatch to the tracer and increases the depth. Each
+// call must be paired with a call to traceExit.
+func traceEnter(target Entity, v Event) {
+	eventTracer(target, v, traceDepth)
+	traceQueued = false
+	traceDepth++
+}
+
+// traceExit decreases the depth once a traced dispatch finishes.
+func traceExit() {
+	traceDepth--
+}
+
+// WriteTracer creates an event tracer for SetEventTracer which writes one
+// line per dispatch to w, indented by depth. Each line gives the type of the
+// Event along with any of its exported fields holding simple values, followed
+// by the type of the target.
+func WriteTracer(w io.Writer) func(target Entity, v Event, depth int) {
+	return func(target Entity, v Event, depth int) {
+		to := "bus"
+		if target != nil {
+			to = fmt.Sprintf("%T", target)
+		}
+		fmt.Fprintf(w, "%s%s -> %s\n", strings.Repeat("  ", depth), describeEvent(v), to)
+	}
+}
+
+// describeEvent gives a compact description of an Event, consisting of its
+// type name and any exported fields which are not references to other values.
+func describeEvent(v Event) string {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() {
+		return "<nil>"
+	}
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Sprintf("%T", v)
+	}
+
+	var fields []string
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.PkgPath != "" || field.Anonymous {
+			continue
+		}
+		switch val.Field(i).Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s=%v", field.Name, val.Field(i).Interface()))
+	}
+	return fmt.Sprintf("%s{%s}", val.Type().Name(), strings.Join(fields, " "))
+}
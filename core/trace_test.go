@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSetEventTracer(t *testing.T) {
+	type dispatch struct {
+		Target Entity
+		Depth  int
+	}
+	var traced []dispatch
+	SetEventTracer(func(target Entity, v Event, depth int) {
+		traced = append(traced, dispatch{target, depth})
+	})
+	defer SetEventTracer(nil)
+
+	inner := ComponentSlice{}
+	outer := ComponentSlice{testfunc(func(v Event) {
+		if _, ok := v.(*Bump); ok {
+			inner.Handle(&Collide{})
+		}
+	})}
+	outer.Handle(&Bump{})
+	bus := NewEventBus()
+	bus.Publish(&Bump{})
+
+	expected := []dispatch{{outer, 0}, {inner, 1}, {nil, 0}}
+	if len(traced) != len(expected) {
+		t.Fatalf("traced %d dispatches != %d", len(traced), len(expected))
+	}
+	for i, d := range expected {
+		if !reflect.DeepEqual(traced[i], d) {
+			t.Errorf("dispatch %d = %v != %v", i, traced[i], d)
+		}
+	}
+}
+
+func TestWriteTracer(t *testing.T) {
+	var buf bytes.Buffer
+	SetEventTracer(WriteTracer(&buf))
+	defer SetEventTracer(nil)
+
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	tiles[0].Occupant = ComponentSlice{}
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+
+	expected := "MoveEntity{Delta={1 0} Cost=0} -> *core.Tile\n" +
+		"  UpdatePos{} -> core.ComponentSlice\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTracer wrote %q != %q", buf.String(), expected)
+	}
+}
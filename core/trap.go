@@ -0,0 +1,58 @@
+package core
+
+// Trap is an Entity intended as the Trigger of a Tile, such as a pit, a
+// pressure plate or a teleporter. When an Entity enters the Tile, the Trap
+// calls Spring with the Entity and becomes Discovered. A Trap which is not
+// Discovered is not rendered, so hidden traps look like ordinary floor. A
+// OneShot Trap removes itself from its Tile once sprung.
+type Trap struct {
+	Face       Glyph
+	Discovered bool
+	OneShot    bool
+	Spring     func(who Entity, pos *Tile)
+}
+
+// Handle implements Entity for Trap.
+func (t *Trap) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		if t.Discovered {
+			v.Render = t.Face
+		}
+	case *Entered:
+		t.Discovered = true
+		if t.Spring != nil {
+			t.Spring(v.Who, v.Pos)
+		}
+		if t.OneShot && v.Pos.Trigger == t {
+			v.Pos.Trigger = nil
+		}
+	}
+}
+
+// Stairs is an Entity intended as the Trigger of a Tile, which sends a
+// Transition to Dest whenever an Entity enters its Tile. Dest is a function so
+// that the destination level can be generated only when first needed. If Dest
+// is nil or returns nil, nothing happens. If Bus is set, each Transition is
+// published on it once the occupant has actually moved, such as for an
+// Autosaver to notice level changes.
+type Stairs struct {
+	Face Glyph
+	Dest func() *Tile
+	Bus  *EventBus
+}
+
+// Handle implements Entity for Stairs.
+func (s *Stairs) Handle(v Event) {
+	switch v := v.(type) {
+	case *RenderRequest:
+		v.Render = s.Face
+	case *Entered:
+		if s.Dest == nil {
+			return
+		}
+		if dest := s.Dest(); dest != nil {
+			send(v.Pos, &Transition{Dest: dest, Bus: s.Bus})
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestTrap(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	var sprung []Entity
+	spring := func(who Entity, pos *Tile) { sprung = append(sprung, who) }
+	pit := &Trap{Face: Glyph{'^', ColorRed}, OneShot: true, Spring: spring}
+	plate := &Trap{Face: Glyph{'_', ColorWhite}, Spring: spring}
+	tiles[1].Trigger, tiles[2].Trigger = pit, plate
+
+	render := RenderRequest{}
+	if tiles[1].Handle(&render); render.Render != tiles[1].Face {
+		t.Errorf("hidden Trap rendered as %v", render.Render)
+	}
+
+	hero := &testally{}
+	ally := &testally{Friendly: true}
+	tiles[0].Occupant = hero
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if len(sprung) != 1 || sprung[0] != hero || tiles[1].Trigger != nil {
+		t.Errorf("one shot Trap = %v, %v", sprung, tiles[1].Trigger)
+	}
+
+	// swapping places triggers the Tile entered by each occupant
+	tiles[2].Occupant = ally
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	tiles[2].Handle(&MoveEntity{Delta: Offset{-1, 0}})
+	if len(sprung) != 3 || sprung[1] != hero || sprung[2] != ally {
+		t.Errorf("repeating Trap sprung by %v", sprung)
+	}
+	if !plate.Discovered {
+		t.Errorf("Trap not discovered")
+	}
+	tiles[2].Occupant = nil
+	if tiles[2].Handle(&render); render.Render != plate.Face {
+		t.Errorf("discovered Trap rendered as %v", render.Render)
+	}
+}
+
+func TestStairs(t *testing.T) {
+	upper := NewTileGrid(2, 1, Offset{}, NewTile)
+	var lower []*Tile
+	generated := 0
+	down := &Stairs{Face: Glyph{'>', ColorWhite}, Dest: func() *Tile {
+		if lower == nil {
+			lower = NewTileGrid(3, 1, Offset{}, NewTile)
+			lower[0].Occupant = &testally{}
+			generated++
+		}
+		return lower[0]
+	}}
+	up := &Stairs{Face: Glyph{'<', ColorWhite}, Dest: func() *Tile { return upper[1] }}
+	upper[1].Trigger = down
+
+	hero := &testally{}
+	upper[0].Occupant = hero
+	upper[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	lower[1].Trigger = up
+
+	// the top of the stairs is occupied, so the hero arrives next to it
+	if upper[1].Occupant != nil || hero.Pos != lower[1] || lower[1].Occupant != hero || generated != 1 {
+		t.Errorf("Stairs moved hero to %v", hero.Pos)
+	}
+
+	transition := Transition{Dest: upper[1]}
+	if lower[1].Handle(&transition); !transition.Done || hero.Pos != upper[1] || generated != 1 {
+		t.Errorf("Transition moved hero to %v", hero.Pos)
+	}
+
+	// Stairs with no destination do nothing
+	upper[0].Trigger = &Stairs{}
+	upper[1].Handle(&MoveEntity{Delta: Offset{-1, 0}})
+	if hero.Pos != upper[0] {
+		t.Errorf("Stairs with nil Dest moved hero to %v", hero.Pos)
+	}
+
+	// arrival looks no further than freeRadius for a free Tile
+	crowd := NewTileGrid(freeRadius+2, 1, Offset{}, NewTile)
+	for _, tile := range crowd[:freeRadius+1] {
+		tile.Occupant = &testally{}
+	}
+	transition = Transition{Dest: crowd[0]}
+	if upper[0].Handle(&transition); transition.Done || hero.Pos != upper[0] {
+		t.Errorf("Transition beyond freeRadius moved hero to %v", hero.Pos)
+	}
+}
+
+func TestStairs_Bus(t *testing.T) {
+	tiles := NewTileGrid(3, 1, Offset{}, NewTile)
+	crowd := NewTileGrid(freeRadius+2, 1, Offset{}, NewTile)
+	for _, tile := range crowd[:freeRadius+1] {
+		tile.Occupant = &testally{}
+	}
+	bus := NewEventBus()
+	var published []*Transition
+	Subscribe(bus, func(v *Transition) { published = append(published, v) })
+	hero := &testally{}
+	tiles[0].Occupant = hero
+
+	// a Transition which fails is never published
+	tiles[1].Trigger = &Stairs{Dest: func() *Tile { return crowd[0] }, Bus: bus}
+	tiles[0].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if hero.Pos != tiles[1] || len(published) != 0 {
+		t.Errorf("failed Transition published %v", published)
+	}
+
+	// with an EventQueue, the Transition is published once delivered
+	queue := NewEventQueue()
+	SetEventQueue(queue)
+	defer SetEventQueue(nil)
+	tiles[2].Trigger = &Stairs{Dest: func() *Tile { return tiles[0] }, Bus: bus}
+	tiles[1].Handle(&MoveEntity{Delta: Offset{1, 0}})
+	if len(published) != 0 {
+		t.Errorf("queued Transition published before delivery")
+	}
+	queue.Drain()
+	if hero.Pos != tiles[0] || len(published) != 1 || !published[0].Done {
+		t.Errorf("delivered Transition moved hero to %v, published %v", hero.Pos, published)
+	}
+}
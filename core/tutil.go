@@ -2,7 +2,9 @@ package core
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nsf/termbox-go"
 )
@@ -37,6 +39,143 @@ func ListSelect(title string, items []interface{}) (index int, ok bool) {
 	return index, true
 }
 
+// Select is a typed version of ListSelect. Each item is displayed using the
+// format function, or using fmt if format is nil. The selected item is
+// returned along with its index.
+func Select[T any](title string, items []T, format func(T) string) (item T, index int, ok bool) {
+	index, ok = ListSelect(title, boxItems(items, format))
+	if ok {
+		item = items[index]
+	}
+	return item, index, ok
+}
+
+// PopupSelect is a typed version of PopupMenu. Each item is displayed using
+// the format function, or using fmt if format is nil. The selected item is
+// returned along with its index.
+func PopupSelect[T any](x, y int, items []T, format func(T) string) (item T, index int, ok bool) {
+	labels := make([]string, len(items))
+	for i, label := range boxItems(items, format) {
+		labels[i] = label.(string)
+	}
+	index, ok = PopupMenu(x, y, labels)
+	if ok {
+		item = items[index]
+	}
+	return item, index, ok
+}
+
+// boxItems formats typed items as strings for use with the untyped menus.
+func boxItems[T any](items []T, format func(T) string) []interface{} {
+	boxed := make([]interface{}, len(items))
+	for i, item := range items {
+		if format != nil {
+			boxed[i] = format(item)
+		} else {
+			boxed[i] = fmt.Sprintf("%v", item)
+		}
+	}
+	return boxed
+}
+
+// PopupMenu displays a bordered menu of items next to the given screen
+// location, and allows the user to select one item. Items can be selected
+// either by moving the highlight with the vertical direction keys and hitting
+// enter, or by pressing the letter of the item. Letters bound in KeyMap are
+// skipped so that they still move the highlight, and any items beyond the
+// remaining letters can only be selected with the highlight. If the menu would
+// run off the screen, it is flipped to the other side of the anchor. The
+// screen is restored once the menu closes. The result is not ok if escape is
+// pressed.
+func PopupMenu(x, y int, items []string) (index int, ok bool) {
+	if len(items) == 0 {
+		return 0, false
+	}
+
+	state := TermSave()
+	defer state.Restore()
+
+	letters := menuLetters(len(items))
+	labels := make([]string, len(items))
+	w, h := 0, len(items)+2
+	for i, item := range items {
+		if i < len(letters) {
+			labels[i] = fmt.Sprintf("%c) %s", letters[i], item)
+		} else {
+			labels[i] = "   " + item
+		}
+		w = Max(w, len([]rune(labels[i]))+2)
+	}
+	cols, rows := termbox.Size()
+	x, y = popupPos(x, y, w, h, cols, rows)
+	border := NewBorder(Glyph{'|', ColorWhite}, Glyph{'-', ColorWhite}, x, y, w, h)
+	border.UpperLeft.Ch, border.UpperRight.Ch = '+', '+'
+	border.LowerLeft.Ch, border.LowerRight.Ch = '+', '+'
+
+	for {
+		state.Restore()
+		border.Update()
+		for i, label := range labels {
+			fg := ColorWhite
+			if i == index {
+				fg = ColorLightWhite
+			}
+			drawString(x+1, y+1+i, label, fg)
+			for dx := len([]rune(label)); dx < w-2; dx++ {
+				TermDraw(x+1+dx, y+1+i, Glyph{' ', fg})
+			}
+		}
+		TermRefresh()
+
+		key := GetKey()
+		if i := indexOfKey(letters, key); i >= 0 {
+			return i, true
+		} else if key == KeyEnter {
+			return index, true
+		} else if key == KeyEsc {
+			return 0, false
+		} else if delta, ok := KeyMap[key]; ok && delta.X == 0 {
+			index = Mod(index+delta.Y, len(items))
+		}
+	}
+}
+
+// menuLetters gives the letters used to select up to n items of a menu, in
+// order, skipping any bound in KeyMap.
+func menuLetters(n int) []Key {
+	var letters []Key
+	for key := Key('a'); key <= 'z' && len(letters) < n; key++ {
+		if _, bound := KeyMap[key]; !bound {
+			letters = append(letters, key)
+		}
+	}
+	return letters
+}
+
+// indexOfKey gives the index of key in keys, or -1 if it is not present.
+func indexOfKey(keys []Key, key Key) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// popupPos computes the location of a popup with the given size so that it
+// is adjacent to the anchor location while still fitting on screen. The popup
+// is placed to the lower right of the anchor unless it would not fit.
+func popupPos(x, y, w, h, cols, rows int) (px, py int) {
+	px, py = x+1, y
+	if px+w > cols {
+		px = x - w
+	}
+	if py+h > rows {
+		py = y - h + 1
+	}
+	return Clamp(0, px, Max(cols-w, 0)), Clamp(0, py, Max(rows-h, 0))
+}
+
 // TermTint recolors every glyph in the buffer to have the given color.
 // No changes are made on screen until RefreshScreen is called.
 func TermTint(c Color) {
@@ -47,50 +186,479 @@ func TermTint(c Color) {
 	}
 }
 
-// Targeter allows for customization of on-screen targeting.
+// Targeter allows for customization of on-screen targeting. The reticle
+// starts at Start, the remembered target, the nearest target or the origin,
+// and is moved with the movement keys, Cycle or the mouse until one of the
+// Accept keys accepts the target under it, or escape cancels aiming. The Trace
+// to the reticle and any area of effect are drawn as it moves. Zero values of
+// every field but Camera and Canvas give the defaults described below.
 type Targeter struct {
+	// Camera is sent a FoVRequest with Radius to find what may be targeted,
+	// with a zero Radius using the default radius of the Camera. The
+	// Reticle and Trace are drawn on the Canvas, and any of the Accept keys
+	// accepts the target under the reticle.
 	Camera  Entity
+	Radius  int
 	Canvas  Entity
 	Reticle Glyph
 	Trace   *Glyph
-	Accept  string
+	Accept  []Key
+
+	// Range limits how far the reticle may move from the origin, measured
+	// using Chebyshev distance, or Euclidean distance if Euclidean is true,
+	// with zero meaning no limit. Any part of the Trace beyond Range is
+	// drawn in the OutOfRange color, or ColorRed if unset, and a target
+	// beyond Range is never accepted.
+	Range      int
+	Euclidean  bool
+	OutOfRange Color
+
+	// The Trace is blocked by the first visible Tile along it which is
+	// impassable or occupied, as a Projectile would be. If BlockedTrace is
+	// set, the Trace beyond that Tile is drawn with it instead, and if
+	// RequireLoS is true, a blocked target is never accepted.
+	BlockedTrace *Glyph
+	RequireLoS   bool
+
+	// FreeAim lets the reticle leave the field of view for any Tile within
+	// Range, such as to throw blindly around a corner, where the Reticle is
+	// drawn with the color of Dim, or dark grey if Dim is unset.
+	FreeAim bool
+
+	// Start, if set, is the initial Offset of the reticle, such as the Tile
+	// the player just walked toward, but is ignored unless the reticle could
+	// move there.
+	Start Offset
+
+	// Memory, if set, stores each accepted target, and the next Aim starts
+	// on it while it is visible, in range and, if Targets is set, still a
+	// target. A remembered Occupant is followed if it has moved, while a
+	// remembered Tile with no Occupant is only used if Targets is nil.
+	Memory *TargetMemory
+
+	// Area, or Disc with BlastRadius if Area is nil and BlastRadius is
+	// positive, gives the Offset affected by a target, such as for a
+	// fireball, which are drawn with Highlight, or with Dim outside the field
+	// of view. Unset glyphs default to a yellow and a dark grey '+'.
+	BlastRadius    int
+	Area           func(Offset) []Offset
+	Highlight, Dim Glyph
+
+	// The Cycle key, or KeyTab if unset, jumps the reticle to the next
+	// visible target in range, from nearest to furthest and wrapping around,
+	// and CycleBack, which has no default since the terminal does not report
+	// Shift-Tab, jumps to the previous one. Targets are the Occupant for which
+	// Targets is true, or which are hostile to the Occupant of the origin by
+	// RelationBetween if Targets is nil.
+	Targets          func(Entity) bool
+	Cycle, CycleBack Key
+
+	// OnKey, if set, is offered every key other than the Accept keys, escape
+	// and movement keys, along with the Tile under the reticle, such as to
+	// change spells. It returns whether it handled the key and whether aiming
+	// is done, which accepts the current target as if by an Accept key, or
+	// cancels aiming if the target could not be accepted.
+	OnKey func(key Key, current *Tile) (handled, done bool)
+
+	// Locate, such as the Locate method of a CameraWidget, converts screen
+	// locations to Offset, so that with mouse input enabled by TermMouse the
+	// reticle follows the mouse and a left click accepts. A right click
+	// cancels aiming whether or not Locate is set.
+	Locate func(x, y int) (Offset, bool)
+
+	// Describe, if set, describes the Tile under the reticle on the screen
+	// row DescribeRow, truncated to the width of the screen.
+	Describe    func(*Tile) string
+	DescribeRow int
 }
 
-// Aim allows the user to select a target from an on-screen Camera view.
+// Aim allows the user to select a target from an on-screen Camera view,
+// pressing one of the Accept keys to accept the target under the reticle. If
+// aiming is cancelled with escape, the target is nil and ok is false.
 func (t Targeter) Aim() (target *Tile, ok bool) {
+	target, _, ok = t.AimVisible()
+	return target, ok
+}
+
+// AimVisible is like Aim, but also reports whether the target was in the
+// field of view, which may only be false with FreeAim.
+func (t Targeter) AimVisible() (target *Tile, visible, ok bool) {
 	state := TermSave()
 	defer state.Restore()
 
-	req := FoVRequest{}
+	req := FoVRequest{Radius: t.Radius}
 	t.Camera.Handle(&req)
-	offset := Offset{}
+	targets := t.targets(req.FoV)
+	offset := t.start(req.FoV, targets)
 
 	var key Key
-	for !strings.Contains(t.Accept, string(key)) && key != KeyEsc {
+	var done bool
+	for !done && key != KeyEsc && !(t.accepts(key) && t.acceptable(offset, req.FoV)) {
 		state.Restore()
 
-		if t.Trace != nil {
-			for _, o := range Trace(offset) {
-				t.Canvas.Handle(&Mark{o, *t.Trace})
+		t.drawArea(offset, req.FoV)
+		t.drawTrace(offset, req.FoV)
+		reticle := t.Reticle
+		if _, seen := req.FoV[offset]; !seen {
+			reticle.Fg = t.Dim.Fg
+			if t.Dim == (Glyph{}) {
+				reticle.Fg = ColorLightBlack
 			}
 		}
-		t.Canvas.Handle(&Mark{offset, t.Reticle})
+		t.Canvas.Handle(&Mark{offset, reticle})
+		if t.Describe != nil {
+			t.drawDescription(t.Describe(req.FoV[offset]))
+		}
 		TermRefresh()
 
-		key = GetKey()
-		delta, ok := KeyMap[key]
-		_, visible := req.FoV[offset.Add(delta)]
-		if ok && visible {
-			offset = offset.Add(delta)
+		input := GetInput()
+		if key = input.Key; input.Mouse {
+			offset, key, done = t.mouse(input, offset, req.FoV)
+			continue
 		}
+		if len(targets) > 0 && (key == t.Cycle || t.Cycle == 0 && key == KeyTab) {
+			offset = cycleTarget(targets, offset, 1)
+			continue
+		} else if len(targets) > 0 && t.CycleBack != 0 && key == t.CycleBack {
+			offset = cycleTarget(targets, offset, -1)
+			continue
+		}
+		if delta, ok := KeyMap[key]; ok {
+			if t.reachable(offset.Add(delta), req.FoV) {
+				offset = offset.Add(delta)
+			}
+		} else if t.OnKey != nil && key != KeyEsc && !t.accepts(key) {
+			current, _ := t.tileAt(offset, req.FoV)
+			_, done = t.OnKey(key, current)
+		}
+	}
+
+	return t.finish(key, offset, req.FoV)
+}
+
+// mouse handles a mouse Input while aiming, returning the new reticle Offset,
+// the Key to treat the Input as, and whether aiming is done. Moving the mouse
+// moves the reticle, a left click also accepts the target if it is acceptable,
+// and a right click is treated as escape.
+func (t Targeter) mouse(input Input, offset Offset, fov map[Offset]*Tile) (Offset, Key, bool) {
+	if input.Key == KeyMouseRight && !input.Motion {
+		return offset, KeyEsc, false
+	}
+	if t.Locate == nil {
+		return offset, 0, false
+	}
+	hovered, ok := t.Locate(input.X, input.Y)
+	if !ok || !t.reachable(hovered, fov) {
+		return offset, 0, false
+	}
+	if input.Key == KeyMouseLeft && !input.Motion && t.acceptable(hovered, fov) {
+		return hovered, KeyMouseLeft, true
 	}
+	return hovered, 0, false
+}
 
-	return req.FoV[offset], key != KeyEsc
+// finish computes the result of AimVisible once aiming ends with the given
+// Key and reticle Offset, remembering the target if it was accepted. Aiming
+// which ends on a target which is not acceptable gives no target, as with
+// escape.
+func (t Targeter) finish(key Key, offset Offset, fov map[Offset]*Tile) (target *Tile, visible, ok bool) {
+	if key == KeyEsc || !t.acceptable(offset, fov) {
+		return nil, false, false
+	}
+	target, visible = t.tileAt(offset, fov)
+	if t.Memory != nil && target != nil {
+		*t.Memory = TargetMemory{Tile: target, Occupant: target.Occupant}
+	}
+	return target, visible, true
 }
 
-// Aim allows the user to select a target from an on-screen Camera view.
+// accepts returns true if the Key is one of the Accept keys.
+func (t Targeter) accepts(key Key) bool {
+	for _, accept := range t.Accept {
+		if key == accept {
+			return true
+		}
+	}
+	return false
+}
+
+// start determines the initial Offset of the reticle, which is Start if it is
+// set and the reticle could move there, or else the remembered target, the
+// nearest target, or the origin, in that order.
+func (t Targeter) start(fov map[Offset]*Tile, targets []Offset) Offset {
+	if t.Start != (Offset{}) && t.reachable(t.Start, fov) {
+		return t.Start
+	}
+	if last, ok := t.remembered(fov); ok {
+		return last
+	}
+	if len(targets) > 0 {
+		return targets[0]
+	}
+	return Offset{}
+}
+
+// TargetMemory is the last target accepted by a Targeter, which the caller
+// keeps between calls to Aim.
+type TargetMemory struct {
+	Tile     *Tile
+	Occupant Entity
+}
+
+// remembered finds the Offset of the target in Memory, if it is still valid.
+func (t Targeter) remembered(fov map[Offset]*Tile) (Offset, bool) {
+	if t.Memory == nil {
+		return Offset{}, false
+	}
+	for o, tile := range fov {
+		if tile == nil || o == (Offset{}) || !t.inRange(o) {
+			continue
+		}
+		if occupant := t.Memory.Occupant; occupant != nil {
+			if sameEntity(tile.Occupant, occupant) && (t.Targets == nil || t.Targets(occupant)) {
+				return o, true
+			}
+		} else if tile == t.Memory.Tile && t.Targets == nil {
+			return o, true
+		}
+	}
+	return Offset{}, false
+}
+
+// drawTrace marks the Trace from the origin to the target, if the Targeter has
+// a Trace, using the OutOfRange color beyond Range and BlockedTrace beyond the
+// first blocker.
+func (t Targeter) drawTrace(target Offset, fov map[Offset]*Tile) {
+	if t.Trace == nil {
+		return
+	}
+
+	path := Trace(target)
+	blocker := traceBlocker(path, fov)
+	for i, o := range path {
+		mark := *t.Trace
+		if i > blocker && t.BlockedTrace != nil {
+			mark = *t.BlockedTrace
+		} else if !t.inRange(o) {
+			mark.Fg = t.OutOfRange
+			if mark.Fg == 0 {
+				mark.Fg = ColorRed
+			}
+		}
+		t.Canvas.Handle(&Mark{o, mark})
+	}
+}
+
+// traceBlocker returns the index of the first visible Tile along the path,
+// before its end, which is impassable or occupied, or the length of the path
+// if it is not blocked.
+func traceBlocker(path []Offset, fov map[Offset]*Tile) int {
+	for i, o := range path[:Max(len(path)-1, 0)] {
+		if tile := fov[o]; tile != nil && (!tile.Pass || tile.Occupant != nil) {
+			return i
+		}
+	}
+	return len(path)
+}
+
+// acceptable returns true if the target Offset is in range, and not blocked
+// if RequireLoS is true.
+func (t Targeter) acceptable(target Offset, fov map[Offset]*Tile) bool {
+	if !t.inRange(target) {
+		return false
+	}
+	path := Trace(target)
+	return !t.RequireLoS || traceBlocker(path, fov) == len(path)
+}
+
+// drawArea marks the area affected by a target at the given Offset, if the
+// Targeter has an Area or BlastRadius.
+func (t Targeter) drawArea(target Offset, fov map[Offset]*Tile) {
+	area := t.Area
+	if area == nil && t.BlastRadius > 0 {
+		area = func(o Offset) []Offset { return Disc(o, t.BlastRadius) }
+	}
+	if area == nil {
+		return
+	}
+
+	highlight, dim := t.Highlight, t.Dim
+	if highlight == (Glyph{}) {
+		highlight = Glyph{'+', ColorYellow}
+	}
+	if dim == (Glyph{}) {
+		dim = Glyph{'+', ColorLightBlack}
+	}
+	for _, o := range area(target) {
+		if _, visible := fov[o]; visible {
+			t.Canvas.Handle(&Mark{o, highlight})
+		} else {
+			t.Canvas.Handle(&Mark{o, dim})
+		}
+	}
+}
+
+// targets finds the Offset of every visible target in range, nearest first.
+func (t Targeter) targets(fov map[Offset]*Tile) []Offset {
+	pred := t.Targets
+	if pred == nil {
+		var self Entity
+		if origin := fov[Offset{}]; origin != nil {
+			self = origin.Occupant
+		}
+		pred = func(e Entity) bool { return self == nil || hostileTo(self, nil, e) }
+	}
+
+	var targets []Offset
+	for _, o := range VisibleEntities(fov, pred) {
+		if t.inRange(o) {
+			targets = append(targets, o)
+		}
+	}
+	return targets
+}
+
+// cycleTarget returns the target after the current Offset in the given
+// direction, wrapping around. If the current Offset is not a target, the
+// first or last target is returned.
+func cycleTarget(targets []Offset, curr Offset, dir int) Offset {
+	for i, o := range targets {
+		if o == curr {
+			return targets[Mod(i+dir, len(targets))]
+		}
+	}
+	if dir < 0 {
+		return targets[len(targets)-1]
+	}
+	return targets[0]
+}
+
+// tileAt returns the Tile at the Offset from the origin, and whether it is in
+// the field of view. Tiles outside the field of view are found by following
+// Adjacent links, and are nil if off the map.
+func (t Targeter) tileAt(o Offset, fov map[Offset]*Tile) (*Tile, bool) {
+	if tile, visible := fov[o]; visible {
+		return tile, true
+	}
+	return offsetTile(fov[Offset{}], o), false
+}
+
+// reachable returns true if the reticle may move to the Offset, which must be
+// in range, and in the field of view or, with FreeAim, on the map.
+func (t Targeter) reachable(o Offset, fov map[Offset]*Tile) bool {
+	if !t.inRange(o) {
+		return false
+	}
+	_, visible := fov[o]
+	return visible || t.FreeAim && offsetTile(fov[Offset{}], o) != nil
+}
+
+// inRange returns true if the Offset from the origin is within Range.
+func (t Targeter) inRange(o Offset) bool {
+	if t.Range <= 0 {
+		return true
+	}
+	if t.Euclidean {
+		return o.Euclidean() <= float64(t.Range)
+	}
+	return o.Chebyshev() <= t.Range
+}
+
+// AimRanged allows the user to select a target with Aim, returning a
+// RangedAttack against it from the given Tile, which should then be sent to
+// the attacker. If the Targeter has no Range, the reticle is limited to rng.
+func (t Targeter) AimRanged(attacker Entity, from *Tile, rng int) (*RangedAttack, bool) {
+	if t.Range == 0 {
+		t.Range = rng
+	}
+	target, ok := t.Aim()
+	if !ok || target == nil {
+		return nil, false
+	}
+	return &RangedAttack{Attacker: attacker, From: from, Target: target, Range: rng, Euclidean: t.Euclidean}, true
+}
+
+// drawDescription draws a description of the target on the DescribeRow,
+// clearing the rest of the row.
+func (t Targeter) drawDescription(desc string) {
+	cols, _ := termbox.Size()
+	runes := []rune(truncate(desc, cols))
+	for x := 0; x < cols; x++ {
+		ch := ' '
+		if x < len(runes) {
+			ch = runes[x]
+		}
+		TermDraw(x, t.DescribeRow, Glyph{ch, ColorWhite})
+	}
+}
+
+// DescribeTile is a simple default for Targeter.Describe. Tile with an
+// occupant are described by the occupant name. Otherwise, the Tile is
+// described by whether or not it is passable. A nil Tile, such as one outside
+// the field of view, is described as unseen.
+func DescribeTile(t *Tile) string {
+	switch {
+	case t == nil:
+		return "You cannot see that location."
+	case t.Occupant != nil:
+		return makeSentence("You see " + getName(t.Occupant))
+	case t.Pass:
+		return "You see the floor."
+	}
+	return "You see a wall."
+}
+
+// Aim allows the user to select a target from an on-screen Camera view with a
+// default Targeter, accepting the target with enter or any of the runes of
+// accept.
 func Aim(camera, canvas Entity, accept string) (target *Tile, ok bool) {
-	return Targeter{camera, canvas, Glyph{'*', ColorRed}, nil, accept}.Aim()
+	keys := append(Keys(accept), KeyEnter)
+	return Targeter{Camera: camera, Canvas: canvas, Reticle: Glyph{'*', ColorRed}, Accept: keys}.Aim()
+}
+
+// AimCone allows the user to aim a cone, such as a breath weapon, in one of
+// the directions of KeyMap. The Camera is sent a FoVRequest with the radius,
+// and pressing a direction key previews the cone in that direction, as
+// computed by FoVCone with the halfAngle, by marking its Offset on the canvas.
+// Pressing another direction key turns the preview, and enter confirms it,
+// returning the direction and the Tiles in the cone, nearest first. Escape
+// cancels aiming.
+func AimCone(camera, canvas Entity, radius int, halfAngle float64) (dir Offset, tiles []*Tile, ok bool) {
+	state := TermSave()
+	defer state.Restore()
+
+	req := FoVRequest{Radius: radius}
+	camera.Handle(&req)
+	var area map[Offset]*Tile
+
+	for {
+		state.Restore()
+		for o := range area {
+			canvas.Handle(&Mark{o, Glyph{'+', ColorYellow}})
+		}
+		TermRefresh()
+
+		key := GetKey()
+		if delta, isDir := KeyMap[key]; isDir && delta != (Offset{}) {
+			dir, area = delta, cone(req.FoV, delta, halfAngle)
+		} else if key == KeyEnter && dir != (Offset{}) {
+			break
+		} else if key == KeyEsc {
+			return Offset{}, nil, false
+		}
+	}
+
+	var offsets []Offset
+	for o, t := range area {
+		if t != nil {
+			offsets = append(offsets, o)
+		}
+	}
+	sortByDistance(offsets)
+	for _, o := range offsets {
+		tiles = append(tiles, area[o])
+	}
+	return dir, tiles, true
 }
 
 // Mark is an Event requesting that a Glyph be drawn on Screen.
@@ -99,45 +667,169 @@ type Mark struct {
 	Mark   Glyph
 }
 
+// AnimateTrace animates a Glyph moving along a path, such as one computed by
+// Trace. Each step is drawn on the canvas using a Mark Event and displayed for
+// the given delay. Pressing escape skips the rest of the animation. The screen
+// is restored once the animation completes.
+func AnimateTrace(canvas Entity, path []Offset, g Glyph, delay time.Duration) {
+	if len(path) == 0 {
+		return
+	}
+
+	state := TermSave()
+	defer TermRefresh()
+	defer state.Restore()
+
+	for _, o := range path {
+		state.Restore()
+		canvas.Handle(&Mark{o, g})
+		TermRefresh()
+		if !animwait(delay) {
+			return
+		}
+	}
+}
+
+// AnimateBlast flashes a Glyph on each of the given Offset simultaneously,
+// such as for an explosion. The Glyph are displayed for the given delay, or
+// until escape is pressed. The screen is restored once the animation
+// completes.
+func AnimateBlast(canvas Entity, area []Offset, g Glyph, delay time.Duration) {
+	if len(area) == 0 {
+		return
+	}
+
+	state := TermSave()
+	defer TermRefresh()
+	defer state.Restore()
+
+	for _, o := range area {
+		canvas.Handle(&Mark{o, g})
+	}
+	TermRefresh()
+	animwait(delay)
+}
+
+// animwait waits for the given delay, ignoring any keys other than escape.
+// The result is false if the wait was cut short by escape.
+func animwait(delay time.Duration) bool {
+	deadline := time.Now().Add(delay)
+	for remaining := delay; remaining > 0; remaining = time.Until(deadline) {
+		if key, ok := PollKey(remaining); ok && key == KeyEsc {
+			return false
+		}
+	}
+	return true
+}
+
 // TextDump displays a large amount of text, with scrolling.
 // Useful for things like displaying large help files.
+//
+// In addition to scrolling with the vertical direction keys and page up/down,
+// 'g' jumps to the top, 'G' jumps to the bottom, and ':' prompts for a line
+// number to jump to. If LineNumbers is true, each line is displayed with its
+// line number in a gutter to the left of the text. If Markup is true, the text
+// may contain color markup as described by ParseMarkup.
 type TextDump struct {
 	Title, Text string
 	Fg          Color
+	LineNumbers bool
+	Markup      bool
+
+	currline int
 }
 
 // NewTextDump creates a new TextDump with the given title and text.
 func NewTextDump(title, text string) *TextDump {
-	return &TextDump{title, text, ColorWhite}
+	return &TextDump{title, text, ColorWhite, false, false, 0}
 }
 
 // Run displays the TextDump text, and allows the user to scroll through it.
 func (t *TextDump) Run() {
-	cols, rows := termbox.Size()
+	RunScene(t)
+}
+
+// visible computes the number of lines which fit on screen below the title.
+func (t *TextDump) visible() int {
+	_, rows := termbox.Size()
+	return Max(rows-1, 1)
+}
+
+// Draw implements Scene for TextDump by displaying the current page, first
+// keeping the current line in bounds in case the screen or Text has changed.
+func (t *TextDump) Draw() {
+	t.clamp()
+	cols, _ := termbox.Size()
 	lines := strings.Split(t.Text, "\n")
-	currline := 0
-	var key Key
+	visible := t.visible()
 
-	for key != KeyEsc {
-		TermClear()
-		for x, ch := range t.Title {
-			TermDraw(x, 0, Glyph{ch, t.Fg})
+	TermClear()
+	for x, ch := range t.Title {
+		TermDraw(x, 0, Glyph{ch, t.Fg})
+	}
+	indicator := ScrollText(t.currline, visible, len(lines))
+	drawString(cols-len(indicator), 0, indicator, t.Fg)
+	gutter := 0
+	if t.LineNumbers {
+		gutter = gutterWidth(len(lines))
+	}
+	for y, line := range lines[t.currline:Min(t.currline+visible, len(lines))] {
+		if gutter > 0 {
+			number := fmt.Sprintf("%*d", gutter-1, t.currline+y+1)
+			drawString(0, y+1, number, ColorLightBlack)
 		}
-		for y, line := range lines[currline : currline+rows-1] {
-			for x, ch := range line {
-				TermDraw(x, y+1, Glyph{ch, t.Fg})
+		for x, g := range textGlyphs(line, t.Fg, t.Markup) {
+			if gutter+x < cols-1 {
+				TermDraw(gutter+x, y+1, g)
 			}
 		}
-		TermRefresh()
+	}
+	scrollbar := NewScrollbarWidget(cols-1, 1, visible)
+	scrollbar.Offset, scrollbar.Visible, scrollbar.Total = t.currline, visible, len(lines)
+	scrollbar.Update()
+}
 
-		key = GetKey()
-		if delta, ok := KeyMap[key]; ok && delta.X == 0 {
-			currline += delta.Y
-		} else if key == KeyPgup {
-			currline -= cols / 2
-		} else if key == KeyPgdn {
-			currline += cols / 2
+// HandleKey implements Scene for TextDump by scrolling the text. Escape pops
+// the TextDump.
+func (t *TextDump) HandleKey(key Key) SceneResult {
+	visible := t.visible()
+	if delta, ok := KeyMap[key]; ok && delta.X == 0 {
+		t.currline += delta.Y
+	} else if key == KeyPgup {
+		t.currline -= visible / 2
+	} else if key == KeyPgdn {
+		t.currline += visible / 2
+	} else if key == 'g' {
+		t.currline = 0
+	} else if key == 'G' {
+		t.currline = strings.Count(t.Text, "\n") + 1
+	} else if key == ':' {
+		_, rows := termbox.Size()
+		answer, ok := Prompter{0, rows - 1, t.Fg}.Prompt(":", 10)
+		if line, err := strconv.Atoi(answer); ok && err == nil {
+			t.currline = line - 1
 		}
-		currline = Clamp(0, currline, len(lines)-rows)
+	} else if key == KeyEsc {
+		return SceneResult{ScenePop, nil}
 	}
+	t.clamp()
+	return SceneResult{}
+}
+
+// Resize implements SceneResizer for TextDump by keeping the current line in
+// bounds for the new size.
+func (t *TextDump) Resize(cols, rows int) {
+	t.clamp()
+}
+
+// clamp limits the current line so the last page is never scrolled past.
+func (t *TextDump) clamp() {
+	lines := strings.Count(t.Text, "\n") + 1
+	t.currline = Clamp(0, t.currline, Max(lines-t.visible(), 0))
+}
+
+// gutterWidth computes the width of a line number gutter for the given number
+// of lines, including a single space of padding.
+func gutterWidth(lines int) int {
+	return len(strconv.Itoa(lines)) + 1
 }
@@ -0,0 +1,439 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPopupPos(t *testing.T) {
+	cases := []struct {
+		x, y, w, h, cols, rows int
+		px, py                 int
+	}{
+		{10, 5, 8, 4, 80, 24, 11, 5},
+		{75, 5, 8, 4, 80, 24, 67, 5},
+		{10, 22, 8, 4, 80, 24, 11, 19},
+		{75, 22, 8, 4, 80, 24, 67, 19},
+		{2, 2, 8, 30, 80, 24, 3, 0},
+		{5, 5, 100, 4, 80, 24, 0, 5},
+	}
+	for _, c := range cases {
+		px, py := popupPos(c.x, c.y, c.w, c.h, c.cols, c.rows)
+		if px != c.px || py != c.py {
+			t.Errorf("popupPos(%d, %d, %d, %d, %d, %d) = %d, %d != %d, %d", c.x, c.y, c.w, c.h, c.cols, c.rows, px, py, c.px, c.py)
+		}
+	}
+}
+
+func TestMenuLetters(t *testing.T) {
+	if letters := menuLetters(10); string(keysToRunes(letters)) != "acdefgimop" {
+		t.Errorf("menuLetters(10) = %q", keysToRunes(letters))
+	}
+	letters := menuLetters(30)
+	for _, key := range letters {
+		if _, bound := KeyMap[key]; bound {
+			t.Errorf("menuLetters(30) used %q, which is bound in KeyMap", key)
+		}
+	}
+	if len(letters) != 18 {
+		t.Errorf("menuLetters(30) gave %d letters", len(letters))
+	}
+}
+
+// keysToRunes converts Keys to runes so they can be compared as a string.
+func keysToRunes(keys []Key) []rune {
+	runes := make([]rune, len(keys))
+	for i, key := range keys {
+		runes[i] = rune(key)
+	}
+	return runes
+}
+
+func TestBoxItems(t *testing.T) {
+	items := []Offset{{1, 2}, {-3, 4}}
+	if actual, expected := boxItems(items, nil), []interface{}{"{1 2}", "{-3 4}"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("boxItems(%v, nil) = %v != %v", items, actual, expected)
+	}
+	format := func(o Offset) string { return strings.Repeat("*", o.Chebyshev()) }
+	if actual, expected := boxItems(items, format), []interface{}{"**", "****"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("boxItems(%v, format) = %v != %v", items, actual, expected)
+	}
+}
+
+func TestGutterWidth(t *testing.T) {
+	cases := []struct {
+		lines, expected int
+	}{
+		{1, 2},
+		{9, 2},
+		{10, 3},
+		{400, 4},
+		{1000, 5},
+	}
+	for _, c := range cases {
+		if actual := gutterWidth(c.lines); actual != c.expected {
+			t.Errorf("gutterWidth(%d) = %d != %d", c.lines, actual, c.expected)
+		}
+	}
+}
+
+func TestTextDump_clamp(t *testing.T) {
+	// without a terminal, only a single line is visible at a time
+	dump := NewTextDump("", "a\nb\nc")
+	cases := []struct {
+		currline, expected int
+	}{
+		{-5, 0},
+		{1, 1},
+		{2, 2},
+		{123, 2},
+	}
+	for _, c := range cases {
+		dump.currline = c.currline
+		if dump.clamp(); dump.currline != c.expected {
+			t.Errorf("clamp() with currline=%d = %d != %d", c.currline, dump.currline, c.expected)
+		}
+	}
+
+	// Draw clamps too, since the screen may have shrunk since the last key
+	dump.currline = 123
+	if dump.Draw(); dump.currline != 2 {
+		t.Errorf("Draw() with currline=123 left %d", dump.currline)
+	}
+}
+
+type testnamed string
+
+func (n testnamed) Handle(Event) {}
+
+func TestDescribeTile(t *testing.T) {
+	floor, wall, occupied := NewTile(Offset{}), NewTile(Offset{}), NewTile(Offset{})
+	wall.Pass = false
+	occupied.Occupant = testnamed("orc")
+	cases := []struct {
+		tile     *Tile
+		expected string
+	}{
+		{nil, "You cannot see that location."},
+		{floor, "You see the floor."},
+		{wall, "You see a wall."},
+		{occupied, "You see the orc."},
+	}
+	for _, c := range cases {
+		if actual := DescribeTile(c.tile); actual != c.expected {
+			t.Errorf("DescribeTile(%v) = %q != %q", c.tile, actual, c.expected)
+		}
+	}
+}
+
+func TestTargeter_inRange(t *testing.T) {
+	cases := []struct {
+		targeter Targeter
+		offset   Offset
+		expected bool
+	}{
+		{Targeter{}, Offset{100, 100}, true},
+		{Targeter{Range: 3}, Offset{3, 3}, true},
+		{Targeter{Range: 3}, Offset{4, 0}, false},
+		{Targeter{Range: 3, Euclidean: true}, Offset{3, 0}, true},
+		{Targeter{Range: 3, Euclidean: true}, Offset{3, 3}, false},
+		{Targeter{Range: 3, Euclidean: true}, Offset{2, -2}, true},
+	}
+	for _, c := range cases {
+		if actual := c.targeter.inRange(c.offset); actual != c.expected {
+			t.Errorf("%+v.inRange(%v) = %v != %v", c.targeter, c.offset, actual, c.expected)
+		}
+	}
+}
+
+// testcanvas records the Mark Events it is sent.
+type testcanvas map[Offset]Glyph
+
+func (c testcanvas) Handle(v Event) {
+	if mark, ok := v.(*Mark); ok {
+		c[mark.Offset] = mark.Mark
+	}
+}
+
+func TestTargeter_drawArea(t *testing.T) {
+	canvas := testcanvas{}
+	fov := map[Offset]*Tile{{0, 0}: NewTile(Offset{}), {1, 0}: NewTile(Offset{1, 0})}
+	Targeter{Canvas: canvas, BlastRadius: 1}.drawArea(Offset{1, 0}, fov)
+	if len(canvas) != 9 {
+		t.Errorf("drawArea() marked %d != 9 Offset", len(canvas))
+	}
+	if g := canvas[Offset{0, 0}]; g != (Glyph{'+', ColorYellow}) {
+		t.Errorf("drawArea() marked visible Offset with %v", g)
+	}
+	if g := canvas[Offset{2, 1}]; g != (Glyph{'+', ColorLightBlack}) {
+		t.Errorf("drawArea() marked unseen Offset with %v", g)
+	}
+
+	canvas = testcanvas{}
+	area := func(o Offset) []Offset { return []Offset{o} }
+	Targeter{Canvas: canvas, Area: area, Highlight: Glyph{'x', ColorRed}}.drawArea(Offset{0, 0}, fov)
+	if len(canvas) != 1 || canvas[Offset{0, 0}] != (Glyph{'x', ColorRed}) {
+		t.Errorf("drawArea() with Area marked %v", canvas)
+	}
+	canvas = testcanvas{}
+	if (Targeter{Canvas: canvas}).drawArea(Offset{}, fov); len(canvas) != 0 {
+		t.Errorf("drawArea() without area marked %v", canvas)
+	}
+}
+
+func TestTargeter_targets(t *testing.T) {
+	SetRelation("test-heroes", "test-orcs", Hostile)
+	at := func(o Offset, e Entity) *Tile {
+		tile := NewTile(o)
+		tile.Occupant = e
+		return tile
+	}
+	fov := map[Offset]*Tile{
+		{0, 0}:  at(Offset{}, NewEntity(&Faction{"test-heroes"})),
+		{1, 0}:  at(Offset{1, 0}, NewEntity(&Faction{"test-heroes"})),
+		{2, 2}:  at(Offset{2, 2}, NewEntity(&Faction{"test-orcs"})),
+		{0, -1}: at(Offset{0, -1}, NewEntity(&Faction{"test-orcs"})),
+		{5, 0}:  at(Offset{5, 0}, NewEntity(&Faction{"test-orcs"})),
+	}
+
+	targets := Targeter{Range: 4}.targets(fov)
+	if expected := []Offset{{0, -1}, {2, 2}}; !reflect.DeepEqual(targets, expected) {
+		t.Errorf("targets() = %v != %v", targets, expected)
+	}
+	everyone := Targeter{Targets: func(Entity) bool { return true }}.targets(fov)
+	if len(everyone) != 4 {
+		t.Errorf("targets() with Targets = %v", everyone)
+	}
+
+	cases := []struct {
+		curr     Offset
+		dir      int
+		expected Offset
+	}{
+		{Offset{0, -1}, 1, Offset{2, 2}},
+		{Offset{2, 2}, 1, Offset{0, -1}},
+		{Offset{0, -1}, -1, Offset{2, 2}},
+		{Offset{3, 3}, 1, Offset{0, -1}},
+		{Offset{3, 3}, -1, Offset{2, 2}},
+	}
+	for _, c := range cases {
+		if actual := cycleTarget(targets, c.curr, c.dir); actual != c.expected {
+			t.Errorf("cycleTarget(%v, %d) = %v != %v", c.curr, c.dir, actual, c.expected)
+		}
+	}
+}
+
+func TestTargeter_blocked(t *testing.T) {
+	fov := make(map[Offset]*Tile)
+	for x := 0; x <= 4; x++ {
+		fov[Offset{x, 0}] = NewTile(Offset{x, 0})
+	}
+	fov[Offset{2, 0}].Occupant = testnamed("orc")
+	fov[Offset{4, 0}].Occupant = testnamed("goblin")
+
+	trace, blocked := Glyph{'*', ColorWhite}, Glyph{'*', ColorLightBlack}
+	canvas := testcanvas{}
+	Targeter{Canvas: canvas, Trace: &trace, BlockedTrace: &blocked}.drawTrace(Offset{4, 0}, fov)
+	expected := testcanvas{{1, 0}: trace, {2, 0}: trace, {3, 0}: blocked, {4, 0}: blocked}
+	if !reflect.DeepEqual(canvas, expected) {
+		t.Errorf("drawTrace() = %v != %v", canvas, expected)
+	}
+	canvas = testcanvas{}
+	Targeter{Canvas: canvas, Trace: &trace}.drawTrace(Offset{4, 0}, fov)
+	if expected := (testcanvas{{1, 0}: trace, {2, 0}: trace, {3, 0}: trace, {4, 0}: trace}); !reflect.DeepEqual(canvas, expected) {
+		t.Errorf("drawTrace() without BlockedTrace = %v != %v", canvas, expected)
+	}
+
+	cases := []struct {
+		targeter Targeter
+		target   Offset
+		expected bool
+	}{
+		{Targeter{}, Offset{4, 0}, true},
+		{Targeter{RequireLoS: true}, Offset{4, 0}, false},
+		{Targeter{RequireLoS: true}, Offset{2, 0}, true},
+		{Targeter{RequireLoS: true, Range: 1}, Offset{2, 0}, false},
+	}
+	for _, c := range cases {
+		if actual := c.targeter.acceptable(c.target, fov); actual != c.expected {
+			t.Errorf("%+v.acceptable(%v) = %v != %v", c.targeter, c.target, actual, c.expected)
+		}
+	}
+}
+
+func TestTargeter_reachable(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2)}
+	cases := []struct {
+		targeter Targeter
+		offset   Offset
+		expected bool
+	}{
+		{Targeter{}, Offset{1, 0}, true},
+		{Targeter{}, Offset{-1, 0}, false},
+		{Targeter{FreeAim: true}, Offset{-1, 0}, true},
+		{Targeter{FreeAim: true}, Offset{-2, -2}, true},
+		{Targeter{FreeAim: true}, Offset{-3, 0}, false},
+		{Targeter{FreeAim: true, Range: 1}, Offset{-2, 0}, false},
+	}
+	for _, c := range cases {
+		if actual := c.targeter.reachable(c.offset, fov); actual != c.expected {
+			t.Errorf("%+v.reachable(%v) = %v != %v", c.targeter, c.offset, actual, c.expected)
+		}
+	}
+}
+
+func TestTargeter_remembered(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	orc := NewEntity()
+	g.At(4, 2).Occupant = orc
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2), {2, 0}: g.At(4, 2)}
+	cases := []struct {
+		targeter Targeter
+		ok       bool
+		expected Offset
+	}{
+		{Targeter{}, false, Offset{}},
+		{Targeter{Memory: &TargetMemory{}}, false, Offset{}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(0, 0), Occupant: orc}}, true, Offset{2, 0}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(4, 2), Occupant: orc}, Range: 1}, false, Offset{}},
+		{Targeter{Memory: &TargetMemory{Occupant: orc}, Targets: func(Entity) bool { return false }}, false, Offset{}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(3, 2)}}, true, Offset{1, 0}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(3, 2)}, Targets: func(Entity) bool { return true }}, false, Offset{}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(0, 2)}}, false, Offset{}},
+	}
+	for i, c := range cases {
+		if actual, ok := c.targeter.remembered(fov); ok != c.ok || actual != c.expected {
+			t.Errorf("case %d: remembered() = %v, %v", i, actual, ok)
+		}
+	}
+
+	// a ComponentSlice target is found by identity
+	slice := ComponentSlice{&testcomponent{}}
+	g.At(3, 2).Occupant = slice
+	targeter := Targeter{Memory: &TargetMemory{Occupant: slice}}
+	if actual, ok := targeter.remembered(fov); !ok || actual != (Offset{1, 0}) {
+		t.Errorf("remembered() ComponentSlice = %v, %v", actual, ok)
+	}
+}
+
+func TestTargeter_tileAt(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2)}
+	cases := []struct {
+		offset   Offset
+		expected *Tile
+		visible  bool
+	}{
+		{Offset{1, 0}, g.At(3, 2), true},
+		{Offset{-2, 1}, g.At(0, 3), false},
+		{Offset{3, 0}, nil, false},
+	}
+	for _, c := range cases {
+		if actual, visible := (Targeter{}).tileAt(c.offset, fov); actual != c.expected || visible != c.visible {
+			t.Errorf("tileAt(%v) = %v, %v", c.offset, actual, visible)
+		}
+	}
+}
+
+func TestTargeter_start(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	orc := NewEntity()
+	g.At(4, 2).Occupant = orc
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2), {2, 0}: g.At(4, 2)}
+	targets := []Offset{{2, 0}}
+	cases := []struct {
+		targeter Targeter
+		targets  []Offset
+		expected Offset
+	}{
+		{Targeter{}, nil, Offset{}},
+		{Targeter{}, targets, Offset{2, 0}},
+		{Targeter{Start: Offset{1, 0}}, targets, Offset{1, 0}},
+		{Targeter{Start: Offset{-1, 0}}, nil, Offset{}},
+		{Targeter{Start: Offset{-1, 0}, FreeAim: true}, nil, Offset{-1, 0}},
+		{Targeter{Start: Offset{9, 9}, FreeAim: true}, targets, Offset{2, 0}},
+		{Targeter{Start: Offset{2, 0}, Range: 1}, nil, Offset{}},
+		{Targeter{Memory: &TargetMemory{Tile: g.At(3, 2)}}, targets, Offset{1, 0}},
+	}
+	for i, c := range cases {
+		if actual := c.targeter.start(fov, c.targets); actual != c.expected {
+			t.Errorf("case %d: start() = %v != %v", i, actual, c.expected)
+		}
+	}
+}
+
+func TestTargeter_accepts(t *testing.T) {
+	targeter := Targeter{Accept: append(Keys("tf"), KeyEnter)}
+	for _, key := range []Key{'t', 'f', KeyEnter} {
+		if !targeter.accepts(key) {
+			t.Errorf("accepts(%q) = false", key)
+		}
+	}
+	for _, key := range []Key{'x', KeyEsc, 0} {
+		if targeter.accepts(key) {
+			t.Errorf("accepts(%q) = true", key)
+		}
+	}
+}
+
+func TestTargeter_finish(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2)}
+	memory := &TargetMemory{}
+	targeter := Targeter{Memory: memory}
+
+	// escape never gives a target, even with the reticle on one
+	if target, visible, ok := targeter.finish(KeyEsc, Offset{1, 0}, fov); target != nil || visible || ok {
+		t.Errorf("finish(KeyEsc) = %v, %v, %v", target, visible, ok)
+	}
+	if memory.Tile != nil {
+		t.Errorf("finish(KeyEsc) remembered %v", memory.Tile)
+	}
+	if target, visible, ok := targeter.finish(KeyEnter, Offset{1, 0}, fov); target != g.At(3, 2) || !visible || !ok {
+		t.Errorf("finish(KeyEnter) = %v, %v, %v", target, visible, ok)
+	}
+	if memory.Tile != g.At(3, 2) {
+		t.Errorf("finish(KeyEnter) remembered %v", memory.Tile)
+	}
+
+	// an OnKey which ends aiming cannot accept a target out of Range
+	targeter.Range = 1
+	fov[Offset{2, 0}] = g.At(4, 2)
+	if target, visible, ok := targeter.finish(0, Offset{2, 0}, fov); target != nil || visible || ok {
+		t.Errorf("finish() out of Range = %v, %v, %v", target, visible, ok)
+	}
+	if memory.Tile != g.At(3, 2) {
+		t.Errorf("finish() out of Range remembered %v", memory.Tile)
+	}
+}
+
+func TestTargeter_mouse(t *testing.T) {
+	g, _ := NewGrid(5, 5)
+	fov := map[Offset]*Tile{{0, 0}: g.At(2, 2), {1, 0}: g.At(3, 2), {2, 0}: g.At(4, 2)}
+	locate := func(x, y int) (Offset, bool) { return Offset{x, y}, x < 5 }
+	cases := []struct {
+		targeter Targeter
+		input    Input
+		offset   Offset
+		key      Key
+		done     bool
+	}{
+		{Targeter{}, Input{Key: KeyMouseRight, Mouse: true}, Offset{}, KeyEsc, false},
+		{Targeter{}, Input{Key: KeyMouseLeft, Mouse: true, X: 1}, Offset{}, 0, false},
+		{Targeter{Locate: locate}, Input{Key: KeyMouseLeft, Mouse: true, X: 1}, Offset{1, 0}, KeyMouseLeft, true},
+		{Targeter{Locate: locate}, Input{Key: KeyMouseLeft, Mouse: true, Motion: true, X: 2}, Offset{2, 0}, 0, false},
+		{Targeter{Locate: locate}, Input{Key: KeyMouseRelease, Mouse: true, X: 1}, Offset{1, 0}, 0, false},
+		{Targeter{Locate: locate}, Input{Key: KeyMouseLeft, Mouse: true, X: 1, Y: 1}, Offset{}, 0, false},
+		{Targeter{Locate: locate}, Input{Key: KeyMouseLeft, Mouse: true, X: 9}, Offset{}, 0, false},
+		{Targeter{Locate: locate, RequireLoS: true}, Input{Key: KeyMouseLeft, Mouse: true, X: 2}, Offset{2, 0}, 0, false},
+	}
+	g.At(3, 2).Pass = false
+	for i, c := range cases {
+		offset, key, done := c.targeter.mouse(c.input, Offset{}, fov)
+		if offset != c.offset || key != c.key || done != c.done {
+			t.Errorf("case %d: mouse() = %v, %v, %v", i, offset, key, done)
+		}
+	}
+}
@@ -1,24 +1,57 @@
 package core
 
-// We use these tables to cheaply approximate FoV, but we cache the tables so
-// we only have to compute them once.
-var tableCache = make(map[int]map[Offset]map[Offset]struct{})
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// We use tables to cheaply approximate FoV. The table for a radius is made of
+// one shell for each Chebyshev distance from the origin less than the radius,
+// holding the links from each Offset at that distance. The shells are the same
+// for every radius, so a larger table extends a smaller one by building only
+// the new shells, each of which is built once, and only when first needed.
+// Each assembled table is then cached by radius in fovTables, so that FoV
+// neither locks nor allocates to get a table it has used before.
+var (
+	fovShellsMu sync.Mutex
+	fovShells   []*fovShell
+	fovTables   sync.Map
+)
+
+// fovShell is a single shell of the FoV tables, built at most once.
+type fovShell struct {
+	once  sync.Once
+	links map[Offset]map[Offset]struct{}
+}
+
+// fovWarmRadius is the radius up to which the FoV tables are built in the
+// background when the package is initialized, so that the first FoV of a
+// common radius need not build them itself.
+const fovWarmRadius = 20
+
+func init() {
+	go PrecomputeFoV(fovWarmRadius)
+}
+
+// PrecomputeFoV builds the tables used by FoV for every radius up to the given
+// one, which otherwise happens on the first use of each radius. It is safe to
+// call while FoV is in use, such as from another goroutine.
+func PrecomputeFoV(radius int) {
+	fovTable(radius)
+}
 
 // FoV uses a simple heuristic to approximate shadowcasting field of view
 // calculation. The offsets in the resulting field are reletive to the given
 // origin.
 func FoV(origin *Tile, radius int) map[Offset]*Tile {
-	// Retrieve (or create and cache) the table for the given radius.
-	// This table maps a particular offset to a set of offsets which can seen
-	// if the given one is transparent. Using this table, we basically just do
-	// a recursive search using the table to guide us. Thus, we get a field of
-	// view algorithm which performs minimal computation, never revisits tiles,
-	// and short circuits on closed maps.
-	table, cached := tableCache[radius]
-	if !cached {
-		table = computeTable(radius)
-		tableCache[radius] = table
-	}
+	// Retrieve (or create and cache) the table for the given radius. Each
+	// shell of this table maps a particular offset to a set of offsets which
+	// can seen if the given one is transparent. Using this table, we
+	// basically just do a recursive search using the table to guide us.
+	// Thus, we get a field of view algorithm which performs minimal
+	// computation, never revisits tiles, and short circuits on closed maps.
+	table := fovTable(radius)
 
 	fov := map[Offset]*Tile{Offset{0, 0}: origin}
 	stack := []Offset{{0, 0}}
@@ -30,7 +63,11 @@ func FoV(origin *Tile, radius int) map[Offset]*Tile {
 		// Get the tile  for that offset
 		tile := fov[off]
 
-		for adj := range table[off] {
+		var links map[Offset]struct{}
+		if d := off.Chebyshev(); d < len(table) {
+			links = table[d][off]
+		}
+		for adj := range links {
 			// Add all the adjacent tiles to the field of view.
 			neighbor := tile.Adjacent[adj.Sub(off)]
 			fov[adj] = neighbor
@@ -38,7 +75,7 @@ func FoV(origin *Tile, radius int) map[Offset]*Tile {
 			// If the neighbor is translucient, push it onto the stack to
 			// continue exploration. Since we already added it to fov, when we
 			// pop it, we'll be able to access the position again.
-			if neighbor.Lite {
+			if neighbor.Transparent() {
 				stack = append(stack, adj)
 			}
 		}
@@ -49,14 +86,77 @@ func FoV(origin *Tile, radius int) map[Offset]*Tile {
 	return fov
 }
 
-// computeTable gets the table for a particular radius. This table will allow
-// us to approxmiate shadowcasting using FoV.
-func computeTable(radius int) map[Offset]map[Offset]struct{} {
-	table := make(map[Offset]map[Offset]struct{})
+// Sight is a Component which handles FoVRequest using FoV from the current
+// position of its Entity. Radius is used unless the FoVRequest gives its own.
+type Sight struct {
+	Pos    *Tile
+	Radius int
+}
 
-	// We start at the origin, and will compute a single octant.
-	addEntry(table, Offset{0, 0}, Offset{1, 0})
-	addEntry(table, Offset{0, 0}, Offset{1, 1})
+// Process implements Component for Sight.
+func (s *Sight) Process(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		s.Pos = v.Pos
+	case *FoVRequest:
+		if s.Pos == nil {
+			return
+		}
+		radius := v.Radius
+		if radius == 0 {
+			radius = s.Radius
+		}
+		v.FoV = FoV(s.Pos, radius)
+	}
+}
+
+// fovTable gets the shells of the table for a particular radius, building any
+// which are not built yet. This table will allow us to approxmiate
+// shadowcasting using FoV. A radius below 1 gets the table for radius 1. The
+// assembled table is shared by every caller with the same radius, and so must
+// not be changed.
+func fovTable(radius int) []map[Offset]map[Offset]struct{} {
+	if radius < 1 {
+		radius = 1
+	}
+	if table, ok := fovTables.Load(radius); ok {
+		return table.([]map[Offset]map[Offset]struct{})
+	}
+
+	fovShellsMu.Lock()
+	for len(fovShells) < radius {
+		fovShells = append(fovShells, &fovShell{})
+	}
+	shells := fovShells[:radius]
+	fovShellsMu.Unlock()
+
+	table := make([]map[Offset]map[Offset]struct{}, radius)
+	for d, shell := range shells {
+		shell.once.Do(func() { shell.links = computeShell(d) })
+		table[d] = shell.links
+	}
+	fovTables.Store(radius, table)
+	return table
+}
+
+// computeShell computes the shell of the FoV tables at a particular distance.
+func computeShell(d int) map[Offset]map[Offset]struct{} {
+	shell := make(map[Offset]map[Offset]struct{})
+
+	// We compute a single octant, linking each Offset to every symmetric
+	// Offset as we go to complete the other 7 octants.
+	link := func(src, dst Offset) {
+		for _, sym := range fovSymmetries {
+			addEntry(shell, sym(src), sym(dst))
+		}
+	}
+
+	// We start at the origin.
+	if d == 0 {
+		link(Offset{0, 0}, Offset{1, 0})
+		link(Offset{0, 0}, Offset{1, 1})
+		return shell
+	}
 
 	// The following algorithm is better described in the blog post at:
 	// http://stonesrl.blogspot.com/2013/02/pre-computed-fov.html
@@ -65,33 +165,49 @@ func computeTable(radius int) map[Offset]map[Offset]struct{} {
 	// Everything below such a tile continues diagoanlly, Everything else goes
 	// horizontally. A picture is worth a thousand words, so check out the
 	// blog post...
+	currBreak := fovBreak(d)
+	nextY := 0
+	for y := 0; y <= d; y++ {
+		pos := Offset{d, y}
+		if y == currBreak {
+			link(pos, Offset{d + 1, nextY})
+			link(pos, Offset{d + 1, nextY + 1})
+			nextY += 2
+		} else {
+			link(pos, Offset{d + 1, nextY})
+			nextY++
+		}
+	}
+
+	return shell
+}
+
+// fovBreak gives the row of the octant at which the links from a column both
+// spawn diagonally and horizontally.
+func fovBreak(x int) int {
 	currBreak := 0
 	breakCount := 0
-	for x := 1; x < radius; x++ {
-		nextY := 0
-		for y := 0; y <= x; y++ {
-			pos := Offset{x, y}
-			if y == currBreak {
-				addEntry(table, pos, Offset{x + 1, nextY})
-				addEntry(table, pos, Offset{x + 1, nextY + 1})
-				nextY += 2
-			} else {
-				addEntry(table, pos, Offset{x + 1, nextY})
-				nextY++
-			}
-		}
+	for i := 1; i < x; i++ {
 		breakCount--
 		if breakCount < 0 {
 			breakCount = currBreak + 1
 			currBreak++
 		}
 	}
+	return currBreak
+}
 
-	// Now that we've computed one octant, reflect and rotate to complete the
-	// other 7 octants.
-	completeTable(table)
-
-	return table
+// fovSymmetries reflect and rotate an Offset in the first octant into each of
+// the 8 octants.
+var fovSymmetries = []func(Offset) Offset{
+	func(o Offset) Offset { return Offset{o.X, o.Y} },
+	func(o Offset) Offset { return Offset{-o.X, o.Y} },
+	func(o Offset) Offset { return Offset{o.X, -o.Y} },
+	func(o Offset) Offset { return Offset{-o.X, -o.Y} },
+	func(o Offset) Offset { return Offset{o.Y, o.X} },
+	func(o Offset) Offset { return Offset{-o.Y, o.X} },
+	func(o Offset) Offset { return Offset{o.Y, -o.X} },
+	func(o Offset) Offset { return Offset{-o.Y, -o.X} },
 }
 
 // addEntry places a link between two offsets, adding the set keyed by src
@@ -105,28 +221,6 @@ func addEntry(table map[Offset]map[Offset]struct{}, src, dst Offset) {
 	neighbors[dst] = struct{}{}
 }
 
-// completeTable uses reflection and rotation to take a table with a single
-// octant and extend it to all 8 octants.
-func completeTable(table map[Offset]map[Offset]struct{}) {
-	for key := range table {
-		from := Offset{key.Y, key.X}
-		for pos := range table[key] {
-			addEntry(table, from, Offset{pos.Y, pos.X})
-		}
-	}
-
-	for key := range table {
-		negX := Offset{-key.X, key.Y}
-		negY := Offset{key.X, -key.Y}
-		negXY := Offset{-key.X, -key.Y}
-		for pos := range table[key] {
-			addEntry(table, negX, Offset{-pos.X, pos.Y})
-			addEntry(table, negY, Offset{pos.X, -pos.Y})
-			addEntry(table, negXY, Offset{-pos.X, -pos.Y})
-		}
-	}
-}
-
 // wallfix fills in some missing wall artifacts in a field of view.
 func wallfix(fov map[Offset]*Tile, radius int) {
 	// Each of the four code block does the same basic thing in a different
@@ -177,7 +271,7 @@ func wallfix(fov map[Offset]*Tile, radius int) {
 
 // We use these tables to cheaply approximate LoS, but we cache the tables so
 // we only have to compute them once. They are computed by reversing FoV tables
-// stored in tableCache.
+// built by fovTable.
 var reverseTableCache = make(map[int]map[Offset]Offset)
 
 // Trace computes a line of Offset from the origin to the goal Offset.
@@ -203,6 +297,82 @@ func Trace(goal Offset) []Offset {
 	return path
 }
 
+// FoVCone computes the part of the field of view from the origin, as computed
+// by FoV, which lies in a cone pointing in the direction dir, such as for a
+// breath weapon. An Offset is in the cone if the angle between it and dir is
+// at most halfAngle, in radians, so math.Pi/4 gives a quarter circle. The
+// origin itself is never in the cone.
+func FoVCone(origin *Tile, radius int, dir Offset, halfAngle float64) map[Offset]*Tile {
+	return cone(FoV(origin, radius), dir, halfAngle)
+}
+
+// cone filters a field of view to the cone described by FoVCone.
+func cone(fov map[Offset]*Tile, dir Offset, halfAngle float64) map[Offset]*Tile {
+	filtered := make(map[Offset]*Tile)
+	if dir == (Offset{}) {
+		return filtered
+	}
+	// a small tolerance keeps Offset exactly on the edge of the cone
+	limit := math.Cos(halfAngle) - 1e-9
+	for o, t := range fov {
+		if o == (Offset{}) {
+			continue
+		}
+		dot := float64(o.X*dir.X + o.Y*dir.Y)
+		if dot/(o.Euclidean()*dir.Euclidean()) >= limit {
+			filtered[o] = t
+		}
+	}
+	return filtered
+}
+
+// VisibleEntities returns the Offset of each occupied Tile in a field of view,
+// such as from a FoVRequest, for which the predicate is true of the Occupant,
+// excluding the origin. The Offset are ordered by Euclidean distance from the
+// origin, with ties broken by row and then column. A nil predicate accepts
+// every Occupant.
+func VisibleEntities(fov map[Offset]*Tile, pred func(Entity) bool) []Offset {
+	var visible []Offset
+	for o, t := range fov {
+		if o != (Offset{}) && t != nil && t.Occupant != nil && (pred == nil || pred(t.Occupant)) {
+			visible = append(visible, o)
+		}
+	}
+	sortByDistance(visible)
+	return visible
+}
+
+// sortByDistance sorts Offset by Euclidean distance from the origin, with ties
+// broken by row and then column.
+func sortByDistance(offsets []Offset) {
+	sort.Slice(offsets, func(i, j int) bool {
+		a, b := offsets[i], offsets[j]
+		if da, db := a.Euclidean(), b.Euclidean(); da != db {
+			return da < db
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+}
+
+// Disc computes the Offset within radius of the center by Euclidean distance,
+// rounded so that the disc has no single Offset spikes at its edges, such as
+// for the area of a Blast. The Offset are ordered by row and then by column.
+// A negative radius gives no Offset.
+func Disc(center Offset, radius int) []Offset {
+	var disc []Offset
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius+radius {
+				disc = append(disc, center.Add(Offset{dx, dy}))
+			}
+		}
+	}
+	return disc
+}
+
 // LoS returns true if the line from origin to goal computed by Trace does not
 // contain a non-translucient Tile. The line is computed using the same
 // heuristic as FoV, so if LoS returns true, then the goal tile would also be
@@ -211,7 +381,7 @@ func LoS(origin, goal *Tile) bool {
 	curr := goal.Offset.Sub(origin.Offset)
 	table := getReverseTable(curr)
 	for goal != origin {
-		if !goal.Lite {
+		if !goal.Transparent() {
 			return false
 		}
 		next := table[curr]
@@ -234,16 +404,12 @@ func getReverseTable(o Offset) map[Offset]Offset {
 
 // computeReverseTable computes a LoS table by reversing a FoV table.
 func computeReverseTable(radius int) map[Offset]Offset {
-	forward, cached := tableCache[radius]
-	if !cached {
-		forward = computeTable(radius)
-		tableCache[radius] = forward
-	}
-
 	reverse := make(map[Offset]Offset)
-	for pos, edges := range forward {
-		for edge := range edges {
-			reverse[edge] = pos
+	for _, shell := range fovTable(radius) {
+		for pos, edges := range shell {
+			for edge := range edges {
+				reverse[edge] = pos
+			}
 		}
 	}
 	return reverse
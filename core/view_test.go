@@ -0,0 +1,189 @@
+package core
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSight(t *testing.T) {
+	var origin *Tile
+	StrGrid{
+		"#########",
+		"#.......#",
+		"#...@...#",
+		"#.......#",
+		"#########",
+	}.Convert(func(t *Tile, ch byte) {
+		t.Lite = ch != '#'
+		t.Pass = ch != '#'
+		if ch == '@' {
+			origin = t
+		}
+	})
+
+	sight := &Sight{Radius: 1}
+	e := ComponentSlice{sight}
+
+	req := FoVRequest{}
+	e.Handle(&req)
+	if req.FoV != nil {
+		t.Errorf("Sight without a position gave FoV %v", req.FoV)
+	}
+
+	e.Handle(&UpdatePos{origin})
+	cases := []struct {
+		radius   int
+		expected int
+	}{
+		{0, 9},
+		{1, 9},
+		{2, 25},
+	}
+	for _, c := range cases {
+		req := FoVRequest{Radius: c.radius}
+		e.Handle(&req)
+		if len(req.FoV) != c.expected {
+			t.Errorf("FoVRequest{Radius: %d} gave %d tiles != %d", c.radius, len(req.FoV), c.expected)
+		}
+		if req.FoV[Offset{0, 0}] != origin {
+			t.Errorf("FoVRequest{Radius: %d} not relative to Sight position", c.radius)
+		}
+	}
+}
+
+func TestDisc(t *testing.T) {
+	cases := []struct {
+		radius, expected int
+	}{
+		{-1, 0},
+		{0, 1},
+		{1, 9},
+		{2, 21},
+		{3, 37},
+	}
+	for _, c := range cases {
+		disc := Disc(Offset{5, -5}, c.radius)
+		if len(disc) != c.expected {
+			t.Errorf("Disc(%d) has %d != %d Offset", c.radius, len(disc), c.expected)
+		}
+		for _, o := range disc {
+			if d := o.Sub(Offset{5, -5}); d.Chebyshev() > c.radius {
+				t.Errorf("Disc(%d) includes %v", c.radius, d)
+			}
+		}
+	}
+}
+
+func TestVisibleEntities(t *testing.T) {
+	occupied := func(o Offset, e Entity) *Tile {
+		tile := NewTile(o)
+		tile.Occupant = e
+		return tile
+	}
+	near, far, tied, skipped := NewEntity(), NewEntity(), NewEntity(), NewEntity()
+	fov := map[Offset]*Tile{
+		{0, 0}:  occupied(Offset{}, NewEntity()),
+		{3, 0}:  occupied(Offset{3, 0}, far),
+		{1, 1}:  occupied(Offset{1, 1}, tied),
+		{-1, 1}: occupied(Offset{-1, 1}, near),
+		{0, 1}:  occupied(Offset{0, 1}, skipped),
+		{2, 0}:  NewTile(Offset{2, 0}),
+	}
+	visible := VisibleEntities(fov, func(e Entity) bool { return e != skipped })
+	expected := []Offset{{-1, 1}, {1, 1}, {3, 0}}
+	if !reflect.DeepEqual(visible, expected) {
+		t.Errorf("VisibleEntities() = %v != %v", visible, expected)
+	}
+	if all := VisibleEntities(fov, nil); len(all) != 4 {
+		t.Errorf("VisibleEntities(nil) = %v", all)
+	}
+}
+
+func TestFoVCone(t *testing.T) {
+	g, _ := NewGrid(9, 9)
+	origin := g.At(4, 4)
+	cases := []struct {
+		dir       Offset
+		halfAngle float64
+		expected  int
+	}{
+		{Offset{1, 0}, math.Pi / 4, 15},
+		{Offset{1, 1}, math.Pi / 4, 15},
+		{Offset{0, -1}, 0, 3},
+		{Offset{-1, 0}, math.Pi, 48},
+		{Offset{}, math.Pi, 0},
+	}
+	for _, c := range cases {
+		cone := FoVCone(origin, 3, c.dir, c.halfAngle)
+		if len(cone) != c.expected {
+			t.Errorf("FoVCone(%v, %v) has %d != %d Offset", c.dir, c.halfAngle, len(cone), c.expected)
+		}
+		if _, ok := cone[Offset{}]; ok {
+			t.Errorf("FoVCone(%v, %v) includes the origin", c.dir, c.halfAngle)
+		}
+	}
+}
+
+func TestPrecomputeFoV(t *testing.T) {
+	// Start over, so the tables are built during the test.
+	fovShellsMu.Lock()
+	fovShells = nil
+	fovShellsMu.Unlock()
+	fovTables.Range(func(radius, _ any) bool {
+		fovTables.Delete(radius)
+		return true
+	})
+
+	g, _ := NewGrid(61, 61)
+	g.Each(func(_ Offset, t *Tile) { t.Lite = true })
+	origin := g.At(30, 30)
+
+	// Warming in the background must not race with foreground use.
+	warmed, done := make(chan bool), make(chan int)
+	go func() {
+		PrecomputeFoV(25)
+		warmed <- true
+	}()
+	for i := 0; i < 4; i++ {
+		go func() { done <- len(FoV(origin, 25)) }()
+	}
+	sizes := map[int]bool{}
+	for i := 0; i < 4; i++ {
+		sizes[<-done] = true
+	}
+	<-warmed
+	if expected := len(FoV(origin, 25)); len(sizes) != 1 || !sizes[expected] {
+		t.Errorf("concurrent FoV gave sizes %v != %d", sizes, expected)
+	}
+
+	// A larger table extends a smaller one rather than being built anew.
+	small, large := fovTable(20), fovTable(21)
+	for d := range small {
+		if reflect.ValueOf(small[d]).Pointer() != reflect.ValueOf(large[d]).Pointer() {
+			t.Errorf("fovTable(21) rebuilt shell %d", d)
+		}
+	}
+
+	// Once assembled, the table for a radius is reused without allocating.
+	if again := fovTable(21); &again[0] != &large[0] {
+		t.Errorf("fovTable(21) assembled the table again")
+	}
+	if allocs := testing.AllocsPerRun(10, func() { fovTable(21) }); allocs != 0 {
+		t.Errorf("fovTable(21) made %v allocations", allocs)
+	}
+}
+
+func BenchmarkFoVTable_Build21(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for d := 0; d < 21; d++ {
+			computeShell(d)
+		}
+	}
+}
+
+func BenchmarkFoVTable_Extend20(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		computeShell(20)
+	}
+}
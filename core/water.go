@@ -0,0 +1,140 @@
+package core
+
+// WaterOptions configures AddWater.
+type WaterOptions struct {
+	// Lakes is the number of lakes, each grown to about LakeSize Tiles, with
+	// a default of 20, over passable Tiles.
+	Lakes    int
+	LakeSize int
+
+	// Rivers is the number of rivers, each wandering from one edge of the
+	// Grid to the opposite edge, through rock or not, and widened by one or
+	// two Tiles.
+	Rivers int
+
+	// Water is the terrain of lakes and rivers, and Bridge the terrain used
+	// to reconnect anything they cut off, with defaults of TerrainWater and
+	// TerrainFloor.
+	Water, Bridge TerrainID
+
+	// Dice, if set, is used in place of the global Dice.
+	Dice Dice
+}
+
+// AddWater adds lakes and rivers to a generated Grid with Grid.SetTerrain,
+// leaving any occupied Tile and the edge of the Grid alone, and returns the
+// number of Tiles turned to water. Since water must be swum, the walkable
+// regions are then joined with ConnectRegions, so that a river cutting a
+// corridor in two is bridged.
+func AddWater(g *Grid, opts WaterOptions) int {
+	if opts.LakeSize == 0 {
+		opts.LakeSize = 20
+	}
+	if opts.Water == "" {
+		opts.Water = TerrainWater
+	}
+	if opts.Bridge == "" {
+		opts.Bridge = TerrainFloor
+	}
+	if opts.Dice.Rand == nil {
+		opts.Dice = globalDice
+	}
+
+	water := make(map[*Tile]bool)
+	flood := func(t *Tile) {
+		if t == nil || water[t] || t.Occupant != nil {
+			return
+		}
+		// the edge, where a Tile lacks neighbours, is never flooded, so
+		// nothing can see or swim off the Grid
+		if len(t.Adjacent) < 8 {
+			return
+		}
+		if g.SetTerrain(t, TileSpec{Terrain: opts.Water}) == nil {
+			water[t] = true
+		}
+	}
+	for i := 0; i < opts.Lakes; i++ {
+		addLake(g, opts, water, flood)
+	}
+	for i := 0; i < opts.Rivers; i++ {
+		addRiver(g, opts, flood)
+	}
+
+	regions := Regions(g, walkable)
+	ConnectRegions(g, regions, func(t *Tile) {
+		g.SetTerrain(t, TileSpec{Terrain: opts.Bridge})
+		delete(water, t)
+	})
+	return len(water)
+}
+
+// Generate adds water with AddWater. The Dice, if set, is used in place of
+// opts.Dice.
+func (opts WaterOptions) Generate(g *Grid, dice Dice, _ *GenResult) error {
+	if dice.Rand != nil {
+		opts.Dice = dice
+	}
+	AddWater(g, opts)
+	return nil
+}
+
+// addLake grows a lake from a random passable Tile by repeatedly flooding a
+// random passable Tile adjacent to the lake, until it reaches LakeSize or
+// runs out of room.
+func addLake(g *Grid, opts WaterOptions, water map[*Tile]bool, flood func(*Tile)) {
+	dry := func(t *Tile) bool {
+		return t.Pass && t.Occupant == nil && !water[t]
+	}
+	start, err := RandomTile(g, opts.Dice, dry)
+	if err != nil {
+		return
+	}
+
+	shore := []*Tile{start}
+	for size := 0; size < opts.LakeSize && len(shore) > 0; {
+		i := opts.Dice.Intn(len(shore))
+		t := shore[i]
+		shore[i] = shore[len(shore)-1]
+		shore = shore[:len(shore)-1]
+		if !dry(t) {
+			continue
+		}
+
+		flood(t)
+		size++
+		for _, delta := range descentOrder[:4] {
+			if adj, ok := t.Adjacent[delta]; ok && dry(adj) {
+				shore = append(shore, adj)
+			}
+		}
+	}
+}
+
+// addRiver floods a random walk from one edge of the Grid to the opposite
+// edge, running either north to south or west to east, along with one or two
+// Tiles to the side of each step. The walk stays inside the edge of the Grid,
+// which is left as it was.
+func addRiver(g *Grid, opts WaterOptions, flood func(*Tile)) {
+	// rivers are walked as if running down the columns, and transposed when
+	// running across the rows
+	along, across := g.Height(), g.Width()
+	at := func(a, c int) *Tile { return g.At(c, a) }
+	if opts.Dice.Bool() {
+		along, across = across, along
+		at = func(a, c int) *Tile { return g.At(a, c) }
+	}
+
+	width := opts.Dice.Range(1, 2)
+	last := across - 2 - width
+	if last < 1 {
+		return
+	}
+	c := opts.Dice.Range(1, last)
+	for a := 1; a < along-1; a++ {
+		for w := 0; w <= width; w++ {
+			flood(at(a, c+w))
+		}
+		c = Clamp(1, c+opts.Dice.Range(-1, 1), last)
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAddWater(t *testing.T) {
+	isWater := func(tile *Tile) bool { return tile.Terrain == TerrainWater }
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := bspCase(60, 40, seed, BSPOptions{MinLeaf: 6, Jitter: .3})
+		added := AddWater(g, WaterOptions{Lakes: 3, Rivers: 2, Dice: NewDice(rand.NewSource(seed))})
+		if added == 0 || added != g.Count(isWater) {
+			t.Errorf("seed %d added %d water for %d Tiles", seed, added, g.Count(isWater))
+		}
+		if regions := Regions(g, walkable); len(regions) != 1 {
+			t.Errorf("seed %d left %d walkable regions", seed, len(regions))
+		}
+	}
+}
+
+func TestAddWater_River(t *testing.T) {
+	edge := func(o Offset) bool { return o.X == 0 || o.Y == 0 || o.X == 29 || o.Y == 19 }
+	for seed := int64(0); seed < 10; seed++ {
+		g, _ := NewGrid(30, 20)
+		g.Each(func(o Offset, tile *Tile) {
+			if edge(o) {
+				tile.SetTerrain(TerrainWall)
+			}
+		})
+		AddWater(g, WaterOptions{Rivers: 1, Dice: NewDice(rand.NewSource(seed))})
+
+		isWater := func(tile *Tile) bool { return tile.Terrain == TerrainWater }
+		north := g.FindAll(func(tile *Tile) bool { return tile.Offset.Y == 1 && isWater(tile) })
+		south := g.FindAll(func(tile *Tile) bool { return tile.Offset.Y == 18 && isWater(tile) })
+		west := g.FindAll(func(tile *Tile) bool { return tile.Offset.X == 1 && isWater(tile) })
+		east := g.FindAll(func(tile *Tile) bool { return tile.Offset.X == 28 && isWater(tile) })
+		if (len(north) == 0 || len(south) == 0) && (len(west) == 0 || len(east) == 0) {
+			t.Errorf("seed %d river did not cross the Grid", seed)
+		}
+		if regions := Regions(g, walkable); len(regions) != 1 {
+			t.Errorf("seed %d river was not bridged", seed)
+		}
+		g.Each(func(o Offset, tile *Tile) {
+			if edge(o) && tile.Terrain != TerrainWall {
+				t.Errorf("seed %d river flooded edge %v", seed, o)
+			}
+		})
+
+		// the ends of the river must not let FoV see off the Grid
+		for _, ends := range [][]*Tile{north, south, west, east} {
+			for _, end := range ends {
+				FoV(end, 10)
+			}
+		}
+	}
+}
+
+func TestAddWater_Lake(t *testing.T) {
+	g, _, _ := ParseGrid(`
+		##########
+		#........#
+		#...@....#
+		#........#
+		##########
+	`, nil)
+	occupied := g.At(4, 2)
+	occupied.Occupant = &testentity{}
+	added := AddWater(g, WaterOptions{Lakes: 1, LakeSize: 40, Dice: NewDice(rand.NewSource(1))})
+	if added != 23 {
+		t.Errorf("AddWater() flooded %d Tiles", added)
+	}
+	if occupied.Terrain != TerrainFloor {
+		t.Errorf("AddWater() flooded occupied Tile")
+	}
+	if g.At(0, 0).Terrain != TerrainWall {
+		t.Errorf("AddWater() lake spread into rock")
+	}
+}
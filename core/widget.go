@@ -49,6 +49,7 @@ func (w *TextWidget) Update() {
 // logmsg is a cached message in LogWidget.
 type logmsg struct {
 	Text  string
+	Fg    Color
 	Count int
 	Seen  bool
 }
@@ -61,23 +62,69 @@ func (m *logmsg) String() string {
 	return fmt.Sprintf("%s (x%d)", m.Text, m.Count)
 }
 
-// LogWidget is a Widget which stores and display log messages.
+// LogWidget is a Widget which stores and display log messages. If Markup is
+// true, messages may contain color markup as described by ParseMarkup.
+//
+// LogWidget is also a Component which logs every Message it processes, so it
+// can be attached to the player Entity, or subscribed to an EventBus using
+// Subscribe(bus, log.LogMessage). If Filter is set, only a Message for which
+// Filter returns true is logged, such as with FoVFilter.
 type LogWidget struct {
 	Widget
-	cache []*logmsg
+	Markup bool
+	Filter func(*Message) bool
+	cache  []*logmsg
 }
 
 // NewLogWidget creates a new empty LogWidget.
 func NewLogWidget(x, y, w, h int) *LogWidget {
-	return &LogWidget{Widget{x, y, w, h}, make([]*logmsg, 0)}
+	return &LogWidget{Widget{x, y, w, h}, false, nil, make([]*logmsg, 0)}
+}
+
+// Process implements Component for LogWidget.
+func (w *LogWidget) Process(v Event) {
+	if v, ok := v.(*Message); ok {
+		w.LogMessage(v)
+	}
+}
+
+// LogMessage logs a Message in its Color, unless rejected by the Filter.
+func (w *LogWidget) LogMessage(m *Message) {
+	if w.Filter == nil || w.Filter(m) {
+		w.LogColor(m.Text, m.Color)
+	}
+}
+
+// FoVFilter creates a LogWidget Filter which drops any Message whose Pos is
+// outside the field of view of the viewer, as given by a FoVRequest.
+func FoVFilter(viewer Entity) func(*Message) bool {
+	return func(m *Message) bool {
+		if m.Pos == nil {
+			return true
+		}
+		req := FoVRequest{}
+		viewer.Handle(&req)
+		for _, tile := range req.FoV {
+			if tile == m.Pos {
+				return true
+			}
+		}
+		return false
+	}
 }
 
 // Log places a new message in the LogWidget cache.
 func (w *LogWidget) Log(msg string) {
+	w.LogColor(msg, 0)
+}
+
+// LogColor places a new message in the LogWidget cache, to be displayed in the
+// given Color until seen. A zero Color is the default white.
+func (w *LogWidget) LogColor(msg string, fg Color) {
 	last := len(w.cache) - 1
 	// if cache is empty, or last message text was different than this one
 	if last < 0 || w.cache[last].Text != msg {
-		w.cache = append(w.cache, &logmsg{msg, 1, false})
+		w.cache = append(w.cache, &logmsg{msg, fg, 1, false})
 		// truncate cache if too long to show on the widget
 		if len(w.cache) > w.h {
 			w.cache = w.cache[len(w.cache)-w.h:]
@@ -95,13 +142,15 @@ func (w *LogWidget) Update() {
 		var fg Color
 		if msg.Seen {
 			fg = ColorLightBlack
+		} else if msg.Fg != 0 {
+			fg = msg.Fg
 		} else {
 			fg = ColorWhite
 		}
 
 		// note we assume no newlines, unlike TextWidget.
-		for x, ch := range msg.String() {
-			w.DrawRel(x, y, Glyph{ch, fg})
+		for x, g := range textGlyphs(msg.String(), fg, w.Markup) {
+			w.DrawRel(x, y, g)
 		}
 
 		// we just displayed the message, so next time should be seen
@@ -109,15 +158,105 @@ func (w *LogWidget) Update() {
 	}
 }
 
-// CameraWidget is a Widget which displays an Entity field of view
+// Greyscale returns the Glyph drawn in grey, such as for a Tile which is
+// remembered but not currently visible.
+func Greyscale(g Glyph) Glyph {
+	return Glyph{g.Ch, ColorLightBlack}
+}
+
+// MapView decides how each Tile of a map is drawn, depending on whether it is
+// visible, remembered or unknown to the viewer. Visible Tiles are drawn by
+// Visible, or live with a RenderRequest if nil. Tiles outside the field of
+// view are drawn from the Explored Faces of Memory through Remembered, or
+// Greyscale if nil, and any others as Unknown, which is blank if unset.
+//
+// Occupants are only drawn while visible, unless Sightings is true, in which
+// case each Sighting in Memory outside the field of view is drawn through
+// Remembered at the place the Entity was last seen.
+type MapView struct {
+	Memory     *Memory
+	Visible    func(*Tile) Glyph
+	Remembered func(Glyph) Glyph
+	Unknown    Glyph
+	Sightings  bool
+}
+
+// TileGlyph returns the Glyph to draw for a Tile using a MapView with the
+// given Memory, which may be nil, and no other customization. The field of
+// view is as given by a FoVRequest to the viewer on the origin Tile.
+func TileGlyph(fov map[Offset]*Tile, origin *Tile, mem *Memory, t *Tile) Glyph {
+	return MapView{Memory: mem}.Glyph(fov, origin, t)
+}
+
+// Glyph returns the Glyph to draw for a Tile. The field of view is as given
+// by a FoVRequest to the viewer on the origin Tile.
+func (v MapView) Glyph(fov map[Offset]*Tile, origin, t *Tile) Glyph {
+	return v.glyphAt(fov, origin.Offset, t.Offset.Sub(origin.Offset), v.sightings(fov, origin.Offset))
+}
+
+// glyphAt returns the Glyph to draw at an Offset relative to the origin, given
+// the remembered sightings from MapView.sightings.
+func (v MapView) glyphAt(fov map[Offset]*Tile, origin, rel Offset, sightings map[Offset]Glyph) Glyph {
+	if tile, ok := fov[rel]; ok {
+		if v.Visible != nil {
+			return v.Visible(tile)
+		}
+		req := RenderRequest{}
+		tile.Handle(&req)
+		return req.Render
+	}
+
+	remembered := v.Remembered
+	if remembered == nil {
+		remembered = Greyscale
+	}
+	if face, ok := sightings[origin.Add(rel)]; ok {
+		return remembered(face)
+	}
+	if v.Memory != nil {
+		if face, ok := v.Memory.Remembered(origin.Add(rel)); ok {
+			return remembered(face)
+		}
+	}
+	if v.Unknown.Ch == 0 {
+		return Glyph{' ', ColorWhite}
+	}
+	return v.Unknown
+}
+
+// sightings renders each Sighting of the Memory outside the field of view,
+// keyed by the Offset of the Tile where it was seen, if Sightings is set.
+func (v MapView) sightings(fov map[Offset]*Tile, origin Offset) map[Offset]Glyph {
+	if !v.Sightings || v.Memory == nil || v.Memory.reg == nil {
+		return nil
+	}
+	faces := make(map[Offset]Glyph)
+	for id, s := range v.Memory.Sightings {
+		e := v.Memory.reg.Lookup(id)
+		if e == nil || fov[s.Pos.Offset.Sub(origin)] == s.Pos {
+			continue
+		}
+		req := RenderRequest{Pos: s.Pos}
+		e.Handle(&req)
+		if req.Render.Ch != 0 {
+			faces[s.Pos.Offset] = req.Render
+		}
+	}
+	return faces
+}
+
+// CameraWidget is a Widget which displays an Entity field of view. Each cell
+// of the Widget is drawn by View, so that with a Memory set, the parts of the
+// map seen before are drawn around the field of view.
 type CameraWidget struct {
 	Widget
 	Camera Entity
+	View   MapView
 }
 
 // NewCameraWidget creates a new CameraWidget with the given camera Entity.
 func NewCameraWidget(camera Entity, x, y, w, h int) *CameraWidget {
-	return &CameraWidget{Widget{x, y, w, h}, camera}
+	return &CameraWidget{Widget{x, y, w, h}, camera, MapView{}}
 }
 
 // Update draws the camera field of view on screen.
@@ -126,10 +265,20 @@ func (w *CameraWidget) Update() {
 	w.Camera.Handle(&req)
 	cx, cy := w.center()
 
-	for offset, tile := range req.FoV {
-		req := RenderRequest{}
-		tile.Handle(&req)
-		w.DrawRel(cx+offset.X, cy+offset.Y, req.Render)
+	// without the Tile of the camera, only the field of view can be placed
+	origin, ok := req.FoV[Offset{}]
+	if !ok {
+		for offset := range req.FoV {
+			w.DrawRel(cx+offset.X, cy+offset.Y, w.View.glyphAt(req.FoV, Offset{}, offset, nil))
+		}
+		return
+	}
+	sightings := w.View.sightings(req.FoV, origin.Offset)
+	for x := 0; x < w.w; x++ {
+		for y := 0; y < w.h; y++ {
+			rel := Offset{x - cx, y - cy}
+			w.DrawRel(x, y, w.View.glyphAt(req.FoV, origin.Offset, rel, sightings))
+		}
 	}
 }
 
@@ -139,16 +288,23 @@ func (w *CameraWidget) Mark(offset Offset, mark Glyph) {
 	w.DrawRel(cx+offset.X, cy+offset.Y, mark)
 }
 
+// Locate converts a screen location, such as of a mouse click, to the Offset
+// relative to the Camera center drawn there, inverting Mark. If the location
+// is outside the Widget, ok is false.
+func (w *CameraWidget) Locate(x, y int) (offset Offset, ok bool) {
+	x, y = x-w.x, y-w.y
+	if !InBounds(x, y, w.w, w.h) {
+		return Offset{}, false
+	}
+	cx, cy := w.center()
+	return Offset{x - cx, y - cy}, true
+}
+
 // center computes the offset of the camera center relative to the Widget.
 func (w *CameraWidget) center() (x, y int) {
 	return w.w / 2, w.h / 2
 }
 
-// FoVRequest is an Event querying an Entity for a field of view.
-type FoVRequest struct {
-	FoV map[Offset]*Tile
-}
-
 // PercentBarWidget displays a percent bar based on a bound percent function.
 type PercentBarWidget struct {
 	Widget
@@ -204,4 +360,254 @@ func (b *PercentBarWidget) Update() {
 	}
 }
 
+// MinimapWidget displays a scaled down overview of a map, with each cell of
+// the Widget summarizing a Scale by Scale block of Tile. A block is drawn as
+// Wall if most of its explored Tile are impassable, and as Floor otherwise,
+// unless Special reports a Glyph for one of its Tile. The block containing
+// the Tile given by Player is always drawn using PlayerGlyph.
+//
+// The block summaries are cached, so Invalidate must be called whenever the
+// map or the explored Tile change. Maps which are smaller than the Widget are
+// centered, while larger maps are scrolled to keep the Player block in view.
+type MinimapWidget struct {
+	Widget
+	Tiles    []*Tile
+	Scale    int
+	Explored func(*Tile) bool
+	Special  func(*Tile) (Glyph, bool)
+	Player   func() *Tile
+
+	Wall, Floor, PlayerGlyph Glyph
+
+	blocks     map[Offset]Glyph
+	origin     Offset
+	cols, rows int
+}
+
+// NewMinimapWidget creates a new MinimapWidget showing the given Tile.
+func NewMinimapWidget(tiles []*Tile, scale int, x, y, w, h int) *MinimapWidget {
+	return &MinimapWidget{
+		Widget:      Widget{x, y, w, h},
+		Tiles:       tiles,
+		Scale:       scale,
+		Wall:        Glyph{'#', ColorWhite},
+		Floor:       Glyph{'.', ColorLightBlack},
+		PlayerGlyph: Glyph{'@', ColorLightYellow},
+	}
+}
+
+// Invalidate marks the cached block summaries as stale, so they will be
+// recomputed on the next Update.
+func (w *MinimapWidget) Invalidate() {
+	w.blocks = nil
+}
+
+// summarize computes the Glyph for each block of explored Tile.
+func (w *MinimapWidget) summarize() {
+	scale := Max(w.Scale, 1)
+	w.blocks = make(map[Offset]Glyph)
+	if len(w.Tiles) == 0 {
+		w.cols, w.rows = 0, 0
+		return
+	}
+
+	// find the bounds of the map so the blocks can be indexed from zero
+	min, max := w.Tiles[0].Offset, w.Tiles[0].Offset
+	for _, t := range w.Tiles {
+		min = Offset{Min(min.X, t.Offset.X), Min(min.Y, t.Offset.Y)}
+		max = Offset{Max(max.X, t.Offset.X), Max(max.Y, t.Offset.Y)}
+	}
+	w.origin = min
+	w.cols, w.rows = (max.X-min.X)/scale+1, (max.Y-min.Y)/scale+1
+
+	// tally walls against floors, with any special Tile taking precedence
+	balance := make(map[Offset]int)
+	special := make(map[Offset]Glyph)
+	for _, t := range w.Tiles {
+		if w.Explored != nil && !w.Explored(t) {
+			continue
+		}
+		block := w.block(t)
+		if t.Pass {
+			balance[block]--
+		} else {
+			balance[block]++
+		}
+		if w.Special != nil {
+			if g, ok := w.Special(t); ok {
+				special[block] = g
+			}
+		}
+	}
+	for block, n := range balance {
+		if g, ok := special[block]; ok {
+			w.blocks[block] = g
+		} else if n > 0 {
+			w.blocks[block] = w.Wall
+		} else {
+			w.blocks[block] = w.Floor
+		}
+	}
+}
+
+// block computes the block containing the given Tile.
+func (w *MinimapWidget) block(t *Tile) Offset {
+	scale := Max(w.Scale, 1)
+	rel := t.Offset.Sub(w.origin)
+	return Offset{rel.X / scale, rel.Y / scale}
+}
+
+// Update draws the minimap on screen, recomputing the blocks if needed.
+func (w *MinimapWidget) Update() {
+	if w.blocks == nil {
+		w.summarize()
+	}
+
+	var player *Tile
+	if w.Player != nil {
+		player = w.Player()
+	}
+
+	// center the map if it fits, otherwise follow the player
+	dx, dy := (w.w-w.cols)/2, (w.h-w.rows)/2
+	if player != nil {
+		pos := w.block(player)
+		if w.cols > w.w {
+			dx = Clamp(w.w-w.cols, w.w/2-pos.X, 0)
+		}
+		if w.rows > w.h {
+			dy = Clamp(w.h-w.rows, w.h/2-pos.Y, 0)
+		}
+	}
+
+	for block, g := range w.blocks {
+		w.DrawRel(block.X+dx, block.Y+dy, g)
+	}
+	if player != nil {
+		pos := w.block(player)
+		w.DrawRel(pos.X+dx, pos.Y+dy, w.PlayerGlyph)
+	}
+}
+
+// ScrollbarWidget is a one column Widget which indicates which portion of a
+// scrolled document is visible. The thumb is sized by the fraction of the
+// Total lines which are Visible, and positioned by the scroll Offset. Nothing
+// is drawn if the entire document is visible.
+type ScrollbarWidget struct {
+	Widget
+	Offset, Visible, Total int
+	Track, Thumb           Glyph
+}
+
+// NewScrollbarWidget creates a new ScrollbarWidget with the given height.
+func NewScrollbarWidget(x, y, h int) *ScrollbarWidget {
+	return &ScrollbarWidget{Widget{x, y, 1, h}, 0, 0, 0, Glyph{'|', ColorLightBlack}, Glyph{'#', ColorWhite}}
+}
+
+// Update draws the scrollbar on screen.
+func (w *ScrollbarWidget) Update() {
+	start, size, ok := scrollThumb(w.Offset, w.Visible, w.Total, w.h)
+	if !ok {
+		return
+	}
+	for y := 0; y < w.h; y++ {
+		if start <= y && y < start+size {
+			w.DrawRel(0, y, w.Thumb)
+		} else {
+			w.DrawRel(0, y, w.Track)
+		}
+	}
+}
+
+// scrollThumb computes the location and size of a scrollbar thumb within a
+// track of the given height. The result is not ok if no scrollbar is needed.
+func scrollThumb(offset, visible, total, height int) (start, size int, ok bool) {
+	if total <= visible || height <= 0 {
+		return 0, 0, false
+	}
+	size = Clamp(1, height*visible/total, height)
+	if maxoffset := total - visible; offset >= maxoffset {
+		start = height - size
+	} else {
+		start = Clamp(0, (height-size)*offset/maxoffset, height-size)
+	}
+	return start, size, true
+}
+
+// ScrollText formats a textual scroll indicator such as "(12-35/400)" giving
+// the range of visible lines, counting from 1. The result is empty if the
+// entire document is visible.
+func ScrollText(offset, visible, total int) string {
+	if total <= visible {
+		return ""
+	}
+	return fmt.Sprintf("(%d-%d/%d)", offset+1, Min(offset+visible, total), total)
+}
+
 // TODO Add non-centering version of CameraWidget
+
+// TableWidget is a Widget which displays rows of text in aligned columns,
+// below a Header row. Each column is as wide as its widest cell, with a space
+// between columns, and text which does not fit in the Widget is truncated.
+// Align gives the alignment of each column, defaulting to AlignLeft. If
+// Highlight is the index of a row, that row is drawn in HighlightFg.
+type TableWidget struct {
+	Widget
+	Header    []string
+	Rows      [][]string
+	Align     []Align
+	Highlight int
+
+	Fg, HeaderFg, HighlightFg Color
+}
+
+// NewTableWidget creates a new TableWidget with no highlighted row.
+func NewTableWidget(header []string, rows [][]string, x, y, w, h int) *TableWidget {
+	return &TableWidget{Widget{x, y, w, h}, header, rows, nil, -1, ColorWhite, ColorLightWhite, ColorLightYellow}
+}
+
+// widths computes the width of each column.
+func (t *TableWidget) widths() []int {
+	var widths []int
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = Max(widths[i], len([]rune(cell)))
+		}
+	}
+	return widths
+}
+
+// Update draws the Header and as many Rows as fit.
+func (t *TableWidget) Update() {
+	widths := t.widths()
+	draw := func(y int, row []string, fg Color) {
+		x := 0
+		for i, cell := range row {
+			align := AlignLeft
+			if i < len(t.Align) {
+				align = t.Align[i]
+			}
+			runes := []rune(truncate(cell, t.w-x))
+			offset := align.offset(len(runes), widths[i])
+			for j, ch := range runes {
+				t.DrawRel(x+offset+j, y, Glyph{ch, fg})
+			}
+			x += widths[i] + 1
+		}
+	}
+	y := 0
+	if len(t.Header) > 0 {
+		draw(0, t.Header, t.HeaderFg)
+		y++
+	}
+	for i, row := range t.Rows {
+		fg := t.Fg
+		if i == t.Highlight {
+			fg = t.HighlightFg
+		}
+		draw(y+i, row, fg)
+	}
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimapWidget_summarize(t *testing.T) {
+	tiles := NewTileGrid(4, 3, Offset{-2, 5}, func(o Offset) *Tile {
+		tile := NewTile(o)
+		// the left two columns are mostly walls
+		tile.Pass = o.X >= 0 || o.Y == 5
+		return tile
+	})
+
+	w := NewMinimapWidget(tiles, 2, 0, 0, 10, 10)
+	w.Explored = func(t *Tile) bool { return t.Offset.Y < 7 }
+	w.Special = func(t *Tile) (Glyph, bool) {
+		return Glyph{'>', ColorWhite}, t.Offset == Offset{1, 5}
+	}
+	w.summarize()
+
+	if w.cols != 2 || w.rows != 2 {
+		t.Errorf("summarize() dimensions = %d, %d != 2, 2", w.cols, w.rows)
+	}
+	expected := map[Offset]Glyph{
+		{0, 0}: w.Floor,
+		{1, 0}: {'>', ColorWhite},
+	}
+	if len(w.blocks) != len(expected) {
+		t.Errorf("summarize() = %v != %v", w.blocks, expected)
+	}
+	for block, g := range expected {
+		if w.blocks[block] != g {
+			t.Errorf("summarize()[%v] = %v != %v", block, w.blocks[block], g)
+		}
+	}
+}
+
+func TestScrollThumb(t *testing.T) {
+	cases := []struct {
+		offset, visible, total, height int
+		start, size                    int
+		ok                             bool
+	}{
+		{0, 20, 20, 20, 0, 0, false},
+		{0, 20, 10, 20, 0, 0, false},
+		{0, 10, 40, 20, 0, 5, true},
+		{30, 10, 40, 20, 15, 5, true},
+		{15, 10, 40, 20, 7, 5, true},
+		{0, 1, 1000, 20, 0, 1, true},
+		{999, 1, 1000, 20, 19, 1, true},
+	}
+	for _, c := range cases {
+		start, size, ok := scrollThumb(c.offset, c.visible, c.total, c.height)
+		if start != c.start || size != c.size || ok != c.ok {
+			t.Errorf("scrollThumb(%d, %d, %d, %d) = %d, %d, %v != %d, %d, %v", c.offset, c.visible, c.total, c.height, start, size, ok, c.start, c.size, c.ok)
+		}
+	}
+}
+
+func TestScrollText(t *testing.T) {
+	cases := []struct {
+		offset, visible, total int
+		expected               string
+	}{
+		{0, 20, 10, ""},
+		{0, 20, 20, ""},
+		{11, 24, 400, "(12-35/400)"},
+		{390, 24, 400, "(391-400/400)"},
+	}
+	for _, c := range cases {
+		if actual := ScrollText(c.offset, c.visible, c.total); actual != c.expected {
+			t.Errorf("ScrollText(%d, %d, %d) = %q != %q", c.offset, c.visible, c.total, actual, c.expected)
+		}
+	}
+}
+
+// testviewer answers FoVRequest with a fixed field of view.
+type testviewer map[Offset]*Tile
+
+func (v testviewer) Handle(e Event) {
+	if e, ok := e.(*FoVRequest); ok {
+		e.FoV = v
+	}
+}
+
+func TestLogWidget_Process(t *testing.T) {
+	tiles := NewTileGrid(2, 1, Offset{}, NewTile)
+	log := NewLogWidget(0, 0, 20, 5)
+	log.Filter = FoVFilter(testviewer{Offset{}: tiles[0]})
+	player := ComponentSlice{log}
+
+	player.Handle(&Message{Text: "You hear a noise.", Color: ColorYellow})
+	player.Handle(&Message{Text: "The door opens.", Pos: tiles[0]})
+	player.Handle(&Message{Text: "The orc dies.", Pos: tiles[1]})
+	player.Handle(Messagef("%s <hit> %o", "you", "orc"))
+
+	var actual []string
+	for _, msg := range log.cache {
+		actual = append(actual, msg.Text)
+	}
+	expected := []string{"You hear a noise.", "The door opens.", "You hit the orc."}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("LogWidget logged %v != %v", actual, expected)
+	}
+	if log.cache[0].Fg != ColorYellow {
+		t.Errorf("LogWidget lost Message Color")
+	}
+}
+
+func TestMapView_Glyph(t *testing.T) {
+	g, _, _ := ParseGrid("#####\n#...#\n#####", nil)
+	reg := NewRegistry()
+	orc, _ := Spawn(&Prototype{Name: "orc", Face: Glyph{'o', ColorGreen}}, g.At(3, 1), reg)
+	mem := NewMemory(reg, 5, 0)
+	mem.Process(&UpdatePos{g.At(1, 1)})
+	mem.Process(&TurnTick{})
+
+	origin := g.At(1, 1)
+	fov := FoV(origin, 1)
+	floor := Terrains[TerrainFloor].Face
+	if actual := TileGlyph(fov, origin, mem, g.At(2, 1)); actual != floor {
+		t.Errorf("TileGlyph(visible) = %v", actual)
+	}
+	if actual := TileGlyph(fov, origin, mem, g.At(3, 1)); actual != Greyscale(floor) {
+		t.Errorf("TileGlyph(remembered) = %v", actual)
+	}
+	if actual := TileGlyph(fov, origin, nil, g.At(3, 1)); actual != (Glyph{' ', ColorWhite}) {
+		t.Errorf("TileGlyph(unknown) = %v", actual)
+	}
+
+	view := MapView{Memory: mem, Sightings: true, Unknown: Glyph{'?', ColorRed}}
+	if actual := view.Glyph(fov, origin, g.At(3, 1)); actual != Greyscale(orc.Face) {
+		t.Errorf("MapView.Glyph(sighting) = %v", actual)
+	}
+	if actual := view.Glyph(fov, origin, g.At(1, 0)); actual != Terrains[TerrainWall].Face {
+		t.Errorf("MapView.Glyph(visible wall) = %v", actual)
+	}
+	if actual := view.Glyph(map[Offset]*Tile{{}: g.At(4, 1)}, g.At(4, 1), g.At(0, 0)); actual != Greyscale(Terrains[TerrainWall].Face) {
+		t.Errorf("MapView.Glyph(remembered wall) = %v", actual)
+	}
+
+	g.At(3, 1).Occupant, g.At(2, 1).Occupant = nil, orc
+	if actual := TileGlyph(fov, origin, mem, g.At(2, 1)); actual != orc.Face {
+		t.Errorf("TileGlyph(visible occupant) = %v", actual)
+	}
+
+	view.Memory = NewMemory(reg, 5, 0)
+	if actual := view.Glyph(fov, origin, g.At(4, 1)); actual != view.Unknown {
+		t.Errorf("MapView.Glyph(unknown) = %v", actual)
+	}
+}
+
+func TestCameraWidget_Locate(t *testing.T) {
+	w := NewCameraWidget(nil, 10, 5, 7, 5)
+	cases := []struct {
+		x, y     int
+		expected Offset
+		ok       bool
+	}{
+		{13, 7, Offset{0, 0}, true},
+		{10, 5, Offset{-3, -2}, true},
+		{16, 9, Offset{3, 2}, true},
+		{17, 7, Offset{}, false},
+		{9, 7, Offset{}, false},
+	}
+	for _, c := range cases {
+		if actual, ok := w.Locate(c.x, c.y); actual != c.expected || ok != c.ok {
+			t.Errorf("Locate(%d, %d) = %v, %v", c.x, c.y, actual, ok)
+		}
+	}
+}
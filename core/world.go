@@ -0,0 +1,279 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// Level is a single floor of a World, as created by a LevelGen. Up and Down
+// are where the World places the Stairs leading to the levels above and
+// below, and may be nil if there are none.
+type Level struct {
+	Grid     *Grid
+	Up, Down *Tile
+}
+
+// LevelGen generates the Level at a depth of a World. The Dice is seeded from
+// the World Seed and the depth, so a generator which only uses the Dice for
+// random choices always generates the same Level.
+type LevelGen func(depth int, dice Dice) Level
+
+// World holds the levels of a dungeon by depth, generating each one only when
+// it is first needed. The Stairs placed on the Down Tile of each level lead to
+// the Up Tile of the level below, and the other way around, with the Up Stairs
+// at depth zero leading nowhere.
+type World struct {
+	Seed int64
+	Gen  LevelGen
+
+	levels map[int]*worldLevel
+	bus    *EventBus
+}
+
+// worldLevel is a generated Level along with the Stairs placed on it.
+type worldLevel struct {
+	Level
+	up, down *Stairs
+}
+
+// NewWorld creates a World with no levels generated yet.
+func NewWorld(seed int64, gen LevelGen) *World {
+	return &World{seed, gen, make(map[int]*worldLevel), nil}
+}
+
+// Level returns the Grid at the given depth, generating it if needed.
+func (w *World) Level(depth int) *Grid {
+	return w.level(depth).Grid
+}
+
+// Stairs returns the Tiles holding the up and down Stairs at the given depth,
+// generating the level if needed.
+func (w *World) Stairs(depth int) (up, down *Tile) {
+	l := w.level(depth)
+	return l.Up, l.Down
+}
+
+// Generated returns true if the level at the given depth has been generated.
+func (w *World) Generated(depth int) bool {
+	_, ok := w.levels[depth]
+	return ok
+}
+
+// Depths returns the depth of every generated level in ascending order.
+func (w *World) Depths() []int {
+	depths := make([]int, 0, len(w.levels))
+	for depth := range w.levels {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	return depths
+}
+
+// SetBus changes the EventBus on which the Stairs of every level publish each
+// Transition they send. Since the EventBus is not saved, it must be set again
+// after LoadLevels.
+func (w *World) SetBus(bus *EventBus) {
+	w.bus = bus
+	for _, l := range w.levels {
+		for _, stairs := range []*Stairs{l.up, l.down} {
+			if stairs != nil {
+				stairs.Bus = bus
+			}
+		}
+	}
+}
+
+// level gets the worldLevel at a depth, generating it if needed.
+func (w *World) level(depth int) *worldLevel {
+	if l, ok := w.levels[depth]; ok {
+		return l
+	}
+	l := &worldLevel{Level: w.Gen(depth, w.dice(depth))}
+	w.levels[depth] = l
+	w.placeStairs(depth, l)
+	return l
+}
+
+// dice creates the Dice used to generate a depth.
+func (w *World) dice(depth int) Dice {
+	return NewDice(rand.NewSource(w.Seed + int64(depth)*1000003))
+}
+
+// placeStairs sets the Trigger of the Up and Down Tiles of a level to Stairs
+// which find their destination when first used.
+func (w *World) placeStairs(depth int, l *worldLevel) {
+	if l.Up != nil {
+		l.up = &Stairs{Face: Glyph{'<', ColorWhite}, Dest: func() *Tile {
+			if depth == 0 {
+				return nil
+			}
+			_, down := w.Stairs(depth - 1)
+			return down
+		}, Bus: w.bus}
+		l.Up.Trigger = l.up
+	}
+	if l.Down != nil {
+		l.down = &Stairs{Face: Glyph{'>', ColorWhite}, Dest: func() *Tile {
+			up, _ := w.Stairs(depth + 1)
+			return up
+		}, Bus: w.bus}
+		l.Down.Trigger = l.down
+	}
+}
+
+// savedLevel is the saved form of a generated level, using SaveWorld for its
+// Tiles. Up and Down are positions within the Grid.
+type savedLevel struct {
+	Depth         int
+	Width, Height int
+	HasUp         bool
+	Up            Offset
+	HasDown       bool
+	Down          Offset
+	Data          []byte
+}
+
+// savedLevels is the saved form of a World.
+type savedLevels struct {
+	Seed   int64
+	Levels []savedLevel
+	Loose  []byte
+}
+
+// SaveLevels writes the Seed and every generated level of the World, along
+// with the Registry of every Entity, using SaveWorld for each level. Levels
+// which have not been generated are not saved, and are generated from the
+// Seed once needed after loading. Every registered Entity which is not on a
+// level, such as one carried in an inventory, is saved separately, so that
+// each Entity is only saved once. The Stairs placed by the World need not be
+// registered, as they are placed again by LoadLevels.
+func (w *World) SaveLevels(out io.Writer, reg *Registry) error {
+	saved := savedLevels{Seed: w.Seed}
+	placed := make(map[EntityID]bool)
+
+	for _, depth := range w.Depths() {
+		l := w.levels[depth]
+		sub := NewRegistry()
+		l.Grid.Each(func(_ Offset, t *Tile) {
+			for _, e := range tileEntities(t) {
+				if id, ok := reg.ID(e); ok {
+					sub.RegisterID(id, e)
+					sub.Tag(id, reg.Tags(id)...)
+					placed[id] = true
+				}
+			}
+		})
+
+		level := savedLevel{Depth: depth, Width: l.Grid.Width(), Height: l.Grid.Height()}
+		if l.Up != nil {
+			level.Up, level.HasUp = gridOffset(l.Grid, l.Up)
+			l.Up.Trigger = nil
+		}
+		if l.Down != nil {
+			level.Down, level.HasDown = gridOffset(l.Grid, l.Down)
+			l.Down.Trigger = nil
+		}
+		var buf bytes.Buffer
+		err := SaveWorld(&buf, l.Grid.tiles, sub)
+		w.placeStairs(depth, l)
+		if err != nil {
+			return err
+		}
+		level.Data = buf.Bytes()
+		saved.Levels = append(saved.Levels, level)
+	}
+
+	loose := NewRegistry()
+	reg.Each(func(id EntityID, e Entity) {
+		if !placed[id] {
+			loose.RegisterID(id, e)
+			loose.Tag(id, reg.Tags(id)...)
+		}
+	})
+	var buf bytes.Buffer
+	if err := SaveWorld(&buf, nil, loose); err != nil {
+		return err
+	}
+	saved.Loose = buf.Bytes()
+
+	return gob.NewEncoder(out).Encode(&saved)
+}
+
+// LoadLevels reads a World written by SaveLevels, using the given LevelGen
+// for any level which was not generated when saved, and returns it along with
+// a Registry of every saved Entity. Once every level is loaded, each Entity is
+// sent a single Loaded with the whole Registry and the Tiles of every level.
+func LoadLevels(in io.Reader, gen LevelGen) (*World, *Registry, error) {
+	var saved savedLevels
+	if err := gob.NewDecoder(in).Decode(&saved); err != nil {
+		return nil, nil, err
+	}
+
+	w := NewWorld(saved.Seed, gen)
+	reg := NewRegistry()
+	merge := func(sub *Registry) error {
+		for _, id := range sub.IDs() {
+			if err := reg.RegisterID(id, sub.Lookup(id)); err != nil {
+				return err
+			}
+			reg.Tag(id, sub.Tags(id)...)
+		}
+		return nil
+	}
+
+	levels := make(map[int]map[Offset]*Tile)
+	for _, level := range saved.Levels {
+		tiles, sub, index, err := loadWorld(bytes.NewReader(level.Data))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tiles) != level.Width*level.Height || len(tiles) == 0 {
+			return nil, nil, ErrInvalidDimensions
+		}
+		if err := merge(sub); err != nil {
+			return nil, nil, err
+		}
+
+		l := &worldLevel{Level: Level{Grid: &Grid{level.Width, level.Height, tiles, nil}}}
+		if level.HasUp {
+			l.Up = l.Grid.At(level.Up.X, level.Up.Y)
+		}
+		if level.HasDown {
+			l.Down = l.Grid.At(level.Down.X, level.Down.Y)
+		}
+		w.levels[level.Depth] = l
+		w.placeStairs(level.Depth, l)
+		levels[level.Depth] = index
+	}
+
+	_, loose, _, err := loadWorld(bytes.NewReader(saved.Loose))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := merge(loose); err != nil {
+		return nil, nil, err
+	}
+
+	loaded := Loaded{Registry: reg, Levels: levels}
+	reg.Each(func(_ EntityID, e Entity) {
+		e.Handle(&loaded)
+	})
+	return w, reg, nil
+}
+
+// tileEntities lists every Entity held by a Tile.
+func tileEntities(t *Tile) []Entity {
+	var entities []Entity
+	if t.Occupant != nil {
+		entities = append(entities, t.Occupant)
+	}
+	entities = append(entities, t.Overlap...)
+	entities = append(entities, t.Items...)
+	if t.Trigger != nil {
+		entities = append(entities, t.Trigger)
+	}
+	return entities
+}
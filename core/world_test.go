@@ -0,0 +1,144 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// worldCase creates a World whose levels are a corridor with stairs at each
+// end and a registered testsaved at a random spot, counting generated levels.
+func worldCase(reg *Registry, generated *int) LevelGen {
+	return func(depth int, dice Dice) Level {
+		*generated++
+		g, marks, _ := ParseGrid("#######\n#<...>#\n#######", nil)
+		monster := &testsaved{Name: "orc"}
+		pos := g.At(dice.Range(2, 4), 1)
+		pos.Occupant, monster.Pos = monster, pos
+		reg.Register(monster)
+		return Level{g, marks["up"][0], marks["down"][0]}
+	}
+}
+
+// occupied lists which Tiles along the corridor of a worldCase are occupied.
+func occupied(g *Grid) []bool {
+	var occ []bool
+	for x := 0; x < g.Width(); x++ {
+		occ = append(occ, g.At(x, 1).Occupant != nil)
+	}
+	return occ
+}
+
+func TestWorld(t *testing.T) {
+	reg := NewRegistry()
+	generated := 0
+	w := NewWorld(7, worldCase(reg, &generated))
+	if generated != 0 {
+		t.Errorf("NewWorld() generated %d levels", generated)
+	}
+
+	top := w.Level(0)
+	if w.Level(0) != top || generated != 1 || w.Generated(1) {
+		t.Errorf("Level() did not generate lazily")
+	}
+
+	// each depth generates the same way for the same seed
+	other := NewWorld(7, worldCase(NewRegistry(), new(int)))
+	if !reflect.DeepEqual(occupied(top), occupied(other.Level(0))) {
+		t.Errorf("Level(0) differs for the same seed")
+	}
+
+	// taking the down stairs generates the next level
+	hero := &testsaved{Name: "hero"}
+	_, down := w.Stairs(0)
+	start := top.At(4, 1)
+	start.Occupant, hero.Pos = hero, start
+	start.Handle(&MoveEntity{Delta: Offset{1, 0}})
+	up, _ := w.Stairs(1)
+	if generated != 2 || hero.Pos == nil || hero.Pos.Offset.Sub(up.Offset).Chebyshev() > 1 || down.Occupant != nil {
+		t.Errorf("down Stairs moved hero to %v after generating %d levels", hero.Pos, generated)
+	}
+	if topUp, _ := w.Stairs(0); topUp.Trigger.(*Stairs).Dest() != nil {
+		t.Errorf("up Stairs at depth 0 lead somewhere")
+	}
+}
+
+// testwatcher is a saveable occupant which records each Loaded it is sent.
+type testwatcher struct {
+	Pos    *Tile
+	loaded []*Loaded
+}
+
+func (e *testwatcher) Handle(v Event) {
+	switch v := v.(type) {
+	case *UpdatePos:
+		e.Pos = v.Pos
+	case *Loaded:
+		e.loaded = append(e.loaded, v)
+	}
+}
+
+func init() {
+	RegisterComponent(&testwatcher{})
+}
+
+func TestWorld_SaveLevels(t *testing.T) {
+	reg := NewRegistry()
+	generated := 0
+	w := NewWorld(3, worldCase(reg, &generated))
+	w.Level(0)
+	w.Level(1)
+	carried := &testsaved{Name: "sword"}
+	reg.Tag(reg.Register(carried), "loot")
+	watcher := &testwatcher{Pos: w.Level(1).At(5, 1)}
+	watcher.Pos.Occupant = watcher
+	watcherID := reg.Register(watcher)
+
+	var buf bytes.Buffer
+	if err := w.SaveLevels(&buf, reg); err != nil {
+		t.Fatalf("SaveLevels() = %v", err)
+	}
+	if _, ok := w.Level(0).At(1, 1).Trigger.(*Stairs); !ok {
+		t.Errorf("SaveLevels() did not restore Stairs")
+	}
+
+	loadedGen := 0
+	loaded, loadedReg, err := LoadLevels(&buf, worldCase(NewRegistry(), &loadedGen))
+	if err != nil {
+		t.Fatalf("LoadLevels() = %v", err)
+	}
+	if depths := loaded.Depths(); len(depths) != 2 || loadedGen != 0 {
+		t.Errorf("LoadLevels() has depths %v after generating %d", depths, loadedGen)
+	}
+	if loadedReg.Len() != reg.Len() || len(loadedReg.WithTag("loot")) != 1 {
+		t.Errorf("LoadLevels() registry has %d != %d entities", loadedReg.Len(), reg.Len())
+	}
+
+	// Loaded is sent once, with every Entity and the Tiles of every level
+	seen := loadedReg.Lookup(watcherID).(*testwatcher).loaded
+	if len(seen) != 1 || seen[0].Registry.Len() != reg.Len() {
+		t.Fatalf("LoadLevels() sent Loaded %v", seen)
+	}
+	pos := loaded.Level(1).At(5, 1)
+	if tiles := seen[0].TilesWith(pos); tiles[pos.Offset] != pos || len(seen[0].Levels) != 2 {
+		t.Errorf("Loaded.TilesWith() gave the wrong level")
+	}
+	for _, depth := range []int{0, 1} {
+		a, b := w.Level(depth), loaded.Level(depth)
+		for x := 0; x < a.Width(); x++ {
+			if (a.At(x, 1).Occupant == nil) != (b.At(x, 1).Occupant == nil) {
+				t.Errorf("LoadLevels() depth %d differs at %d", depth, x)
+			}
+		}
+	}
+
+	// the stairs work again, and ungenerated levels are generated on demand
+	_, down := loaded.Stairs(1)
+	if dest := down.Trigger.(*Stairs).Dest(); dest == nil || loadedGen != 1 || !loaded.Generated(2) {
+		t.Errorf("LoadLevels() Stairs lead to %v", dest)
+	}
+	up, _ := loaded.Stairs(1)
+	if _, upper := loaded.Stairs(0); up.Trigger.(*Stairs).Dest() != upper {
+		t.Errorf("LoadLevels() up Stairs do not lead to depth 0")
+	}
+}
@@ -27,7 +27,7 @@ func (e *Skin) Handle(v core.Event) {
 	case *Action:
 		key := core.GetKey()
 		if delta, ok := core.KeyMap[key]; ok {
-			e.Pos.Handle(&core.MoveEntity{Delta: delta})
+			core.Move(e.Pos, delta)
 		} else if key == 't' {
 			if target, ok := core.Aim(e, e, "t"); ok {
 				e.Target = target
@@ -46,9 +46,17 @@ func (e *Skin) Handle(v core.Event) {
 	case *core.Bump:
 		e.Logger.Log(core.Fmt("%s <bump> %o", e, v.Bumped))
 	case *core.Collide:
-		e.Logger.Log(core.Fmt("%s <cannot> pass %o", e, v.Obstacle))
+		if v.Obstacle == nil {
+			e.Logger.Log(core.Fmt("%s <cannot> go that way", e))
+		} else {
+			e.Logger.Log(core.Fmt("%s <cannot> pass %o", e, v.Obstacle))
+		}
 	case *core.FoVRequest:
-		v.FoV = core.FoV(e.Pos, 5)
+		radius := v.Radius
+		if radius == 0 {
+			radius = 5
+		}
+		v.FoV = core.FoV(e.Pos, radius)
 	case *core.Mark:
 		e.View.Mark(v.Offset, v.Mark)
 	}